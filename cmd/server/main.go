@@ -3,34 +3,64 @@ package main
 import (
 	"context"
 	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/biodoia/ghrego/internal/adapters/ai"
+	"github.com/biodoia/ghrego/internal/adapters/gitea"
 	"github.com/biodoia/ghrego/internal/adapters/github"
+	"github.com/biodoia/ghrego/internal/adapters/gitlab"
 	"github.com/biodoia/ghrego/internal/adapters/handler/http"
+	"github.com/biodoia/ghrego/internal/adapters/ingest"
+	"github.com/biodoia/ghrego/internal/adapters/local"
+	"github.com/biodoia/ghrego/internal/adapters/osv"
+	"github.com/biodoia/ghrego/internal/adapters/registry"
 	"github.com/biodoia/ghrego/internal/adapters/storage/postgres"
+	"github.com/biodoia/ghrego/internal/cache"
 	"github.com/biodoia/ghrego/internal/config"
+	"github.com/biodoia/ghrego/internal/core/domain"
 	"github.com/biodoia/ghrego/internal/core/ports"
 	"github.com/biodoia/ghrego/internal/core/services"
-	"github.com/rs/zerolog"
+	"github.com/biodoia/ghrego/internal/logging"
+	"github.com/biodoia/ghrego/internal/metrics"
+	"github.com/biodoia/ghrego/internal/telemetry"
 	"github.com/rs/zerolog/log"
 )
 
 func main() {
-	// Setup Logger
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	// Cancelled on SIGINT/SIGTERM to start a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Load Config
 	cfg := config.Load()
+
+	// Setup Logger
+	if err := logging.Setup(cfg.LogLevel, cfg.LogFormat); err != nil {
+		log.Fatal().Err(err).Msg("Invalid logging configuration")
+	}
+
 	if err := cfg.Validate(); err != nil {
 		log.Fatal().Err(err).Msg("Invalid configuration")
 	}
 
+	// Tracing
+	shutdownTracing, err := telemetry.SetupTracing(ctx, cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to set up tracing")
+	}
+
 	// Connect to DB
 	db, err := postgres.NewDB(cfg)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to connect to database")
 	}
 	defer db.Close()
+	if err := metrics.RegisterPoolStats(db.PoolStats); err != nil {
+		log.Warn().Err(err).Msg("Failed to register database pool metrics")
+	}
 
 	// Initialize Repositories
 	userRepo := postgres.NewUserRepository(db)
@@ -38,34 +68,170 @@ func main() {
 	analysisRepo := postgres.NewAnalysisRepository(db)
 	featureRepo := postgres.NewFeatureRepository(db)
 	techRepo := postgres.NewTechnologyRepository(db)
+	licenseRepo := postgres.NewLicenseRepository(db)
 	suggestionRepo := postgres.NewSuggestionRepository(db)
+	usageRepo := postgres.NewUsageRepository(db)
+	batchRepo := postgres.NewBatchRepository(db)
+	workspaceRepo := postgres.NewWorkspaceRepository(db)
+	tokenRepo := postgres.NewAPITokenRepository(db)
+	webhookRepo := postgres.NewWebhookRepository(db)
+
+	// Shared cache and rate limiter: Redis when configured (shared across instances), in-memory otherwise
+	var appCache ports.Cache = cache.NewMemoryCache()
+	limiter := cache.NewMemoryRateLimiter()
+	var redisClient *cache.RedisClient
+	if cfg.RedisEnabled {
+		redisClient, err = cache.NewRedisClient(cache.Config{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+			Prefix:   cfg.RedisPrefix,
+		})
+		if err != nil {
+			log.Warn().Err(err).Msg("Redis unavailable, falling back to in-memory cache")
+			redisClient = nil
+		} else {
+			defer redisClient.Close()
+			appCache = cache.NewRedisCache(redisClient)
+			limiter = cache.NewRedisRateLimiter(redisClient)
+		}
+	}
+	analysisCache := cache.NewAnalysisCache(appCache, postgres.NewAnalysisCacheRepository(db), 24*time.Hour)
 
 	// Initialize Adapters
-	ghClient := github.NewClient(cfg.APIKey) // Use APIKey as GitHub Token for now
+	if cfg.GitHubToken == "" && cfg.APIKey != "" {
+		log.Warn().Msg("API_KEY is no longer used as GitHub token, set GITHUB_TOKEN")
+	}
+	var ghClient ports.GitHubClient
+	var ghInstallations ports.GitHubInstallations
+	if cfg.GitHubAppID != 0 {
+		privateKey := []byte(cfg.GitHubAppPrivateKey)
+		if len(privateKey) == 0 {
+			if privateKey, err = os.ReadFile(cfg.GitHubAppPrivateKeyFile); err != nil {
+				log.Fatal().Err(err).Msg("Failed to read GitHub App private key")
+			}
+		}
+		app, err := github.NewApp(cfg.GitHubAppID, privateKey, cfg.GitHubAPIURL, nil)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to initialize GitHub App")
+		}
+		appClient := github.NewAppClient(app, appCache)
+		ghClient, ghInstallations = appClient, appClient
+		log.Info().Int64("app_id", cfg.GitHubAppID).Msg("Authenticating to GitHub as an App")
+	} else {
+		ghClient = github.NewClient(cfg.GitHubToken, appCache)
+	}
+	hosts := services.NewSourceHosts(ghClient)
+	if cfg.GitLabToken != "" {
+		hosts[domain.ProviderGitLab] = gitlab.NewClient(cfg.GitLabURL, cfg.GitLabToken, nil)
+	}
+	if cfg.GiteaURL != "" {
+		hosts[domain.ProviderGitea] = gitea.NewClient(cfg.GiteaURL, cfg.GiteaToken, nil)
+	}
+	if cfg.LocalReposRoot != "" {
+		localClient, err := local.NewClient(cfg.LocalReposRoot)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to open local repositories root")
+		}
+		hosts[domain.ProviderLocal] = localClient
+	}
+
+	// Analysis reads repository files from shallow clones; without git it
+	// falls back to metadata only
+	var ingester ports.CodeIngester
+	if _, err := exec.LookPath("git"); err != nil {
+		log.Warn().Msg("git not found, code ingestion disabled")
+	} else if !cfg.IngestDisabled {
+		ing, err := ingest.New(cfg.IngestDir, ingest.Limits{
+			MaxRepoBytes:  cfg.IngestMaxRepoMB << 20,
+			MaxWorkspaces: cfg.IngestMaxWorkspaces,
+			MaxIdle:       cfg.IngestMaxIdle,
+			Concurrency:   cfg.IngestConcurrency,
+			CloneTimeout:  cfg.IngestCloneTimeout,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Str("dir", cfg.IngestDir).Msg("Failed to initialize code ingestion")
+		}
+		ingester = ing
+	}
+
+	// Dependencies are checked against OSV dumps on disk, never the network
+	var advisories ports.AdvisoryDatabase
+	if cfg.AdvisoryDir != "" {
+		db, err := osv.Load(cfg.AdvisoryDir)
+		if err != nil {
+			log.Fatal().Err(err).Str("dir", cfg.AdvisoryDir).Msg("Failed to load advisory database")
+		}
+		log.Info().Int("advisories", db.Len()).Msg("Advisory database loaded")
+		advisories = db
+	}
+	var packageRegistry ports.PackageRegistry
+	if cfg.RegistrySnapshot != "" {
+		snapshot, err := registry.Load(cfg.RegistrySnapshot)
+		if err != nil {
+			log.Fatal().Err(err).Str("file", cfg.RegistrySnapshot).Msg("Failed to load registry snapshot")
+		}
+		log.Info().Int("packages", snapshot.Len()).Msg("Registry snapshot loaded")
+		packageRegistry = snapshot
+	}
 	
 	// Setup Gemini Client
+	var aiClient ports.AIClient
 	geminiClient, err := ai.NewGeminiClient(context.Background(), os.Getenv("GEMINI_API_KEY"))
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to initialize Gemini Client (AI features disabled)")
 	} else {
 		defer geminiClient.Close()
+		aiClient = geminiClient
 	}
 
 	// Initialize Services
-	ghService := services.NewGitHubService(ghClient, repoStore, userRepo)
-	
-	var aiService ports.AIAnalysisService
-	if geminiClient != nil {
-		aiService = services.NewAIAnalysisService(geminiClient, repoStore, analysisRepo, featureRepo, techRepo, suggestionRepo)
-	} else {
-		log.Warn().Msg("AI Service not initialized - Using NoOp or failing calls")
-		// Ideally pass a NoOp implementation here to avoid nil pointer in Handler
+	jobs := services.NewBackgroundJobs()
+	usageService := services.NewUsageService(usageRepo, domain.ModelPricing{
+		InputPerMTok:  cfg.AIInputPricePerMTok,
+		OutputPerMTok: cfg.AIOutputPricePerMTok,
+		CachedPerMTok: cfg.AICachedPricePerMTok,
+	}, cfg.AIMonthlyBudget)
+	authz := services.NewAuthorizer(userRepo, repoStore, workspaceRepo)
+	ghService := services.NewGitHubService(ghClient, hosts, repoStore, userRepo, appCache, authz)
+	workspaceService := services.NewWorkspaceService(workspaceRepo, userRepo, authz)
+	tokenService := services.NewTokenService(tokenRepo)
+
+	// Without an AI client analyses fail as upstream unavailable, while reports and suggestions keep working
+	aiService := services.NewAIAnalysisService(aiClient, hosts, ingester, advisories, analysisCache, repoStore, analysisRepo, featureRepo, techRepo, licenseRepo, suggestionRepo, appCache, usageService, authz)
+	dependencyService := services.NewDependencyService(repoStore, techRepo, licenseRepo, suggestionRepo, packageRegistry, appCache, authz)
+	webhookService := services.NewWebhookService(webhookRepo, repoStore, aiService, appCache, authz, jobs, ghInstallations)
+	bulkService := services.NewBulkAnalysisService(aiService, repoStore, batchRepo, authz, jobs, cfg.MaxBulkRepos, cfg.BulkWorkers, cfg.BulkProviderConcurrency)
+
+	if aiClient != nil {
+		if err := bulkService.ResumeInterrupted(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to resume interrupted bulk analyses")
+		}
 	}
 
 	// Initialize HTTP Server
-	server := http.NewServer(cfg, ghService, aiService, repoStore, userRepo, suggestionRepo)
-	
-	if err := server.Run(); err != nil {
-		log.Fatal().Err(err).Msg("Server failed")
+	server := http.NewServer(cfg, ghService, aiService, userRepo, usageService, bulkService, workspaceService, tokenService, webhookService, dependencyService, limiter, jobs)
+	server.AddReadinessCheck("postgres", db.HealthCheck)
+	if redisClient != nil {
+		server.AddReadinessCheck("redis", redisClient.HealthCheck)
+	}
+	if geminiClient != nil {
+		// Every check is a request to the provider
+		server.AddCachedReadinessCheck("ai", 30*time.Second, geminiClient.HealthCheck)
+	}
+
+	if err := server.Run(ctx); err != nil {
+		log.Error().Err(err).Msg("Server failed")
+	}
+
+	// Let background analyses finish, or checkpoint and stop once the grace period expires
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := jobs.Shutdown(shutdownCtx); err != nil {
+		log.Warn().Err(err).Msg("Background jobs interrupted")
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Warn().Err(err).Msg("Failed to flush traces")
 	}
+	log.Info().Msg("Shutdown complete")
 }
\ No newline at end of file
@@ -5,16 +5,27 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/generative-ai-go/genai"
 	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/metrics"
+	"github.com/biodoia/ghrego/internal/telemetry"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/api/option"
 )
 
+var tracer = telemetry.Tracer("github.com/biodoia/ghrego/internal/adapters/ai")
+
+// DefaultModel is the Gemini model used for repository analysis
+const DefaultModel = "gemini-1.5-flash"
+
 type GeminiClient struct {
-	client *genai.Client
-	model  *genai.GenerativeModel
+	client    *genai.Client
+	model     *genai.GenerativeModel
+	modelName string
 }
 
 func NewGeminiClient(ctx context.Context, apiKey string) (*GeminiClient, error) {
@@ -24,12 +35,13 @@ func NewGeminiClient(ctx context.Context, apiKey string) (*GeminiClient, error)
 	}
 
 	// Use Gemini 1.5 Flash for speed and cost, or Pro for complex analysis
-	model := client.GenerativeModel("gemini-1.5-flash")
+	model := client.GenerativeModel(DefaultModel)
 	model.SetTemperature(0.2) // Low temperature for deterministic analysis
 
 	return &GeminiClient{
-		client: client,
-		model:  model,
+		client:    client,
+		model:     model,
+		modelName: DefaultModel,
 	}, nil
 }
 
@@ -37,6 +49,19 @@ func (c *GeminiClient) Close() {
 	c.client.Close()
 }
 
+// ModelName returns the model used for generation; it is part of the analysis cache fingerprint
+func (c *GeminiClient) ModelName() string {
+	return c.modelName
+}
+
+// HealthCheck verifies the provider is reachable by fetching the model metadata
+func (c *GeminiClient) HealthCheck(ctx context.Context) error {
+	if _, err := c.model.Info(ctx); err != nil {
+		return fmt.Errorf("gemini model info failed: %w", err)
+	}
+	return nil
+}
+
 func (c *GeminiClient) AnalyzeRepository(ctx context.Context, prompt string) (*domain.RepositoryAnalysisResponse, error) {
 	// Configure JSON mode
 	c.model.ResponseMIMEType = "application/json"
@@ -55,13 +80,45 @@ Rispondi in formato JSON strutturato con i campi: architecture, features, techno
 		},
 	}
 
+	ctx, span := tracer.Start(ctx, "gemini.GenerateContent", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("gen_ai.system", "gemini"), attribute.String("gen_ai.request.model", c.modelName)))
+	defer span.End()
+
+	start := time.Now()
 	resp, err := c.model.GenerateContent(ctx, genai.Text(prompt))
+	metrics.AIRequestDuration.WithLabelValues(c.modelName, metrics.Outcome(err)).Observe(time.Since(start).Seconds())
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate content: %w", err)
+		telemetry.RecordError(span, err)
+		return nil, domain.UpstreamUnavailable("AI provider", fmt.Errorf("failed to generate content: %w", err))
+	}
+	// Counted before parsing: tokens are billed even when the response is unusable
+	usage := domain.TokenUsage{Model: c.modelName}
+	if md := resp.UsageMetadata; md != nil {
+		usage.PromptTokens = int(md.PromptTokenCount)
+		usage.OutputTokens = int(md.CandidatesTokenCount)
+		usage.CachedTokens = int(md.CachedContentTokenCount)
+		metrics.AITokens.WithLabelValues(c.modelName, "prompt").Add(float64(md.PromptTokenCount))
+		metrics.AITokens.WithLabelValues(c.modelName, "output").Add(float64(md.CandidatesTokenCount))
+		metrics.AITokens.WithLabelValues(c.modelName, "cached").Add(float64(md.CachedContentTokenCount))
+		span.SetAttributes(
+			attribute.Int("gen_ai.usage.input_tokens", int(md.PromptTokenCount)),
+			attribute.Int("gen_ai.usage.output_tokens", int(md.CandidatesTokenCount)),
+		)
 	}
 
+	analysis, err := c.parseResponse(ctx, resp)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, &domain.BilledError{Usage: usage, Err: err}
+	}
+	analysis.Usage = usage
+	return analysis, nil
+}
+
+// parseResponse decodes the JSON analysis in the first candidate
+func (c *GeminiClient) parseResponse(ctx context.Context, resp *genai.GenerateContentResponse) (*domain.RepositoryAnalysisResponse, error) {
 	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
-		return nil, fmt.Errorf("empty response from model")
+		return nil, domain.UpstreamUnavailable("AI provider", fmt.Errorf("empty response from model"))
 	}
 
 	var analysis domain.RepositoryAnalysisResponse
@@ -80,10 +137,10 @@ Rispondi in formato JSON strutturato con i campi: architecture, features, techno
 	rawJSON = strings.TrimSuffix(rawJSON, "```")
 	
 	if err := json.Unmarshal([]byte(rawJSON), &analysis); err != nil {
-		log.Error().Err(err).Str("raw", rawJSON).Msg("Failed to unmarshal JSON from LLM")
-		return nil, fmt.Errorf("failed to parse LLM response: %w", err)
+		metrics.AIParseFailures.WithLabelValues(c.modelName).Inc()
+		log.Ctx(ctx).Error().Err(err).Str("raw", rawJSON).Msg("Failed to unmarshal JSON from LLM")
+		return nil, domain.UpstreamUnavailable("AI provider", fmt.Errorf("failed to parse LLM response: %w", err))
 	}
-
 	return &analysis, nil
 }
 
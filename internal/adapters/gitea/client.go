@@ -0,0 +1,282 @@
+// Package gitea reads repositories from a Gitea (or Forgejo) instance through
+// the REST API v1.
+package gitea
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/metrics"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+const (
+	// pageLimit stays within Gitea's default MAX_RESPONSE_ITEMS
+	pageLimit = 50
+	// treePageSize is the page size for tree listings, which Gitea caps separately
+	treePageSize = 1000
+)
+
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient creates a client for the Gitea instance at baseURL, authenticated
+// with an access token
+func NewClient(baseURL, token string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		// Every API call gets a client span, child of the caller's span
+		httpClient = &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	}
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/") + "/api/v1/",
+		token:   token,
+		http:    httpClient,
+	}
+}
+
+func (c *Client) Provider() domain.SourceProvider {
+	return domain.ProviderGitea
+}
+
+// ListRepositories lists the repositories of an organization or a user
+func (c *Client) ListRepositories(ctx context.Context, account string) ([]*domain.Repository, error) {
+	repos, err := c.listRepositories(ctx, "orgs/"+url.PathEscape(account)+"/repos", account)
+	if errors.Is(err, domain.ErrNotFound) {
+		repos, err = c.listRepositories(ctx, "users/"+url.PathEscape(account)+"/repos", account)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories: %w", err)
+	}
+
+	domainRepos := make([]*domain.Repository, 0, len(repos))
+	for i := range repos {
+		domainRepos = append(domainRepos, repos[i].toDomain())
+	}
+	return domainRepos, nil
+}
+
+// listRepositories pages until an empty page: the server may cap the limit below pageLimit
+func (c *Client) listRepositories(ctx context.Context, path, account string) ([]repository, error) {
+	var all []repository
+	for page := 1; ; page++ {
+		var repos []repository
+		query := url.Values{"limit": {strconv.Itoa(pageLimit)}, "page": {strconv.Itoa(page)}}
+		if err := c.get(ctx, "list_repositories", path, query, &repos, "Gitea account", account); err != nil {
+			return nil, err
+		}
+		if len(repos) == 0 {
+			return all, nil
+		}
+		all = append(all, repos...)
+	}
+}
+
+func (c *Client) GetRepository(ctx context.Context, owner, repoName string) (*domain.Repository, error) {
+	var r repository
+	if err := c.get(ctx, "get_repository", repoPath(owner, repoName), nil, &r, "Gitea repository", owner+"/"+repoName); err != nil {
+		return nil, err
+	}
+	return r.toDomain(), nil
+}
+
+// GetFileContent returns a file from the default branch
+func (c *Client) GetFileContent(ctx context.Context, owner, repo, path string) (string, error) {
+	resp, err := c.do(ctx, "get_file", repoPath(owner, repo)+"/raw/"+escapeFilePath(path), nil, "file", path)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", domain.UpstreamUnavailable("Gitea", err)
+	}
+	return string(content), nil
+}
+
+// GetLanguages returns bytes of code per language
+func (c *Client) GetLanguages(ctx context.Context, owner, repo string) (map[string]int, error) {
+	var langs map[string]int
+	if err := c.get(ctx, "list_languages", repoPath(owner, repo)+"/languages", nil, &langs, "Gitea repository", owner+"/"+repo); err != nil {
+		return nil, err
+	}
+	return langs, nil
+}
+
+func (c *Client) GetBranchSHA(ctx context.Context, owner, repo, branch string) (string, error) {
+	var b struct {
+		Commit struct {
+			ID string `json:"id"`
+		} `json:"commit"`
+	}
+	if err := c.get(ctx, "get_branch", repoPath(owner, repo)+"/branches/"+url.PathEscape(branch), nil, &b, "branch", branch); err != nil {
+		return "", fmt.Errorf("failed to get branch: %w", err)
+	}
+	return b.Commit.ID, nil
+}
+
+// GetTree lists the tree at ref, following Gitea's pagination of large trees
+func (c *Client) GetTree(ctx context.Context, owner, repo, ref string) ([]domain.TreeEntry, error) {
+	var entries []domain.TreeEntry
+	for page := 1; ; page++ {
+		var tree struct {
+			Tree []struct {
+				Path string `json:"path"`
+				Type string `json:"type"`
+				Size int64  `json:"size"`
+			} `json:"tree"`
+			TotalCount int `json:"total_count"`
+		}
+		query := url.Values{"recursive": {"true"}, "per_page": {strconv.Itoa(treePageSize)}, "page": {strconv.Itoa(page)}}
+		if err := c.get(ctx, "get_tree", repoPath(owner, repo)+"/git/trees/"+url.PathEscape(ref), query, &tree, "tree", ref); err != nil {
+			return nil, err
+		}
+		for _, e := range tree.Tree {
+			entries = append(entries, domain.TreeEntry{Path: e.Path, Type: e.Type, Size: e.Size})
+		}
+		if len(tree.Tree) == 0 || len(entries) >= tree.TotalCount {
+			return entries, nil
+		}
+	}
+}
+
+// CloneSource returns the repository's HTTPS clone URL. Gitea takes an access
+// token as the user name when the password is x-oauth-basic.
+func (c *Client) CloneSource(ctx context.Context, owner, repo string) (domain.CloneSource, error) {
+	var r repository
+	if err := c.get(ctx, "get_repository", repoPath(owner, repo), nil, &r, "Gitea repository", owner+"/"+repo); err != nil {
+		return domain.CloneSource{}, err
+	}
+	src := domain.CloneSource{URL: r.CloneURL}
+	if c.token != "" {
+		src.AuthHeader = "Basic " + base64.StdEncoding.EncodeToString([]byte(c.token+":x-oauth-basic"))
+	}
+	return src, nil
+}
+
+type repository struct {
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	FullName      string    `json:"full_name"`
+	Description   string    `json:"description"`
+	HTMLURL       string    `json:"html_url"`
+	CloneURL      string    `json:"clone_url"`
+	Private       bool      `json:"private"`
+	Archived      bool      `json:"archived"`
+	Stars         int       `json:"stars_count"`
+	Forks         int       `json:"forks_count"`
+	Size          int       `json:"size"`
+	DefaultBranch string    `json:"default_branch"`
+	Language      string    `json:"language"`
+	Topics        []string  `json:"topics"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func (r *repository) toDomain() *domain.Repository {
+	repo := &domain.Repository{
+		Provider:      domain.ProviderGitea,
+		GithubID:      strconv.FormatInt(r.ID, 10),
+		Name:          r.Name,
+		FullName:      r.FullName,
+		URL:           r.HTMLURL,
+		IsPrivate:     r.Private,
+		IsArchived:    r.Archived,
+		Stars:         r.Stars,
+		Forks:         r.Forks,
+		Size:          r.Size,
+		DefaultBranch: r.DefaultBranch,
+		Topics:        r.Topics,
+		CreatedAt:     r.CreatedAt,
+		UpdatedAt:     r.UpdatedAt,
+	}
+	if r.Description != "" {
+		repo.Description = sql.NullString{String: r.Description, Valid: true}
+	}
+	if r.Language != "" {
+		repo.Language = sql.NullString{String: r.Language, Valid: true}
+	}
+	return repo
+}
+
+func repoPath(owner, repo string) string {
+	return "repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+}
+
+// escapeFilePath escapes each segment of a file path, keeping the slashes
+func escapeFilePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+func (c *Client) get(ctx context.Context, operation, path string, query url.Values, out any, resource, id string) error {
+	resp, err := c.do(ctx, operation, path, query, resource, id)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return domain.UpstreamUnavailable("Gitea", fmt.Errorf("invalid response: %w", err))
+	}
+	return nil
+}
+
+// do sends an authenticated GET and maps error statuses to domain errors. On
+// success the caller owns the response body.
+func (c *Client) do(ctx context.Context, operation, path string, query url.Values, resource, id string) (*http.Response, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err == nil && resp.StatusCode >= 300 {
+		resp.Body.Close()
+		err = mapStatus(resp, resource, id)
+	} else if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		err = domain.UpstreamUnavailable("Gitea", err)
+	}
+	metrics.SourceHostRequests.WithLabelValues(string(domain.ProviderGitea), operation, metrics.Outcome(err)).Inc()
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func mapStatus(resp *http.Response, resource, id string) error {
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return domain.NotFound(resource, id)
+	case http.StatusTooManyRequests:
+		rateLimited := &domain.Error{Kind: domain.ErrRateLimited, Message: "Gitea rate limit exceeded"}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			rateLimited.RetryAt = time.Now().Add(time.Duration(seconds) * time.Second)
+		}
+		return rateLimited
+	default:
+		return domain.UpstreamUnavailable("Gitea", fmt.Errorf("unexpected status %d", resp.StatusCode))
+	}
+}
@@ -0,0 +1,131 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeGitea(t *testing.T) *Client {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/orgs/octo/repos", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "1":
+			fmt.Fprint(w, `[{"id": 1, "name": "api", "full_name": "octo/api", "private": true, "language": "Go", "default_branch": "main", "stars_count": 2}]`)
+		case "2":
+			fmt.Fprint(w, `[{"id": 2, "name": "web", "full_name": "octo/web", "archived": true, "description": "Frontend"}]`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	})
+	mux.HandleFunc("GET /api/v1/orgs/alice/repos", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "org not found"}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("GET /api/v1/users/alice/repos", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "1" {
+			fmt.Fprint(w, `[{"id": 3, "name": "dotfiles", "full_name": "alice/dotfiles"}]`)
+			return
+		}
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("GET /api/v1/repos/octo/api", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": 1, "name": "api", "full_name": "octo/api", "default_branch": "main"}`)
+	})
+	mux.HandleFunc("GET /api/v1/repos/octo/api/raw/cmd/main.go", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "package main\n")
+	})
+	mux.HandleFunc("GET /api/v1/repos/octo/api/languages", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Go": 4096, "Makefile": 120}`)
+	})
+	mux.HandleFunc("GET /api/v1/repos/octo/api/branches/main", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name": "main", "commit": {"id": "abc123"}}`)
+	})
+	mux.HandleFunc("GET /api/v1/repos/octo/api/git/trees/main", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.URL.Query().Get("recursive"))
+		if r.URL.Query().Get("page") == "1" {
+			fmt.Fprint(w, `{"tree": [{"path": "cmd", "type": "tree"}], "truncated": true, "total_count": 2}`)
+			return
+		}
+		fmt.Fprint(w, `{"tree": [{"path": "cmd/main.go", "type": "blob", "size": 13}], "total_count": 2}`)
+	})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token gitea-test" {
+			http.Error(w, `{"message": "token is required"}`, http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient(srv.URL, "gitea-test", srv.Client())
+}
+
+func TestClient_ListRepositories(t *testing.T) {
+	c := fakeGitea(t)
+
+	t.Run("organization, paginated", func(t *testing.T) {
+		repos, err := c.ListRepositories(context.Background(), "octo")
+		require.NoError(t, err)
+		require.Len(t, repos, 2)
+		assert.Equal(t, domain.ProviderGitea, repos[0].Provider)
+		assert.Equal(t, "1", repos[0].GithubID)
+		assert.True(t, repos[0].IsPrivate)
+		assert.Equal(t, "Go", repos[0].Language.String)
+		assert.True(t, repos[1].IsArchived)
+		assert.Equal(t, "Frontend", repos[1].Description.String)
+	})
+
+	t.Run("falls back to the user", func(t *testing.T) {
+		repos, err := c.ListRepositories(context.Background(), "alice")
+		require.NoError(t, err)
+		require.Len(t, repos, 1)
+		assert.Equal(t, "alice/dotfiles", repos[0].FullName)
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		_, err := c.ListRepositories(context.Background(), "nobody")
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+}
+
+func TestClient_RepositoryContent(t *testing.T) {
+	c := fakeGitea(t)
+	ctx := context.Background()
+
+	repo, err := c.GetRepository(ctx, "octo", "api")
+	require.NoError(t, err)
+	assert.Equal(t, "main", repo.DefaultBranch)
+
+	content, err := c.GetFileContent(ctx, "octo", "api", "cmd/main.go")
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", content)
+
+	langs, err := c.GetLanguages(ctx, "octo", "api")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"Go": 4096, "Makefile": 120}, langs)
+
+	sha, err := c.GetBranchSHA(ctx, "octo", "api", "main")
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", sha)
+
+	tree, err := c.GetTree(ctx, "octo", "api", "main")
+	require.NoError(t, err)
+	assert.Equal(t, []domain.TreeEntry{{Path: "cmd", Type: "tree"}, {Path: "cmd/main.go", Type: "blob", Size: 13}}, tree)
+
+	_, err = c.GetFileContent(ctx, "octo", "api", "missing.txt")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestClient_BadToken(t *testing.T) {
+	c := fakeGitea(t)
+	c.token = "wrong"
+
+	_, err := c.GetRepository(context.Background(), "octo", "api")
+	assert.ErrorIs(t, err, domain.ErrUpstreamUnavailable)
+}
@@ -0,0 +1,264 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v69/github"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// jwtLifetime stays under GitHub's 10 minute maximum
+	jwtLifetime = 9 * time.Minute
+	// jwtClockSkew backdates iat, as GitHub recommends, to tolerate clock drift
+	jwtClockSkew = time.Minute
+	// tokenRefreshMargin renews installation tokens (valid one hour) before they expire mid-request
+	tokenRefreshMargin = 5 * time.Minute
+	// tokenMintTimeout bounds a token request, which outlives the caller that started it
+	tokenMintTimeout = 30 * time.Second
+)
+
+// revokedInstallationError is a token request GitHub refused because the
+// installation was deleted or suspended
+type revokedInstallationError struct {
+	installationID int64
+	err            error
+}
+
+func (e *revokedInstallationError) Error() string { return e.err.Error() }
+func (e *revokedInstallationError) Unwrap() error { return e.err }
+
+// App authenticates as a GitHub App: it signs JWTs with the app's private key
+// and exchanges them for installation access tokens, cached until shortly
+// before they expire.
+type App struct {
+	id  int64
+	key *rsa.PrivateKey
+	api *github.Client // authenticated with the app JWT
+	now func() time.Time
+
+	mu     sync.Mutex
+	tokens map[int64]*github.InstallationToken
+	// minting shares a token request between the callers of one installation
+	minting singleflight.Group
+}
+
+// NewApp creates a GitHub App from its ID and PEM private key. baseURL
+// overrides the API endpoint (GitHub Enterprise, tests); empty means github.com.
+func NewApp(appID int64, privateKeyPEM []byte, baseURL string, httpClient *http.Client) (*App, error) {
+	key, err := parsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	app := &App{
+		id:     appID,
+		key:    key,
+		now:    time.Now,
+		tokens: make(map[int64]*github.InstallationToken),
+	}
+	api := github.NewClient(&http.Client{Transport: &jwtTransport{app: app, base: transportOf(httpClient)}})
+	if baseURL != "" {
+		if api, err = api.WithEnterpriseURLs(baseURL, baseURL); err != nil {
+			return nil, fmt.Errorf("invalid GitHub API URL: %w", err)
+		}
+	}
+	app.api = api
+	return app, nil
+}
+
+// JWT returns a token authenticating as the app itself
+func (a *App) JWT() (string, error) {
+	now := a.now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-jwtClockSkew).Unix(),
+		"exp": now.Add(jwtLifetime).Unix(),
+		"iss": strconv.FormatInt(a.id, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign app JWT: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// InstallationToken returns a cached access token for the installation,
+// minting a new one when none is cached or it is about to expire. Concurrent
+// callers for the same installation share one request; other installations
+// are not held up.
+func (a *App) InstallationToken(ctx context.Context, installationID int64) (string, error) {
+	if token, ok := a.cachedToken(installationID); ok {
+		return token, nil
+	}
+	token, err, _ := a.minting.Do(strconv.FormatInt(installationID, 10), func() (any, error) {
+		// A request that just finished may have cached one
+		if token, ok := a.cachedToken(installationID); ok {
+			return token, nil
+		}
+		// Shared by every waiter, so not cancelled with the first one
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tokenMintTimeout)
+		defer cancel()
+		token, resp, err := a.api.Apps.CreateInstallationToken(ctx, installationID, nil)
+		observe("create_installation_token", resp, err)
+		if err != nil {
+			revoked := installationRevoked(err)
+			err = fmt.Errorf("failed to create installation token: %w", mapError(err, "GitHub App installation", strconv.FormatInt(installationID, 10)))
+			if revoked {
+				a.ForgetToken(installationID)
+				return "", &revokedInstallationError{installationID: installationID, err: err}
+			}
+			return "", err
+		}
+		a.mu.Lock()
+		a.tokens[installationID] = token
+		a.mu.Unlock()
+		return token.GetToken(), nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return token.(string), nil
+}
+
+// installationRevoked tells whether GitHub refused a token because the
+// installation is gone or suspended. Other refusals, a throttled app or a
+// rejected JWT, say nothing about the installation.
+func installationRevoked(err error) bool {
+	var respErr *github.ErrorResponse
+	if !errors.As(err, &respErr) || respErr.Response == nil {
+		return false
+	}
+	switch respErr.Response.StatusCode {
+	case http.StatusNotFound:
+		return true
+	case http.StatusForbidden:
+		return strings.Contains(strings.ToLower(respErr.Message), "suspended")
+	}
+	return false
+}
+
+func (a *App) cachedToken(installationID int64) (string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if token, ok := a.tokens[installationID]; ok && a.now().Add(tokenRefreshMargin).Before(token.GetExpiresAt().Time) {
+		return token.GetToken(), true
+	}
+	return "", false
+}
+
+// ForgetToken drops the cached token of an installation, so the next call mints a new one
+func (a *App) ForgetToken(installationID int64) {
+	a.mu.Lock()
+	delete(a.tokens, installationID)
+	a.mu.Unlock()
+}
+
+// FindInstallation returns the app's installation on a user or organization account
+func (a *App) FindInstallation(ctx context.Context, account string) (int64, error) {
+	inst, resp, err := a.api.Apps.FindUserInstallation(ctx, account)
+	observe("find_installation", resp, err)
+	if isNotFound(err) {
+		inst, resp, err = a.api.Apps.FindOrganizationInstallation(ctx, account)
+		observe("find_installation", resp, err)
+	}
+	if err != nil {
+		return 0, mapError(err, "GitHub App installation for", account)
+	}
+	return inst.GetID(), nil
+}
+
+// jwtTransport authenticates app-level API calls
+type jwtTransport struct {
+	app  *App
+	base http.RoundTripper
+}
+
+func (t *jwtTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.app.JWT()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(req)
+}
+
+// installationTransport authenticates calls as an installation, refreshing
+// its token as needed. A token GitHub no longer accepts is dropped, and an
+// installation it no longer issues tokens for is forgotten by the client.
+type installationTransport struct {
+	client         *Client
+	installationID int64
+	base           http.RoundTripper
+}
+
+func (t *installationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.client.app.InstallationToken(req.Context(), t.installationID)
+	var revoked *revokedInstallationError
+	if errors.As(err, &revoked) {
+		t.client.ForgetInstallation(req.Context(), t.installationID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "token "+token)
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		t.client.app.ForgetToken(t.installationID)
+	}
+	return resp, err
+}
+
+func transportOf(c *http.Client) http.RoundTripper {
+	if c.Transport != nil {
+		return c.Transport
+	}
+	return http.DefaultTransport
+}
+
+// parsePrivateKey accepts the PKCS#1 keys GitHub issues as well as PKCS#8
+func parsePrivateKey(pemBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, domain.Validation("GitHub App private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
@@ -0,0 +1,271 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/cache"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGitHubApp serves the App endpoints the client uses, checking the JWT on
+// app-level calls and the installation token on repository calls
+type fakeGitHubApp struct {
+	t       *testing.T
+	key     *rsa.PublicKey
+	now     time.Time
+	minted  atomic.Int32
+	lastJWT atomic.Value
+	// installation is the current installation on octo; tokens for others are refused
+	installation atomic.Int64
+	// blocked holds token requests for installation 7 until it is closed
+	blocked chan struct{}
+	// refusal, when set, is the status and message token requests fail with
+	refusal atomic.Pointer[[2]string]
+	lookups atomic.Int32
+}
+
+func (f *fakeGitHubApp) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v3/app/installations/{id}/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		f.verifyJWT(r)
+		if refusal := f.refusal.Load(); refusal != nil {
+			status, _ := strconv.Atoi(refusal[0])
+			http.Error(w, fmt.Sprintf(`{"message": %q}`, refusal[1]), status)
+			return
+		}
+		if r.PathValue("id") == "7" {
+			<-f.blocked
+		} else if r.PathValue("id") != strconv.FormatInt(f.installation.Load(), 10) {
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+			return
+		}
+		n := f.minted.Add(1)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "inst-token-%d", "expires_at": %q}`, n, f.now.Add(time.Hour).Format(time.RFC3339))
+	})
+	mux.HandleFunc("GET /api/v3/users/octo/installation", func(w http.ResponseWriter, r *http.Request) {
+		f.verifyJWT(r)
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("GET /api/v3/orgs/octo/installation", func(w http.ResponseWriter, r *http.Request) {
+		f.verifyJWT(r)
+		f.lookups.Add(1)
+		fmt.Fprintf(w, `{"id": %d}`, f.installation.Load())
+	})
+	mux.HandleFunc("GET /api/v3/repos/octo/repo/languages", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "token inst-token-") {
+			http.Error(w, `{"message": "Bad credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"Go": 100}`)
+	})
+	return mux
+}
+
+func (f *fakeGitHubApp) verifyJWT(r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	require.True(f.t, ok, "app call without JWT")
+	parts := strings.Split(token, ".")
+	require.Len(f.t, parts, 3)
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(f.t, err)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	require.NoError(f.t, rsa.VerifyPKCS1v15(f.key, crypto.SHA256, digest[:], sig))
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(f.t, err)
+	var claims struct {
+		Iat int64  `json:"iat"`
+		Exp int64  `json:"exp"`
+		Iss string `json:"iss"`
+	}
+	require.NoError(f.t, json.Unmarshal(payload, &claims))
+	assert.Equal(f.t, "12345", claims.Iss)
+	assert.Less(f.t, claims.Iat, f.now.Unix())
+	assert.LessOrEqual(f.t, claims.Exp, f.now.Add(10*time.Minute).Unix())
+	f.lastJWT.Store(token)
+}
+
+func newTestApp(t *testing.T) (*App, *fakeGitHubApp) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	fake := &fakeGitHubApp{t: t, key: &key.PublicKey, now: time.Now(), blocked: make(chan struct{})}
+	fake.installation.Store(5)
+	srv := httptest.NewServer(fake.handler())
+	t.Cleanup(srv.Close)
+
+	app, err := NewApp(12345, keyPEM, srv.URL, srv.Client())
+	require.NoError(t, err)
+	app.now = func() time.Time { return fake.now }
+	return app, fake
+}
+
+func TestApp_InstallationToken(t *testing.T) {
+	app, fake := newTestApp(t)
+	ctx := context.Background()
+
+	token, err := app.InstallationToken(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "inst-token-1", token)
+	assert.NotEmpty(t, fake.lastJWT.Load())
+
+	// Cached while comfortably valid
+	fake.now = fake.now.Add(30 * time.Minute)
+	token, err = app.InstallationToken(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "inst-token-1", token)
+	assert.EqualValues(t, 1, fake.minted.Load())
+
+	// Refreshed shortly before it expires
+	fake.now = fake.now.Add(26 * time.Minute)
+	token, err = app.InstallationToken(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "inst-token-2", token)
+}
+
+func TestApp_InstallationTokenLocksPerInstallation(t *testing.T) {
+	app, fake := newTestApp(t)
+	ctx := context.Background()
+
+	// A slow request for installation 7 does not hold up installation 5
+	slow := make(chan error, 1)
+	go func() {
+		_, err := app.InstallationToken(ctx, 7)
+		slow <- err
+	}()
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := app.InstallationToken(ctx, 5)
+			assert.NoError(t, err)
+			assert.Equal(t, "inst-token-1", token)
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, fake.minted.Load(), "concurrent callers share one request")
+
+	close(fake.blocked)
+	require.NoError(t, <-slow)
+}
+
+func TestApp_PKCS8Key(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	_, err = NewApp(1, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), "", nil)
+	assert.NoError(t, err)
+	_, err = NewApp(1, []byte("not a key"), "", nil)
+	assert.Error(t, err)
+}
+
+func TestAppClient_UsesInstallationToken(t *testing.T) {
+	app, fake := newTestApp(t)
+	client := NewAppClient(app, cache.NewMemoryCache())
+
+	langs, err := client.GetLanguages(context.Background(), "octo", "repo")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"Go": 100}, langs)
+
+	// The installation and its token are reused across calls
+	_, err = client.GetLanguages(context.Background(), "octo", "repo")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, fake.minted.Load())
+}
+
+func TestAppClient_ReinstalledApp(t *testing.T) {
+	app, fake := newTestApp(t)
+	client := NewAppClient(app, cache.NewMemoryCache())
+	ctx := context.Background()
+
+	_, err := client.GetLanguages(ctx, "octo", "repo")
+	require.NoError(t, err)
+
+	// Reinstalled: installation 5 is gone, octo is now on 9
+	fake.installation.Store(9)
+	fake.now = fake.now.Add(time.Hour)
+
+	// The refused token makes the client forget installation 5...
+	_, err = client.GetLanguages(ctx, "octo", "repo")
+	assert.Error(t, err)
+
+	// ...so the next call finds the new one
+	langs, err := client.GetLanguages(ctx, "octo", "repo")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"Go": 100}, langs)
+
+	// Forgotten on the webhook too: suspended, then back on 5
+	fake.installation.Store(5)
+	client.ForgetInstallation(ctx, 9)
+	_, err = client.GetLanguages(ctx, "octo", "repo")
+	require.NoError(t, err)
+}
+
+func TestAppClient_RefusedTokens(t *testing.T) {
+	app, fake := newTestApp(t)
+	c := cache.NewMemoryCache()
+	client := NewAppClient(app, c)
+	ctx := context.Background()
+
+	_, err := client.GetLanguages(ctx, "octo", "repo")
+	require.NoError(t, err)
+	require.NoError(t, c.Set(ctx, "github:repos:octo", []string{"repo"}, time.Minute, domain.GitHubAccountCacheTag("Octo")))
+
+	// Throttling and a rejected JWT say nothing about the installation
+	for _, refusal := range [][2]string{
+		{"403", "You have exceeded a secondary rate limit"},
+		{"401", "A JSON web token could not be decoded"},
+	} {
+		fake.refusal.Store(&refusal)
+		fake.now = fake.now.Add(time.Hour)
+		_, err = client.GetLanguages(ctx, "octo", "repo")
+		assert.Error(t, err, refusal[1])
+	}
+	fake.refusal.Store(nil)
+	_, err = client.GetLanguages(ctx, "octo", "repo")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, fake.lookups.Load())
+	found, err := c.Get(ctx, "github:repos:octo", new([]string))
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	// A suspended installation is forgotten, with the responses cached for its accounts
+	fake.refusal.Store(&[2]string{"403", "This installation has been suspended"})
+	fake.now = fake.now.Add(time.Hour)
+	_, err = client.GetLanguages(ctx, "octo", "repo")
+	assert.Error(t, err)
+	found, err = c.Get(ctx, "github:repos:octo", new([]string))
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	fake.refusal.Store(nil)
+	_, err = client.GetLanguages(ctx, "octo", "repo")
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, fake.lookups.Load())
+}
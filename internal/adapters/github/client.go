@@ -2,99 +2,261 @@ package github
 
 import (
 	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/v69/github"
+	"github.com/biodoia/ghrego/internal/core/analyzers"
 	"github.com/biodoia/ghrego/internal/core/domain"
-	"github.com/patrickmn/go-cache"
+	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/biodoia/ghrego/internal/metrics"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"golang.org/x/oauth2"
 )
 
+// cacheTTL bounds how stale GitHub metadata served from cache can be
+const cacheTTL = 5 * time.Minute
+
 type Client struct {
-	client *github.Client
-	cache  *cache.Cache
+	client *github.Client // token mode
+	token  string
+	cache  ports.Cache
+
+	// GitHub App mode: calls are made as the installation on the repository owner
+	app           *App
+	base          http.RoundTripper
+	mu            sync.Mutex
+	installations map[string]int64 // account login -> installation ID
+	clients       map[int64]*github.Client
 }
 
-func NewClient(token string) *Client {
-	ctx := context.Background()
+func NewClient(token string, c ports.Cache) *Client {
+	// Every API call gets a client span, child of the caller's span
+	base := &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, base)
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
 	tc := oauth2.NewClient(ctx, ts)
 	client := github.NewClient(tc)
 
-	// Initialize cache with 5 minute default expiration and 10 minute cleanup interval
-	c := cache.New(5*time.Minute, 10*time.Minute)
-
 	return &Client{
 		client: client,
+		token:  token,
 		cache:  c,
 	}
 }
 
+// NewAppClient creates a client authenticating as installations of a GitHub App
+func NewAppClient(app *App, c ports.Cache) *Client {
+	return &Client{
+		cache:         c,
+		app:           app,
+		base:          otelhttp.NewTransport(http.DefaultTransport),
+		installations: make(map[string]int64),
+		clients:       make(map[int64]*github.Client),
+	}
+}
+
+// api returns the client to use for an account's repositories and, in App
+// mode, the ID of the installation it authenticates as
+func (c *Client) api(ctx context.Context, owner string) (*github.Client, int64, error) {
+	if c.app == nil {
+		return c.client, 0, nil
+	}
+
+	key := strings.ToLower(owner)
+	c.mu.Lock()
+	id, ok := c.installations[key]
+	c.mu.Unlock()
+	if !ok {
+		var err error
+		if id, err = c.app.FindInstallation(ctx, owner); err != nil {
+			return nil, 0, err
+		}
+		c.mu.Lock()
+		c.installations[key] = id
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	client, ok := c.clients[id]
+	if !ok {
+		client = github.NewClient(&http.Client{Transport: &installationTransport{client: c, installationID: id, base: c.base}})
+		// Same API endpoint as the app itself (GitHub Enterprise, tests)
+		client.BaseURL = c.app.api.BaseURL
+		c.clients[id] = client
+	}
+	return client, id, nil
+}
+
+// ForgetInstallation drops the accounts resolved to an installation, its API
+// client, its token and the responses cached for those accounts, which carry
+// the installation ID, after the installation was deleted or suspended. The
+// next call for those accounts looks their installation up again.
+func (c *Client) ForgetInstallation(ctx context.Context, installationID int64) {
+	if c.app == nil {
+		return
+	}
+	var tags []string
+	c.mu.Lock()
+	for account, id := range c.installations {
+		if id == installationID {
+			delete(c.installations, account)
+			tags = append(tags, domain.GitHubAccountCacheTag(account))
+		}
+	}
+	delete(c.clients, installationID)
+	c.mu.Unlock()
+	c.app.ForgetToken(installationID)
+
+	if len(tags) > 0 {
+		if err := c.cache.InvalidateTags(ctx, tags...); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Int64("installation_id", installationID).Msg("Failed to invalidate GitHub cache of a forgotten installation")
+		}
+	}
+}
+
+// Provider identifies the client as the GitHub source host
+func (c *Client) Provider() domain.SourceProvider {
+	return domain.ProviderGitHub
+}
+
+// ListRepositories lists the repositories of a user or organization
+func (c *Client) ListRepositories(ctx context.Context, account string) ([]*domain.Repository, error) {
+	return c.GetUserRepositories(ctx, account)
+}
+
 // GetUserRepositories retrieves all repositories for a user
 func (c *Client) GetUserRepositories(ctx context.Context, username string) ([]*domain.Repository, error) {
-	cacheKey := fmt.Sprintf("repos:%s", username)
-	if cached, found := c.cache.Get(cacheKey); found {
-		return cached.([]*domain.Repository), nil
+	cacheKey := fmt.Sprintf("github:repos:%s", username)
+	var cached []*domain.Repository
+	if found, err := c.cache.Get(ctx, cacheKey, &cached); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("key", cacheKey).Msg("GitHub cache read failed")
+	} else if found {
+		return cached, nil
 	}
 
+	gh, installationID, err := c.api(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories: %w", err)
+	}
+	var allRepos []*github.Repository
+	if installationID != 0 {
+		allRepos, err = listInstallationRepositories(ctx, gh)
+	} else {
+		allRepos, err = listAccountRepositories(ctx, gh, username)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories: %w", mapError(err, "GitHub user", username))
+	}
+
+	// Map to domain.Repository
+	var domainRepos []*domain.Repository
+	for _, r := range allRepos {
+		repo := mapGitHubRepoToDomain(r)
+		if installationID != 0 {
+			repo.InstallationID = sql.NullInt64{Int64: installationID, Valid: true}
+		}
+		domainRepos = append(domainRepos, repo)
+	}
+
+	if err := c.cache.Set(ctx, cacheKey, domainRepos, cacheTTL, domain.GitHubAccountCacheTag(username)); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("key", cacheKey).Msg("GitHub cache write failed")
+	}
+	return domainRepos, nil
+}
+
+func listAccountRepositories(ctx context.Context, gh *github.Client, username string) ([]*github.Repository, error) {
 	opts := &github.RepositoryListOptions{
 		Type:        "all",
 		ListOptions: github.ListOptions{PerPage: 100},
 	}
-
-	var allRepos []*github.Repository
+	var all []*github.Repository
 	for {
-		repos, resp, err := c.client.Repositories.List(ctx, username, opts)
+		repos, resp, err := gh.Repositories.List(ctx, username, opts)
+		observe("list_repositories", resp, err)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list repositories: %w", err)
+			return nil, err
 		}
-		allRepos = append(allRepos, repos...)
+		all = append(all, repos...)
 		if resp.NextPage == 0 {
-			break
+			return all, nil
 		}
 		opts.Page = resp.NextPage
 	}
+}
 
-	// Map to domain.Repository
-	var domainRepos []*domain.Repository
-	for _, r := range allRepos {
-		domainRepos = append(domainRepos, mapGitHubRepoToDomain(r))
+// listInstallationRepositories lists every repository the installation was granted
+func listInstallationRepositories(ctx context.Context, gh *github.Client) ([]*github.Repository, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	var all []*github.Repository
+	for {
+		list, resp, err := gh.Apps.ListRepos(ctx, opts)
+		observe("list_installation_repositories", resp, err)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, list.Repositories...)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
 	}
-
-	c.cache.Set(cacheKey, domainRepos, cache.DefaultExpiration)
-	return domainRepos, nil
 }
 
 // GetRepository retrieves detailed information about a repository
 func (c *Client) GetRepository(ctx context.Context, owner, repoName string) (*domain.Repository, error) {
-	cacheKey := fmt.Sprintf("repo:%s/%s", owner, repoName)
-	if cached, found := c.cache.Get(cacheKey); found {
-		return cached.(*domain.Repository), nil
+	cacheKey := fmt.Sprintf("github:repo:%s/%s", owner, repoName)
+	var cached domain.Repository
+	if found, err := c.cache.Get(ctx, cacheKey, &cached); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("key", cacheKey).Msg("GitHub cache read failed")
+	} else if found {
+		return &cached, nil
 	}
 
-	repo, _, err := c.client.Repositories.Get(ctx, owner, repoName)
+	gh, installationID, err := c.api(ctx, owner)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get repository: %w", err)
 	}
+	repo, resp, err := gh.Repositories.Get(ctx, owner, repoName)
+	observe("get_repository", resp, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository: %w", mapError(err, "GitHub repository", owner+"/"+repoName))
+	}
 
 	domainRepo := mapGitHubRepoToDomain(repo)
-	c.cache.Set(cacheKey, domainRepo, cache.DefaultExpiration)
+	if installationID != 0 {
+		domainRepo.InstallationID = sql.NullInt64{Int64: installationID, Valid: true}
+	}
+	if err := c.cache.Set(ctx, cacheKey, domainRepo, cacheTTL, domain.GitHubAccountCacheTag(owner)); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("key", cacheKey).Msg("GitHub cache write failed")
+	}
 	return domainRepo, nil
 }
 
 // GetFileContent retrieves content of a file
 func (c *Client) GetFileContent(ctx context.Context, owner, repo, path string) (string, error) {
-	fileContent, _, _, err := c.client.Repositories.GetContents(ctx, owner, repo, path, nil)
+	gh, _, err := c.api(ctx, owner)
 	if err != nil {
-		// Handle 404
-		if strings.Contains(err.Error(), "404") {
+		return "", err
+	}
+	fileContent, _, resp, err := gh.Repositories.GetContents(ctx, owner, repo, path, nil)
+	observe("get_contents", resp, err)
+	if err != nil {
+		// A missing file is an expected outcome, not an error
+		if isNotFound(err) {
 			return "", nil
 		}
-		return "", err
+		return "", mapError(err, "file", path)
 	}
 
 	if fileContent == nil {
@@ -110,46 +272,148 @@ func (c *Client) GetFileContent(ctx context.Context, owner, repo, path string) (
 
 // GetLanguages retrieves language statistics
 func (c *Client) GetLanguages(ctx context.Context, owner, repo string) (map[string]int, error) {
-	langs, _, err := c.client.Repositories.ListLanguages(ctx, owner, repo)
+	gh, _, err := c.api(ctx, owner)
 	if err != nil {
 		return nil, err
 	}
+	langs, resp, err := gh.Repositories.ListLanguages(ctx, owner, repo)
+	observe("list_languages", resp, err)
+	if err != nil {
+		return nil, mapError(err, "GitHub repository", owner+"/"+repo)
+	}
 	return langs, nil
 }
 
-// AnalyzeStructure performs a tree analysis (equivalent to analyzeRepositoryStructure)
-func (c *Client) AnalyzeStructure(ctx context.Context, owner, repo string) (int, []string, map[string]int, error) {
-	repoData, _, err := c.client.Repositories.Get(ctx, owner, repo)
+// GetBranchSHA returns the head commit SHA of a branch
+func (c *Client) GetBranchSHA(ctx context.Context, owner, repo, branch string) (string, error) {
+	gh, _, err := c.api(ctx, owner)
 	if err != nil {
-		return 0, nil, nil, err
+		return "", err
 	}
-	branch := repoData.GetDefaultBranch()
+	b, resp, err := gh.Repositories.GetBranch(ctx, owner, repo, branch, 1)
+	observe("get_branch", resp, err)
+	if err != nil {
+		return "", fmt.Errorf("failed to get branch: %w", mapError(err, "branch", branch))
+	}
+	return b.GetCommit().GetSHA(), nil
+}
 
-	tree, _, err := c.client.Git.GetTree(ctx, owner, repo, branch, true)
+// IsOrgMember reports whether a user belongs to a GitHub organization
+func (c *Client) IsOrgMember(ctx context.Context, org, username string) (bool, error) {
+	gh, _, err := c.api(ctx, org)
 	if err != nil {
-		return 0, nil, nil, err
+		return false, err
 	}
+	member, resp, err := gh.Organizations.IsMember(ctx, org, username)
+	observe("is_org_member", resp, err)
+	if err != nil {
+		return false, mapError(err, "GitHub organization", org)
+	}
+	return member, nil
+}
 
-	var totalFiles int
-	var dirs []string
-	fileTypes := make(map[string]int)
+// GetTree lists the repository tree at ref, recursively
+func (c *Client) GetTree(ctx context.Context, owner, repo, ref string) ([]domain.TreeEntry, error) {
+	gh, _, err := c.api(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	tree, resp, err := gh.Git.GetTree(ctx, owner, repo, ref, true)
+	observe("get_tree", resp, err)
+	if err != nil {
+		return nil, mapError(err, "tree", ref)
+	}
 
+	entries := make([]domain.TreeEntry, 0, len(tree.Entries))
 	for _, entry := range tree.Entries {
-		if entry.GetType() == "blob" {
-			totalFiles++
-			path := entry.GetPath()
-			parts := strings.Split(path, ".")
-			ext := "no-extension"
-			if len(parts) > 1 {
-				ext = parts[len(parts)-1]
-			}
-			fileTypes[ext]++
-		} else if entry.GetType() == "tree" {
-			dirs = append(dirs, entry.GetPath())
+		entries = append(entries, domain.TreeEntry{Path: entry.GetPath(), Type: entry.GetType(), Size: int64(entry.GetSize())})
+	}
+	return entries, nil
+}
+
+// CloneSource returns the repository's HTTPS clone URL, authenticated with the
+// token or, in App mode, with an installation token
+func (c *Client) CloneSource(ctx context.Context, owner, repo string) (domain.CloneSource, error) {
+	gh, installationID, err := c.api(ctx, owner)
+	if err != nil {
+		return domain.CloneSource{}, err
+	}
+	repoData, resp, err := gh.Repositories.Get(ctx, owner, repo)
+	observe("get_repository", resp, err)
+	if err != nil {
+		return domain.CloneSource{}, mapError(err, "GitHub repository", owner+"/"+repo)
+	}
+
+	token := c.token
+	if installationID != 0 {
+		if token, err = c.app.InstallationToken(ctx, installationID); err != nil {
+			return domain.CloneSource{}, err
 		}
 	}
+	src := domain.CloneSource{URL: repoData.GetCloneURL()}
+	if token != "" {
+		src.AuthHeader = "Basic " + base64.StdEncoding.EncodeToString([]byte("x-access-token:"+token))
+	}
+	return src, nil
+}
+
+// AnalyzeStructure classifies the default branch tree (equivalent to
+// analyzeRepositoryStructure). .gitattributes files and extensionless scripts
+// are fetched through the contents API.
+func (c *Client) AnalyzeStructure(ctx context.Context, owner, repo string) (*domain.RepositoryStructure, error) {
+	gh, _, err := c.api(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	repoData, resp, err := gh.Repositories.Get(ctx, owner, repo)
+	observe("get_repository", resp, err)
+	if err != nil {
+		return nil, mapError(err, "GitHub repository", owner+"/"+repo)
+	}
 
-	return totalFiles, dirs, fileTypes, nil
+	tree, err := c.GetTree(ctx, owner, repo, repoData.GetDefaultBranch())
+	if err != nil {
+		return nil, err
+	}
+
+	return analyzers.Structure(ctx, tree, func(ctx context.Context, path string) (string, error) {
+		return c.GetFileContent(ctx, owner, repo, path)
+	})
+}
+
+// mapError translates go-github errors into domain errors
+func mapError(err error, resource, id string) error {
+	var rateErr *github.RateLimitError
+	var abuseErr *github.AbuseRateLimitError
+	switch {
+	case errors.As(err, &rateErr):
+		return &domain.Error{Kind: domain.ErrRateLimited, Message: "GitHub rate limit exceeded", Err: err, RetryAt: rateErr.Rate.Reset.Time}
+	case errors.As(err, &abuseErr):
+		rateLimited := &domain.Error{Kind: domain.ErrRateLimited, Message: "GitHub rate limit exceeded", Err: err}
+		if abuseErr.RetryAfter != nil {
+			rateLimited.RetryAt = time.Now().Add(*abuseErr.RetryAfter)
+		}
+		return rateLimited
+	case isNotFound(err):
+		return domain.NotFound(resource, id)
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	default:
+		return domain.UpstreamUnavailable("GitHub", err)
+	}
+}
+
+func isNotFound(err error) bool {
+	var respErr *github.ErrorResponse
+	return errors.As(err, &respErr) && respErr.Response != nil && respErr.Response.StatusCode == http.StatusNotFound
+}
+
+// observe records the outcome of an API call and the rate limit GitHub reported with it
+func observe(operation string, resp *github.Response, err error) {
+	metrics.GitHubRequests.WithLabelValues(operation, metrics.Outcome(err)).Inc()
+	if resp != nil && resp.Rate.Limit > 0 {
+		metrics.GitHubRateLimitRemaining.Set(float64(resp.Rate.Remaining))
+	}
 }
 
 // Helper to map GitHub struct to Domain struct
@@ -159,15 +423,18 @@ func mapGitHubRepoToDomain(ghRepo *github.Repository) *domain.Repository {
 	// Real implementation needs to handle pointers.
 	
 	repo := &domain.Repository{
+		Provider:      domain.ProviderGitHub,
 		GithubID:      fmt.Sprintf("%d", ghRepo.GetID()),
 		Name:          ghRepo.GetName(),
 		FullName:      ghRepo.GetFullName(),
 		URL:           ghRepo.GetHTMLURL(),
 		IsPrivate:     ghRepo.GetPrivate(),
+		IsArchived:    ghRepo.GetArchived(),
 		Stars:         ghRepo.GetStargazersCount(),
 		Forks:         ghRepo.GetForksCount(),
 		Size:          ghRepo.GetSize(),
 		DefaultBranch: ghRepo.GetDefaultBranch(),
+		Topics:        ghRepo.Topics,
 		CreatedAt:     ghRepo.GetCreatedAt().Time,
 		UpdatedAt:     ghRepo.GetUpdatedAt().Time,
 	}
@@ -0,0 +1,32 @@
+package github
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/google/go-github/v69/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapError(t *testing.T) {
+	respErr := func(status int) error {
+		return &github.ErrorResponse{Response: &http.Response{StatusCode: status, Request: &http.Request{}}, Message: "boom"}
+	}
+
+	assert.ErrorIs(t, mapError(respErr(http.StatusNotFound), "file", "go.mod"), domain.ErrNotFound)
+	reset := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	rateErr := mapError(&github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: reset}}, Response: &http.Response{Request: &http.Request{}}}, "repo", "a/b")
+	assert.ErrorIs(t, rateErr, domain.ErrRateLimited)
+	var de *domain.Error
+	require.ErrorAs(t, rateErr, &de)
+	assert.Equal(t, reset, de.RetryAt)
+	assert.ErrorIs(t, mapError(respErr(http.StatusBadGateway), "repo", "a/b"), domain.ErrUpstreamUnavailable)
+
+	// A 404-looking message is no longer mistaken for a missing resource
+	assert.False(t, isNotFound(errors.New("unexpected status 404 from proxy")))
+	assert.True(t, isNotFound(respErr(http.StatusNotFound)))
+}
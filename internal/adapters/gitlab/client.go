@@ -0,0 +1,285 @@
+// Package gitlab reads repositories from GitLab (gitlab.com or self-managed)
+// through the REST API v4.
+package gitlab
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/metrics"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// DefaultBaseURL is used when no instance URL is configured
+const DefaultBaseURL = "https://gitlab.com"
+
+const perPage = 100
+
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient creates a client for the GitLab instance at baseURL ("" for
+// gitlab.com), authenticated with a personal, group or project access token
+func NewClient(baseURL, token string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		// Every API call gets a client span, child of the caller's span
+		httpClient = &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	}
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/") + "/api/v4/",
+		token:   token,
+		http:    httpClient,
+	}
+}
+
+func (c *Client) Provider() domain.SourceProvider {
+	return domain.ProviderGitLab
+}
+
+// ListRepositories lists the projects of a group, including subgroups, or of a user
+func (c *Client) ListRepositories(ctx context.Context, account string) ([]*domain.Repository, error) {
+	query := url.Values{"include_subgroups": {"true"}}
+	projects, err := listPages[project](ctx, c, "list_repositories", "groups/"+url.PathEscape(account)+"/projects", query, "GitLab account", account)
+	if errors.Is(err, domain.ErrNotFound) {
+		projects, err = listPages[project](ctx, c, "list_repositories", "users/"+url.PathEscape(account)+"/projects", nil, "GitLab account", account)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories: %w", err)
+	}
+
+	repos := make([]*domain.Repository, 0, len(projects))
+	for i := range projects {
+		repos = append(repos, projects[i].toDomain())
+	}
+	return repos, nil
+}
+
+func (c *Client) GetRepository(ctx context.Context, owner, repoName string) (*domain.Repository, error) {
+	var p project
+	if err := c.get(ctx, "get_repository", projectPath(owner, repoName), nil, &p, "GitLab repository", owner+"/"+repoName); err != nil {
+		return nil, err
+	}
+	return p.toDomain(), nil
+}
+
+// GetFileContent returns a file from the default branch
+func (c *Client) GetFileContent(ctx context.Context, owner, repo, path string) (string, error) {
+	resp, err := c.do(ctx, "get_file", projectPath(owner, repo)+"/repository/files/"+url.PathEscape(path)+"/raw", nil, "file", path)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", domain.UpstreamUnavailable("GitLab", err)
+	}
+	return string(content), nil
+}
+
+// GetLanguages returns the language breakdown. GitLab only reports
+// percentages, scaled here to hundredths of a percent: the shares, not the
+// absolute sizes, are what callers compare.
+func (c *Client) GetLanguages(ctx context.Context, owner, repo string) (map[string]int, error) {
+	var percentages map[string]float64
+	if err := c.get(ctx, "list_languages", projectPath(owner, repo)+"/languages", nil, &percentages, "GitLab repository", owner+"/"+repo); err != nil {
+		return nil, err
+	}
+	langs := make(map[string]int, len(percentages))
+	for lang, pct := range percentages {
+		langs[lang] = int(pct * 100)
+	}
+	return langs, nil
+}
+
+func (c *Client) GetBranchSHA(ctx context.Context, owner, repo, branch string) (string, error) {
+	var b struct {
+		Commit struct {
+			ID string `json:"id"`
+		} `json:"commit"`
+	}
+	if err := c.get(ctx, "get_branch", projectPath(owner, repo)+"/repository/branches/"+url.PathEscape(branch), nil, &b, "branch", branch); err != nil {
+		return "", fmt.Errorf("failed to get branch: %w", err)
+	}
+	return b.Commit.ID, nil
+}
+
+// GetTree lists the tree at ref. GitLab does not report blob sizes in trees.
+func (c *Client) GetTree(ctx context.Context, owner, repo, ref string) ([]domain.TreeEntry, error) {
+	query := url.Values{"recursive": {"true"}, "ref": {ref}}
+	nodes, err := listPages[treeNode](ctx, c, "get_tree", projectPath(owner, repo)+"/repository/tree", query, "tree", ref)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]domain.TreeEntry, 0, len(nodes))
+	for _, n := range nodes {
+		entries = append(entries, domain.TreeEntry{Path: n.Path, Type: n.Type})
+	}
+	return entries, nil
+}
+
+// CloneSource returns the project's HTTPS clone URL; GitLab takes access
+// tokens as the password of any user name
+func (c *Client) CloneSource(ctx context.Context, owner, repo string) (domain.CloneSource, error) {
+	var p project
+	if err := c.get(ctx, "get_repository", projectPath(owner, repo), nil, &p, "GitLab repository", owner+"/"+repo); err != nil {
+		return domain.CloneSource{}, err
+	}
+	src := domain.CloneSource{URL: p.HTTPURLToRepo}
+	if c.token != "" {
+		src.AuthHeader = "Basic " + base64.StdEncoding.EncodeToString([]byte("oauth2:"+c.token))
+	}
+	return src, nil
+}
+
+type project struct {
+	ID                int64      `json:"id"`
+	Name              string     `json:"name"`
+	PathWithNamespace string     `json:"path_with_namespace"`
+	Description       *string    `json:"description"`
+	WebURL            string     `json:"web_url"`
+	HTTPURLToRepo     string     `json:"http_url_to_repo"`
+	Visibility        string     `json:"visibility"`
+	StarCount         int        `json:"star_count"`
+	ForksCount        int        `json:"forks_count"`
+	DefaultBranch     string     `json:"default_branch"`
+	Archived          bool       `json:"archived"`
+	Topics            []string   `json:"topics"`
+	CreatedAt         time.Time  `json:"created_at"`
+	LastActivityAt    *time.Time `json:"last_activity_at"`
+}
+
+func (p *project) toDomain() *domain.Repository {
+	repo := &domain.Repository{
+		Provider:      domain.ProviderGitLab,
+		GithubID:      strconv.FormatInt(p.ID, 10),
+		Name:          p.Name,
+		FullName:      p.PathWithNamespace,
+		URL:           p.WebURL,
+		IsPrivate:     p.Visibility != "public",
+		IsArchived:    p.Archived,
+		Stars:         p.StarCount,
+		Forks:         p.ForksCount,
+		DefaultBranch: p.DefaultBranch,
+		Topics:        p.Topics,
+		CreatedAt:     p.CreatedAt,
+	}
+	if p.Description != nil && *p.Description != "" {
+		repo.Description = sql.NullString{String: *p.Description, Valid: true}
+	}
+	if p.LastActivityAt != nil {
+		repo.UpdatedAt = *p.LastActivityAt
+		repo.LastCommitAt = sql.NullTime{Time: *p.LastActivityAt, Valid: true}
+	}
+	return repo
+}
+
+type treeNode struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+// projectPath addresses a project by its URL-encoded full path; owner may
+// hold the first segment only, with subgroups left in repo
+func projectPath(owner, repo string) string {
+	return "projects/" + url.PathEscape(owner+"/"+repo)
+}
+
+// listPages follows GitLab's X-Next-Page header until the last page
+func listPages[T any](ctx context.Context, c *Client, operation, path string, query url.Values, resource, id string) ([]T, error) {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("per_page", strconv.Itoa(perPage))
+
+	var all []T
+	for page := "1"; page != ""; {
+		q.Set("page", page)
+		resp, err := c.do(ctx, operation, path, q, resource, id)
+		if err != nil {
+			return nil, err
+		}
+		var items []T
+		err = json.NewDecoder(resp.Body).Decode(&items)
+		resp.Body.Close()
+		if err != nil {
+			return nil, domain.UpstreamUnavailable("GitLab", fmt.Errorf("invalid response: %w", err))
+		}
+		all = append(all, items...)
+		page = resp.Header.Get("X-Next-Page")
+	}
+	return all, nil
+}
+
+func (c *Client) get(ctx context.Context, operation, path string, query url.Values, out any, resource, id string) error {
+	resp, err := c.do(ctx, operation, path, query, resource, id)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return domain.UpstreamUnavailable("GitLab", fmt.Errorf("invalid response: %w", err))
+	}
+	return nil
+}
+
+// do sends an authenticated GET and maps error statuses to domain errors. On
+// success the caller owns the response body.
+func (c *Client) do(ctx context.Context, operation, path string, query url.Values, resource, id string) (*http.Response, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err == nil && resp.StatusCode >= 300 {
+		resp.Body.Close()
+		err = mapStatus(resp, resource, id)
+	} else if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		err = domain.UpstreamUnavailable("GitLab", err)
+	}
+	metrics.SourceHostRequests.WithLabelValues(string(domain.ProviderGitLab), operation, metrics.Outcome(err)).Inc()
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func mapStatus(resp *http.Response, resource, id string) error {
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return domain.NotFound(resource, id)
+	case http.StatusTooManyRequests:
+		rateLimited := &domain.Error{Kind: domain.ErrRateLimited, Message: "GitLab rate limit exceeded"}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			rateLimited.RetryAt = time.Now().Add(time.Duration(seconds) * time.Second)
+		}
+		return rateLimited
+	default:
+		return domain.UpstreamUnavailable("GitLab", fmt.Errorf("unexpected status %d", resp.StatusCode))
+	}
+}
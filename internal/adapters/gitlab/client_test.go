@@ -0,0 +1,117 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGitLab routes on the escaped path, as GitLab addresses projects by their encoded full path
+func fakeGitLab(t *testing.T) *Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "glpat-test" {
+			http.Error(w, `{"message": "401 Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.EscapedPath() {
+		case "/api/v4/groups/octo/projects":
+			assert.Equal(t, "true", r.URL.Query().Get("include_subgroups"))
+			if r.URL.Query().Get("page") == "1" {
+				w.Header().Set("X-Next-Page", "2")
+				fmt.Fprint(w, `[{"id": 1, "name": "api", "path_with_namespace": "octo/api", "visibility": "private", "default_branch": "main", "star_count": 3}]`)
+				return
+			}
+			fmt.Fprint(w, `[{"id": 2, "name": "web", "path_with_namespace": "octo/platform/web", "visibility": "public", "description": "Frontend", "archived": true}]`)
+		case "/api/v4/groups/alice/projects":
+			http.Error(w, `{"message": "404 Group Not Found"}`, http.StatusNotFound)
+		case "/api/v4/users/alice/projects":
+			fmt.Fprint(w, `[{"id": 3, "name": "dotfiles", "path_with_namespace": "alice/dotfiles", "visibility": "public"}]`)
+		case "/api/v4/projects/octo%2Fplatform%2Fweb":
+			fmt.Fprint(w, `{"id": 2, "name": "web", "path_with_namespace": "octo/platform/web", "visibility": "public", "default_branch": "main"}`)
+		case "/api/v4/projects/octo%2Fapi/repository/files/cmd%2Fmain.go/raw":
+			fmt.Fprint(w, "package main\n")
+		case "/api/v4/projects/octo%2Fapi/languages":
+			fmt.Fprint(w, `{"Go": 87.5, "Shell": 12.5}`)
+		case "/api/v4/projects/octo%2Fapi/repository/branches/main":
+			fmt.Fprint(w, `{"name": "main", "commit": {"id": "abc123"}}`)
+		case "/api/v4/projects/octo%2Fapi/repository/tree":
+			assert.Equal(t, "main", r.URL.Query().Get("ref"))
+			fmt.Fprint(w, `[{"path": "cmd", "type": "tree"}, {"path": "cmd/main.go", "type": "blob"}]`)
+		default:
+			http.Error(w, `{"message": "404 Not Found"}`, http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient(srv.URL, "glpat-test", srv.Client())
+}
+
+func TestClient_ListRepositories(t *testing.T) {
+	c := fakeGitLab(t)
+
+	t.Run("group with subgroups, paginated", func(t *testing.T) {
+		repos, err := c.ListRepositories(context.Background(), "octo")
+		require.NoError(t, err)
+		require.Len(t, repos, 2)
+		assert.Equal(t, domain.ProviderGitLab, repos[0].Provider)
+		assert.Equal(t, "1", repos[0].GithubID)
+		assert.True(t, repos[0].IsPrivate)
+		assert.Equal(t, "octo/platform/web", repos[1].FullName)
+		assert.True(t, repos[1].IsArchived)
+		assert.Equal(t, "Frontend", repos[1].Description.String)
+	})
+
+	t.Run("falls back to the user", func(t *testing.T) {
+		repos, err := c.ListRepositories(context.Background(), "alice")
+		require.NoError(t, err)
+		require.Len(t, repos, 1)
+		assert.Equal(t, "alice/dotfiles", repos[0].FullName)
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		_, err := c.ListRepositories(context.Background(), "nobody")
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+}
+
+func TestClient_RepositoryContent(t *testing.T) {
+	c := fakeGitLab(t)
+	ctx := context.Background()
+
+	// Subgroups stay in the repository part of the full name
+	repo, err := c.GetRepository(ctx, "octo", "platform/web")
+	require.NoError(t, err)
+	assert.Equal(t, "main", repo.DefaultBranch)
+
+	content, err := c.GetFileContent(ctx, "octo", "api", "cmd/main.go")
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", content)
+
+	langs, err := c.GetLanguages(ctx, "octo", "api")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"Go": 8750, "Shell": 1250}, langs)
+
+	sha, err := c.GetBranchSHA(ctx, "octo", "api", "main")
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", sha)
+
+	tree, err := c.GetTree(ctx, "octo", "api", "main")
+	require.NoError(t, err)
+	assert.Equal(t, []domain.TreeEntry{{Path: "cmd", Type: "tree"}, {Path: "cmd/main.go", Type: "blob"}}, tree)
+
+	_, err = c.GetFileContent(ctx, "octo", "api", "missing.txt")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestClient_BadToken(t *testing.T) {
+	c := fakeGitLab(t)
+	c.token = "wrong"
+
+	_, err := c.GetRepository(context.Background(), "octo", "api")
+	assert.ErrorIs(t, err, domain.ErrUpstreamUnavailable)
+}
@@ -0,0 +1,207 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/biodoia/ghrego/internal/cache"
+	"github.com/biodoia/ghrego/internal/config"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/services"
+	"github.com/biodoia/ghrego/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// noopJobs accepts background jobs without running them
+type noopJobs struct{}
+
+func (noopJobs) Go(ctx context.Context, name string, fn func(ctx context.Context)) error { return nil }
+
+// authzCaller describes the caller (user 1 in the mock auth middleware)
+type authzCaller struct {
+	name          string
+	platformRole  domain.UserRole
+	workspaceRole domain.WorkspaceRole // role in team workspace 7, "" when not a member
+}
+
+// newAuthzTestServer wires the real services over mocked storage. Repositories
+// 10 and 12 belong to team workspace 7, where user 3 is a maintainer; the
+// batch was started by user 2.
+func newAuthzTestServer(t *testing.T, caller authzCaller, batchID uuid.UUID) *Server {
+	t.Helper()
+
+	userRepo := new(mocks.UserRepository)
+	repoStore := new(mocks.RepositoryStore)
+	workspaceRepo := new(mocks.WorkspaceRepository)
+	ghClient := new(mocks.GitHubClient)
+	localHost := &mocks.SourceHost{Name: domain.ProviderLocal}
+	analysisRepo := new(mocks.AnalysisRepository)
+	featureRepo := new(mocks.FeatureRepository)
+	techRepo := new(mocks.TechnologyRepository)
+	licenseRepo := new(mocks.LicenseRepository)
+	suggRepo := new(mocks.SuggestionRepository)
+	batchRepo := new(mocks.BatchRepository)
+	usage := new(mocks.UsageService)
+	tokenRepo := new(mocks.APITokenRepository)
+	webhookRepo := new(mocks.WebhookRepository)
+
+	user := &domain.User{ID: 1, OpenID: "open-1", Role: caller.platformRole, GithubUsername: domain.SQLNullString("caller")}
+	team := &domain.Workspace{ID: 7, Name: "team"}
+	repo := domain.Repository{ID: 10, UserID: 2, WorkspaceID: 7, FullName: "team/repo"}
+
+	userRepo.On("GetByID", mock.Anything, 1).Return(user, nil)
+	if caller.workspaceRole != "" {
+		workspaceRepo.On("GetMember", mock.Anything, 7, 1).Return(&domain.WorkspaceMember{WorkspaceID: 7, UserID: 1, Role: caller.workspaceRole}, nil)
+	} else {
+		workspaceRepo.On("GetMember", mock.Anything, 7, 1).Return(nil, domain.NotFound("workspace member", 1))
+	}
+	workspaceRepo.On("GetMember", mock.Anything, 7, 3).Return(&domain.WorkspaceMember{WorkspaceID: 7, UserID: 3, Role: domain.WorkspaceRoleMaintainer}, nil)
+	workspaceRepo.On("GetByID", mock.Anything, 7).Return(team, nil)
+	workspaceRepo.On("GetPersonal", mock.Anything, 1).Return(&domain.Workspace{ID: 5, Name: "caller"}, nil)
+	workspaceRepo.On("ListByUser", mock.Anything, 1).Return([]domain.WorkspaceMembership{}, nil)
+	workspaceRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Workspace"), 1).Return(nil)
+	workspaceRepo.On("ListMembers", mock.Anything, 7).Return([]domain.WorkspaceMember{}, nil)
+	workspaceRepo.On("RemoveMember", mock.Anything, 7, 3).Return(nil)
+	workspaceRepo.On("CreateInvitation", mock.Anything, mock.AnythingOfType("*domain.WorkspaceInvitation")).Return(nil)
+	workspaceRepo.On("ListPendingInvitations", mock.Anything, "caller").Return([]domain.WorkspaceInvitation{}, nil)
+	workspaceRepo.On("GetInvitation", mock.Anything, 9).Return(&domain.WorkspaceInvitation{ID: 9, WorkspaceID: 7, GithubUsername: "caller", Role: domain.WorkspaceRoleViewer, Status: domain.InvitationStatusPending}, nil)
+	workspaceRepo.On("AcceptInvitation", mock.Anything, mock.AnythingOfType("*domain.WorkspaceInvitation"), 1).Return(nil)
+	ghClient.On("GetUserRepositories", mock.Anything, "caller").Return([]*domain.Repository{}, nil)
+	localHost.On("GetRepository", mock.Anything, "team", "api").Return(&domain.Repository{FullName: "team/api"}, nil)
+	repoStore.On("Upsert", mock.Anything, mock.AnythingOfType("*domain.Repository")).Return(11, nil)
+	repoStore.On("GetByID", mock.Anything, 10).Return(&repo, nil)
+	repoStore.On("GetByID", mock.Anything, 12).Return(&domain.Repository{ID: 12, UserID: 2, WorkspaceID: 7, FullName: "team/web"}, nil)
+	repoStore.On("GetByIDs", mock.Anything, []int{10}).Return([]domain.Repository{repo}, nil)
+	repoStore.On("GetByWorkspaceID", mock.Anything, 7).Return([]domain.Repository{repo}, nil)
+	repoStore.On("GetStats", mock.Anything, 7).Return(map[string]interface{}{"totalRepositories": 1}, nil)
+	repoStore.On("Delete", mock.Anything, 10).Return(nil)
+	analysisRepo.On("GetByRepositoryID", mock.Anything, 10).Return([]domain.Analysis{}, nil)
+	featureRepo.On("GetByRepositoryID", mock.Anything, 10).Return([]domain.Feature{}, nil)
+	techRepo.On("GetByRepositoryID", mock.Anything, 10).Return([]domain.Technology{}, nil)
+	techRepo.On("GetByWorkspaceID", mock.Anything, 7).Return([]domain.Technology{}, nil)
+	licenseRepo.On("GetByRepositoryIDs", mock.Anything, mock.Anything).Return([]domain.RepositoryLicense{}, nil)
+	suggRepo.On("GetByRepositoryID", mock.Anything, 10).Return([]domain.Suggestion{}, nil)
+	suggRepo.On("GetAllPending", mock.Anything, 7).Return([]domain.Suggestion{}, nil)
+	suggRepo.On("GetByID", mock.Anything, 4).Return(&domain.Suggestion{ID: 4, RepositoryID: 10}, nil)
+	suggRepo.On("UpdateStatus", mock.Anything, 4, domain.SuggestionStatusAccepted).Return(nil)
+	batchRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.AnalysisBatch"), []int{10}).Return(nil)
+	batchRepo.On("GetByID", mock.Anything, batchID).Return(&domain.AnalysisBatch{ID: batchID, UserID: 2, Total: 1}, nil)
+	batchRepo.On("GetItems", mock.Anything, batchID).Return([]domain.AnalysisBatchItem{}, nil)
+	tokenRepo.On("ListByUser", mock.Anything, 1).Return([]domain.APIToken{}, nil)
+	tokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.APIToken")).Return(nil)
+	tokenRepo.On("Delete", mock.Anything, 1, 3).Return(nil)
+	webhookRepo.On("GetDelivery", mock.Anything, "d-1").Return(&domain.WebhookDelivery{ID: "d-1", Event: "ping", Payload: []byte(`{}`)}, nil)
+	webhookRepo.On("UpdateDeliveryStatus", mock.Anything, "d-1", domain.WebhookDeliveryIgnored, "").Return(nil)
+	usage.On("CheckBudget", mock.Anything).Return(nil)
+	usage.On("GetUserUsage", mock.Anything, 1, mock.Anything).Return(&domain.UsageReport{}, nil)
+
+	appCache := cache.NewMemoryCache()
+	authz := services.NewAuthorizer(userRepo, repoStore, workspaceRepo)
+	ghService := services.NewGitHubService(ghClient, services.NewSourceHosts(ghClient, localHost), repoStore, userRepo, appCache, authz)
+	aiService := services.NewAIAnalysisService(nil, services.NewSourceHosts(ghClient), nil, nil, nil, repoStore, analysisRepo, featureRepo, techRepo, licenseRepo, suggRepo, appCache, usage, authz)
+	bulkService := services.NewBulkAnalysisService(aiService, repoStore, batchRepo, authz, noopJobs{}, 10, 1, 1)
+	workspaceService := services.NewWorkspaceService(workspaceRepo, userRepo, authz)
+	webhookService := services.NewWebhookService(webhookRepo, repoStore, aiService, appCache, authz, noopJobs{}, nil)
+	dependencyService := services.NewDependencyService(repoStore, techRepo, licenseRepo, suggRepo, nil, appCache, authz)
+
+	return NewServer(&config.Config{Port: "8080"}, ghService, aiService, userRepo, usage, bulkService, workspaceService, services.NewTokenService(tokenRepo), webhookService, dependencyService, nil, noopJobs{})
+}
+
+func TestServer_authorization(t *testing.T) {
+	batchID := uuid.New()
+
+	tests := []struct {
+		method, route, path, body string
+		// required is the minimum role in workspace 7, "" when the route is not scoped to it
+		required domain.WorkspaceRole
+		// othersOnly marks resources of another user that only admins may access
+		othersOnly bool
+		status     int
+	}{
+		{"GET", "/api/auth/me", "/api/auth/me", "", "", false, http.StatusOK},
+		{"POST", "/api/auth/logout", "/api/auth/logout", "", "", false, http.StatusOK},
+		{"GET", "/api/tokens/", "/api/tokens", "", "", false, http.StatusOK},
+		{"POST", "/api/tokens/", "/api/tokens", `{"name": "ci", "scopes": ["repos:read"]}`, "", false, http.StatusCreated},
+		{"DELETE", "/api/tokens/{tokenId}", "/api/tokens/3", "", "", false, http.StatusOK},
+		{"GET", "/api/workspaces/", "/api/workspaces", "", "", false, http.StatusOK},
+		{"POST", "/api/workspaces/", "/api/workspaces", `{"name": "new team"}`, "", false, http.StatusCreated},
+		{"GET", "/api/workspaces/{workspaceId}/members", "/api/workspaces/7/members", "", domain.WorkspaceRoleViewer, false, http.StatusOK},
+		{"DELETE", "/api/workspaces/{workspaceId}/members/{userId}", "/api/workspaces/7/members/3", "", domain.WorkspaceRoleOwner, false, http.StatusOK},
+		{"POST", "/api/workspaces/{workspaceId}/invitations", "/api/workspaces/7/invitations", `{"githubUsername": "hubot", "role": "viewer"}`, domain.WorkspaceRoleOwner, false, http.StatusCreated},
+		{"GET", "/api/invitations", "/api/invitations", "", "", false, http.StatusOK},
+		{"POST", "/api/invitations/{invitationId}/accept", "/api/invitations/9/accept", "", "", false, http.StatusOK},
+		{"POST", "/api/repositories/sync", "/api/repositories/sync", "", domain.WorkspaceRoleMaintainer, false, http.StatusOK},
+		{"POST", "/api/repositories/local", "/api/repositories/local", `{"path": "team/api"}`, domain.WorkspaceRoleMaintainer, true, http.StatusCreated},
+		{"GET", "/api/repositories/list", "/api/repositories/list", "", domain.WorkspaceRoleViewer, false, http.StatusOK},
+		{"GET", "/api/repositories/stats", "/api/repositories/stats", "", domain.WorkspaceRoleViewer, false, http.StatusOK},
+		{"GET", "/api/repositories/{id}/", "/api/repositories/10", "", domain.WorkspaceRoleViewer, false, http.StatusOK},
+		{"DELETE", "/api/repositories/{id}/", "/api/repositories/10", "", domain.WorkspaceRoleMaintainer, false, http.StatusOK},
+		{"GET", "/api/repositories/{id}/licenses", "/api/repositories/10/licenses", "", domain.WorkspaceRoleViewer, false, http.StatusOK},
+		{"GET", "/api/repositories/{id}/sbom", "/api/repositories/10/sbom?format=spdx", "", domain.WorkspaceRoleViewer, false, http.StatusOK},
+		{"POST", "/api/analysis/start", "/api/analysis/start", `{"repositoryId": 10}`, domain.WorkspaceRoleMaintainer, false, http.StatusOK},
+		{"GET", "/api/analysis/get", "/api/analysis/get?repositoryId=10", "", domain.WorkspaceRoleViewer, false, http.StatusOK},
+		{"GET", "/api/analysis/list", "/api/analysis/list", "", domain.WorkspaceRoleViewer, false, http.StatusOK},
+		{"POST", "/api/analysis/bulk", "/api/analysis/bulk", `{"repositoryIds": [10]}`, domain.WorkspaceRoleMaintainer, false, http.StatusAccepted},
+		{"GET", "/api/analysis/bulk/{batchId}", "/api/analysis/bulk/" + batchID.String(), "", domain.WorkspaceRoleViewer, true, http.StatusOK},
+		{"GET", "/api/suggestions/list", "/api/suggestions/list", "", domain.WorkspaceRoleViewer, false, http.StatusOK},
+		{"POST", "/api/suggestions/updateStatus", "/api/suggestions/updateStatus", `{"suggestionId": 4, "status": "accepted"}`, domain.WorkspaceRoleMaintainer, false, http.StatusOK},
+		{"GET", "/api/portfolio/dependencies", "/api/portfolio/dependencies", "", domain.WorkspaceRoleViewer, false, http.StatusOK},
+		{"POST", "/api/portfolio/dependencies/suggestions", "/api/portfolio/dependencies/suggestions", "", domain.WorkspaceRoleMaintainer, false, http.StatusOK},
+		{"POST", "/api/portfolio/licenses/unification-check", "/api/portfolio/licenses/unification-check", `{"repositoryIds": [10, 12]}`, domain.WorkspaceRoleViewer, false, http.StatusOK},
+		{"GET", "/api/usage", "/api/usage", "", "", false, http.StatusOK},
+		// Unsigned deliveries are rejected whoever the caller is
+		{"POST", "/api/webhooks/github", "/api/webhooks/github", `{}`, "", false, http.StatusUnauthorized},
+		{"POST", "/api/webhooks/deliveries/{deliveryId}/replay", "/api/webhooks/deliveries/d-1/replay", "", "", true, http.StatusOK},
+	}
+
+	callers := []authzCaller{
+		{"outsider", domain.UserRoleUser, ""},
+		{"viewer", domain.UserRoleUser, domain.WorkspaceRoleViewer},
+		{"maintainer", domain.UserRoleUser, domain.WorkspaceRoleMaintainer},
+		{"owner", domain.UserRoleUser, domain.WorkspaceRoleOwner},
+		{"admin", domain.UserRoleAdmin, ""},
+	}
+
+	t.Run("every API route is covered", func(t *testing.T) {
+		covered := map[string]bool{}
+		for _, tt := range tests {
+			covered[tt.method+" "+tt.route] = true
+		}
+		server := newAuthzTestServer(t, callers[0], batchID)
+		err := chi.Walk(server.router, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+			if strings.HasPrefix(route, "/api/") {
+				assert.True(t, covered[method+" "+route], "route %s %s has no authorization test case", method, route)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	for _, caller := range callers {
+		for _, tt := range tests {
+			t.Run(caller.name+" "+tt.method+" "+tt.route, func(t *testing.T) {
+				server := newAuthzTestServer(t, caller, batchID)
+
+				req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+				req.Header.Set("X-Workspace-ID", "7")
+				rr := httptest.NewRecorder()
+				server.router.ServeHTTP(rr, req)
+
+				allowed := caller.platformRole == domain.UserRoleAdmin ||
+					(tt.required == "" || caller.workspaceRole.Allows(tt.required)) && !tt.othersOnly
+				want := http.StatusForbidden
+				if allowed {
+					want = tt.status
+				}
+				assert.Equal(t, want, rr.Code, rr.Body.String())
+			})
+		}
+	}
+}
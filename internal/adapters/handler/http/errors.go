@@ -0,0 +1,164 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// Application error codes. They are part of the API contract: clients branch on
+// them, so existing values must never change meaning.
+const (
+	CodeInternal            int64 = 1000
+	CodeValidation          int64 = 1001
+	CodeUnauthorized        int64 = 1002
+	CodeForbidden           int64 = 1003
+	CodeNotFound            int64 = 1004
+	CodeConflict            int64 = 1005
+	CodeRequestTooLarge     int64 = 1006
+	CodeRateLimited         int64 = 1007
+	CodeBudgetExceeded      int64 = 1008
+	CodeUpstreamUnavailable int64 = 1009
+	CodeShuttingDown        int64 = 1010
+)
+
+// problemContentType is the RFC 7807 media type for error bodies
+const problemContentType = "application/problem+json"
+
+// ErrResponse is an RFC 7807 problem details body
+type ErrResponse struct {
+	Err            error `json:"-"`      // low-level runtime error, never sent to clients
+	HTTPStatusCode int   `json:"status"` // http response status code
+
+	Type       string `json:"type"`             // problem type URI, stable per AppCode
+	StatusText string `json:"title"`            // short summary of the problem type
+	ErrorText  string `json:"detail,omitempty"` // explanation specific to this occurrence
+	Instance   string `json:"instance,omitempty"`
+	AppCode    int64  `json:"code"` // application-specific error code
+
+	RetryAfter time.Duration `json:"-"` // sent as the Retry-After header when positive
+}
+
+func (e *ErrResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	render.Status(r, e.HTTPStatusCode)
+	return nil
+}
+
+func init() {
+	render.Respond = respond
+}
+
+// respond writes ErrResponse values as problem+json and everything else with the default responder
+func respond(w http.ResponseWriter, r *http.Request, v interface{}) {
+	e, ok := v.(*ErrResponse)
+	if !ok {
+		render.DefaultResponder(w, r, v)
+		return
+	}
+
+	if e.HTTPStatusCode >= 500 && e.Err != nil {
+		log.Ctx(r.Context()).Error().Err(e.Err).Int64("code", e.AppCode).Msg("Request failed")
+	}
+
+	// Copy: the predefined responses are shared between requests
+	problem := *e
+	problem.Instance = r.URL.Path
+	w.Header().Set("Content-Type", problemContentType)
+	if e.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	}
+	w.WriteHeader(e.HTTPStatusCode)
+	json.NewEncoder(w).Encode(problem)
+}
+
+func newProblem(status int, code int64, slug, title string) *ErrResponse {
+	return &ErrResponse{
+		HTTPStatusCode: status,
+		Type:           "urn:ghrego:problem:" + slug,
+		StatusText:     title,
+		AppCode:        code,
+	}
+}
+
+func withDetail(p *ErrResponse, err error, detail string) *ErrResponse {
+	p.Err = err
+	p.ErrorText = detail
+	return p
+}
+
+var ErrUnauthorized = newProblem(http.StatusUnauthorized, CodeUnauthorized, "unauthorized", "Unauthorized")
+var ErrRequestTooLarge = newProblem(http.StatusRequestEntityTooLarge, CodeRequestTooLarge, "request-too-large", "Request body too large")
+var ErrUnavailable = newProblem(http.StatusServiceUnavailable, CodeShuttingDown, "shutting-down", "Service shutting down")
+
+// withRetry sets Retry-After to the time left until the RetryAt of err, if known
+func withRetry(p *ErrResponse, err error) *ErrResponse {
+	var de *domain.Error
+	if errors.As(err, &de) && !de.RetryAt.IsZero() {
+		p.RetryAfter = time.Until(de.RetryAt)
+	}
+	return p
+}
+
+func ErrInvalidRequest(err error) render.Renderer {
+	return withDetail(newProblem(http.StatusBadRequest, CodeValidation, "validation", "Invalid request"), err, err.Error())
+}
+
+// ErrDecode maps a request body decoding failure, distinguishing oversized bodies
+func ErrDecode(err error) render.Renderer {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return ErrRequestTooLarge
+	}
+	return ErrInvalidRequest(err)
+}
+
+func ErrRateLimited(retryAfter time.Duration) *ErrResponse {
+	p := withDetail(newProblem(http.StatusTooManyRequests, CodeRateLimited, "rate-limited", "Too many requests"),
+		nil, fmt.Sprintf("rate limit exceeded, retry in %s", retryAfter.Round(time.Second)))
+	p.RetryAfter = retryAfter
+	return p
+}
+
+// ErrInternal hides the cause from the client; it is logged by the responder
+func ErrInternal(err error) render.Renderer {
+	return withDetail(newProblem(http.StatusInternalServerError, CodeInternal, "internal", "Internal Server Error"), err, "")
+}
+
+// ErrFromDomain is the single mapping from service and adapter errors to
+// responses; errors without a domain kind are internal errors
+func ErrFromDomain(err error) render.Renderer {
+	switch {
+	case errors.Is(err, domain.ErrAIBudgetExceeded):
+		return withRetry(withDetail(newProblem(http.StatusTooManyRequests, CodeBudgetExceeded, "budget-exceeded", "Monthly AI budget exceeded"), err, err.Error()), err)
+	case errors.Is(err, domain.ErrValidation):
+		return ErrInvalidRequest(err)
+	case errors.Is(err, domain.ErrNotFound):
+		return withDetail(newProblem(http.StatusNotFound, CodeNotFound, "not-found", "Resource not found"), err, err.Error())
+	case errors.Is(err, domain.ErrUnauthenticated):
+		return withDetail(newProblem(http.StatusUnauthorized, CodeUnauthorized, "unauthorized", "Unauthorized"), err, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		return withDetail(newProblem(http.StatusForbidden, CodeForbidden, "forbidden", "Forbidden"), err, err.Error())
+	case errors.Is(err, domain.ErrConflict):
+		return withDetail(newProblem(http.StatusConflict, CodeConflict, "conflict", "Conflict"), err, err.Error())
+	case errors.Is(err, domain.ErrRateLimited):
+		return withRetry(withDetail(newProblem(http.StatusTooManyRequests, CodeRateLimited, "rate-limited", "Too many requests"), err, err.Error()), err)
+	case errors.Is(err, domain.ErrUpstreamUnavailable):
+		// Only the domain message: the cause may carry upstream internals
+		detail := ""
+		var de *domain.Error
+		if errors.As(err, &de) {
+			detail = de.Message
+		}
+		return withDetail(newProblem(http.StatusBadGateway, CodeUpstreamUnavailable, "upstream-unavailable", "Upstream service unavailable"), err, detail)
+	default:
+		return ErrInternal(err)
+	}
+}
@@ -0,0 +1,100 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/biodoia/ghrego/internal/config"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrFromDomain(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   int64
+		wantDetail string
+	}{
+		{"not found", domain.NotFound("repository", 5), http.StatusNotFound, CodeNotFound, "repository 5 not found"},
+		{"wrapped not found", fmt.Errorf("failed to get repository: %w", domain.NotFound("repository", 5)), http.StatusNotFound, CodeNotFound, "failed to get repository: repository 5 not found"},
+		{"unauthenticated", domain.Unauthenticated("token expired"), http.StatusUnauthorized, CodeUnauthorized, "token expired"},
+		{"forbidden", domain.Forbidden("not your repository"), http.StatusForbidden, CodeForbidden, "not your repository"},
+		{"conflict", domain.Conflict("already running"), http.StatusConflict, CodeConflict, "already running"},
+		{"validation", domain.ErrEmptyBatch, http.StatusBadRequest, CodeValidation, "bulk request matches no repositories"},
+		{"rate limited", &domain.Error{Kind: domain.ErrRateLimited, Message: "GitHub rate limit exceeded"}, http.StatusTooManyRequests, CodeRateLimited, "GitHub rate limit exceeded"},
+		{"budget", domain.ErrAIBudgetExceeded, http.StatusTooManyRequests, CodeBudgetExceeded, "monthly AI budget exceeded"},
+		{"upstream hides cause", domain.UpstreamUnavailable("GitHub", errors.New("dial tcp 10.0.0.1:443")), http.StatusBadGateway, CodeUpstreamUnavailable, "GitHub is unavailable"},
+		{"internal hides cause", errors.New("pq: connection refused"), http.StatusInternalServerError, CodeInternal, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := ErrFromDomain(tt.err).(*ErrResponse)
+			assert.Equal(t, tt.wantStatus, resp.HTTPStatusCode)
+			assert.Equal(t, tt.wantCode, resp.AppCode)
+			assert.Equal(t, tt.wantDetail, resp.ErrorText)
+		})
+	}
+}
+
+func TestErrFromDomain_RetryAfter(t *testing.T) {
+	for name, err := range map[string]error{
+		"budget":       domain.AIBudgetExceeded(time.Now().Add(90 * time.Second)),
+		"rate limited": fmt.Errorf("failed to list repositories: %w", &domain.Error{Kind: domain.ErrRateLimited, Message: "GitHub rate limit exceeded", RetryAt: time.Now().Add(90 * time.Second)}),
+	} {
+		rr := httptest.NewRecorder()
+		render.Render(rr, httptest.NewRequest("POST", "/api/analysis/start", nil), ErrFromDomain(err))
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code, name)
+		assert.Equal(t, "90", rr.Header().Get("Retry-After"), name)
+	}
+
+	// Without a known reset time there is no header
+	rr := httptest.NewRecorder()
+	render.Render(rr, httptest.NewRequest("GET", "/", nil), ErrFromDomain(domain.ErrAIBudgetExceeded))
+	assert.Empty(t, rr.Header().Get("Retry-After"))
+}
+
+func TestProblemResponse(t *testing.T) {
+	mockGHService := new(mocks.GitHubService)
+	server := NewServer(&config.Config{Port: "8080"}, mockGHService, nil, nil, nil, nil, personalWorkspace(), nil, nil, nil, nil, nil)
+	mockGHService.On("GetRepositoryDetails", mock.Anything, 1, 3).Return(nil, domain.NotFound("repository", 3))
+
+	t.Run("not found as problem+json", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/repositories/3", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, "urn:ghrego:problem:not-found", body["type"])
+		assert.Equal(t, "Resource not found", body["title"])
+		assert.Equal(t, float64(404), body["status"])
+		assert.Equal(t, "repository 3 not found", body["detail"])
+		assert.Equal(t, "/api/repositories/3", body["instance"])
+		assert.Equal(t, float64(CodeNotFound), body["code"])
+	})
+
+	t.Run("non-numeric id is a validation error", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/repositories/abc", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, float64(CodeValidation), body["code"])
+		mockGHService.AssertNotCalled(t, "GetRepositoryDetails", mock.Anything, 1, 0)
+	})
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// readinessTimeout bounds each dependency check so a hung backend cannot stall the probe
+const readinessTimeout = 2 * time.Second
+
+type readinessCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// AddReadinessCheck registers a dependency that must be healthy for /readyz to succeed
+func (s *Server) AddReadinessCheck(name string, check func(ctx context.Context) error) {
+	s.readiness = append(s.readiness, readinessCheck{name: name, check: check})
+}
+
+// AddCachedReadinessCheck registers a dependency whose check is costly, such
+// as a call to a paid API: its result is reused by the probes for ttl
+func (s *Server) AddCachedReadinessCheck(name string, ttl time.Duration, check func(ctx context.Context) error) {
+	var (
+		mu      sync.Mutex
+		checked time.Time
+		last    error
+	)
+	s.AddReadinessCheck(name, func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if checked.IsZero() || time.Since(checked) >= ttl {
+			last, checked = check(ctx), time.Now()
+		}
+		return last
+	})
+}
+
+// handleHealthz is the liveness probe: the process is up and serving
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, map[string]string{"status": "ok"})
+}
+
+// handleReadyz is the readiness probe: fails while draining or when a dependency is down
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if s.draining.Load() {
+		render.Status(r, http.StatusServiceUnavailable)
+		render.JSON(w, r, map[string]interface{}{"status": "draining"})
+		return
+	}
+
+	status := http.StatusOK
+	checks := make(map[string]string, len(s.readiness))
+	for _, c := range s.readiness {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		err := c.check(ctx)
+		cancel()
+
+		if err != nil {
+			status = http.StatusServiceUnavailable
+			checks[c.name] = err.Error()
+			continue
+		}
+		checks[c.name] = "ok"
+	}
+
+	result := "ok"
+	if status != http.StatusOK {
+		result = "unavailable"
+	}
+	render.Status(r, status)
+	render.JSON(w, r, map[string]interface{}{"status": result, "checks": checks})
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/metrics"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrument puts a request-scoped logger in the context, then records latency
+// labelled by the matched chi route pattern, names the request span after it and
+// logs the request. It must run after middleware.RequestID.
+func instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		logger := log.With().
+			Ctx(r.Context()).
+			Str("request_id", middleware.GetReqID(r.Context())).
+			Logger()
+		// withLogFields replaces reqLogger with the children it derives, so the
+		// access log carries the user and workspace resolved further down
+		reqLogger := &logger
+		r = r.WithContext(context.WithValue(logger.WithContext(r.Context()), accessLoggerKey{}, reqLogger))
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		// The pattern is only complete once routing has finished
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		metrics.HTTPRequestDuration.WithLabelValues(route, r.Method, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+		trace.SpanFromContext(r.Context()).SetName(r.Method + " " + route)
+
+		event := reqLogger.Info()
+		switch {
+		case status >= 500:
+			event = reqLogger.Error()
+		case status >= 400:
+			event = reqLogger.Warn()
+		case isProbe(r.URL.Path):
+			event = reqLogger.Debug() // scraped every few seconds
+		}
+		event.
+			Str("method", r.Method).
+			Str("route", route).
+			Str("path", r.URL.Path).
+			Int("status", status).
+			Int("bytes", ww.BytesWritten()).
+			Dur("latency", time.Since(start)).
+			Msg("HTTP request")
+	})
+}
+
+type accessLoggerKey struct{}
+
+// withLogFields stores in ctx a child of its logger with the fields added,
+// leaving the parent untouched
+func withLogFields(ctx context.Context, fields func(zerolog.Context) zerolog.Context) context.Context {
+	logger := fields(zerolog.Ctx(ctx).With()).Logger()
+	if reqLogger, ok := ctx.Value(accessLoggerKey{}).(*zerolog.Logger); ok {
+		*reqLogger = logger
+	}
+	return logger.WithContext(ctx)
+}
+
+func isProbe(path string) bool {
+	return path == "/healthz" || path == "/readyz" || path == "/metrics"
+}
+
+// generalLimit is the per-client limit applied to every request
+func (s *Server) generalLimit() domain.RateLimit {
+	return domain.RateLimit{Rate: float64(s.config.RateLimitRPS), Burst: s.config.RateLimitRPS}
+}
+
+// expensiveLimit is the per-user limit for endpoints that call GitHub or the AI provider
+func (s *Server) expensiveLimit() domain.RateLimit {
+	perMinute := s.config.ExpensiveRateLimitPerMinute
+	return domain.RateLimit{Rate: float64(perMinute) / 60, Burst: perMinute}
+}
+
+// rateLimitByIP limits requests per client IP. It must run after middleware.RealIP.
+func (s *Server) rateLimitByIP(scope string, limit domain.RateLimit) func(http.Handler) http.Handler {
+	return s.rateLimit(limit, func(r *http.Request) string {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		return scope + ":ip:" + ip
+	})
+}
+
+// rateLimitByUser limits requests per authenticated user. It must run after authMiddleware.
+func (s *Server) rateLimitByUser(scope string, limit domain.RateLimit) func(http.Handler) http.Handler {
+	return s.rateLimit(limit, func(r *http.Request) string {
+		userID, _ := r.Context().Value("user_id").(int)
+		return scope + ":user:" + strconv.Itoa(userID)
+	})
+}
+
+func (s *Server) rateLimit(limit domain.RateLimit, keyFn func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if s.limiter == nil || !limit.Enabled() {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := keyFn(r)
+			allowed, retryAfter, err := s.limiter.Allow(r.Context(), key, limit)
+			if err != nil {
+				// Fail open: a limiter outage must not take the API down
+				log.Ctx(r.Context()).Warn().Err(err).Str("key", key).Msg("Rate limiter unavailable")
+			} else if !allowed {
+				render.Render(w, r, ErrRateLimited(retryAfter))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// limitRequestSize rejects bodies larger than MaxRequestSize
+func (s *Server) limitRequestSize(next http.Handler) http.Handler {
+	max := s.config.MaxRequestSize
+	if max <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > max {
+			render.Render(w, r, ErrRequestTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, max)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/go-chi/render"
+)
+
+type CheckUnificationRequest struct {
+	RepositoryIDs []int `json:"repositoryIds"`
+}
+
+func (s *Server) handleGetDependencyReport(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	workspaceID := r.Context().Value("workspace_id").(int)
+	report, err := s.dependencyService.GetDriftReport(r.Context(), userID, workspaceID)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.JSON(w, r, report)
+}
+
+// handleSuggestDependencyConsolidation returns the suggestions it created
+func (s *Server) handleSuggestDependencyConsolidation(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	workspaceID := r.Context().Value("workspace_id").(int)
+	suggs, err := s.dependencyService.SuggestConsolidation(r.Context(), userID, workspaceID)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.JSON(w, r, suggs)
+}
+
+func (s *Server) handleGetLicenseReport(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	id, err := intURLParam(r, "id")
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	report, err := s.dependencyService.GetLicenseReport(r.Context(), userID, id)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.JSON(w, r, report)
+}
+
+// handleCheckUnification only reads, but takes the repositories in the body like bulk analysis
+func (s *Server) handleCheckUnification(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	var req CheckUnificationRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Render(w, r, ErrDecode(err))
+		return
+	}
+	check, err := s.dependencyService.CheckUnification(r.Context(), userID, req.RepositoryIDs)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.JSON(w, r, check)
+}
+
+// handleGetSBOM writes the document as is; format defaults to cyclonedx
+func (s *Server) handleGetSBOM(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	id, err := intURLParam(r, "id")
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	format := domain.SBOMFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		format = domain.SBOMFormatCycloneDX
+	}
+	sbom, err := s.dependencyService.GetSBOM(r.Context(), userID, id, format)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	w.Header().Set("Content-Type", sbom.Format.ContentType())
+	w.WriteHeader(http.StatusOK)
+	w.Write(sbom.Document)
+}
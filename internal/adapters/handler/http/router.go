@@ -2,118 +2,326 @@ package http
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/go-chi/render"
+	"github.com/google/uuid"
 	"github.com/biodoia/ghrego/internal/config"
 	"github.com/biodoia/ghrego/internal/core/domain"
 	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/biodoia/ghrego/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
 type Server struct {
-	router      *chi.Mux
-	config      *config.Config
-	ghService   ports.GitHubService
-	aiService   ports.AIAnalysisService
-	repoStore   ports.RepositoryStore
-	userRepo    ports.UserRepository
-	suggRepo    ports.SuggestionRepository
+	router       *chi.Mux
+	config       *config.Config
+	ghService        ports.GitHubService
+	aiService        ports.AIAnalysisService
+	userRepo         ports.UserRepository
+	usageService     ports.UsageService
+	bulkService      ports.BulkAnalysisService
+	workspaceService ports.WorkspaceService
+	tokenService     ports.TokenService
+	webhookService   ports.WebhookService
+	dependencyService ports.DependencyService
+	limiter          ports.RateLimiter
+	jobs             ports.JobRunner
+
+	readiness []readinessCheck
+	draining  atomic.Bool
 }
 
 func NewServer(
 	cfg *config.Config,
 	ghService ports.GitHubService,
 	aiService ports.AIAnalysisService,
-	repoStore ports.RepositoryStore,
 	userRepo ports.UserRepository,
-	suggRepo ports.SuggestionRepository,
+	usageService ports.UsageService,
+	bulkService ports.BulkAnalysisService,
+	workspaceService ports.WorkspaceService,
+	tokenService ports.TokenService,
+	webhookService ports.WebhookService,
+	dependencyService ports.DependencyService,
+	limiter ports.RateLimiter,
+	jobs ports.JobRunner,
 ) *Server {
 	s := &Server{
-		router:    chi.NewRouter(),
-		config:    cfg,
-		ghService: ghService,
-		aiService: aiService,
-		repoStore: repoStore,
-		userRepo:  userRepo,
-		suggRepo:  suggRepo,
+		router:           chi.NewRouter(),
+		config:           cfg,
+		ghService:        ghService,
+		aiService:        aiService,
+		userRepo:         userRepo,
+		usageService:     usageService,
+		bulkService:      bulkService,
+		workspaceService: workspaceService,
+		tokenService:     tokenService,
+		webhookService:   webhookService,
+		dependencyService: dependencyService,
+		limiter:          limiter,
+		jobs:             jobs,
 	}
 	s.setupRoutes()
 	return s
 }
 
 func (s *Server) setupRoutes() {
+	s.router.Use(otelhttp.NewMiddleware("http.server"))
 	s.router.Use(middleware.RequestID)
 	s.router.Use(middleware.RealIP)
-	s.router.Use(middleware.Logger)
+	s.router.Use(instrument)
 	s.router.Use(middleware.Recoverer)
-	s.router.Use(middleware.Timeout(60 * time.Second))
-	s.router.Use(render.SetContentType(render.ContentTypeJSON))
-
-	// CORS
-	s.router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   s.config.AllowedOrigins,
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	}))
-
-	s.router.Route("/api", func(r chi.Router) {
-		r.Use(s.authMiddleware) // Mock auth for now
+
+	// Probes and metrics are registered outside the API middleware so scrapers are never throttled
+	s.router.Get("/healthz", s.handleHealthz)
+	s.router.Get("/readyz", s.handleReadyz)
+	s.router.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+
+	api := s.router.With(
+		s.rateLimitByIP("api", s.generalLimit()),
+		s.limitRequestSize,
+		render.SetContentType(render.ContentTypeJSON),
+		// CORS
+		cors.Handler(cors.Options{
+			AllowedOrigins:   s.config.AllowedOrigins,
+			AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Workspace-ID"},
+			ExposedHeaders:   []string{"Link"},
+			AllowCredentials: true,
+			MaxAge:           300,
+		}),
+	)
+
+	// Signed by GitHub instead of authenticated as a user
+	api.Post("/api/webhooks/github", s.handleGitHubWebhook)
+
+	api.Route("/api", func(r chi.Router) {
+		r.Use(s.authMiddleware)
+		r.Use(s.rateLimitByUser("api", s.generalLimit()))
 
 		// Auth
 		r.Get("/auth/me", s.handleGetMe)
 		r.Post("/auth/logout", s.handleLogout)
 
-		// Repositories
-		r.Route("/repositories", func(r chi.Router) {
-			r.Post("/sync", s.handleSyncRepositories)
-			r.Get("/list", s.handleListRepositories)
-			r.Get("/stats", s.handleGetRepositoryStats)
-			r.Route("/{id}", func(r chi.Router) {
-				r.Get("/", s.handleGetRepository)
-				r.Delete("/", s.handleDeleteRepository)
-			})
+		// Personal access tokens
+		r.Route("/tokens", func(r chi.Router) {
+			r.Use(denyAPITokens)
+			r.Get("/", s.handleListTokens)
+			r.Post("/", s.handleCreateToken)
+			r.Delete("/{tokenId}", s.handleRevokeToken)
 		})
 
-		// Analysis
-		r.Route("/analysis", func(r chi.Router) {
-			r.Post("/start", s.handleStartAnalysis)
-			r.Get("/get", s.handleGetAnalysis) // using Query param ?repositoryId=... to match tRPC style
-			r.Get("/list", s.handleListAnalysis)
+		// Workspaces and invitations
+		r.Route("/workspaces", func(r chi.Router) {
+			r.With(requireScope(domain.ScopeWorkspacesRead)).Get("/", s.handleListWorkspaces)
+			r.With(requireScope(domain.ScopeWorkspacesWrite)).Post("/", s.handleCreateWorkspace)
+			r.Route("/{workspaceId}", func(r chi.Router) {
+				r.With(requireScope(domain.ScopeWorkspacesRead)).Get("/members", s.handleListWorkspaceMembers)
+				r.With(requireScope(domain.ScopeWorkspacesWrite)).Delete("/members/{userId}", s.handleRemoveWorkspaceMember)
+				r.With(requireScope(domain.ScopeWorkspacesWrite)).Post("/invitations", s.handleInviteToWorkspace)
+			})
 		})
+		r.With(requireScope(domain.ScopeWorkspacesRead)).Get("/invitations", s.handleListInvitations)
+		r.With(requireScope(domain.ScopeWorkspacesWrite)).Post("/invitations/{invitationId}/accept", s.handleAcceptInvitation)
+
+		// Everything below acts on the current workspace
+		r.Group(func(r chi.Router) {
+			r.Use(s.workspaceMiddleware)
+
+			// Repositories
+			r.Route("/repositories", func(r chi.Router) {
+				r.With(requireScope(domain.ScopeReposWrite), s.rateLimitByUser("sync", s.expensiveLimit())).Post("/sync", s.handleSyncRepositories)
+				r.With(requireScope(domain.ScopeReposWrite)).Post("/local", s.handleRegisterLocalRepository)
+				r.With(requireScope(domain.ScopeReposRead)).Get("/list", s.handleListRepositories)
+				r.With(requireScope(domain.ScopeReposRead)).Get("/stats", s.handleGetRepositoryStats)
+				r.Route("/{id}", func(r chi.Router) {
+					r.With(requireScope(domain.ScopeReposRead)).Get("/", s.handleGetRepository)
+					r.With(requireScope(domain.ScopeReposWrite)).Delete("/", s.handleDeleteRepository)
+					r.With(requireScope(domain.ScopeAnalysisRead)).Get("/licenses", s.handleGetLicenseReport)
+					r.With(requireScope(domain.ScopeAnalysisRead)).Get("/sbom", s.handleGetSBOM)
+				})
+			})
+
+			// Analysis
+			r.Route("/analysis", func(r chi.Router) {
+				r.With(requireScope(domain.ScopeAnalysisWrite), s.rateLimitByUser("analysis", s.expensiveLimit())).Post("/start", s.handleStartAnalysis)
+				r.With(requireScope(domain.ScopeAnalysisRead)).Get("/get", s.handleGetAnalysis) // using Query param ?repositoryId=... to match tRPC style
+				r.With(requireScope(domain.ScopeAnalysisRead)).Get("/list", s.handleListAnalysis)
+				r.With(requireScope(domain.ScopeAnalysisWrite), s.rateLimitByUser("analysis", s.expensiveLimit())).Post("/bulk", s.handleStartBulkAnalysis)
+				r.With(requireScope(domain.ScopeAnalysisRead)).Get("/bulk/{batchId}", s.handleGetBulkAnalysis)
+			})
+
+			// Suggestions
+			r.Route("/suggestions", func(r chi.Router) {
+				r.With(requireScope(domain.ScopeAnalysisRead)).Get("/list", s.handleListSuggestions)
+				r.With(requireScope(domain.ScopeAnalysisWrite)).Post("/updateStatus", s.handleUpdateSuggestionStatus)
+			})
 
-		// Suggestions
-		r.Route("/suggestions", func(r chi.Router) {
-			r.Get("/list", s.handleListSuggestions)
-			r.Post("/updateStatus", s.handleUpdateSuggestionStatus)
+			// Dependencies and licenses across the workspace's repositories
+			r.Route("/portfolio", func(r chi.Router) {
+				r.With(requireScope(domain.ScopeAnalysisRead)).Get("/dependencies", s.handleGetDependencyReport)
+				r.With(requireScope(domain.ScopeAnalysisWrite)).Post("/dependencies/suggestions", s.handleSuggestDependencyConsolidation)
+				r.With(requireScope(domain.ScopeAnalysisRead)).Post("/licenses/unification-check", s.handleCheckUnification)
+			})
 		})
+
+		// Webhook deliveries are stored for replay (admins only)
+		r.With(requireScope(domain.ScopeReposWrite)).Post("/webhooks/deliveries/{deliveryId}/replay", s.handleReplayWebhook)
+
+		// AI usage and cost
+		r.With(requireScope(domain.ScopeAnalysisRead)).Get("/usage", s.handleGetUsage)
 	})
 }
 
-func (s *Server) Run() error {
-	log.Info().Str("port", s.config.Port).Msg("Starting HTTP Server")
-	return http.ListenAndServe(":"+s.config.Port, s.router)
+// Run serves HTTP until ctx is cancelled, then keeps serving while failing
+// readiness for ShutdownDrainDelay and drains in-flight requests for up to ShutdownTimeout
+func (s *Server) Run(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              ":" + s.config.Port,
+		Handler:           s.router,
+		ReadHeaderTimeout: s.config.ServerTimeout,
+		ReadTimeout:       s.config.ServerTimeout,
+		WriteTimeout:      s.config.ServerTimeout,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Info().Str("port", s.config.Port).Msg("Starting HTTP Server")
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	// Fail readiness first so load balancers stop routing new traffic here
+	s.draining.Store(true)
+	if s.config.ShutdownDrainDelay > 0 {
+		// New connections keep being accepted until the load balancers notice
+		log.Info().Dur("delay", s.config.ShutdownDrainDelay).Msg("Draining HTTP Server")
+		time.Sleep(s.config.ShutdownDrainDelay)
+	}
+	log.Info().Dur("timeout", s.config.ShutdownTimeout).Msg("Shutting down HTTP Server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down http server: %w", err)
+	}
+	return nil
 }
 
-// Mock Middleware - Extract user from header or session
+// authMiddleware authenticates the caller. A personal access token acts as its
+// owner, limited to its scopes. When API_KEY is configured every other request
+// must present it and acts as the default user; without it the default user is
+// assumed (development mode, until sessions land).
 func (s *Server) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Real implementation should decode JWT
-		// For now, assume User ID 1 is logged in if not specified, 
-		// OR check header "X-User-ID"
-		
+		ctx := r.Context()
 		userID := 1 // Default for MVP/Dev
-		// ... Auth logic ...
-		
-		ctx := context.WithValue(r.Context(), "user_id", userID)
+
+		bearer, hasBearer := bearerToken(r)
+		switch {
+		case hasBearer && strings.HasPrefix(bearer, domain.APITokenPrefix):
+			token, err := s.tokenService.Authenticate(ctx, bearer)
+			if err != nil {
+				render.Render(w, r, ErrFromDomain(err))
+				return
+			}
+			userID = token.UserID
+			ctx = context.WithValue(ctx, "api_token", token)
+			ctx = withLogFields(ctx, func(c zerolog.Context) zerolog.Context {
+				return c.Int("token_id", token.ID)
+			})
+		case s.config.APIKey == "":
+		case !hasBearer || subtle.ConstantTimeCompare([]byte(bearer), []byte(s.config.APIKey)) != 1:
+			render.Render(w, r, ErrUnauthorized)
+			return
+		}
+
+		ctx = context.WithValue(ctx, "user_id", userID)
+		ctx = withLogFields(ctx, func(c zerolog.Context) zerolog.Context {
+			return c.Int("user_id", userID)
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// requireScope rejects requests authenticated by a personal access token
+// lacking scope. Other callers are not limited by scopes.
+func requireScope(scope domain.TokenScope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token, ok := r.Context().Value("api_token").(*domain.APIToken); ok && !token.HasScope(scope) {
+				render.Render(w, r, ErrFromDomain(domain.Forbidden(fmt.Sprintf("token lacks the %s scope", scope))))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// denyAPITokens keeps personal access tokens from managing tokens, so a leaked
+// token cannot mint broader or longer-lived ones
+func denyAPITokens(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value("api_token").(*domain.APIToken); ok {
+			render.Render(w, r, ErrFromDomain(domain.Forbidden("personal access tokens cannot manage tokens")))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// workspaceMiddleware resolves the workspace a request acts on from the
+// X-Workspace-ID header, defaulting to the caller's personal workspace
+func (s *Server) workspaceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("user_id").(int)
+
+		requested := 0
+		if v := r.Header.Get("X-Workspace-ID"); v != "" {
+			id, err := strconv.Atoi(v)
+			if err != nil || id <= 0 {
+				render.Render(w, r, ErrFromDomain(domain.Validation("X-Workspace-ID must be a positive integer")))
+				return
+			}
+			requested = id
+		}
+
+		workspaceID, err := s.workspaceService.Resolve(r.Context(), userID, requested)
+		if err != nil {
+			render.Render(w, r, ErrFromDomain(err))
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "workspace_id", workspaceID)
+		ctx = withLogFields(ctx, func(c zerolog.Context) zerolog.Context {
+			return c.Int("workspace_id", workspaceID)
+		})
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -124,11 +332,7 @@ func (s *Server) handleGetMe(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id").(int)
 	user, err := s.userRepo.GetByID(r.Context(), userID)
 	if err != nil {
-		render.Render(w, r, ErrInternal(err))
-		return
-	}
-	if user == nil {
-		render.Render(w, r, ErrNotFound)
+		render.Render(w, r, ErrFromDomain(err))
 		return
 	}
 	render.JSON(w, r, user)
@@ -141,21 +345,33 @@ func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleSyncRepositories(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id").(int)
-	
-	// Get User OpenID - needed for sync logic (legacy/db requirement)
-	user, err := s.userRepo.GetByID(r.Context(), userID)
-	if err != nil || user == nil {
+	workspaceID := r.Context().Value("workspace_id").(int)
+
+	// The body is optional: without it the caller's own GitHub account is synced
+	var req SyncRepositoriesRequest
+	if r.ContentLength != 0 {
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			render.Render(w, r, ErrDecode(err))
+			return
+		}
+	}
+
+	// Sessions may outlive their user
+	if _, err := s.userRepo.GetByID(r.Context(), userID); errors.Is(err, domain.ErrNotFound) {
 		render.Render(w, r, ErrUnauthorized)
 		return
+	} else if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
 	}
 
-	if err := s.ghService.SyncUserRepositories(r.Context(), userID, user.OpenID); err != nil {
-		render.Render(w, r, ErrInternal(err))
+	if err := s.ghService.SyncUserRepositories(r.Context(), userID, workspaceID, req.Provider, req.Account); err != nil {
+		render.Render(w, r, ErrFromDomain(err))
 		return
 	}
 
 	// Fetch updated list count
-	repos, _ := s.repoStore.GetByUserID(r.Context(), userID)
+	repos, _ := s.ghService.ListRepositories(r.Context(), userID, workspaceID)
 	
 	render.JSON(w, r, map[string]interface{}{
 		"success": true,
@@ -163,38 +379,62 @@ func (s *Server) handleSyncRepositories(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+func (s *Server) handleRegisterLocalRepository(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	workspaceID := r.Context().Value("workspace_id").(int)
+
+	var req RegisterLocalRepositoryRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Render(w, r, ErrDecode(err))
+		return
+	}
+
+	repo, err := s.ghService.RegisterLocalRepository(r.Context(), userID, workspaceID, req.Path)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, repo)
+}
+
 func (s *Server) handleListRepositories(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id").(int)
-	repos, err := s.repoStore.GetByUserID(r.Context(), userID)
+	workspaceID := r.Context().Value("workspace_id").(int)
+	repos, err := s.ghService.ListRepositories(r.Context(), userID, workspaceID)
 	if err != nil {
-		render.Render(w, r, ErrInternal(err))
+		render.Render(w, r, ErrFromDomain(err))
 		return
 	}
 	render.JSON(w, r, repos)
 }
 
 func (s *Server) handleGetRepository(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	id, _ := strconv.Atoi(idStr)
-	
-	repo, err := s.repoStore.GetByID(r.Context(), id)
+	userID := r.Context().Value("user_id").(int)
+	id, err := intURLParam(r, "id")
 	if err != nil {
-		render.Render(w, r, ErrInternal(err))
+		render.Render(w, r, ErrFromDomain(err))
 		return
 	}
-	if repo == nil {
-		render.Render(w, r, ErrNotFound)
+
+	repo, err := s.ghService.GetRepositoryDetails(r.Context(), userID, id)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
 		return
 	}
 	render.JSON(w, r, repo)
 }
 
 func (s *Server) handleDeleteRepository(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	id, _ := strconv.Atoi(idStr)
-	
-	if err := s.repoStore.Delete(r.Context(), id); err != nil {
-		render.Render(w, r, ErrInternal(err))
+	userID := r.Context().Value("user_id").(int)
+	id, err := intURLParam(r, "id")
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+
+	if err := s.ghService.DeleteRepository(r.Context(), userID, id); err != nil {
+		render.Render(w, r, ErrFromDomain(err))
 		return
 	}
 	render.JSON(w, r, map[string]bool{"success": true})
@@ -202,9 +442,10 @@ func (s *Server) handleDeleteRepository(w http.ResponseWriter, r *http.Request)
 
 func (s *Server) handleGetRepositoryStats(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id").(int)
-	stats, err := s.repoStore.GetStats(r.Context(), userID)
+	workspaceID := r.Context().Value("workspace_id").(int)
+	stats, err := s.ghService.GetRepositoryStats(r.Context(), userID, workspaceID)
 	if err != nil {
-		render.Render(w, r, ErrInternal(err))
+		render.Render(w, r, ErrFromDomain(err))
 		return
 	}
 	render.JSON(w, r, stats)
@@ -212,39 +453,128 @@ func (s *Server) handleGetRepositoryStats(w http.ResponseWriter, r *http.Request
 
 // Analysis Handlers
 
+type SyncRepositoriesRequest struct {
+	// Provider is the source host to sync from; empty for GitHub
+	Provider domain.SourceProvider `json:"provider"`
+	// Account is a GitHub organization the caller belongs to, empty for the
+	// caller's own account; required on GitLab (user or group) and Gitea
+	Account string `json:"account"`
+}
+
+type RegisterLocalRepositoryRequest struct {
+	// Path is <directory>/<repository> below LOCAL_REPOS_ROOT
+	Path string `json:"path"`
+}
+
 type StartAnalysisRequest struct {
-	RepositoryID int `json:"repositoryId"`
+	RepositoryID int                 `json:"repositoryId"`
+	AnalysisType domain.AnalysisType `json:"analysisType"` // default "architecture"
+	Force        bool                `json:"force"`        // bypass the analysis cache
 }
 
 func (s *Server) handleStartAnalysis(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
 	var req StartAnalysisRequest
 	if err := render.DecodeJSON(r.Body, &req); err != nil {
-		render.Render(w, r, ErrInvalidRequest(err))
+		render.Render(w, r, ErrDecode(err))
 		return
 	}
 
-	// In async go routine
-	go func() {
-		// Create new context for background task
-		ctx := context.Background()
-		_, err := s.aiService.AnalyzeRepository(ctx, req.RepositoryID, domain.AnalysisTypeArchitecture)
+	if req.AnalysisType == "" {
+		req.AnalysisType = domain.AnalysisTypeArchitecture
+	}
+	if !req.AnalysisType.Valid() {
+		render.Render(w, r, ErrFromDomain(domain.Validation("unknown analysis type %q", req.AnalysisType)))
+		return
+	}
+
+	// Refuse up front rather than failing in the background
+	if err := s.aiService.AuthorizeAnalysis(r.Context(), userID, req.RepositoryID); err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	// Metrics do not call the AI provider
+	if req.AnalysisType != domain.AnalysisTypeMetrics {
+		if err := s.usageService.CheckBudget(r.Context()); err != nil {
+			render.Render(w, r, ErrFromDomain(err))
+			return
+		}
+	}
+
+	// Run in the background; the job is drained on shutdown
+	err := s.jobs.Go(r.Context(), "analysis", func(ctx context.Context) {
+		_, err := s.aiService.StartAnalysis(ctx, userID, req.RepositoryID, req.AnalysisType, req.Force)
 		if err != nil {
-			log.Error().Err(err).Int("repo_id", req.RepositoryID).Msg("Background analysis failed")
+			log.Ctx(ctx).Error().Err(err).Int("repo_id", req.RepositoryID).Msg("Background analysis failed")
 		}
-	}()
+	})
+	if err != nil {
+		render.Render(w, r, ErrUnavailable)
+		return
+	}
 
 	render.JSON(w, r, map[string]interface{}{"success": true, "message": "Analysis started"})
 }
 
 func (s *Server) handleGetAnalysis(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
 	// tRPC style uses Query params for GET input
-	repoIDStr := r.URL.Query().Get("repositoryId") // Changed from input.repositoryId
-	repoID, _ := strconv.Atoi(repoIDStr)
-	_ = repoID // Silence unused variable warning until implemented
+	repoID, err := strconv.Atoi(r.URL.Query().Get("repositoryId"))
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(domain.Validation("repositoryId must be an integer")))
+		return
+	}
+
+	report, err := s.aiService.GetAnalysisReport(r.Context(), userID, repoID)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.JSON(w, r, report)
+}
+
+func (s *Server) handleStartBulkAnalysis(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	workspaceID := r.Context().Value("workspace_id").(int)
+
+	var req domain.BulkAnalysisRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Render(w, r, ErrDecode(err))
+		return
+	}
 
-	// Stub - needs implementation of aggregating all data
-	// See tRPC implementation: fetches analyses, features, techs, suggestions
-	render.JSON(w, r, map[string]interface{}{"status": "implemented in next step"})
+	if err := s.usageService.CheckBudget(r.Context()); err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+
+	batch, err := s.bulkService.StartBatch(r.Context(), userID, workspaceID, req)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, map[string]interface{}{"batchId": batch.ID, "total": batch.Total})
+}
+
+func (s *Server) handleGetBulkAnalysis(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	batchID, err := uuid.Parse(chi.URLParam(r, "batchId"))
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(domain.Validation("batchId must be a UUID")))
+		return
+	}
+
+	progress, err := s.bulkService.GetProgress(r.Context(), userID, batchID)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.JSON(w, r, progress)
 }
 
 func (s *Server) handleListAnalysis(w http.ResponseWriter, r *http.Request) {
@@ -256,52 +586,66 @@ func (s *Server) handleListAnalysis(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleListSuggestions(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id").(int)
-	suggs, err := s.suggRepo.GetAllPending(r.Context(), userID)
+	workspaceID := r.Context().Value("workspace_id").(int)
+	suggs, err := s.aiService.ListPendingSuggestions(r.Context(), userID, workspaceID)
 	if err != nil {
-		render.Render(w, r, ErrInternal(err))
+		render.Render(w, r, ErrFromDomain(err))
 		return
 	}
 	render.JSON(w, r, suggs)
 }
 
-func (s *Server) handleUpdateSuggestionStatus(w http.ResponseWriter, r *http.Request) {
-	// Implementation skipped for brevity
-	render.JSON(w, r, map[string]bool{"success": true})
+type UpdateSuggestionStatusRequest struct {
+	SuggestionID int                     `json:"suggestionId"`
+	Status       domain.SuggestionStatus `json:"status"`
 }
 
-// --- Errors ---
+func (s *Server) handleUpdateSuggestionStatus(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
 
-type ErrResponse struct {
-	Err            error `json:"-"` // low-level runtime error
-	HTTPStatusCode int   `json:"-"` // http response status code
+	var req UpdateSuggestionStatusRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Render(w, r, ErrDecode(err))
+		return
+	}
 
-	StatusText string `json:"status"`          // user-level status message
-	AppCode    int64  `json:"code,omitempty"`  // application-specific error code
-	ErrorText  string `json:"error,omitempty"` // application-level error message, for debugging
+	if err := s.aiService.UpdateSuggestionStatus(r.Context(), userID, req.SuggestionID, req.Status); err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.JSON(w, r, map[string]bool{"success": true})
 }
 
-func (e *ErrResponse) Render(w http.ResponseWriter, r *http.Request) error {
-	render.Status(r, e.HTTPStatusCode)
-	return nil
-}
+// Usage Handlers
 
-func ErrInvalidRequest(err error) render.Renderer {
-	return &ErrResponse{
-		Err:            err,
-		HTTPStatusCode: 400,
-		StatusText:     "Invalid request",
-		ErrorText:      err.Error(),
+func (s *Server) handleGetUsage(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	days := 30
+	if v := r.URL.Query().Get("days"); v != "" {
+		d, err := strconv.Atoi(v)
+		if err != nil || d <= 0 {
+			render.Render(w, r, ErrFromDomain(domain.Validation("days must be a positive integer")))
+			return
+		}
+		days = d
 	}
-}
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -(days - 1))
 
-var ErrNotFound = &ErrResponse{HTTPStatusCode: 404, StatusText: "Resource not found"}
-var ErrUnauthorized = &ErrResponse{HTTPStatusCode: 401, StatusText: "Unauthorized"}
+	report, err := s.usageService.GetUserUsage(r.Context(), userID, since)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.JSON(w, r, report)
+}
 
-func ErrInternal(err error) render.Renderer {
-	return &ErrResponse{
-		Err:            err,
-		HTTPStatusCode: 500,
-		StatusText:     "Internal Server Error",
-		ErrorText:      err.Error(),
+// intURLParam parses an integer path parameter
+func intURLParam(r *http.Request, name string) (int, error) {
+	v, err := strconv.Atoi(chi.URLParam(r, name))
+	if err != nil {
+		return 0, domain.Validation("%s must be an integer", name)
 	}
+	return v, nil
 }
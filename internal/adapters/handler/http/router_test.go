@@ -1,27 +1,45 @@
 package http
 
 import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/biodoia/ghrego/internal/cache"
 	"github.com/biodoia/ghrego/internal/config"
 	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/services"
 	"github.com/biodoia/ghrego/internal/mocks"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
+// personalWorkspace resolves every request to workspace 5, the caller's personal workspace
+func personalWorkspace() *mocks.WorkspaceService {
+	m := new(mocks.WorkspaceService)
+	m.On("Resolve", mock.Anything, mock.Anything, 0).Return(5, nil)
+	return m
+}
+
 func TestServer_handleGetRepository(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		mockRepoStore := new(mocks.RepositoryStore)
-		mockUserRepo := new(mocks.UserRepository)
-		server := NewServer(&config.Config{Port: "8080"}, nil, nil, mockRepoStore, mockUserRepo, nil)
+		mockGHService := new(mocks.GitHubService)
+		server := NewServer(&config.Config{Port: "8080"}, mockGHService, nil, nil, nil, nil, personalWorkspace(), nil, nil, nil, nil, nil)
 
 		repo := &domain.Repository{ID: 10, Name: "my-repo"}
-		mockRepoStore.On("GetByID", mock.Anything, 10).Return(repo, nil)
+		mockGHService.On("GetRepositoryDetails", mock.Anything, 1, 10).Return(repo, nil)
 
 		req := httptest.NewRequest("GET", "/api/repositories/10", nil)
 		rr := httptest.NewRecorder()
@@ -34,11 +52,10 @@ func TestServer_handleGetRepository(t *testing.T) {
 	})
 
 	t.Run("not found", func(t *testing.T) {
-		mockRepoStore := new(mocks.RepositoryStore)
-		mockUserRepo := new(mocks.UserRepository)
-		server := NewServer(&config.Config{Port: "8080"}, nil, nil, mockRepoStore, mockUserRepo, nil)
+		mockGHService := new(mocks.GitHubService)
+		server := NewServer(&config.Config{Port: "8080"}, mockGHService, nil, nil, nil, nil, personalWorkspace(), nil, nil, nil, nil, nil)
 
-		mockRepoStore.On("GetByID", mock.Anything, 99).Return(nil, nil)
+		mockGHService.On("GetRepositoryDetails", mock.Anything, 1, 99).Return(nil, domain.NotFound("repository", 99))
 
 		req := httptest.NewRequest("GET", "/api/repositories/99", nil)
 		rr := httptest.NewRecorder()
@@ -51,14 +68,13 @@ func TestServer_handleGetRepository(t *testing.T) {
 func TestServer_handleSyncRepositories(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockGHService := new(mocks.GitHubService)
-		mockRepoStore := new(mocks.RepositoryStore)
 		mockUserRepo := new(mocks.UserRepository)
-		server := NewServer(&config.Config{Port: "8080"}, mockGHService, nil, mockRepoStore, mockUserRepo, nil)
+		server := NewServer(&config.Config{Port: "8080"}, mockGHService, nil, mockUserRepo, nil, nil, personalWorkspace(), nil, nil, nil, nil, nil)
 
 		user := &domain.User{ID: 1, OpenID: "open-123"}
 		mockUserRepo.On("GetByID", mock.Anything, 1).Return(user, nil)
-		mockGHService.On("SyncUserRepositories", mock.Anything, 1, "open-123").Return(nil)
-		mockRepoStore.On("GetByUserID", mock.Anything, 1).Return([]domain.Repository{{ID: 1}}, nil)
+		mockGHService.On("SyncUserRepositories", mock.Anything, 1, 5, domain.SourceProvider(""), "").Return(nil)
+		mockGHService.On("ListRepositories", mock.Anything, 1, 5).Return([]domain.Repository{{ID: 1}}, nil)
 
 		req := httptest.NewRequest("POST", "/api/repositories/sync", nil)
 		rr := httptest.NewRecorder()
@@ -70,12 +86,11 @@ func TestServer_handleSyncRepositories(t *testing.T) {
 	
 	t.Run("auth error", func(t *testing.T) {
 		mockGHService := new(mocks.GitHubService)
-		mockRepoStore := new(mocks.RepositoryStore)
 		mockUserRepo := new(mocks.UserRepository)
-		server := NewServer(&config.Config{Port: "8080"}, mockGHService, nil, mockRepoStore, mockUserRepo, nil)
+		server := NewServer(&config.Config{Port: "8080"}, mockGHService, nil, mockUserRepo, nil, nil, personalWorkspace(), nil, nil, nil, nil, nil)
 
 		// Mock GetByID failing (e.g. user not found)
-		mockUserRepo.On("GetByID", mock.Anything, 1).Return(nil, errors.New("db err"))
+		mockUserRepo.On("GetByID", mock.Anything, 1).Return(nil, domain.NotFound("user", 1))
 
 		req := httptest.NewRequest("POST", "/api/repositories/sync", nil)
 		rr := httptest.NewRecorder()
@@ -83,4 +98,241 @@ func TestServer_handleSyncRepositories(t *testing.T) {
 
 		assert.Equal(t, http.StatusUnauthorized, rr.Code)
 	})
-}
\ No newline at end of file
+}
+
+func TestServer_rateLimiting(t *testing.T) {
+	t.Run("expensive endpoint returns 429 with Retry-After", func(t *testing.T) {
+		mockGHService := new(mocks.GitHubService)
+		mockUserRepo := new(mocks.UserRepository)
+		cfg := &config.Config{Port: "8080", RateLimitRPS: 100, ExpensiveRateLimitPerMinute: 1}
+		server := NewServer(cfg, mockGHService, nil, mockUserRepo, nil, nil, personalWorkspace(), nil, nil, nil, cache.NewMemoryRateLimiter(), nil)
+
+		mockUserRepo.On("GetByID", mock.Anything, 1).Return(&domain.User{ID: 1, OpenID: "open-123"}, nil)
+		mockGHService.On("SyncUserRepositories", mock.Anything, 1, 5, domain.SourceProvider(""), "").Return(nil)
+		mockGHService.On("ListRepositories", mock.Anything, 1, 5).Return([]domain.Repository{}, nil)
+
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("POST", "/api/repositories/sync", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("POST", "/api/repositories/sync", nil))
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "60", rr.Header().Get("Retry-After"))
+
+		// Cheap endpoints are not affected by the expensive quota
+		rr = httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/repositories/list", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("oversized body returns 413", func(t *testing.T) {
+		cfg := &config.Config{Port: "8080", MaxRequestSize: 16}
+		server := NewServer(cfg, nil, nil, nil, nil, nil, personalWorkspace(), nil, nil, nil, nil, nil)
+
+		body := strings.NewReader(`{"repositoryId": 1, "force": true, "padding": "xxxxxxxx"}`)
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("POST", "/api/analysis/start", body))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	})
+}
+
+
+func TestServer_probes(t *testing.T) {
+	t.Run("liveness always ok", func(t *testing.T) {
+		server := NewServer(&config.Config{Port: "8080"}, nil, nil, nil, nil, nil, personalWorkspace(), nil, nil, nil, nil, nil)
+
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/healthz", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("readiness fails when a dependency is down", func(t *testing.T) {
+		server := NewServer(&config.Config{Port: "8080"}, nil, nil, nil, nil, nil, personalWorkspace(), nil, nil, nil, nil, nil)
+		server.AddReadinessCheck("postgres", func(ctx context.Context) error { return nil })
+		server.AddReadinessCheck("redis", func(ctx context.Context) error { return errors.New("connection refused") })
+
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+		var body struct {
+			Checks map[string]string `json:"checks"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &body)
+		assert.Equal(t, "ok", body.Checks["postgres"])
+		assert.Equal(t, "connection refused", body.Checks["redis"])
+	})
+
+	t.Run("cached readiness check", func(t *testing.T) {
+		server := NewServer(&config.Config{Port: "8080"}, nil, nil, nil, nil, nil, personalWorkspace(), nil, nil, nil, nil, nil)
+		calls := 0
+		server.AddCachedReadinessCheck("ai", time.Minute, func(ctx context.Context) error {
+			calls++
+			return errors.New("quota exceeded")
+		})
+
+		for range 3 {
+			rr := httptest.NewRecorder()
+			server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+			assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		}
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("readiness fails while draining", func(t *testing.T) {
+		server := NewServer(&config.Config{Port: "8080"}, nil, nil, nil, nil, nil, personalWorkspace(), nil, nil, nil, nil, nil)
+		server.draining.Store(true)
+
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	})
+
+	t.Run("analysis rejected once shutdown has begun", func(t *testing.T) {
+		mockUsage := new(mocks.UsageService)
+		jobs := services.NewBackgroundJobs()
+		require.NoError(t, jobs.Shutdown(context.Background()))
+		mockAIService := new(mocks.AIAnalysisService)
+		server := NewServer(&config.Config{Port: "8080"}, nil, mockAIService, nil, mockUsage, nil, personalWorkspace(), nil, nil, nil, nil, jobs)
+
+		mockAIService.On("AuthorizeAnalysis", mock.Anything, 1, 1).Return(nil)
+		mockUsage.On("CheckBudget", mock.Anything).Return(nil)
+
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("POST", "/api/analysis/start", strings.NewReader(`{"repositoryId": 1}`)))
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	})
+}
+
+func TestServer_metrics(t *testing.T) {
+	mockGHService := new(mocks.GitHubService)
+	server := NewServer(&config.Config{Port: "8080"}, mockGHService, nil, nil, nil, nil, personalWorkspace(), nil, nil, nil, nil, nil)
+	mockGHService.On("GetRepositoryDetails", mock.Anything, 1, 42).Return(nil, domain.NotFound("repository", 42))
+
+	server.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/repositories/42", nil))
+
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	// Labelled by route pattern, not by the concrete ID
+	assert.Contains(t, rr.Body.String(), `ghrego_http_request_duration_seconds_count{method="GET",route="/api/repositories/{id}",status="404"}`)
+}
+
+func TestServer_requestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(l zerolog.Logger) { log.Logger = l }(log.Logger)
+	log.Logger = zerolog.New(&buf)
+
+	mockGHService := new(mocks.GitHubService)
+	server := NewServer(&config.Config{Port: "8080"}, mockGHService, nil, nil, nil, nil, personalWorkspace(), nil, nil, nil, nil, nil)
+	mockGHService.On("GetRepositoryDetails", mock.Anything, 1, 7).Return(&domain.Repository{ID: 7}, nil)
+
+	req := httptest.NewRequest("GET", "/api/repositories/7", nil)
+	req.Header.Set("X-Request-Id", "req-123")
+	server.router.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "HTTP request", entry["message"])
+	assert.Equal(t, "req-123", entry["request_id"])
+	assert.Equal(t, float64(1), entry["user_id"])
+	assert.Equal(t, "/api/repositories/{id}", entry["route"])
+	assert.Equal(t, float64(200), entry["status"])
+	assert.Contains(t, entry, "latency")
+
+	// Request fields stay out of the global logger
+	buf.Reset()
+	log.Info().Msg("after")
+	assert.NotContains(t, buf.String(), "user_id")
+}
+
+func TestServer_authentication(t *testing.T) {
+	token := &domain.APIToken{ID: 3, UserID: 7, Scopes: []domain.TokenScope{domain.ScopeReposRead}}
+	newServer := func(apiKey string) (*Server, *mocks.GitHubService) {
+		mockGHService := new(mocks.GitHubService)
+		mockTokens := new(mocks.TokenService)
+		mockTokens.On("Authenticate", mock.Anything, "ghr_valid").Return(token, nil)
+		mockTokens.On("Authenticate", mock.Anything, "ghr_expired").Return(nil, domain.Unauthenticated("token expired"))
+		mockGHService.On("ListRepositories", mock.Anything, mock.Anything, 5).Return([]domain.Repository{}, nil)
+		cfg := &config.Config{Port: "8080", APIKey: apiKey}
+		return NewServer(cfg, mockGHService, nil, nil, nil, nil, personalWorkspace(), mockTokens, nil, nil, nil, nil), mockGHService
+	}
+
+	tests := []struct {
+		name, apiKey, method, path, authorization string
+		want                                      int
+	}{
+		{"token acts as its owner", "", "GET", "/api/repositories/list", "Bearer ghr_valid", http.StatusOK},
+		{"token lacking scope", "", "POST", "/api/repositories/sync", "Bearer ghr_valid", http.StatusForbidden},
+		{"token cannot manage tokens", "", "GET", "/api/tokens", "Bearer ghr_valid", http.StatusForbidden},
+		{"expired token", "", "GET", "/api/repositories/list", "Bearer ghr_expired", http.StatusUnauthorized},
+		{"api key required when configured", "secret", "GET", "/api/repositories/list", "", http.StatusUnauthorized},
+		{"wrong api key", "secret", "GET", "/api/repositories/list", "Bearer nope", http.StatusUnauthorized},
+		{"api key", "secret", "GET", "/api/repositories/list", "Bearer secret", http.StatusOK},
+		{"token alongside api key", "secret", "GET", "/api/repositories/list", "Bearer ghr_valid", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, mockGHService := newServer(tt.apiKey)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rr := httptest.NewRecorder()
+			server.router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.want, rr.Code, rr.Body.String())
+			if tt.authorization == "Bearer ghr_valid" && rr.Code == http.StatusOK {
+				mockGHService.AssertCalled(t, "ListRepositories", mock.Anything, 7, 5)
+			}
+		})
+	}
+}
+
+func TestServer_githubWebhook(t *testing.T) {
+	body := `{"action": "renamed"}`
+	sign := func(secret string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := []struct {
+		name, secret, signature string
+		want                    int
+	}{
+		{"valid signature", "s3cret", sign("s3cret"), http.StatusOK},
+		{"wrong secret", "s3cret", sign("other"), http.StatusUnauthorized},
+		{"missing signature", "s3cret", "", http.StatusUnauthorized},
+		{"webhooks not configured", "", sign(""), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockWebhooks := new(mocks.WebhookService)
+			mockWebhooks.On("HandleDelivery", mock.Anything, mock.MatchedBy(func(d *domain.WebhookDelivery) bool {
+				return d.ID == "d-1" && d.Event == "repository" && d.Action == "renamed"
+			})).Return(false, nil)
+			cfg := &config.Config{Port: "8080", GitHubWebhookSecret: tt.secret, APIKey: "required-elsewhere"}
+			server := NewServer(cfg, nil, nil, nil, nil, nil, nil, nil, mockWebhooks, nil, nil, nil)
+
+			req := httptest.NewRequest("POST", "/api/webhooks/github", strings.NewReader(body))
+			req.Header.Set("X-GitHub-Delivery", "d-1")
+			req.Header.Set("X-GitHub-Event", "repository")
+			if tt.signature != "" {
+				req.Header.Set("X-Hub-Signature-256", tt.signature)
+			}
+			rr := httptest.NewRecorder()
+			server.router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.want, rr.Code, rr.Body.String())
+			if tt.want == http.StatusOK {
+				mockWebhooks.AssertExpectations(t)
+			} else {
+				mockWebhooks.AssertNotCalled(t, "HandleDelivery", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
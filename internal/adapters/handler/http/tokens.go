@@ -0,0 +1,60 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/biodoia/ghrego/internal/core/domain"
+)
+
+type CreateTokenRequest struct {
+	Name          string              `json:"name"`
+	Scopes        []domain.TokenScope `json:"scopes"`
+	ExpiresInDays int                 `json:"expiresInDays"` // 0 for the default lifetime
+}
+
+func (s *Server) handleListTokens(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	tokens, err := s.tokenService.List(r.Context(), userID)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.JSON(w, r, tokens)
+}
+
+// handleCreateToken returns the plaintext token; it cannot be retrieved again
+func (s *Server) handleCreateToken(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	var req CreateTokenRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Render(w, r, ErrDecode(err))
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	token, err := s.tokenService.Create(r.Context(), userID, req.Name, req.Scopes, ttl)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, token)
+}
+
+func (s *Server) handleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	tokenID, err := intURLParam(r, "tokenId")
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+
+	if err := s.tokenService.Revoke(r.Context(), userID, tokenID); err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.JSON(w, r, map[string]bool{"success": true})
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// handleGitHubWebhook receives GitHub deliveries. It is authenticated by the
+// X-Hub-Signature-256 HMAC of the body rather than by authMiddleware.
+func (s *Server) handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		render.Render(w, r, ErrDecode(err))
+		return
+	}
+	if s.config.GitHubWebhookSecret == "" {
+		log.Ctx(r.Context()).Warn().Msg("GitHub webhook received but GITHUB_WEBHOOK_SECRET is not set")
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+	if !validSignature(s.config.GitHubWebhookSecret, r.Header.Get("X-Hub-Signature-256"), body) {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	delivery := &domain.WebhookDelivery{
+		ID:      r.Header.Get("X-GitHub-Delivery"),
+		Event:   r.Header.Get("X-GitHub-Event"),
+		Payload: body,
+	}
+	if delivery.ID == "" || delivery.Event == "" {
+		render.Render(w, r, ErrFromDomain(domain.Validation("X-GitHub-Delivery and X-GitHub-Event are required")))
+		return
+	}
+	var envelope struct {
+		Action string `json:"action"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	delivery.Action = envelope.Action
+
+	duplicate, err := s.webhookService.HandleDelivery(r.Context(), delivery)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.JSON(w, r, map[string]interface{}{"delivery": delivery.ID, "status": delivery.Status, "duplicate": duplicate})
+}
+
+func (s *Server) handleReplayWebhook(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	delivery, err := s.webhookService.Replay(r.Context(), userID, chi.URLParam(r, "deliveryId"))
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.JSON(w, r, delivery)
+}
+
+// validSignature checks a "sha256=<hex>" HMAC of body in constant time
+func validSignature(secret, header string, body []byte) bool {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/biodoia/ghrego/internal/core/domain"
+)
+
+type CreateWorkspaceRequest struct {
+	Name string `json:"name"`
+}
+
+type InviteRequest struct {
+	GithubUsername string               `json:"githubUsername"`
+	Role           domain.WorkspaceRole `json:"role"`
+}
+
+func (s *Server) handleListWorkspaces(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	workspaces, err := s.workspaceService.List(r.Context(), userID)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.JSON(w, r, workspaces)
+}
+
+func (s *Server) handleCreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	var req CreateWorkspaceRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Render(w, r, ErrDecode(err))
+		return
+	}
+
+	workspace, err := s.workspaceService.Create(r.Context(), userID, req.Name)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, workspace)
+}
+
+func (s *Server) handleListWorkspaceMembers(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	workspaceID, err := intURLParam(r, "workspaceId")
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+
+	members, err := s.workspaceService.ListMembers(r.Context(), userID, workspaceID)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.JSON(w, r, members)
+}
+
+func (s *Server) handleRemoveWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	workspaceID, err := intURLParam(r, "workspaceId")
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	memberID, err := intURLParam(r, "userId")
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+
+	if err := s.workspaceService.RemoveMember(r.Context(), userID, workspaceID, memberID); err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.JSON(w, r, map[string]bool{"success": true})
+}
+
+func (s *Server) handleInviteToWorkspace(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	workspaceID, err := intURLParam(r, "workspaceId")
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+
+	var req InviteRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Render(w, r, ErrDecode(err))
+		return
+	}
+
+	invitation, err := s.workspaceService.Invite(r.Context(), userID, workspaceID, req.GithubUsername, req.Role)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, invitation)
+}
+
+func (s *Server) handleListInvitations(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	invitations, err := s.workspaceService.ListInvitations(r.Context(), userID)
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.JSON(w, r, invitations)
+}
+
+func (s *Server) handleAcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	invitationID, err := intURLParam(r, "invitationId")
+	if err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+
+	if err := s.workspaceService.AcceptInvitation(r.Context(), userID, invitationID); err != nil {
+		render.Render(w, r, ErrFromDomain(err))
+		return
+	}
+	render.JSON(w, r, map[string]bool{"success": true})
+}
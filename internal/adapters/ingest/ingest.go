@@ -0,0 +1,429 @@
+// Package ingest checks repositories out into managed workspaces on disk, so
+// analysis reads files locally instead of making one API call per file.
+// Workspaces are keyed by commit SHA: analyses of an unchanged repository, or
+// of forks at the same commit, share one checkout.
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/biodoia/ghrego/internal/metrics"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// Prefixes of entries the ingester may delete from its root
+	clonePrefix   = ".clone-"
+	evictedPrefix = ".evicted-"
+)
+
+// shaPattern matches full SHA-1 and SHA-256 commit IDs, the workspace names
+var shaPattern = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+// Limits bound what the ingester keeps on disk and how much it clones at once.
+// Zero values disable the corresponding limit. MaxRepoBytes is checked against
+// the size reported by the host before cloning and against the fetched objects
+// before checking them out: when the host reports no size, only CloneTimeout
+// bounds the download itself.
+type Limits struct {
+	MaxRepoBytes  int64         // checkouts larger than this are refused
+	MaxWorkspaces int           // idle workspaces beyond this are evicted, least recently used first
+	MaxIdle       time.Duration // idle workspaces unused for longer are evicted
+	Concurrency   int           // clones running at once
+	CloneTimeout  time.Duration
+}
+
+type Ingester struct {
+	root   string
+	limits Limits
+	sem    chan struct{}
+	now    func() time.Time
+
+	mu         sync.Mutex
+	workspaces map[string]*workspace // by commit SHA
+}
+
+type workspace struct {
+	dir      string
+	leases   int // open snapshots, plus the clone in progress
+	lastUsed time.Time
+	ready    chan struct{} // closed once the checkout finished
+	err      error
+}
+
+// New creates an ingester keeping its workspaces in root. Workspaces left by a
+// previous run are reused; interrupted clones are removed.
+func New(root string, limits Limits) (*Ingester, error) {
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		return nil, err
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if limits.Concurrency < 1 {
+		limits.Concurrency = 1
+	}
+	i := &Ingester{
+		root:       root,
+		limits:     limits,
+		sem:        make(chan struct{}, limits.Concurrency),
+		now:        time.Now,
+		workspaces: make(map[string]*workspace),
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		switch {
+		case e.IsDir() && shaPattern.MatchString(name):
+			ws := &workspace{dir: filepath.Join(root, name), ready: make(chan struct{})}
+			close(ws.ready)
+			if info, err := e.Info(); err == nil {
+				ws.lastUsed = info.ModTime()
+			}
+			i.workspaces[name] = ws
+		case strings.HasPrefix(name, clonePrefix), strings.HasPrefix(name, evictedPrefix):
+			if err := os.RemoveAll(filepath.Join(root, name)); err != nil {
+				log.Warn().Err(err).Str("dir", name).Msg("Failed to remove stale ingest directory")
+			}
+		}
+	}
+	i.prune()
+	return i, nil
+}
+
+// Checkout returns the repository at commit sha, cloning it from host unless a
+// workspace for that commit already exists. Concurrent checkouts of the same
+// commit wait for a single clone, which does not depend on any of them: a
+// caller giving up does not fail the others.
+func (i *Ingester) Checkout(ctx context.Context, host ports.SourceHost, repo *domain.Repository, sha string) (ports.CodeSnapshot, error) {
+	if !shaPattern.MatchString(sha) {
+		return nil, domain.Validation("invalid commit %q", sha)
+	}
+	// Size is reported by the host in KB
+	if i.limits.MaxRepoBytes > 0 && int64(repo.Size)*1024 > i.limits.MaxRepoBytes {
+		return nil, domain.Validation("repository %s is too large to check out (%d KB)", repo.FullName, repo.Size)
+	}
+
+	snap, shared, err := i.checkout(ctx, host, repo, sha)
+	// A shared clone that ran out of time is tried once more by a caller
+	// still willing to wait
+	if shared && ctx.Err() == nil && (errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)) {
+		snap, _, err = i.checkout(ctx, host, repo, sha)
+	}
+	return snap, err
+}
+
+// checkout waits for the workspace of sha, starting its clone unless one is
+// under way. It also reports whether the workspace was shared with another caller.
+func (i *Ingester) checkout(ctx context.Context, host ports.SourceHost, repo *domain.Repository, sha string) (ports.CodeSnapshot, bool, error) {
+	i.mu.Lock()
+	ws, found := i.workspaces[sha]
+	if !found {
+		// The clone holds a lease of its own until it is done
+		ws = &workspace{dir: filepath.Join(i.root, sha), ready: make(chan struct{}), leases: 1}
+		i.workspaces[sha] = ws
+	}
+	ws.leases++
+	i.mu.Unlock()
+
+	if !found {
+		go i.fill(context.WithoutCancel(ctx), host, repo, sha, ws)
+	}
+	select {
+	case <-ws.ready:
+	case <-ctx.Done():
+		i.release(ws)
+		return nil, found, ctx.Err()
+	}
+	if ws.err != nil {
+		metrics.IngestCheckouts.WithLabelValues(metrics.OutcomeError).Inc()
+		return nil, found, ws.err
+	}
+
+	root, err := os.OpenRoot(ws.dir)
+	if err != nil {
+		i.release(ws)
+		metrics.IngestCheckouts.WithLabelValues(metrics.OutcomeError).Inc()
+		return nil, found, err
+	}
+	if found {
+		metrics.IngestCheckouts.WithLabelValues("reused").Inc()
+	} else {
+		metrics.IngestCheckouts.WithLabelValues("cloned").Inc()
+		log.Ctx(ctx).Info().Str("repo", repo.FullName).Str("sha", sha).Msg("Checked out repository")
+	}
+	return &snapshot{FS: root.FS(), root: root, sha: sha, release: func() { i.release(ws) }}, found, nil
+}
+
+// fill clones a new workspace and wakes up the callers waiting for it. The
+// clone gives up its lease first, so the workspace is idle once they are done.
+func (i *Ingester) fill(ctx context.Context, host ports.SourceHost, repo *domain.Repository, sha string, ws *workspace) {
+	ws.err = i.clone(ctx, host, repo, sha, ws.dir)
+	i.mu.Lock()
+	if ws.err != nil {
+		delete(i.workspaces, sha)
+	}
+	ws.leases--
+	ws.lastUsed = i.now()
+	i.mu.Unlock()
+	if ws.err != nil {
+		log.Ctx(ctx).Warn().Err(ws.err).Str("repo", repo.FullName).Str("sha", sha).Msg("Repository checkout failed")
+	}
+	close(ws.ready)
+	i.prune()
+}
+
+// clone fetches a single commit into a temporary directory and moves it to dir
+// once complete, so a workspace on disk is always a finished checkout
+func (i *Ingester) clone(ctx context.Context, host ports.SourceHost, repo *domain.Repository, sha, dir string) error {
+	select {
+	case i.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-i.sem }()
+	if i.limits.CloneTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.limits.CloneTimeout)
+		defer cancel()
+	}
+
+	owner, name, ok := strings.Cut(repo.FullName, "/")
+	if !ok {
+		return domain.Validation("invalid repository name %q", repo.FullName)
+	}
+	src, err := host.CloneSource(ctx, owner, name)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp(i.root, clonePrefix)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp) // nothing left to remove after the rename
+
+	g := gitCommand{dir: tmp, src: src}
+	if _, err := g.run(ctx, "init", "-q", "--template="); err != nil {
+		return err
+	}
+	if _, err := g.run(ctx, "fetch", "-q", "--depth=1", "--no-tags", "--", src.URL, sha); err != nil {
+		// Hosts that refuse to serve a commit by ID still serve the branch head
+		if repo.DefaultBranch == "" || strings.HasPrefix(repo.DefaultBranch, "-") {
+			return err
+		}
+		if _, err := g.run(ctx, "fetch", "-q", "--depth=1", "--no-tags", "--", src.URL, "refs/heads/"+repo.DefaultBranch); err != nil {
+			return err
+		}
+		head, err := g.run(ctx, "rev-parse", "FETCH_HEAD")
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(string(head)) != sha {
+			return fmt.Errorf("branch %s of %s is no longer at %s", repo.DefaultBranch, repo.FullName, sha)
+		}
+	}
+	// The host may not report a size: the compressed objects already fetched
+	// are a lower bound of the checkout, measured before writing it out
+	if i.limits.MaxRepoBytes > 0 {
+		size, err := g.packSize(ctx)
+		if err != nil {
+			return err
+		}
+		if size > i.limits.MaxRepoBytes {
+			return domain.Validation("repository %s is too large to check out (%d bytes compressed)", repo.FullName, size)
+		}
+	}
+	if _, err := g.run(ctx, "checkout", "-q", "--detach", "FETCH_HEAD"); err != nil {
+		return err
+	}
+	// Workspaces are content only
+	if err := os.RemoveAll(filepath.Join(tmp, ".git")); err != nil {
+		return err
+	}
+
+	if i.limits.MaxRepoBytes > 0 {
+		size, err := dirSize(tmp)
+		if err != nil {
+			return err
+		}
+		if size > i.limits.MaxRepoBytes {
+			return domain.Validation("repository %s is too large to check out (%d bytes)", repo.FullName, size)
+		}
+	}
+	return os.Rename(tmp, dir)
+}
+
+func (i *Ingester) release(ws *workspace) {
+	i.mu.Lock()
+	ws.leases--
+	ws.lastUsed = i.now()
+	i.mu.Unlock()
+	i.prune()
+}
+
+// prune evicts idle workspaces over the count limit, least recently used
+// first, and those idle for longer than MaxIdle
+func (i *Ingester) prune() {
+	now := i.now()
+	var evicted []string
+
+	i.mu.Lock()
+	idle := make([]string, 0, len(i.workspaces))
+	for sha, ws := range i.workspaces {
+		if ws.leases == 0 {
+			idle = append(idle, sha)
+		}
+	}
+	sort.Slice(idle, func(a, b int) bool {
+		return i.workspaces[idle[a]].lastUsed.Before(i.workspaces[idle[b]].lastUsed)
+	})
+	excess := len(i.workspaces) - i.limits.MaxWorkspaces
+	for _, sha := range idle {
+		ws := i.workspaces[sha]
+		overLimit := i.limits.MaxWorkspaces > 0 && excess > 0
+		expired := i.limits.MaxIdle > 0 && now.Sub(ws.lastUsed) > i.limits.MaxIdle
+		if !overLimit && !expired {
+			continue
+		}
+		// Moved aside under the lock so a new checkout of the same commit can
+		// reuse the name while the old files are being deleted
+		trash := filepath.Join(i.root, evictedPrefix+sha+"-"+strconv.FormatInt(now.UnixNano(), 36))
+		if err := os.Rename(ws.dir, trash); err != nil {
+			log.Warn().Err(err).Str("sha", sha).Msg("Failed to evict ingest workspace")
+			continue
+		}
+		delete(i.workspaces, sha)
+		evicted = append(evicted, trash)
+		excess--
+	}
+	metrics.IngestWorkspaces.Set(float64(len(i.workspaces)))
+	i.mu.Unlock()
+
+	for _, dir := range evicted {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Warn().Err(err).Str("dir", dir).Msg("Failed to remove evicted ingest workspace")
+		}
+	}
+}
+
+// snapshot reads a workspace through an os.Root, which refuses paths leading
+// outside it
+type snapshot struct {
+	fs.FS
+	root    *os.Root
+	sha     string
+	once    sync.Once
+	release func()
+}
+
+func (s *snapshot) SHA() string {
+	return s.sha
+}
+
+func (s *snapshot) Release() {
+	s.once.Do(func() {
+		s.root.Close()
+		s.release()
+	})
+}
+
+// gitCommand runs git for one clone. Configuration is hermetic: no system or
+// global settings (credential helpers, LFS filters), symlinks are checked out
+// as plain files and the credentials only go to the clone URL.
+type gitCommand struct {
+	dir string
+	src domain.CloneSource
+}
+
+func (g gitCommand) run(ctx context.Context, args ...string) ([]byte, error) {
+	config := [][2]string{
+		{"core.symlinks", "false"},
+		{"core.fsmonitor", "false"},
+	}
+	if g.src.AuthHeader != "" {
+		config = append(config, [2]string{"http." + g.src.URL + ".extraHeader", "Authorization: " + g.src.AuthHeader})
+	}
+	if path, ok := strings.CutPrefix(g.src.URL, "file://"); ok {
+		// Local sources may belong to another user
+		config = append(config, [2]string{"safe.directory", path})
+	}
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", g.dir}, args...)...)
+	// Passed in the environment rather than with -c, to keep the header out of the process list
+	cmd.Env = append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0", "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL="+os.DevNull,
+		"GIT_CONFIG_COUNT="+strconv.Itoa(len(config)),
+	)
+	for n, kv := range config {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", n, kv[0]), fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", n, kv[1]))
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// packSize is the size of the objects fetched so far, in bytes
+func (g gitCommand) packSize(ctx context.Context) (int64, error) {
+	out, err := g.run(ctx, "count-objects", "-v")
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, _ := strings.Cut(line, ": ")
+		if key == "size" || key == "size-pack" { // in KB
+			kb, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("git count-objects: %w", err)
+			}
+			size += kb * 1024
+		}
+	}
+	return size, nil
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,181 @@
+package ingest
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/biodoia/ghrego/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null",
+	)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+// newSource creates a repository with two commits and a host serving it,
+// returning the commit SHAs oldest first
+func newSource(t *testing.T) (*mocks.SourceHost, *domain.Repository, []string) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# api\n"), 0o644))
+	require.NoError(t, os.Symlink("/etc/passwd", filepath.Join(dir, "passwd")))
+	git(t, dir, "init", "-q", "-b", "main")
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "-q", "-m", "first")
+	first := git(t, dir, "rev-parse", "HEAD")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644))
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "-q", "-m", "second")
+	second := git(t, dir, "rev-parse", "HEAD")
+
+	host := &mocks.SourceHost{Name: domain.ProviderLocal}
+	host.On("CloneSource", mock.Anything, "team", "api").Return(domain.CloneSource{URL: "file://" + dir}, nil)
+	repo := &domain.Repository{FullName: "team/api", DefaultBranch: "main"}
+	return host, repo, []string{first, second}
+}
+
+func TestIngester_CheckoutReusesWorkspaces(t *testing.T) {
+	host, repo, shas := newSource(t)
+	ing, err := New(t.TempDir(), Limits{MaxWorkspaces: 5})
+	require.NoError(t, err)
+
+	snap, err := ing.Checkout(context.Background(), host, repo, shas[1])
+	require.NoError(t, err)
+	assert.Equal(t, shas[1], snap.SHA())
+
+	content, err := fs.ReadFile(snap, "main.go")
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(content))
+	_, err = fs.Stat(snap, ".git")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	// Symlinks are plain files holding their target
+	link, err := fs.ReadFile(snap, "passwd")
+	require.NoError(t, err)
+	assert.Equal(t, "/etc/passwd", string(link))
+	snap.Release()
+
+	// An older commit is a separate workspace; the same commit is not cloned again
+	old, err := ing.Checkout(context.Background(), host, repo, shas[0])
+	require.NoError(t, err)
+	_, err = fs.Stat(old, "main.go")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	old.Release()
+
+	again, err := ing.Checkout(context.Background(), host, repo, shas[1])
+	require.NoError(t, err)
+	again.Release()
+	host.AssertNumberOfCalls(t, "CloneSource", 2)
+}
+
+func TestIngester_CheckoutOutlivesCancelledCaller(t *testing.T) {
+	source, repo, shas := newSource(t)
+	src, err := source.CloneSource(context.Background(), "team", "api")
+	require.NoError(t, err)
+	started, gate := make(chan struct{}), make(chan struct{})
+	host := &mocks.SourceHost{Name: domain.ProviderLocal}
+	host.On("CloneSource", mock.Anything, "team", "api").Run(func(mock.Arguments) {
+		close(started)
+		<-gate
+	}).Return(src, nil)
+	ing, err := New(t.TempDir(), Limits{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := ing.Checkout(ctx, host, repo, shas[1])
+		first <- err
+	}()
+	<-started
+	type result struct {
+		snap ports.CodeSnapshot
+		err  error
+	}
+	second := make(chan result, 1)
+	go func() {
+		snap, err := ing.Checkout(context.Background(), host, repo, shas[1])
+		second <- result{snap, err}
+	}()
+
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled)
+	close(gate)
+	got := <-second
+	require.NoError(t, got.err)
+	_, err = fs.Stat(got.snap, "main.go")
+	assert.NoError(t, err)
+	got.snap.Release()
+	host.AssertNumberOfCalls(t, "CloneSource", 1)
+}
+
+func TestIngester_EvictsLeastRecentlyUsed(t *testing.T) {
+	host, repo, shas := newSource(t)
+	root := t.TempDir()
+	ing, err := New(root, Limits{MaxWorkspaces: 1})
+	require.NoError(t, err)
+
+	first, err := ing.Checkout(context.Background(), host, repo, shas[0])
+	require.NoError(t, err)
+	second, err := ing.Checkout(context.Background(), host, repo, shas[1])
+	require.NoError(t, err)
+	// Both are in use, neither can go
+	assert.DirExists(t, filepath.Join(root, shas[0]))
+
+	first.Release()
+	assert.NoDirExists(t, filepath.Join(root, shas[0]))
+	second.Release()
+	assert.DirExists(t, filepath.Join(root, shas[1]))
+
+	// A restart adopts the remaining workspace and drops interrupted clones
+	require.NoError(t, os.Mkdir(filepath.Join(root, clonePrefix+"123"), 0o700))
+	ing, err = New(root, Limits{MaxWorkspaces: 1})
+	require.NoError(t, err)
+	assert.NoDirExists(t, filepath.Join(root, clonePrefix+"123"))
+	snap, err := ing.Checkout(context.Background(), host, repo, shas[1])
+	require.NoError(t, err)
+	snap.Release()
+	host.AssertNumberOfCalls(t, "CloneSource", 2)
+}
+
+func TestIngester_Limits(t *testing.T) {
+	host, repo, shas := newSource(t)
+	root := t.TempDir()
+	ing, err := New(root, Limits{MaxRepoBytes: 10})
+	require.NoError(t, err)
+
+	_, err = ing.Checkout(context.Background(), host, &domain.Repository{FullName: "team/api", Size: 1}, shas[1])
+	assert.ErrorIs(t, err, domain.ErrValidation)
+	host.AssertNotCalled(t, "CloneSource", mock.Anything, mock.Anything, mock.Anything)
+
+	// The host reported no size; the fetched objects are measured
+	_, err = ing.Checkout(context.Background(), host, repo, shas[1])
+	assert.ErrorIs(t, err, domain.ErrValidation)
+	assert.ErrorContains(t, err, "compressed")
+	entries, err := os.ReadDir(root)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	_, err = ing.Checkout(context.Background(), host, repo, "main")
+	assert.ErrorIs(t, err, domain.ErrValidation)
+}
@@ -0,0 +1,347 @@
+// Package local reads repositories from git clones and bare repositories on
+// the server's filesystem, for air-gapped analysis. Repositories live under a
+// root directory and are addressed like on GitHub, as <owner>/<name>
+// directories below it; content is read from commits, not the working tree.
+package local
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/analyzers"
+	"github.com/biodoia/ghrego/internal/core/domain"
+)
+
+// placeholderDescription is what git writes into a new bare repository's description file
+const placeholderDescription = "Unnamed repository; edit this file 'description' to name the repository."
+
+type Client struct {
+	root string
+}
+
+// NewClient serves the repositories below root
+func NewClient(root string) (*Client, error) {
+	abs, err := filepath.Abs(root)
+	if err == nil {
+		// Repository directories are compared with the paths git reports, which are resolved
+		abs, err = filepath.EvalSymlinks(abs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid local repositories root: %w", err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid local repositories root: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("local repositories root %s is not a directory", abs)
+	}
+	return &Client{root: abs}, nil
+}
+
+func (c *Client) Provider() domain.SourceProvider {
+	return domain.ProviderLocal
+}
+
+// ListRepositories lists the git repositories directly inside the account directory
+func (c *Client) ListRepositories(ctx context.Context, account string) ([]*domain.Repository, error) {
+	dir, err := c.resolve(account)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, domain.NotFound("local directory", account)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories: %w", err)
+	}
+
+	var repos []*domain.Repository
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		repo, err := c.GetRepository(ctx, account, entry.Name())
+		if errors.Is(err, domain.ErrNotFound) {
+			continue // a plain directory, not a repository
+		}
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
+// GetRepository describes a repository from its git history
+func (c *Client) GetRepository(ctx context.Context, owner, repoName string) (*domain.Repository, error) {
+	fullName := path.Join(owner, repoName)
+	dir, err := c.repoDir(ctx, owner, repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &domain.Repository{
+		Provider: domain.ProviderLocal,
+		GithubID: localID(fullName),
+		Name:     strings.TrimSuffix(path.Base(fullName), ".git"),
+		FullName: fullName,
+		URL:      "local://" + fullName,
+		// Never published anywhere, so treated as private
+		IsPrivate: true,
+	}
+	if branch, err := git(ctx, dir, "symbolic-ref", "--short", "HEAD"); err == nil {
+		repo.DefaultBranch = strings.TrimSpace(string(branch))
+	}
+	if desc, err := os.ReadFile(filepath.Join(gitDir(dir), "description")); err == nil {
+		if d := strings.TrimSpace(string(desc)); d != "" && d != placeholderDescription {
+			repo.Description = sql.NullString{String: d, Valid: true}
+		}
+	}
+
+	// Empty repositories have no log; they are still listed
+	if out, err := git(ctx, dir, "log", "-1", "--format=%ct"); err == nil {
+		if last, ok := parseUnix(out); ok {
+			repo.LastCommitAt = sql.NullTime{Time: last, Valid: true}
+			repo.UpdatedAt = last
+		}
+	}
+	if out, err := git(ctx, dir, "log", "--max-parents=0", "--format=%ct", "HEAD"); err == nil {
+		for _, line := range strings.Fields(string(out)) {
+			if first, ok := parseUnix([]byte(line)); ok && (repo.CreatedAt.IsZero() || first.Before(repo.CreatedAt)) {
+				repo.CreatedAt = first
+			}
+		}
+	}
+	if out, err := git(ctx, dir, "count-objects", "-v"); err == nil {
+		repo.Size = objectsSize(out)
+	}
+	if structure, err := c.structure(ctx, dir); err == nil && structure.PrimaryLanguage != "" {
+		repo.Language = sql.NullString{String: structure.PrimaryLanguage, Valid: true}
+	}
+	return repo, nil
+}
+
+// GetFileContent returns a file as committed on HEAD
+func (c *Client) GetFileContent(ctx context.Context, owner, repo, filePath string) (string, error) {
+	dir, err := c.repoDir(ctx, owner, repo)
+	if err != nil {
+		return "", err
+	}
+	out, err := git(ctx, dir, "cat-file", "blob", "HEAD:"+strings.TrimPrefix(filePath, "/"))
+	if err != nil {
+		return "", domain.NotFound("file", filePath)
+	}
+	return string(out), nil
+}
+
+// GetLanguages returns bytes of code per language on HEAD. Files are
+// classified by path only, so vendored and generated code does not count.
+func (c *Client) GetLanguages(ctx context.Context, owner, repo string) (map[string]int, error) {
+	dir, err := c.repoDir(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	structure, err := c.structure(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	langs := make(map[string]int, len(structure.Languages))
+	for lang, share := range structure.Languages {
+		langs[lang] = int(share.Bytes)
+	}
+	return langs, nil
+}
+
+func (c *Client) structure(ctx context.Context, dir string) (*domain.RepositoryStructure, error) {
+	tree, err := lsTree(ctx, dir, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return analyzers.Structure(ctx, tree, nil)
+}
+
+func (c *Client) GetBranchSHA(ctx context.Context, owner, repo, branch string) (string, error) {
+	dir, err := c.repoDir(ctx, owner, repo)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(branch, "-") {
+		return "", domain.Validation("invalid branch name %q", branch)
+	}
+	out, err := git(ctx, dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch+"^{commit}")
+	if err != nil {
+		return "", domain.NotFound("branch", branch)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (c *Client) GetTree(ctx context.Context, owner, repo, ref string) ([]domain.TreeEntry, error) {
+	dir, err := c.repoDir(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(ref, "-") {
+		return nil, domain.Validation("invalid ref %q", ref)
+	}
+	return lsTree(ctx, dir, ref)
+}
+
+// CloneSource points git at the repository directory. The file:// form makes
+// shallow fetches work, which git ignores for plain local paths.
+func (c *Client) CloneSource(ctx context.Context, owner, repo string) (domain.CloneSource, error) {
+	dir, err := c.repoDir(ctx, owner, repo)
+	if err != nil {
+		return domain.CloneSource{}, err
+	}
+	return domain.CloneSource{URL: "file://" + filepath.ToSlash(dir)}, nil
+}
+
+// AnalyzeStructure classifies the HEAD tree like the GitHub client does
+func (c *Client) AnalyzeStructure(ctx context.Context, owner, repo string) (*domain.RepositoryStructure, error) {
+	tree, err := c.GetTree(ctx, owner, repo, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return analyzers.Structure(ctx, tree, func(ctx context.Context, path string) (string, error) {
+		return c.GetFileContent(ctx, owner, repo, path)
+	})
+}
+
+// resolve maps a slash-separated name to a directory below the root, refusing
+// anything that would escape it
+func (c *Client) resolve(name string) (string, error) {
+	clean := path.Clean("/" + name)
+	if clean == "/" || name != strings.Trim(clean, "/") {
+		return "", domain.Validation("invalid local repository path %q", name)
+	}
+	return filepath.Join(c.root, filepath.FromSlash(clean)), nil
+}
+
+// repoDir returns the directory of a repository, which must be a git work tree or bare repository
+func (c *Client) repoDir(ctx context.Context, owner, repo string) (string, error) {
+	fullName := path.Join(owner, repo)
+	dir, err := c.resolve(fullName)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return "", domain.NotFound("local repository", fullName)
+	}
+	// rev-parse would walk up to an enclosing repository; the root's own must not count
+	top, err := git(ctx, dir, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", domain.NotFound("local repository", fullName)
+	}
+	if gd := strings.TrimSpace(string(top)); gd != dir && gd != filepath.Join(dir, ".git") {
+		return "", domain.NotFound("local repository", fullName)
+	}
+	return dir, nil
+}
+
+// git runs a read-only git command in dir. Repositories may belong to another
+// user, so ownership checks are relaxed for that directory only, and
+// repository configuration is not allowed to spawn helpers.
+func git(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	command := args[0]
+	args = append([]string{"-c", "safe.directory=" + dir, "-c", "core.fsmonitor=false", "-C", dir}, args...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_CONFIG_NOSYSTEM=1")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("git %s: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// lsTree lists a tree recursively, directories included, with blob sizes
+func lsTree(ctx context.Context, dir, ref string) ([]domain.TreeEntry, error) {
+	out, err := git(ctx, dir, "ls-tree", "-r", "-t", "-l", "-z", ref)
+	if err != nil {
+		return nil, domain.NotFound("tree", ref)
+	}
+
+	var entries []domain.TreeEntry
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	scanner.Split(splitNUL)
+	for scanner.Scan() {
+		// <mode> SP <type> SP <object> SP+ <size> TAB <path>
+		meta, p, ok := strings.Cut(scanner.Text(), "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 4 {
+			continue
+		}
+		entry := domain.TreeEntry{Path: p, Type: fields[1]}
+		if entry.Type == "commit" {
+			continue // submodule
+		}
+		entry.Size, _ = strconv.ParseInt(fields[3], 10, 64)
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func splitNUL(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// gitDir returns the git directory of a work tree or bare repository
+func gitDir(dir string) string {
+	if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info.IsDir() {
+		return filepath.Join(dir, ".git")
+	}
+	return dir
+}
+
+// localID derives a stable repository ID from its path below the root
+func localID(fullName string) string {
+	sum := sha1.Sum([]byte(fullName))
+	return hex.EncodeToString(sum[:])
+}
+
+func parseUnix(out []byte) (time.Time, bool) {
+	secs, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(secs, 0).UTC(), true
+}
+
+// objectsSize sums loose and packed object sizes (KiB) from count-objects -v
+func objectsSize(out []byte) int {
+	var total int
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, _ := strings.Cut(line, ": ")
+		if key == "size" || key == "size-pack" {
+			n, _ := strconv.Atoi(strings.TrimSpace(value))
+			total += n
+		}
+	}
+	return total
+}
@@ -0,0 +1,132 @@
+package local
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var commitDate = time.Date(2026, 3, 17, 12, 0, 0, 0, time.UTC)
+
+func run(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_AUTHOR_DATE="+commitDate.Format(time.RFC3339), "GIT_COMMITTER_DATE="+commitDate.Format(time.RFC3339),
+		"GIT_CONFIG_GLOBAL=/dev/null",
+	)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+// newRoot lays out team/api (a clone with a work tree), team/web.git (bare)
+// and team/notes (not a repository)
+func newRoot(t *testing.T) *Client {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	api := filepath.Join(root, "team", "api")
+	require.NoError(t, os.MkdirAll(filepath.Join(api, "cmd"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "team", "notes"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(api, "go.mod"), []byte("module example.com/api\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(api, "cmd", "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(api, "build.sh"), []byte("#!/bin/sh\n"), 0o644))
+
+	run(t, api, "init", "-q", "-b", "main")
+	run(t, api, "add", ".")
+	run(t, api, "commit", "-q", "-m", "initial")
+	// Uncommitted changes are not part of the analysed content
+	require.NoError(t, os.WriteFile(filepath.Join(api, "scratch.go"), []byte("package main\n"), 0o644))
+
+	run(t, filepath.Join(root, "team"), "clone", "-q", "--bare", "api", "web.git")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "team", "web.git", "description"), []byte("Web frontend\n"), 0o644))
+
+	c, err := NewClient(root)
+	require.NoError(t, err)
+	return c
+}
+
+func TestClient_ListRepositories(t *testing.T) {
+	c := newRoot(t)
+
+	repos, err := c.ListRepositories(context.Background(), "team")
+	require.NoError(t, err)
+	require.Len(t, repos, 2)
+
+	api, web := repos[0], repos[1]
+	assert.Equal(t, domain.ProviderLocal, api.Provider)
+	assert.Equal(t, "team/api", api.FullName)
+	assert.Equal(t, "main", api.DefaultBranch)
+	assert.Equal(t, "Go", api.Language.String)
+	assert.Equal(t, commitDate, api.LastCommitAt.Time)
+	assert.Equal(t, commitDate, api.CreatedAt)
+	assert.False(t, api.Description.Valid)
+
+	assert.Equal(t, "web", web.Name)
+	assert.Equal(t, "team/web.git", web.FullName)
+	assert.Equal(t, "Web frontend", web.Description.String)
+	assert.NotEqual(t, api.GithubID, web.GithubID)
+
+	_, err = c.ListRepositories(context.Background(), "missing")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestClient_RepositoryContent(t *testing.T) {
+	c := newRoot(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"api", "web.git"} {
+		t.Run(name, func(t *testing.T) {
+			content, err := c.GetFileContent(ctx, "team", name, "cmd/main.go")
+			require.NoError(t, err)
+			assert.Contains(t, content, "package main")
+
+			_, err = c.GetFileContent(ctx, "team", name, "scratch.go")
+			assert.ErrorIs(t, err, domain.ErrNotFound)
+
+			langs, err := c.GetLanguages(ctx, "team", name)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]int{"Go": 29, "Shell": 10}, langs)
+
+			sha, err := c.GetBranchSHA(ctx, "team", name, "main")
+			require.NoError(t, err)
+			assert.Len(t, sha, 40)
+
+			structure, err := c.AnalyzeStructure(ctx, "team", name)
+			require.NoError(t, err)
+			assert.Equal(t, 3, structure.Files)
+			assert.Equal(t, []string{"cmd"}, structure.Directories)
+			assert.Equal(t, map[domain.FileCategory]int{domain.FileSource: 2, domain.FileConfiguration: 1}, structure.Categories)
+			assert.Equal(t, domain.LanguageShare{Files: 1, Bytes: 29, Percentage: 74.4}, structure.Languages["Go"])
+			assert.Equal(t, "Go", structure.PrimaryLanguage)
+		})
+	}
+}
+
+func TestClient_RejectsPathsOutsideRepositories(t *testing.T) {
+	c := newRoot(t)
+	ctx := context.Background()
+
+	_, err := c.GetRepository(ctx, "team", "notes")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = c.GetRepository(ctx, "..", "etc")
+	assert.ErrorIs(t, err, domain.ErrValidation)
+
+	_, err = c.GetFileContent(ctx, "team/../..", "api", "go.mod")
+	assert.ErrorIs(t, err, domain.ErrValidation)
+
+	_, err = c.GetTree(ctx, "team", "api", "--output=/tmp/x")
+	assert.ErrorIs(t, err, domain.ErrValidation)
+}
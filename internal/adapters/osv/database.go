@@ -0,0 +1,127 @@
+// Package osv serves security advisories from OSV dumps on the local
+// filesystem, such as the per-ecosystem all.zip archives published at
+// https://osv-vulnerabilities.storage.googleapis.com, so that analyses need
+// no network access. Dumps are loaded into memory once, at startup.
+package osv
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/biodoia/ghrego/internal/core/versions"
+)
+
+type packageKey struct {
+	ecosystem, name string
+}
+
+// Database is an in-memory index of advisories by affected package
+type Database struct {
+	byPackage map[packageKey][]domain.Advisory
+	count     int
+}
+
+var _ ports.AdvisoryDatabase = (*Database)(nil)
+
+// Load reads every advisory below dir: *.json files holding one advisory
+// each and *.zip archives of them. Withdrawn advisories are skipped.
+func Load(dir string) (*Database, error) {
+	db := &Database{byPackage: make(map[packageKey][]domain.Advisory)}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return db.add(path, data)
+		case ".zip":
+			return db.addArchive(path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load advisories: %w", err)
+	}
+	return db, nil
+}
+
+func (db *Database) addArchive(path string) error {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	for _, f := range archive.File {
+		if !strings.HasSuffix(strings.ToLower(f.Name), ".json") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		if err := db.add(path+"!"+f.Name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *Database) add(source string, data []byte) error {
+	var adv domain.Advisory
+	if err := json.Unmarshal(data, &adv); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	if adv.ID == "" || adv.Withdrawn != "" {
+		return nil
+	}
+	seen := make(map[packageKey]bool)
+	for _, affected := range adv.Affected {
+		key := keyOf(affected.Package.Ecosystem, affected.Package.Name)
+		if key.name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		db.byPackage[key] = append(db.byPackage[key], adv)
+	}
+	db.count++
+	return nil
+}
+
+// Len is the number of advisories loaded
+func (db *Database) Len() int {
+	return db.count
+}
+
+// Advisories returns the advisories listing the package as affected. Callers
+// still match the version against each advisory's ranges.
+func (db *Database) Advisories(_ context.Context, ecosystem, name string) ([]domain.Advisory, error) {
+	return db.byPackage[keyOf(ecosystem, name)], nil
+}
+
+// keyOf drops the release suffix of ecosystems like "Debian:12" and applies
+// the ecosystem's name normalisation (PEP 503 for PyPI)
+func keyOf(ecosystem, name string) packageKey {
+	ecosystem, _, _ = strings.Cut(ecosystem, ":")
+	return packageKey{ecosystem: ecosystem, name: versions.NormalizeName(ecosystem, name)}
+}
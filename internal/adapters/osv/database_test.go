@@ -0,0 +1,72 @@
+package osv
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeArchive(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "Go"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Go", "GO-2024-0001.json"), []byte(`{
+		"id": "GO-2024-0001",
+		"affected": [
+			{"package": {"ecosystem": "Go", "name": "github.com/acme/web"}},
+			{"package": {"ecosystem": "Go", "name": "github.com/acme/web"}}
+		]}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not an advisory"), 0o644))
+	writeArchive(t, filepath.Join(dir, "PyPI.zip"), map[string]string{
+		"PYSEC-2024-1.json": `{"id": "PYSEC-2024-1", "affected": [{"package": {"ecosystem": "PyPI", "name": "Zope.Interface"}}]}`,
+		"PYSEC-2024-2.json": `{"id": "PYSEC-2024-2", "withdrawn": "2024-05-01T00:00:00Z", "affected": [{"package": {"ecosystem": "PyPI", "name": "zope.interface"}}]}`,
+		"GHSA-5555.json":    `{"id": "GHSA-5555", "affected": [{"package": {"ecosystem": "npm", "name": "@Acme/UI"}}, {"package": {"ecosystem": "Debian:12", "name": "acme"}}]}`,
+	})
+
+	db, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 3, db.Len())
+
+	ctx := context.Background()
+	goAdvisories, _ := db.Advisories(ctx, "Go", "github.com/acme/web")
+	require.Len(t, goAdvisories, 1, "an advisory is indexed once per package")
+	assert.Equal(t, "GO-2024-0001", goAdvisories[0].ID)
+
+	pypi, _ := db.Advisories(ctx, "PyPI", "zope-interface")
+	require.Len(t, pypi, 1, "PyPI names are normalised and withdrawn advisories skipped")
+	assert.Equal(t, "PYSEC-2024-1", pypi[0].ID)
+
+	npm, _ := db.Advisories(ctx, "npm", "@acme/ui")
+	assert.Len(t, npm, 1)
+	debian, _ := db.Advisories(ctx, "Debian", "acme")
+	assert.Len(t, debian, 1)
+	none, _ := db.Advisories(ctx, "Go", "github.com/acme/cli")
+	assert.Empty(t, none)
+}
+
+func TestLoadInvalidAdvisory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"id": `), 0o644))
+
+	_, err := Load(dir)
+	assert.ErrorContains(t, err, "broken.json")
+}
@@ -0,0 +1,103 @@
+// Package registry serves package versions and licenses from a snapshot of the public
+// registries kept on the local filesystem, so that drift reports can compare
+// against upstream releases without network access.
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/biodoia/ghrego/internal/core/licenses"
+	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/biodoia/ghrego/internal/core/versions"
+)
+
+type packageKey struct {
+	ecosystem, name string
+}
+
+// Snapshot holds the newest stable version and the license of each package in the file
+type Snapshot struct {
+	latest   map[packageKey]string
+	licenses map[packageKey]string
+}
+
+var _ ports.PackageRegistry = (*Snapshot)(nil)
+
+// Load reads a JSON snapshot keyed by OSV ecosystem and package name. A
+// package maps to its latest version or to the list of its released
+// versions, of which the newest stable one is kept, or to an object that
+// also gives its SPDX license:
+//
+//	{"npm": {"react": "18.3.1"}, "Go": {"github.com/go-chi/chi/v5": ["5.0.12", "5.2.1"]},
+//	 "PyPI": {"django": {"versions": ["5.0.3"], "license": "BSD-3-Clause"}}}
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry snapshot: %w", err)
+	}
+	var raw map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse registry snapshot: %w", err)
+	}
+
+	s := &Snapshot{latest: make(map[packageKey]string), licenses: make(map[packageKey]string)}
+	for ecosystem, packages := range raw {
+		for name, value := range packages {
+			var released []string
+			var latest string
+			var entry struct {
+				Versions []string `json:"versions"`
+				License  string   `json:"license"`
+			}
+			switch {
+			case json.Unmarshal(value, &latest) == nil:
+				released = []string{latest}
+			case json.Unmarshal(value, &released) == nil:
+			case json.Unmarshal(value, &entry) == nil:
+				released = entry.Versions
+			default:
+				return nil, fmt.Errorf("registry snapshot: %s %s: versions must be a string, a list of strings or an object", ecosystem, name)
+			}
+			key := packageKey{ecosystem, versions.NormalizeName(ecosystem, name)}
+			if v := newestStable(ecosystem, released); v != "" {
+				s.latest[key] = v
+			}
+			if entry.License != "" {
+				s.licenses[key] = licenses.NormalizeExpression(entry.License)
+			}
+		}
+	}
+	return s, nil
+}
+
+func newestStable(ecosystem string, released []string) string {
+	newest := ""
+	for _, v := range released {
+		if !versions.Valid(ecosystem, v) || versions.IsPrerelease(ecosystem, v) {
+			continue
+		}
+		if newest == "" || versions.Compare(ecosystem, v, newest) > 0 {
+			newest = v
+		}
+	}
+	return newest
+}
+
+// Len is the number of packages in the snapshot with a stable version
+func (s *Snapshot) Len() int {
+	return len(s.latest)
+}
+
+// LatestVersion returns the newest stable version of a package, "" when the
+// snapshot does not list it
+func (s *Snapshot) LatestVersion(_ context.Context, ecosystem, name string) (string, error) {
+	return s.latest[packageKey{ecosystem, versions.NormalizeName(ecosystem, name)}], nil
+}
+
+// License returns the SPDX license of a package, "" when the snapshot does not give it
+func (s *Snapshot) License(_ context.Context, ecosystem, name string) (string, error) {
+	return s.licenses[packageKey{ecosystem, versions.NormalizeName(ecosystem, name)}], nil
+}
@@ -0,0 +1,52 @@
+package registry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"npm": {"React": "18.3.1", "left-pad": ["1.3.0", "1.1.3", "2.0.0-beta.1"]},
+		"PyPI": {"Django": {"versions": ["4.2.11", "5.0.3", "5.1a1"], "license": "BSD-3"}},
+		"Go": {"github.com/go-chi/chi/v5": ["v5.0.12", "v5.2.1"], "example.com/none": ["latest"]}
+	}`), 0o644))
+
+	s, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 4, s.Len())
+
+	ctx := context.Background()
+	for _, tt := range []struct{ ecosystem, name, want string }{
+		{"npm", "react", "18.3.1"},
+		{"npm", "left-pad", "1.3.0"},
+		{"PyPI", "django", "5.0.3"},
+		{"Go", "github.com/go-chi/chi/v5", "v5.2.1"},
+		{"Go", "example.com/none", ""},
+		{"npm", "vue", ""},
+	} {
+		got, err := s.LatestVersion(ctx, tt.ecosystem, tt.name)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, got, tt.name)
+	}
+
+	license, err := s.License(ctx, "PyPI", "django")
+	assert.NoError(t, err)
+	assert.Equal(t, "BSD-3-Clause", license)
+	license, _ = s.License(ctx, "npm", "react")
+	assert.Empty(t, license)
+}
+
+func TestLoadInvalidSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"npm": {"react": 18}}`), 0o644))
+
+	_, err := Load(path)
+	assert.ErrorContains(t, err, "npm react")
+}
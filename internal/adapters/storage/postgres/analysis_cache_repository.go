@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+)
+
+// AnalysisCacheRepository persists AI responses keyed by analysis fingerprint
+type AnalysisCacheRepository struct {
+	db *DB
+}
+
+func NewAnalysisCacheRepository(db *DB) ports.AnalysisCacheRepository {
+	return &AnalysisCacheRepository{db: db}
+}
+
+// Get returns the cached response for a fingerprint, or nil on a cache miss
+func (r *AnalysisCacheRepository) Get(ctx context.Context, fingerprint string) (*domain.RepositoryAnalysisResponse, error) {
+	const query = `SELECT response FROM "analysisCache" WHERE fingerprint = $1`
+
+	var raw []byte
+	err := r.db.Pool.QueryRow(ctx, query, fingerprint).Scan(&raw)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get cached analysis: %w", err)
+	}
+
+	var response domain.RepositoryAnalysisResponse
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode cached analysis: %w", err)
+	}
+	return &response, nil
+}
+
+// Set stores (or replaces) the response for a fingerprint
+func (r *AnalysisCacheRepository) Set(ctx context.Context, fingerprint string, repoID int, response *domain.RepositoryAnalysisResponse) error {
+	const query = `
+		INSERT INTO "analysisCache" (fingerprint, "repositoryId", response, "createdAt")
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (fingerprint) DO UPDATE SET
+			"repositoryId" = EXCLUDED."repositoryId",
+			response = EXCLUDED.response,
+			"createdAt" = NOW()
+	`
+
+	raw, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to encode analysis: %w", err)
+	}
+
+	if _, err := r.db.Pool.Exec(ctx, query, fingerprint, repoID, raw); err != nil {
+		return fmt.Errorf("failed to cache analysis: %w", err)
+	}
+	return nil
+}
@@ -18,7 +18,7 @@ func NewAnalysisRepository(db *DB) ports.AnalysisRepository {
 
 func (r *AnalysisRepository) GetByRepositoryID(ctx context.Context, repoID int) ([]domain.Analysis, error) {
 	const query = `
-		SELECT id, "repositoryId", "analysisType", status, result, summary, score, "errorMessage", "createdAt", "completedAt"
+		SELECT id, "repositoryId", "analysisType", status, result, summary, score, "errorMessage", fingerprint, "cacheHit", "createdAt", "completedAt"
 		FROM analyses
 		WHERE "repositoryId" = $1
 		ORDER BY "createdAt" DESC
@@ -36,7 +36,7 @@ func (r *AnalysisRepository) GetByRepositoryID(ctx context.Context, repoID int)
 		// pgx handles string -> custom string type well.
 		if err := rows.Scan(
 			&a.ID, &a.RepositoryID, &a.AnalysisType, &a.Status, &a.Result, &a.Summary,
-			&a.Score, &a.ErrorMessage, &a.CreatedAt, &a.CompletedAt,
+			&a.Score, &a.ErrorMessage, &a.Fingerprint, &a.CacheHit, &a.CreatedAt, &a.CompletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -47,7 +47,7 @@ func (r *AnalysisRepository) GetByRepositoryID(ctx context.Context, repoID int)
 
 func (r *AnalysisRepository) GetByUserID(ctx context.Context, userID int) ([]domain.Analysis, error) {
 	const query = `
-		SELECT a.id, a."repositoryId", a."analysisType", a.status, a.result, a.summary, a.score, a."errorMessage", a."createdAt", a."completedAt"
+		SELECT a.id, a."repositoryId", a."analysisType", a.status, a.result, a.summary, a.score, a."errorMessage", a.fingerprint, a."cacheHit", a."createdAt", a."completedAt"
 		FROM analyses a
 		JOIN repositories r ON a."repositoryId" = r.id
 		WHERE r."userId" = $1
@@ -64,7 +64,7 @@ func (r *AnalysisRepository) GetByUserID(ctx context.Context, userID int) ([]dom
 		var a domain.Analysis
 		if err := rows.Scan(
 			&a.ID, &a.RepositoryID, &a.AnalysisType, &a.Status, &a.Result, &a.Summary,
-			&a.Score, &a.ErrorMessage, &a.CreatedAt, &a.CompletedAt,
+			&a.Score, &a.ErrorMessage, &a.Fingerprint, &a.CacheHit, &a.CreatedAt, &a.CompletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -76,9 +76,9 @@ func (r *AnalysisRepository) GetByUserID(ctx context.Context, userID int) ([]dom
 func (r *AnalysisRepository) Create(ctx context.Context, analysis *domain.Analysis) (int, error) {
 	const query = `
 		INSERT INTO analyses (
-			"repositoryId", "analysisType", status, result, summary, score, "errorMessage", "createdAt", "completedAt"
+			"repositoryId", "analysisType", status, result, summary, score, "errorMessage", fingerprint, "cacheHit", "createdAt", "completedAt"
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, NOW(), $8
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), $10
 		)
 		RETURNING id, "createdAt"
 	`
@@ -90,6 +90,8 @@ func (r *AnalysisRepository) Create(ctx context.Context, analysis *domain.Analys
 		analysis.Summary,
 		analysis.Score,
 		analysis.ErrorMessage,
+		analysis.Fingerprint,
+		analysis.CacheHit,
 		analysis.CompletedAt,
 	).Scan(&analysis.ID, &analysis.CreatedAt)
 
@@ -115,7 +117,7 @@ func (r *AnalysisRepository) Update(ctx context.Context, id int, updates map[str
 	for k, v := range updates {
 		// Whitelist columns for safety
 		switch k {
-		case "status", "result", "summary", "score", "errorMessage", "completedAt":
+		case "status", "result", "summary", "score", "errorMessage", "fingerprint", "cacheHit", "completedAt":
 			query += fmt.Sprintf(`"%s" = $%d, `, k, i)
 			args = append(args, v)
 			i++
@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+)
+
+// BatchRepository persists bulk analysis batches and their per-repository items
+type BatchRepository struct {
+	db *DB
+}
+
+func NewBatchRepository(db *DB) ports.BatchRepository {
+	return &BatchRepository{db: db}
+}
+
+// Create inserts the batch and one queued item per repository in a single transaction
+func (r *BatchRepository) Create(ctx context.Context, batch *domain.AnalysisBatch, repoIDs []int) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO "analysisBatches" (id, "userId", "analysisType", force, total, "createdAt")
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING "createdAt"
+	`, batch.ID, batch.UserID, batch.AnalysisType, batch.Force, batch.Total).Scan(&batch.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create batch: %w", err)
+	}
+
+	rows := make([][]interface{}, 0, len(repoIDs))
+	for _, id := range repoIDs {
+		rows = append(rows, []interface{}{batch.ID, id, domain.BatchItemStatusQueued})
+	}
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"analysisBatchItems"},
+		[]string{"batchId", "repositoryId", "status"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create batch items: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *BatchRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.AnalysisBatch, error) {
+	const query = `SELECT id, "userId", "analysisType", force, total, "createdAt" FROM "analysisBatches" WHERE id = $1`
+	var b domain.AnalysisBatch
+	err := r.db.Pool.QueryRow(ctx, query, id).Scan(&b.ID, &b.UserID, &b.AnalysisType, &b.Force, &b.Total, &b.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NotFound("batch", id)
+		}
+		return nil, fmt.Errorf("failed to get batch: %w", err)
+	}
+	return &b, nil
+}
+
+func (r *BatchRepository) GetItems(ctx context.Context, batchID uuid.UUID) ([]domain.AnalysisBatchItem, error) {
+	const query = `
+		SELECT "batchId", "repositoryId", status, "analysisId", "errorMessage", "updatedAt"
+		FROM "analysisBatchItems"
+		WHERE "batchId" = $1
+		ORDER BY "repositoryId"
+	`
+	return r.queryItems(ctx, query, batchID)
+}
+
+func (r *BatchRepository) queryItems(ctx context.Context, query string, args ...any) ([]domain.AnalysisBatchItem, error) {
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query batch items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []domain.AnalysisBatchItem
+	for rows.Next() {
+		var i domain.AnalysisBatchItem
+		if err := rows.Scan(&i.BatchID, &i.RepositoryID, &i.Status, &i.AnalysisID, &i.ErrorMessage, &i.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan batch item: %w", err)
+		}
+		items = append(items, i)
+	}
+	return items, nil
+}
+
+// GetUnfinishedItems returns queued or running items across all batches
+func (r *BatchRepository) GetUnfinishedItems(ctx context.Context) ([]domain.AnalysisBatchItem, error) {
+	const query = `
+		SELECT "batchId", "repositoryId", status, "analysisId", "errorMessage", "updatedAt"
+		FROM "analysisBatchItems"
+		WHERE status IN ('queued', 'running')
+		ORDER BY "batchId", "repositoryId"
+	`
+	return r.queryItems(ctx, query)
+}
+
+func (r *BatchRepository) UpdateItem(ctx context.Context, item *domain.AnalysisBatchItem) error {
+	const query = `
+		UPDATE "analysisBatchItems"
+		SET status = $1, "analysisId" = $2, "errorMessage" = $3, "updatedAt" = NOW()
+		WHERE "batchId" = $4 AND "repositoryId" = $5
+	`
+	_, err := r.db.Pool.Exec(ctx, query, item.Status, item.AnalysisID, item.ErrorMessage, item.BatchID, item.RepositoryID)
+	if err != nil {
+		return fmt.Errorf("failed to update batch item: %w", err)
+	}
+	return nil
+}
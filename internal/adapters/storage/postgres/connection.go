@@ -9,6 +9,7 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/biodoia/ghrego/internal/config"
+	"github.com/biodoia/ghrego/internal/metrics"
 	"github.com/rs/zerolog/log"
 )
 
@@ -41,6 +42,7 @@ func NewDB(cfg *config.Config) (*DB, error) {
 	poolConfig.MinConns = 2
 	poolConfig.MaxConnLifetime = time.Hour
 	poolConfig.MaxConnIdleTime = 30 * time.Minute
+	poolConfig.ConnConfig.Tracer = newQueryTracer()
 
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
@@ -55,6 +57,32 @@ func NewDB(cfg *config.Config) (*DB, error) {
 	return &DB{Pool: pool}, nil
 }
 
+// HealthCheck pings the database; used by the readiness probe
+func (db *DB) HealthCheck(ctx context.Context) error {
+	if err := db.Pool.Ping(ctx); err != nil {
+		return fmt.Errorf("database ping failed: %w", err)
+	}
+	return nil
+}
+
+// PoolStats reports connection pool statistics for the metrics endpoint
+func (db *DB) PoolStats() metrics.PoolStats {
+	pool, ok := db.Pool.(interface{ Stat() *pgxpool.Stat })
+	if !ok {
+		return metrics.PoolStats{}
+	}
+	st := pool.Stat()
+	return metrics.PoolStats{
+		TotalConns:      st.TotalConns(),
+		IdleConns:       st.IdleConns(),
+		AcquiredConns:   st.AcquiredConns(),
+		MaxConns:        st.MaxConns(),
+		AcquireCount:    st.AcquireCount(),
+		EmptyAcquires:   st.EmptyAcquireCount(),
+		AcquireDuration: st.AcquireDuration().Seconds(),
+	}
+}
+
 func (db *DB) Close() {
 	db.Pool.Close()
 }
\ No newline at end of file
@@ -2,6 +2,8 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/biodoia/ghrego/internal/core/domain"
@@ -55,12 +57,37 @@ func (r *FeatureRepository) BulkCreate(ctx context.Context, features []domain.Fe
 	_, err := r.db.Pool.CopyFrom(
 		ctx,
 		pgx.Identifier{"features"},
-		[]string{"repositoryId", "name", "description", "category", "filePaths", "codeSnippet", "confidence", "createdAt"},
+		featureColumns,
 		pgx.CopyFromRows(rows),
 	)
 	return err
 }
 
+// Replace deletes the repository's features and inserts the new ones in one transaction
+func (r *FeatureRepository) Replace(ctx context.Context, repoID int, features []domain.Feature) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM features WHERE "repositoryId" = $1`, repoID); err != nil {
+		return fmt.Errorf("failed to delete features: %w", err)
+	}
+	if len(features) > 0 {
+		rows := [][]interface{}{}
+		for _, f := range features {
+			rows = append(rows, []interface{}{f.RepositoryID, f.Name, f.Description, f.Category, f.FilePaths, f.CodeSnippet, f.Confidence, f.CreatedAt})
+		}
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"features"}, featureColumns, pgx.CopyFromRows(rows)); err != nil {
+			return fmt.Errorf("failed to insert features: %w", err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+var featureColumns = []string{"repositoryId", "name", "description", "category", "filePaths", "codeSnippet", "confidence", "createdAt"}
+
 // Technology Repository
 type TechnologyRepository struct {
 	db *DB
@@ -71,7 +98,7 @@ func NewTechnologyRepository(db *DB) ports.TechnologyRepository {
 }
 
 func (r *TechnologyRepository) GetByRepositoryID(ctx context.Context, repoID int) ([]domain.Technology, error) {
-	const query = `SELECT id, "repositoryId", name, version, type, "packageManager", "createdAt" FROM technologies WHERE "repositoryId" = $1`
+	const query = `SELECT id, "repositoryId", name, version, type, "packageManager", evidence, license, "createdAt" FROM technologies WHERE "repositoryId" = $1`
 	rows, err := r.db.Pool.Query(ctx, query, repoID)
 	if err != nil {
 		return nil, err
@@ -80,7 +107,7 @@ func (r *TechnologyRepository) GetByRepositoryID(ctx context.Context, repoID int
 	var items []domain.Technology
 	for rows.Next() {
 		var i domain.Technology
-		if err := rows.Scan(&i.ID, &i.RepositoryID, &i.Name, &i.Version, &i.Type, &i.PackageManager, &i.CreatedAt); err != nil {
+		if err := rows.Scan(&i.ID, &i.RepositoryID, &i.Name, &i.Version, &i.Type, &i.PackageManager, &i.Evidence, &i.License, &i.CreatedAt); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -88,23 +115,138 @@ func (r *TechnologyRepository) GetByRepositoryID(ctx context.Context, repoID int
 	return items, nil
 }
 
+func (r *TechnologyRepository) GetByWorkspaceID(ctx context.Context, workspaceID int) ([]domain.Technology, error) {
+	const query = `SELECT t.id, t."repositoryId", t.name, t.version, t.type, t."packageManager", t.evidence, t.license, t."createdAt"
+		FROM technologies t JOIN repositories r ON r.id = t."repositoryId"
+		WHERE r."workspaceId" = $1 ORDER BY t.name, t."repositoryId"`
+	rows, err := r.db.Pool.Query(ctx, query, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []domain.Technology
+	for rows.Next() {
+		var i domain.Technology
+		if err := rows.Scan(&i.ID, &i.RepositoryID, &i.Name, &i.Version, &i.Type, &i.PackageManager, &i.Evidence, &i.License, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
 func (r *TechnologyRepository) BulkCreate(ctx context.Context, techs []domain.Technology) error {
 	if len(techs) == 0 {
 		return nil
 	}
+	_, err := r.db.Pool.CopyFrom(ctx, pgx.Identifier{"technologies"}, technologyColumns, technologyRows(techs))
+	return err
+}
+
+// ReplaceDetected deletes the previous detections and inserts the new ones in one transaction
+func (r *TechnologyRepository) ReplaceDetected(ctx context.Context, repoID int, techs []domain.Technology) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM technologies WHERE "repositoryId" = $1 AND evidence IS NOT NULL`, repoID); err != nil {
+		return fmt.Errorf("failed to delete detected technologies: %w", err)
+	}
+	if len(techs) > 0 {
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"technologies"}, technologyColumns, technologyRows(techs)); err != nil {
+			return fmt.Errorf("failed to insert detected technologies: %w", err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// ReplaceAnalyzed deletes the technologies of previous AI analyses and inserts the new ones in one transaction
+func (r *TechnologyRepository) ReplaceAnalyzed(ctx context.Context, repoID int, techs []domain.Technology) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM technologies WHERE "repositoryId" = $1 AND evidence IS NULL`, repoID); err != nil {
+		return fmt.Errorf("failed to delete analyzed technologies: %w", err)
+	}
+	if len(techs) > 0 {
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"technologies"}, technologyColumns, technologyRows(techs)); err != nil {
+			return fmt.Errorf("failed to insert analyzed technologies: %w", err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+var technologyColumns = []string{"repositoryId", "name", "version", "type", "packageManager", "evidence", "license", "createdAt"}
+
+func technologyRows(techs []domain.Technology) pgx.CopyFromSource {
 	rows := [][]interface{}{}
 	for _, t := range techs {
-		rows = append(rows, []interface{}{t.RepositoryID, t.Name, t.Version, t.Type, t.PackageManager, t.CreatedAt})
+		rows = append(rows, []interface{}{t.RepositoryID, t.Name, t.Version, t.Type, t.PackageManager, t.Evidence, t.License, t.CreatedAt})
 	}
-	_, err := r.db.Pool.CopyFrom(
-		ctx,
-		pgx.Identifier{"technologies"},
-		[]string{"repositoryId", "name", "version", "type", "packageManager", "createdAt"},
-		pgx.CopyFromRows(rows),
-	)
-	return err
+	return pgx.CopyFromRows(rows)
 }
 
+// License Repository
+type LicenseRepository struct {
+	db *DB
+}
+
+func NewLicenseRepository(db *DB) ports.LicenseRepository {
+	return &LicenseRepository{db: db}
+}
+
+func (r *LicenseRepository) GetByRepositoryIDs(ctx context.Context, repoIDs []int) ([]domain.RepositoryLicense, error) {
+	if len(repoIDs) == 0 {
+		return nil, nil
+	}
+	const query = `SELECT id, "repositoryId", "spdxId", source, method, confidence, "createdAt" FROM "repositoryLicenses"
+		WHERE "repositoryId" = ANY($1) ORDER BY "repositoryId", source`
+	rows, err := r.db.Pool.Query(ctx, query, repoIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []domain.RepositoryLicense
+	for rows.Next() {
+		var i domain.RepositoryLicense
+		if err := rows.Scan(&i.ID, &i.RepositoryID, &i.SPDXID, &i.Source, &i.Method, &i.Confidence, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+// Replace deletes the previous licenses and inserts the new ones in one transaction
+func (r *LicenseRepository) Replace(ctx context.Context, repoID int, licenses []domain.RepositoryLicense) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM "repositoryLicenses" WHERE "repositoryId" = $1`, repoID); err != nil {
+		return fmt.Errorf("failed to delete licenses: %w", err)
+	}
+	if len(licenses) > 0 {
+		rows := [][]interface{}{}
+		for _, l := range licenses {
+			rows = append(rows, []interface{}{repoID, l.SPDXID, l.Source, l.Method, l.Confidence, l.CreatedAt})
+		}
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"repositoryLicenses"}, licenseColumns, pgx.CopyFromRows(rows)); err != nil {
+			return fmt.Errorf("failed to insert licenses: %w", err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+var licenseColumns = []string{"repositoryId", "spdxId", "source", "method", "confidence", "createdAt"}
+
 // Suggestion Repository
 type SuggestionRepository struct {
 	db *DB
@@ -132,14 +274,14 @@ func (r *SuggestionRepository) GetByRepositoryID(ctx context.Context, repoID int
 	return items, nil
 }
 
-func (r *SuggestionRepository) GetAllPending(ctx context.Context, userID int) ([]domain.Suggestion, error) {
+func (r *SuggestionRepository) GetAllPending(ctx context.Context, workspaceID int) ([]domain.Suggestion, error) {
 	const query = `
 		SELECT s.id, s."repositoryId", s."suggestionType", s.title, s.description, s."sourceRepositoryId", s.priority, s.status, s."createdAt", s."updatedAt"
 		FROM suggestions s
 		JOIN repositories r ON s."repositoryId" = r.id
-		WHERE r."userId" = $1 AND s.status = 'pending'
+		WHERE r."workspaceId" = $1 AND s.status = 'pending'
 	`
-	rows, err := r.db.Pool.Query(ctx, query, userID)
+	rows, err := r.db.Pool.Query(ctx, query, workspaceID)
 	if err != nil {
 		return nil, err
 	}
@@ -160,8 +302,8 @@ func (r *SuggestionRepository) GetByID(ctx context.Context, id int) (*domain.Sug
 	var i domain.Suggestion
 	err := r.db.Pool.QueryRow(ctx, query, id).Scan(&i.ID, &i.RepositoryID, &i.SuggestionType, &i.Title, &i.Description, &i.SourceRepositoryID, &i.Priority, &i.Status, &i.CreatedAt, &i.UpdatedAt)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, nil
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NotFound("suggestion", id)
 		}
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -100,8 +101,8 @@ func (r *UnificationRepository) GetByID(ctx context.Context, operationID uuid.UU
 		&op.CreatedAt, &op.UpdatedAt, &op.CompletedAt,
 	)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, nil
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NotFound("unification operation", operationID)
 		}
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/jackc/pgx/v5"
@@ -17,17 +18,17 @@ func NewRepositoryStore(db *DB) ports.RepositoryStore {
 	return &RepositoryStore{db: db}
 }
 
-func (r *RepositoryStore) GetByUserID(ctx context.Context, userID int) ([]domain.Repository, error) {
+func (r *RepositoryStore) GetByWorkspaceID(ctx context.Context, workspaceID int) ([]domain.Repository, error) {
 	const query = `
-		SELECT id, "userId", "githubId", name, "fullName", description, url, language, 
-		       "isPrivate", stars, forks, size, "defaultBranch", "lastCommitAt", "lastSyncAt", 
+		SELECT id, "userId", "workspaceId", provider, "githubId", "installationId", name, "fullName", description, url, language, 
+		       "isPrivate", "isArchived", stars, forks, size, "defaultBranch", topics, "lastCommitAt", "lastSyncAt", 
 		       "createdAt", "updatedAt"
 		FROM repositories
-		WHERE "userId" = $1
+		WHERE "workspaceId" = $1
 		ORDER BY "updatedAt" DESC
 	`
 
-	rows, err := r.db.Pool.Query(ctx, query, userID)
+	rows, err := r.db.Pool.Query(ctx, query, workspaceID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query repositories: %w", err)
 	}
@@ -37,9 +38,9 @@ func (r *RepositoryStore) GetByUserID(ctx context.Context, userID int) ([]domain
 	for rows.Next() {
 		var repo domain.Repository
 		if err := rows.Scan(
-			&repo.ID, &repo.UserID, &repo.GithubID, &repo.Name, &repo.FullName,
-			&repo.Description, &repo.URL, &repo.Language, &repo.IsPrivate,
-			&repo.Stars, &repo.Forks, &repo.Size, &repo.DefaultBranch,
+			&repo.ID, &repo.UserID, &repo.WorkspaceID, &repo.Provider, &repo.GithubID, &repo.InstallationID, &repo.Name, &repo.FullName,
+			&repo.Description, &repo.URL, &repo.Language, &repo.IsPrivate, &repo.IsArchived,
+			&repo.Stars, &repo.Forks, &repo.Size, &repo.DefaultBranch, &repo.Topics,
 			&repo.LastCommitAt, &repo.LastSyncAt, &repo.CreatedAt, &repo.UpdatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan repository: %w", err)
@@ -51,8 +52,8 @@ func (r *RepositoryStore) GetByUserID(ctx context.Context, userID int) ([]domain
 
 func (r *RepositoryStore) GetByID(ctx context.Context, id int) (*domain.Repository, error) {
 	const query = `
-		SELECT id, "userId", "githubId", name, "fullName", description, url, language, 
-		       "isPrivate", stars, forks, size, "defaultBranch", "lastCommitAt", "lastSyncAt", 
+		SELECT id, "userId", "workspaceId", provider, "githubId", "installationId", name, "fullName", description, url, language, 
+		       "isPrivate", "isArchived", stars, forks, size, "defaultBranch", topics, "lastCommitAt", "lastSyncAt", 
 		       "createdAt", "updatedAt"
 		FROM repositories
 		WHERE id = $1
@@ -60,15 +61,15 @@ func (r *RepositoryStore) GetByID(ctx context.Context, id int) (*domain.Reposito
 
 	var repo domain.Repository
 	err := r.db.Pool.QueryRow(ctx, query, id).Scan(
-		&repo.ID, &repo.UserID, &repo.GithubID, &repo.Name, &repo.FullName,
-		&repo.Description, &repo.URL, &repo.Language, &repo.IsPrivate,
-		&repo.Stars, &repo.Forks, &repo.Size, &repo.DefaultBranch,
+		&repo.ID, &repo.UserID, &repo.WorkspaceID, &repo.Provider, &repo.GithubID, &repo.InstallationID, &repo.Name, &repo.FullName,
+		&repo.Description, &repo.URL, &repo.Language, &repo.IsPrivate, &repo.IsArchived,
+		&repo.Stars, &repo.Forks, &repo.Size, &repo.DefaultBranch, &repo.Topics,
 		&repo.LastCommitAt, &repo.LastSyncAt, &repo.CreatedAt, &repo.UpdatedAt,
 	)
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, nil // Or custom ErrNotFound
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NotFound("repository", id)
 		}
 		return nil, fmt.Errorf("failed to get repository: %w", err)
 	}
@@ -76,43 +77,8 @@ func (r *RepositoryStore) GetByID(ctx context.Context, id int) (*domain.Reposito
 }
 
 func (r *RepositoryStore) Upsert(ctx context.Context, repo *domain.Repository) (int, error) {
-	// Logic from db.ts: Check if exists by userId + githubId, if so update, else insert.
-	// Postgres UPSERT handles this efficiently.
-
-	const query = `
-		INSERT INTO repositories (
-			"userId", "githubId", name, "fullName", description, url, language,
-			"isPrivate", stars, forks, size, "defaultBranch", "lastCommitAt", "lastSyncAt",
-			"updatedAt"
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NOW()
-		)
-		ON CONFLICT ("userId", "githubId") DO UPDATE SET
-			name = EXCLUDED.name,
-			"fullName" = EXCLUDED."fullName",
-			description = EXCLUDED.description,
-			url = EXCLUDED.url,
-			language = EXCLUDED.language,
-			"isPrivate" = EXCLUDED."isPrivate",
-			stars = EXCLUDED.stars,
-			forks = EXCLUDED.forks,
-			size = EXCLUDED.size,
-			"defaultBranch" = EXCLUDED."defaultBranch",
-			"lastCommitAt" = EXCLUDED."lastCommitAt",
-			"lastSyncAt" = EXCLUDED."lastSyncAt",
-			"updatedAt" = NOW()
-		RETURNING id
-	`
-
-	// Note: We need a unique constraint on (userId, githubId) in the DB schema for this ON CONFLICT to work.
-	// The Drizzle schema defined indices but not explicit unique constraints on that pair?
-	// Checking schema.ts: `userIdIdx: index("userId_idx").on(table.userId)`... doesn't look like unique constraint on pair.
-	// However, `upsertRepository` in db.ts manually checks existence: `existing = await db.select()...limit(1)`.
-	// To replicate exact behavior without schema change, we should do the same manual check or Ensure schema has Unique constraint.
-	// For "Refactoring in Go", we should make it robust. I will assume we can add the constraint or use the manual check approach for safety.
-	// I'll use the manual check approach to match db.ts logic exactly and avoid SQL errors if constraint is missing.
-	
-	// Manual check approach (transactional):
+	// A repository is stored once per workspace and provider, whichever member synced it.
+	// Like db.ts this checks for an existing row instead of relying on ON CONFLICT.
 	tx, err := r.db.Pool.Begin(ctx)
 	if err != nil {
 		return 0, err
@@ -120,20 +86,20 @@ func (r *RepositoryStore) Upsert(ctx context.Context, repo *domain.Repository) (
 	defer tx.Rollback(ctx)
 
 	var existingID int
-	err = tx.QueryRow(ctx, `SELECT id FROM repositories WHERE "userId" = $1 AND "githubId" = $2`, repo.UserID, repo.GithubID).Scan(&existingID)
+	err = tx.QueryRow(ctx, `SELECT id FROM repositories WHERE "workspaceId" = $1 AND provider = $2 AND "githubId" = $3`, repo.WorkspaceID, domain.ProviderOf(repo), repo.GithubID).Scan(&existingID)
 	
 	if err == pgx.ErrNoRows {
 		// Insert
 		err = tx.QueryRow(ctx, `
 			INSERT INTO repositories (
-				"userId", "githubId", name, "fullName", description, url, language,
-				"isPrivate", stars, forks, size, "defaultBranch", "lastCommitAt", "lastSyncAt",
+				"userId", "workspaceId", provider, "githubId", "installationId", name, "fullName", description, url, language,
+				"isPrivate", "isArchived", stars, forks, size, "defaultBranch", topics, "lastCommitAt", "lastSyncAt",
 				"createdAt", "updatedAt"
 			) VALUES (
-				$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NOW(), NOW()
+				$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, NOW(), NOW()
 			) RETURNING id
-		`, repo.UserID, repo.GithubID, repo.Name, repo.FullName, repo.Description, repo.URL, repo.Language,
-		   repo.IsPrivate, repo.Stars, repo.Forks, repo.Size, repo.DefaultBranch, repo.LastCommitAt, repo.LastSyncAt).Scan(&existingID)
+		`, repo.UserID, repo.WorkspaceID, domain.ProviderOf(repo), repo.GithubID, repo.InstallationID, repo.Name, repo.FullName, repo.Description, repo.URL, repo.Language,
+		   repo.IsPrivate, repo.IsArchived, repo.Stars, repo.Forks, repo.Size, repo.DefaultBranch, repo.Topics, repo.LastCommitAt, repo.LastSyncAt).Scan(&existingID)
 		if err != nil {
 			return 0, fmt.Errorf("failed to insert repo: %w", err)
 		}
@@ -144,11 +110,11 @@ func (r *RepositoryStore) Upsert(ctx context.Context, repo *domain.Repository) (
 		_, err = tx.Exec(ctx, `
 			UPDATE repositories SET
 				name = $1, "fullName" = $2, description = $3, url = $4, language = $5,
-				"isPrivate" = $6, stars = $7, forks = $8, size = $9, "defaultBranch" = $10,
-				"lastCommitAt" = $11, "lastSyncAt" = $12, "updatedAt" = NOW()
-			WHERE id = $13
+				"isPrivate" = $6, "isArchived" = $7, stars = $8, forks = $9, size = $10, "defaultBranch" = $11,
+				topics = $12, "lastCommitAt" = $13, "lastSyncAt" = $14, "installationId" = $15, "updatedAt" = NOW()
+			WHERE id = $16
 		`, repo.Name, repo.FullName, repo.Description, repo.URL, repo.Language,
-		   repo.IsPrivate, repo.Stars, repo.Forks, repo.Size, repo.DefaultBranch, repo.LastCommitAt, repo.LastSyncAt, existingID)
+		   repo.IsPrivate, repo.IsArchived, repo.Stars, repo.Forks, repo.Size, repo.DefaultBranch, repo.Topics, repo.LastCommitAt, repo.LastSyncAt, repo.InstallationID, existingID)
 		if err != nil {
 			return 0, fmt.Errorf("failed to update repo: %w", err)
 		}
@@ -167,8 +133,8 @@ func (r *RepositoryStore) GetByIDs(ctx context.Context, ids []int) ([]domain.Rep
 		return nil, nil
 	}
 	const query = `
-		SELECT id, "userId", "githubId", name, "fullName", description, url, language, 
-		       "isPrivate", stars, forks, size, "defaultBranch", "lastCommitAt", "lastSyncAt", 
+		SELECT id, "userId", "workspaceId", provider, "githubId", "installationId", name, "fullName", description, url, language, 
+		       "isPrivate", "isArchived", stars, forks, size, "defaultBranch", topics, "lastCommitAt", "lastSyncAt", 
 		       "createdAt", "updatedAt"
 		FROM repositories
 		WHERE id = ANY($1)
@@ -184,9 +150,9 @@ func (r *RepositoryStore) GetByIDs(ctx context.Context, ids []int) ([]domain.Rep
 	for rows.Next() {
 		var repo domain.Repository
 		if err := rows.Scan(
-			&repo.ID, &repo.UserID, &repo.GithubID, &repo.Name, &repo.FullName,
-			&repo.Description, &repo.URL, &repo.Language, &repo.IsPrivate,
-			&repo.Stars, &repo.Forks, &repo.Size, &repo.DefaultBranch,
+			&repo.ID, &repo.UserID, &repo.WorkspaceID, &repo.Provider, &repo.GithubID, &repo.InstallationID, &repo.Name, &repo.FullName,
+			&repo.Description, &repo.URL, &repo.Language, &repo.IsPrivate, &repo.IsArchived,
+			&repo.Stars, &repo.Forks, &repo.Size, &repo.DefaultBranch, &repo.Topics,
 			&repo.LastCommitAt, &repo.LastSyncAt, &repo.CreatedAt, &repo.UpdatedAt,
 		); err != nil {
 			return nil, err
@@ -196,8 +162,113 @@ func (r *RepositoryStore) GetByIDs(ctx context.Context, ids []int) ([]domain.Rep
 	return repos, nil
 }
 
-func (r *RepositoryStore) GetStats(ctx context.Context, userID int) (map[string]interface{}, error) {
-	// Implement statistics aggregation similar to db.ts
-	// Skipping for brevity in this turn, returning empty map
-	return map[string]interface{}{}, nil
+// GetByGithubID returns every stored copy of a GitHub repository, one per workspace.
+// Other providers' IDs can collide with GitHub's, so only GitHub rows match.
+func (r *RepositoryStore) GetByGithubID(ctx context.Context, githubID string) ([]domain.Repository, error) {
+	const query = `
+		SELECT id, "userId", "workspaceId", provider, "githubId", "installationId", name, "fullName", description, url, language, 
+		       "isPrivate", "isArchived", stars, forks, size, "defaultBranch", topics, "lastCommitAt", "lastSyncAt", 
+		       "createdAt", "updatedAt"
+		FROM repositories
+		WHERE "githubId" = $1 AND provider = 'github'
+	`
+	rows, err := r.db.Pool.Query(ctx, query, githubID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query repositories: %w", err)
+	}
+	defer rows.Close()
+
+	var repos []domain.Repository
+	for rows.Next() {
+		var repo domain.Repository
+		if err := rows.Scan(
+			&repo.ID, &repo.UserID, &repo.WorkspaceID, &repo.Provider, &repo.GithubID, &repo.InstallationID, &repo.Name, &repo.FullName,
+			&repo.Description, &repo.URL, &repo.Language, &repo.IsPrivate, &repo.IsArchived,
+			&repo.Stars, &repo.Forks, &repo.Size, &repo.DefaultBranch, &repo.Topics,
+			&repo.LastCommitAt, &repo.LastSyncAt, &repo.CreatedAt, &repo.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan repository: %w", err)
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
+// GetLatestByAccount matches GitHub repositories by the owner in their full
+// name, case-insensitively like GitHub logins, or by installation
+func (r *RepositoryStore) GetLatestByAccount(ctx context.Context, owner string, installationID int64) ([]domain.Repository, error) {
+	const query = `
+		SELECT DISTINCT ON ("workspaceId")
+		       id, "userId", "workspaceId", provider, "githubId", "installationId", name, "fullName", description, url, language, 
+		       "isPrivate", "isArchived", stars, forks, size, "defaultBranch", topics, "lastCommitAt", "lastSyncAt", 
+		       "createdAt", "updatedAt"
+		FROM repositories
+		WHERE provider = 'github' AND (lower(split_part("fullName", '/', 1)) = lower($1) OR "installationId" = $2)
+		ORDER BY "workspaceId", "lastSyncAt" DESC NULLS LAST
+	`
+	rows, err := r.db.Pool.Query(ctx, query, owner, installationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query repositories: %w", err)
+	}
+	defer rows.Close()
+
+	var repos []domain.Repository
+	for rows.Next() {
+		var repo domain.Repository
+		if err := rows.Scan(
+			&repo.ID, &repo.UserID, &repo.WorkspaceID, &repo.Provider, &repo.GithubID, &repo.InstallationID, &repo.Name, &repo.FullName,
+			&repo.Description, &repo.URL, &repo.Language, &repo.IsPrivate, &repo.IsArchived,
+			&repo.Stars, &repo.Forks, &repo.Size, &repo.DefaultBranch, &repo.Topics,
+			&repo.LastCommitAt, &repo.LastSyncAt, &repo.CreatedAt, &repo.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan repository: %w", err)
+		}
+		repos = append(repos, repo)
+	}
+	return repos, rows.Err()
+}
+
+func (r *RepositoryStore) GetStats(ctx context.Context, workspaceID int) (map[string]interface{}, error) {
+	const totalsQuery = `
+		SELECT COUNT(*), COALESCE(SUM(stars), 0), COUNT(*) FILTER (WHERE "isPrivate"),
+		       (SELECT COUNT(DISTINCT a."repositoryId") FROM analyses a
+		        JOIN repositories ar ON a."repositoryId" = ar.id WHERE ar."workspaceId" = $1)
+		FROM repositories
+		WHERE "workspaceId" = $1
+	`
+	var total, stars, private, analyzed int
+	if err := r.db.Pool.QueryRow(ctx, totalsQuery, workspaceID).Scan(&total, &stars, &private, &analyzed); err != nil {
+		return nil, fmt.Errorf("failed to get repository stats: %w", err)
+	}
+
+	const languagesQuery = `
+		SELECT language, COUNT(*)
+		FROM repositories
+		WHERE "workspaceId" = $1 AND language IS NOT NULL
+		GROUP BY language
+		ORDER BY COUNT(*) DESC
+	`
+	rows, err := r.db.Pool.Query(ctx, languagesQuery, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get language stats: %w", err)
+	}
+	defer rows.Close()
+
+	languages := map[string]int{}
+	for rows.Next() {
+		var lang string
+		var count int
+		if err := rows.Scan(&lang, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan language stats: %w", err)
+		}
+		languages[lang] = count
+	}
+
+	return map[string]interface{}{
+		"totalRepositories":    total,
+		"totalStars":           stars,
+		"privateRepositories":  private,
+		"analyzedRepositories": analyzed,
+		"languages":            languages,
+	}, nil
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/biodoia/ghrego/internal/core/domain"
 	"github.com/jackc/pgx/v5"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
@@ -42,12 +43,12 @@ func TestUserRepository_GetByID(t *testing.T) {
 
 		user, err := repo.GetByID(context.Background(), 99)
 		
-		assert.NoError(t, err)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
 		assert.Nil(t, user)
 	})
 }
 
-func TestRepositoryStore_GetByUserID(t *testing.T) {
+func TestRepositoryStore_GetByWorkspaceID(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
@@ -60,20 +61,203 @@ func TestRepositoryStore_GetByUserID(t *testing.T) {
 	
 	t.Run("success", func(t *testing.T) {
 		rows := pgxmock.NewRows([]string{
-			"id", "userId", "githubId", "name", "fullName", "description", "url", "language", 
-			"isPrivate", "stars", "forks", "size", "defaultBranch", "lastCommitAt", "lastSyncAt", 
+			"id", "userId", "workspaceId", "provider", "githubId", "installationId", "name", "fullName", "description", "url", "language", 
+			"isPrivate", "isArchived", "stars", "forks", "size", "defaultBranch", "topics", "lastCommitAt", "lastSyncAt", 
 			"createdAt", "updatedAt",
 		}).
-		AddRow(10, 1, "gh-10", "my-repo", "owner/my-repo", "desc", "url", "Go", false, 5, 1, 100, "main", nil, nil, time.Now(), time.Now())
+		AddRow(10, 1, 3, "gitlab", "gh-10", nil, "my-repo", "owner/my-repo", "desc", "url", "Go", false, false, 5, 1, 100, "main", []string{"cli"}, nil, nil, time.Now(), time.Now())
 		
-		mock.ExpectQuery(`SELECT .* FROM repositories WHERE "userId" = \$1`).
-			WithArgs(1).
+		mock.ExpectQuery(`SELECT .* FROM repositories WHERE "workspaceId" = \$1`).
+			WithArgs(3).
 			WillReturnRows(rows)
 			
-		repos, err := repoStore.GetByUserID(context.Background(), 1)
+		repos, err := repoStore.GetByWorkspaceID(context.Background(), 3)
 		
 		assert.NoError(t, err)
 		assert.Len(t, repos, 1)
 		assert.Equal(t, "my-repo", repos[0].Name)
+		assert.Equal(t, 3, repos[0].WorkspaceID)
+		assert.Equal(t, domain.ProviderGitLab, repos[0].Provider)
+	})
+}
+
+func TestWorkspaceRepository_AcceptInvitation(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	repo := &WorkspaceRepository{
+		db: &DB{Pool: mock},
+	}
+	inv := &domain.WorkspaceInvitation{ID: 8, WorkspaceID: 3, Role: domain.WorkspaceRoleMaintainer}
+
+	t.Run("adds the member", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "workspaceInvitations" SET status`).
+			WithArgs(domain.InvitationStatusAccepted, 8, domain.InvitationStatusPending).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec(`INSERT INTO "workspaceMembers"`).
+			WithArgs(3, 2, domain.WorkspaceRoleMaintainer).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.AcceptInvitation(context.Background(), inv, 2))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("already accepted", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "workspaceInvitations" SET status`).
+			WithArgs(domain.InvitationStatusAccepted, 8, domain.InvitationStatusPending).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectRollback()
+
+		err := repo.AcceptInvitation(context.Background(), inv, 2)
+		assert.ErrorIs(t, err, domain.ErrConflict)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTechnologyRepository_ReplaceDetected(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	repo := &TechnologyRepository{
+		db: &DB{Pool: mock},
+	}
+	now := time.Now()
+	techs := []domain.Technology{{
+		RepositoryID: 5, Name: "chi", Version: domain.SQLNullString("5.2.3"), Type: domain.TechnologyTypeFramework,
+		PackageManager: domain.SQLNullString("go"), Evidence: domain.SQLNullString("cmd/server/main.go"), CreatedAt: now,
+	}}
+
+	t.Run("replaces previous detections", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM technologies WHERE "repositoryId" = \$1 AND evidence IS NOT NULL`).
+			WithArgs(5).
+			WillReturnResult(pgxmock.NewResult("DELETE", 2))
+		mock.ExpectCopyFrom(pgx.Identifier{"technologies"}, technologyColumns).
+			WillReturnResult(1)
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.ReplaceDetected(context.Background(), 5, techs))
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
+
+	t.Run("nothing detected", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM technologies`).
+			WithArgs(5).
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.ReplaceDetected(context.Background(), 5, nil))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTechnologyRepository_ReplaceAnalyzed(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	repo := &TechnologyRepository{
+		db: &DB{Pool: mock},
+	}
+	techs := []domain.Technology{{RepositoryID: 5, Name: "React", Type: domain.TechnologyTypeFramework, CreatedAt: time.Now()}}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM technologies WHERE "repositoryId" = \$1 AND evidence IS NULL`).
+		WithArgs(5).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectCopyFrom(pgx.Identifier{"technologies"}, technologyColumns).
+		WillReturnResult(1)
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.ReplaceAnalyzed(context.Background(), 5, techs))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFeatureRepository_Replace(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	repo := &FeatureRepository{
+		db: &DB{Pool: mock},
+	}
+	features := []domain.Feature{{RepositoryID: 5, Name: "Auth", Confidence: 90, CreatedAt: time.Now()}}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM features WHERE "repositoryId" = \$1`).
+		WithArgs(5).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectCopyFrom(pgx.Identifier{"features"}, featureColumns).
+		WillReturnResult(1)
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.Replace(context.Background(), 5, features))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTechnologyRepository_GetByWorkspaceID(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	repo := &TechnologyRepository{
+		db: &DB{Pool: mock},
+	}
+	rows := pgxmock.NewRows([]string{"id", "repositoryId", "name", "version", "type", "packageManager", "evidence", "license", "createdAt"}).
+		AddRow(1, 5, "react", domain.SQLNullString("18.3.1"), domain.TechnologyTypeLibrary, domain.SQLNullString("npm"), domain.SQLNullString("package.json"), domain.SQLNullString("MIT"), time.Now()).
+		AddRow(2, 6, "react", domain.SQLNullString("17.0.2"), domain.TechnologyTypeLibrary, domain.SQLNullString("npm"), domain.SQLNullString("web/package.json"), domain.SQLNullString(""), time.Now())
+	mock.ExpectQuery(`FROM technologies t JOIN repositories r ON r.id = t."repositoryId"\s+WHERE r."workspaceId" = \$1`).
+		WithArgs(7).
+		WillReturnRows(rows)
+
+	techs, err := repo.GetByWorkspaceID(context.Background(), 7)
+
+	assert.NoError(t, err)
+	assert.Len(t, techs, 2)
+	assert.Equal(t, 6, techs[1].RepositoryID)
+	assert.Equal(t, "17.0.2", techs[1].Version.String)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLicenseRepository_Replace(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	repo := &LicenseRepository{
+		db: &DB{Pool: mock},
+	}
+	found := []domain.RepositoryLicense{
+		{RepositoryID: 5, SPDXID: "MIT", Source: "LICENSE", Method: domain.LicenseMethodText, Confidence: 0.98, CreatedAt: time.Now()},
+		{RepositoryID: 5, SPDXID: "MIT", Source: "package.json", Method: domain.LicenseMethodManifest, Confidence: 1, CreatedAt: time.Now()},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "repositoryLicenses" WHERE "repositoryId" = \$1`).
+		WithArgs(5).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectCopyFrom(pgx.Identifier{"repositoryLicenses"}, licenseColumns).
+		WillReturnResult(2)
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.Replace(context.Background(), 5, found))
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+)
+
+// APITokenRepository persists personal access tokens. Only hashes are stored.
+type APITokenRepository struct {
+	db *DB
+}
+
+func NewAPITokenRepository(db *DB) ports.APITokenRepository {
+	return &APITokenRepository{db: db}
+}
+
+const apiTokenColumns = `id, "userId", name, prefix, "tokenHash", scopes, "expiresAt", "lastUsedAt", "createdAt"`
+
+func (r *APITokenRepository) Create(ctx context.Context, token *domain.APIToken) error {
+	query := `
+		INSERT INTO "apiTokens" ("userId", name, prefix, "tokenHash", scopes, "expiresAt", "createdAt")
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		RETURNING id, "createdAt"
+	`
+	err := r.db.Pool.QueryRow(ctx, query,
+		token.UserID, token.Name, token.Prefix, token.TokenHash, scopeStrings(token.Scopes), token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create api token: %w", err)
+	}
+	return nil
+}
+
+func (r *APITokenRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.APIToken, error) {
+	query := `SELECT ` + apiTokenColumns + ` FROM "apiTokens" WHERE "tokenHash" = $1`
+	token, err := scanAPIToken(r.db.Pool.QueryRow(ctx, query, tokenHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NotFound("api token", "")
+		}
+		return nil, fmt.Errorf("failed to get api token: %w", err)
+	}
+	return token, nil
+}
+
+func (r *APITokenRepository) ListByUser(ctx context.Context, userID int) ([]domain.APIToken, error) {
+	query := `SELECT ` + apiTokenColumns + ` FROM "apiTokens" WHERE "userId" = $1 ORDER BY "createdAt" DESC`
+	rows, err := r.db.Pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var items []domain.APIToken
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api token: %w", err)
+		}
+		items = append(items, *token)
+	}
+	return items, nil
+}
+
+func (r *APITokenRepository) Delete(ctx context.Context, userID, id int) error {
+	tag, err := r.db.Pool.Exec(ctx, `DELETE FROM "apiTokens" WHERE id = $1 AND "userId" = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete api token: %w", err)
+	}
+	// Other users' tokens are reported as missing rather than forbidden
+	if tag.RowsAffected() == 0 {
+		return domain.NotFound("api token", id)
+	}
+	return nil
+}
+
+func (r *APITokenRepository) TouchLastUsed(ctx context.Context, id int, at time.Time) error {
+	_, err := r.db.Pool.Exec(ctx, `UPDATE "apiTokens" SET "lastUsedAt" = $1 WHERE id = $2`, at, id)
+	if err != nil {
+		return fmt.Errorf("failed to update api token: %w", err)
+	}
+	return nil
+}
+
+func scanAPIToken(row pgx.Row) (*domain.APIToken, error) {
+	var t domain.APIToken
+	var scopes []string
+	if err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.Prefix, &t.TokenHash, &scopes, &t.ExpiresAt, &t.LastUsedAt, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	for _, s := range scopes {
+		t.Scopes = append(t.Scopes, domain.TokenScope(s))
+	}
+	return &t, nil
+}
+
+func scopeStrings(scopes []domain.TokenScope) []string {
+	out := make([]string, len(scopes))
+	for i, s := range scopes {
+		out[i] = string(s)
+	}
+	return out
+}
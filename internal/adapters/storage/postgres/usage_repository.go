@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+)
+
+// UsageRepository persists AI token usage, one row per billed call
+type UsageRepository struct {
+	db *DB
+}
+
+func NewUsageRepository(db *DB) ports.UsageRepository {
+	return &UsageRepository{db: db}
+}
+
+func (r *UsageRepository) Create(ctx context.Context, usage *domain.AIUsage) (int, error) {
+	const query = `
+		INSERT INTO "aiUsage" (
+			"analysisId", "repositoryId", "userId", model, "promptTokens", "outputTokens", "cachedTokens", "costUsd", "createdAt"
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		)
+		RETURNING id
+	`
+	// "createdAt" has no time zone: times are stored and compared in UTC
+	// whatever the session time zone, so that months match monthStart
+	err := r.db.Pool.QueryRow(ctx, query,
+		usage.AnalysisID, usage.RepositoryID, usage.UserID, usage.Model,
+		usage.PromptTokens, usage.OutputTokens, usage.CachedTokens, usage.CostUSD, usage.CreatedAt.UTC(),
+	).Scan(&usage.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record AI usage: %w", err)
+	}
+	return usage.ID, nil
+}
+
+// SumCostSince returns the total cost across all users since the given time
+func (r *UsageRepository) SumCostSince(ctx context.Context, since time.Time) (float64, error) {
+	const query = `SELECT COALESCE(SUM("costUsd"), 0) FROM "aiUsage" WHERE "createdAt" >= $1`
+	var total float64
+	if err := r.db.Pool.QueryRow(ctx, query, since.UTC()).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to sum AI cost: %w", err)
+	}
+	return total, nil
+}
+
+func (r *UsageRepository) GetDailyByUser(ctx context.Context, userID int, since time.Time) ([]domain.DailyUsage, error) {
+	const query = `
+		SELECT to_char(date_trunc('day', "createdAt"), 'YYYY-MM-DD'), COUNT(*),
+		       SUM("promptTokens"), SUM("outputTokens"), SUM("cachedTokens"), SUM("costUsd")
+		FROM "aiUsage"
+		WHERE "userId" = $1 AND "createdAt" >= $2
+		GROUP BY 1
+		ORDER BY 1
+	`
+	rows, err := r.db.Pool.Query(ctx, query, userID, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily usage: %w", err)
+	}
+	defer rows.Close()
+
+	var items []domain.DailyUsage
+	for rows.Next() {
+		var d domain.DailyUsage
+		if err := rows.Scan(&d.Date, &d.Calls, &d.PromptTokens, &d.OutputTokens, &d.CachedTokens, &d.CostUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan daily usage: %w", err)
+		}
+		items = append(items, d)
+	}
+	return items, nil
+}
+
+func (r *UsageRepository) GetByRepositoryForUser(ctx context.Context, userID int, since time.Time) ([]domain.RepositoryUsage, error) {
+	const query = `
+		SELECT "repositoryId", COUNT(*),
+		       SUM("promptTokens"), SUM("outputTokens"), SUM("cachedTokens"), SUM("costUsd")
+		FROM "aiUsage"
+		WHERE "userId" = $1 AND "createdAt" >= $2
+		GROUP BY "repositoryId"
+		ORDER BY SUM("costUsd") DESC
+	`
+	rows, err := r.db.Pool.Query(ctx, query, userID, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query repository usage: %w", err)
+	}
+	defer rows.Close()
+
+	var items []domain.RepositoryUsage
+	for rows.Next() {
+		var u domain.RepositoryUsage
+		if err := rows.Scan(&u.RepositoryID, &u.Calls, &u.PromptTokens, &u.OutputTokens, &u.CachedTokens, &u.CostUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan repository usage: %w", err)
+		}
+		items = append(items, u)
+	}
+	return items, nil
+}
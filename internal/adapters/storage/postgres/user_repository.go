@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -97,8 +98,8 @@ func (r *UserRepository) GetByOpenID(ctx context.Context, openID string) (*domai
 	)
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, nil // Not found is not an error in domain logic usually, or handle as specific error
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NotFound("user", openID)
 		}
 		return nil, fmt.Errorf("failed to get user by openId: %w", err)
 	}
@@ -134,8 +135,8 @@ func (r *UserRepository) GetByID(ctx context.Context, id int) (*domain.User, err
 	)
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, nil
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NotFound("user", id)
 		}
 		return nil, fmt.Errorf("failed to get user by id: %w", err)
 	}
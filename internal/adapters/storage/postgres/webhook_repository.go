@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+)
+
+// WebhookRepository persists webhook deliveries and GitHub App installations
+type WebhookRepository struct {
+	db *DB
+}
+
+func NewWebhookRepository(db *DB) ports.WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (bool, error) {
+	const query = `
+		INSERT INTO "webhookDeliveries" (id, event, action, payload, status, "receivedAt")
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (id) DO NOTHING
+		RETURNING "receivedAt"
+	`
+	err := r.db.Pool.QueryRow(ctx, query,
+		delivery.ID, delivery.Event, delivery.Action, delivery.Payload, delivery.Status,
+	).Scan(&delivery.ReceivedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to store webhook delivery: %w", err)
+	}
+	return true, nil
+}
+
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	const query = `
+		SELECT id, event, action, payload, status, error, "receivedAt", "processedAt"
+		FROM "webhookDeliveries"
+		WHERE id = $1
+	`
+	var d domain.WebhookDelivery
+	err := r.db.Pool.QueryRow(ctx, query, id).Scan(&d.ID, &d.Event, &d.Action, &d.Payload, &d.Status, &d.Error, &d.ReceivedAt, &d.ProcessedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NotFound("webhook delivery", id)
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	return &d, nil
+}
+
+func (r *WebhookRepository) UpdateDeliveryStatus(ctx context.Context, id string, status domain.WebhookDeliveryStatus, errMsg string) error {
+	_, err := r.db.Pool.Exec(ctx, `
+		UPDATE "webhookDeliveries" SET status = $1, error = NULLIF($2, ''), "processedAt" = NOW()
+		WHERE id = $3
+	`, status, errMsg, id)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) UpsertInstallation(ctx context.Context, installation *domain.GitHubInstallation) error {
+	const query = `
+		INSERT INTO "githubInstallations" (id, "accountLogin", "accountType", "suspendedAt", "createdAt", "updatedAt")
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		ON CONFLICT (id) DO UPDATE SET
+			"accountLogin" = EXCLUDED."accountLogin",
+			"accountType" = EXCLUDED."accountType",
+			"suspendedAt" = EXCLUDED."suspendedAt",
+			"updatedAt" = NOW()
+		RETURNING "createdAt", "updatedAt"
+	`
+	err := r.db.Pool.QueryRow(ctx, query,
+		installation.ID, installation.AccountLogin, installation.AccountType, installation.SuspendedAt,
+	).Scan(&installation.CreatedAt, &installation.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store installation: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) DeleteInstallation(ctx context.Context, id int64) error {
+	if _, err := r.db.Pool.Exec(ctx, `DELETE FROM "githubInstallations" WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete installation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+)
+
+// WorkspaceRepository persists workspaces, their members and invitations
+type WorkspaceRepository struct {
+	db *DB
+}
+
+func NewWorkspaceRepository(db *DB) ports.WorkspaceRepository {
+	return &WorkspaceRepository{db: db}
+}
+
+// Create inserts the workspace and its owner in a single transaction
+func (r *WorkspaceRepository) Create(ctx context.Context, workspace *domain.Workspace, ownerID int) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO workspaces (name, "personalForUserId", "createdAt", "updatedAt")
+		VALUES ($1, $2, NOW(), NOW())
+		RETURNING id, "createdAt", "updatedAt"
+	`, workspace.Name, workspace.PersonalForUserID).Scan(&workspace.ID, &workspace.CreatedAt, &workspace.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO "workspaceMembers" ("workspaceId", "userId", role, "createdAt")
+		VALUES ($1, $2, $3, NOW())
+	`, workspace.ID, ownerID, domain.WorkspaceRoleOwner)
+	if err != nil {
+		return fmt.Errorf("failed to add workspace owner: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *WorkspaceRepository) GetByID(ctx context.Context, id int) (*domain.Workspace, error) {
+	const query = `SELECT id, name, "personalForUserId", "createdAt", "updatedAt" FROM workspaces WHERE id = $1`
+	var w domain.Workspace
+	err := r.db.Pool.QueryRow(ctx, query, id).Scan(&w.ID, &w.Name, &w.PersonalForUserID, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NotFound("workspace", id)
+		}
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	return &w, nil
+}
+
+func (r *WorkspaceRepository) GetPersonal(ctx context.Context, userID int) (*domain.Workspace, error) {
+	const query = `SELECT id, name, "personalForUserId", "createdAt", "updatedAt" FROM workspaces WHERE "personalForUserId" = $1`
+	var w domain.Workspace
+	err := r.db.Pool.QueryRow(ctx, query, userID).Scan(&w.ID, &w.Name, &w.PersonalForUserID, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NotFound("personal workspace of user", userID)
+		}
+		return nil, fmt.Errorf("failed to get personal workspace: %w", err)
+	}
+	return &w, nil
+}
+
+func (r *WorkspaceRepository) ListByUser(ctx context.Context, userID int) ([]domain.WorkspaceMembership, error) {
+	const query = `
+		SELECT w.id, w.name, w."personalForUserId", w."createdAt", w."updatedAt", m.role
+		FROM workspaces w
+		JOIN "workspaceMembers" m ON m."workspaceId" = w.id
+		WHERE m."userId" = $1
+		ORDER BY w."personalForUserId" IS NULL, w.name
+	`
+	rows, err := r.db.Pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspaces: %w", err)
+	}
+	defer rows.Close()
+
+	var items []domain.WorkspaceMembership
+	for rows.Next() {
+		var m domain.WorkspaceMembership
+		if err := rows.Scan(&m.ID, &m.Name, &m.PersonalForUserID, &m.CreatedAt, &m.UpdatedAt, &m.Role); err != nil {
+			return nil, fmt.Errorf("failed to scan workspace: %w", err)
+		}
+		items = append(items, m)
+	}
+	return items, nil
+}
+
+func (r *WorkspaceRepository) GetMember(ctx context.Context, workspaceID, userID int) (*domain.WorkspaceMember, error) {
+	const query = `
+		SELECT m."workspaceId", m."userId", m.role, u."githubUsername", m."createdAt"
+		FROM "workspaceMembers" m
+		LEFT JOIN users u ON u.id = m."userId"
+		WHERE m."workspaceId" = $1 AND m."userId" = $2
+	`
+	var m domain.WorkspaceMember
+	err := r.db.Pool.QueryRow(ctx, query, workspaceID, userID).Scan(&m.WorkspaceID, &m.UserID, &m.Role, &m.GithubUsername, &m.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NotFound("workspace member", userID)
+		}
+		return nil, fmt.Errorf("failed to get workspace member: %w", err)
+	}
+	return &m, nil
+}
+
+func (r *WorkspaceRepository) ListMembers(ctx context.Context, workspaceID int) ([]domain.WorkspaceMember, error) {
+	const query = `
+		SELECT m."workspaceId", m."userId", m.role, u."githubUsername", m."createdAt"
+		FROM "workspaceMembers" m
+		LEFT JOIN users u ON u.id = m."userId"
+		WHERE m."workspaceId" = $1
+		ORDER BY m."createdAt"
+	`
+	rows, err := r.db.Pool.Query(ctx, query, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspace members: %w", err)
+	}
+	defer rows.Close()
+
+	var items []domain.WorkspaceMember
+	for rows.Next() {
+		var m domain.WorkspaceMember
+		if err := rows.Scan(&m.WorkspaceID, &m.UserID, &m.Role, &m.GithubUsername, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan workspace member: %w", err)
+		}
+		items = append(items, m)
+	}
+	return items, nil
+}
+
+func (r *WorkspaceRepository) RemoveMember(ctx context.Context, workspaceID, userID int) error {
+	tag, err := r.db.Pool.Exec(ctx, `DELETE FROM "workspaceMembers" WHERE "workspaceId" = $1 AND "userId" = $2`, workspaceID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove workspace member: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.NotFound("workspace member", userID)
+	}
+	return nil
+}
+
+func (r *WorkspaceRepository) CreateInvitation(ctx context.Context, invitation *domain.WorkspaceInvitation) error {
+	const query = `
+		INSERT INTO "workspaceInvitations" ("workspaceId", "githubUsername", role, "invitedBy", status, "createdAt")
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING id, "createdAt"
+	`
+	err := r.db.Pool.QueryRow(ctx, query,
+		invitation.WorkspaceID, invitation.GithubUsername, invitation.Role, invitation.InvitedBy, invitation.Status,
+	).Scan(&invitation.ID, &invitation.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+	return nil
+}
+
+func (r *WorkspaceRepository) GetInvitation(ctx context.Context, id int) (*domain.WorkspaceInvitation, error) {
+	const query = `
+		SELECT id, "workspaceId", "githubUsername", role, "invitedBy", status, "createdAt", "acceptedAt"
+		FROM "workspaceInvitations"
+		WHERE id = $1
+	`
+	var i domain.WorkspaceInvitation
+	err := r.db.Pool.QueryRow(ctx, query, id).Scan(&i.ID, &i.WorkspaceID, &i.GithubUsername, &i.Role, &i.InvitedBy, &i.Status, &i.CreatedAt, &i.AcceptedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NotFound("invitation", id)
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	return &i, nil
+}
+
+func (r *WorkspaceRepository) ListPendingInvitations(ctx context.Context, githubUsername string) ([]domain.WorkspaceInvitation, error) {
+	const query = `
+		SELECT id, "workspaceId", "githubUsername", role, "invitedBy", status, "createdAt", "acceptedAt"
+		FROM "workspaceInvitations"
+		WHERE "githubUsername" = $1 AND status = 'pending'
+		ORDER BY "createdAt" DESC
+	`
+	rows, err := r.db.Pool.Query(ctx, query, githubUsername)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query invitations: %w", err)
+	}
+	defer rows.Close()
+
+	var items []domain.WorkspaceInvitation
+	for rows.Next() {
+		var i domain.WorkspaceInvitation
+		if err := rows.Scan(&i.ID, &i.WorkspaceID, &i.GithubUsername, &i.Role, &i.InvitedBy, &i.Status, &i.CreatedAt, &i.AcceptedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan invitation: %w", err)
+		}
+		items = append(items, i)
+	}
+	return items, nil
+}
+
+// AcceptInvitation marks the invitation accepted and adds the member in a single
+// transaction. An existing member keeps the higher of the two roles.
+func (r *WorkspaceRepository) AcceptInvitation(ctx context.Context, invitation *domain.WorkspaceInvitation, userID int) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE "workspaceInvitations" SET status = $1, "acceptedAt" = NOW()
+		WHERE id = $2 AND status = $3
+	`, domain.InvitationStatusAccepted, invitation.ID, domain.InvitationStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.Conflict("invitation is no longer pending")
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO "workspaceMembers" ("workspaceId", "userId", role, "createdAt")
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT ("workspaceId", "userId") DO UPDATE SET role = CASE
+			WHEN "workspaceMembers".role = 'owner' OR EXCLUDED.role = 'viewer' THEN "workspaceMembers".role
+			ELSE EXCLUDED.role END
+	`, invitation.WorkspaceID, userID, invitation.Role)
+	if err != nil {
+		return fmt.Errorf("failed to add workspace member: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
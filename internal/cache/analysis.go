@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/rs/zerolog/log"
+)
+
+// AnalysisCache puts the shared cache in front of a persistent analysis cache.
+// Reads try the shared cache first and fall back to the backing store, repopulating it on a hit.
+type AnalysisCache struct {
+	cache ports.Cache
+	store ports.AnalysisCacheRepository
+	ttl   time.Duration
+}
+
+// NewAnalysisCache creates a read-through cache over store
+func NewAnalysisCache(cache ports.Cache, store ports.AnalysisCacheRepository, ttl time.Duration) ports.AnalysisCacheRepository {
+	return &AnalysisCache{cache: cache, store: store, ttl: ttl}
+}
+
+func analysisKey(fingerprint string) string {
+	return "analysis:" + fingerprint
+}
+
+// Get returns the cached response, or nil on a miss in both tiers
+func (c *AnalysisCache) Get(ctx context.Context, fingerprint string) (*domain.RepositoryAnalysisResponse, error) {
+	var response domain.RepositoryAnalysisResponse
+	found, err := c.cache.Get(ctx, analysisKey(fingerprint), &response)
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("Analysis cache read failed, falling back to store")
+	}
+	if found {
+		return &response, nil
+	}
+
+	stored, err := c.store.Get(ctx, fingerprint)
+	if err != nil || stored == nil {
+		return stored, err
+	}
+
+	if err := c.cache.Set(ctx, analysisKey(fingerprint), stored, c.ttl); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("Failed to repopulate analysis cache")
+	}
+	return stored, nil
+}
+
+// Set writes the response to the backing store and the shared cache
+func (c *AnalysisCache) Set(ctx context.Context, fingerprint string, repoID int, response *domain.RepositoryAnalysisResponse) error {
+	if err := c.store.Set(ctx, fingerprint, repoID, response); err != nil {
+		return err
+	}
+	if err := c.cache.Set(ctx, analysisKey(fingerprint), response, c.ttl); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("Failed to write analysis cache")
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedisCache(t *testing.T) (ports.Cache, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client, err := NewRedisClient(Config{Addr: mr.Addr(), Prefix: "test:"})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return NewRedisCache(client), mr
+}
+
+func TestCaches(t *testing.T) {
+	redisCache, _ := newTestRedisCache(t)
+	impls := map[string]ports.Cache{
+		"memory": NewMemoryCache(),
+		"redis":  redisCache,
+	}
+
+	type payload struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+
+	for name, c := range impls {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			t.Run("miss", func(t *testing.T) {
+				var got payload
+				found, err := c.Get(ctx, "missing", &got)
+				assert.NoError(t, err)
+				assert.False(t, found)
+			})
+
+			t.Run("set and get", func(t *testing.T) {
+				require.NoError(t, c.Set(ctx, "k1", payload{Name: "a", Count: 2}, time.Minute))
+				var got payload
+				found, err := c.Get(ctx, "k1", &got)
+				assert.NoError(t, err)
+				assert.True(t, found)
+				assert.Equal(t, payload{Name: "a", Count: 2}, got)
+			})
+
+			t.Run("delete", func(t *testing.T) {
+				require.NoError(t, c.Set(ctx, "k2", payload{Name: "b"}, time.Minute))
+				require.NoError(t, c.Delete(ctx, "k2"))
+				found, err := c.Get(ctx, "k2", &payload{})
+				assert.NoError(t, err)
+				assert.False(t, found)
+			})
+
+			t.Run("invalidate tags", func(t *testing.T) {
+				require.NoError(t, c.Set(ctx, "stats:1", payload{Name: "s"}, time.Minute, "user:1"))
+				require.NoError(t, c.Set(ctx, "report:5", payload{Name: "r"}, time.Minute, "repo:5", "user:1"))
+				require.NoError(t, c.Set(ctx, "report:6", payload{Name: "o"}, time.Minute, "repo:6"))
+
+				require.NoError(t, c.InvalidateTags(ctx, "user:1"))
+
+				for _, key := range []string{"stats:1", "report:5"} {
+					found, err := c.Get(ctx, key, &payload{})
+					assert.NoError(t, err)
+					assert.False(t, found, key)
+				}
+				found, err := c.Get(ctx, "report:6", &payload{})
+				assert.NoError(t, err)
+				assert.True(t, found)
+			})
+		})
+	}
+}
+
+func TestMemoryCache_TagsPrunedWithKey(t *testing.T) {
+	c := NewMemoryCache().(*MemoryCache)
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "expiring", "v", time.Millisecond, "user:1", "repo:5"))
+	require.NoError(t, c.Set(ctx, "deleted", "v", time.Minute, "user:1"))
+	require.NoError(t, c.Set(ctx, "kept", "v", time.Minute, "repo:5"))
+
+	time.Sleep(5 * time.Millisecond)
+	c.items.DeleteExpired()
+	require.NoError(t, c.Delete(ctx, "deleted"))
+
+	assert.Equal(t, map[string]map[string]struct{}{"repo:5": {"kept": {}}}, c.tags)
+	assert.Len(t, c.keyTags, 1)
+
+	require.NoError(t, c.InvalidateTags(ctx, "repo:5"))
+	assert.Empty(t, c.tags)
+	assert.Empty(t, c.keyTags)
+}
+
+func TestRedisCache_TagMarkersExpireWithKey(t *testing.T) {
+	c, mr := newTestRedisCache(t)
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "k", "v", time.Minute, "user:1"))
+	assert.True(t, mr.Exists("test:tag:user:1:k"))
+
+	mr.FastForward(2 * time.Minute)
+
+	assert.False(t, mr.Exists("test:k"))
+	assert.False(t, mr.Exists("test:tag:user:1:k"))
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/ports"
+	gocache "github.com/patrickmn/go-cache"
+)
+
+// MemoryCache implements ports.Cache in process memory. It is the default when
+// Redis is not configured; values are JSON-encoded so callers get the same
+// copy semantics as with Redis.
+type MemoryCache struct {
+	items *gocache.Cache
+
+	mu   sync.Mutex
+	tags map[string]map[string]struct{}
+	// keyTags indexes tags by key, to prune them when the key is evicted
+	keyTags map[string]map[string]struct{}
+}
+
+// NewMemoryCache creates an in-process cache
+func NewMemoryCache() ports.Cache {
+	c := &MemoryCache{
+		items:   gocache.New(5*time.Minute, 10*time.Minute),
+		tags:    make(map[string]map[string]struct{}),
+		keyTags: make(map[string]map[string]struct{}),
+	}
+	c.items.OnEvicted(c.evicted)
+	return c
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string, dest interface{}) (bool, error) {
+	data, found := c.items.Get(key)
+	if !found {
+		return false, nil
+	}
+	if err := json.Unmarshal(data.([]byte), dest); err != nil {
+		return false, fmt.Errorf("unmarshal failed: %w", err)
+	}
+	return true, nil
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration, tags ...string) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal failed: %w", err)
+	}
+	// Under the lock, so that an eviction of the previous value cannot prune the new tags
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items.Set(key, data, ttl)
+	for _, tag := range tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = make(map[string]struct{})
+		}
+		c.tags[tag][key] = struct{}{}
+		if c.keyTags[key] == nil {
+			c.keyTags[key] = make(map[string]struct{})
+		}
+		c.keyTags[key][tag] = struct{}{}
+	}
+	return nil
+}
+
+func (c *MemoryCache) Delete(ctx context.Context, key string) error {
+	c.items.Delete(key)
+	return nil
+}
+
+func (c *MemoryCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	// Deleting calls evicted, which takes the lock and prunes the tags
+	var keys []string
+	c.mu.Lock()
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			keys = append(keys, key)
+		}
+	}
+	c.mu.Unlock()
+	for _, key := range keys {
+		c.items.Delete(key)
+	}
+	return nil
+}
+
+// evicted drops an expired or deleted key from its tag sets, and the sets it
+// leaves empty, so that they do not grow with every key ever cached
+func (c *MemoryCache) evicted(key string, _ interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, found := c.items.Get(key); found {
+		// Set again since
+		return
+	}
+	for tag := range c.keyTags[key] {
+		delete(c.tags[tag], key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+	delete(c.keyTags, key)
+}
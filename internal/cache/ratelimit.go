@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/redis/go-redis/v9"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// MemoryRateLimiter keeps token buckets in process memory; limits are per instance
+type MemoryRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// NewMemoryRateLimiter creates a rate limiter for single-instance deployments
+func NewMemoryRateLimiter() ports.RateLimiter {
+	return &MemoryRateLimiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (l *MemoryRateLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+		l.evictFull(now, limit)
+	}
+
+	tokens, allowed, wait := limit.TakeToken(b.tokens, now.Sub(b.last).Seconds())
+	b.tokens, b.last = tokens, now
+	return allowed, secondsToDuration(wait), nil
+}
+
+// evictFull drops buckets that have refilled completely, bounding memory to active clients
+func (l *MemoryRateLimiter) evictFull(now time.Time, limit domain.RateLimit) {
+	if len(l.buckets) < 10000 {
+		return
+	}
+	refill := time.Duration(float64(limit.Burst) / limit.Rate * float64(time.Second))
+	for k, b := range l.buckets {
+		if now.Sub(b.last) > refill {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// tokenBucketScript mirrors domain.RateLimit.TakeToken atomically in Redis.
+// KEYS[1] bucket key; ARGV rate (tokens/s), burst, now (ms). Returns {allowed, waitMs}.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local data = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(data[1]) or burst
+local ts = tonumber(data[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) / rate * 1000)
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, wait}
+`)
+
+// RedisRateLimiter shares token buckets across instances through Redis
+type RedisRateLimiter struct {
+	client *RedisClient
+	now    func() time.Time
+}
+
+// NewRedisRateLimiter creates a rate limiter for multi-instance deployments
+func NewRedisRateLimiter(client *RedisClient) ports.RateLimiter {
+	return &RedisRateLimiter{client: client, now: time.Now}
+}
+
+func (l *RedisRateLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit) (bool, time.Duration, error) {
+	res, err := tokenBucketScript.Run(ctx, l.client.client,
+		[]string{l.client.prefix + "ratelimit:" + key},
+		limit.Rate, limit.Burst, l.now().UnixMilli(),
+	).Int64Slice()
+	if err != nil {
+		return true, 0, fmt.Errorf("redis rate limit failed: %w", err)
+	}
+	return res[0] == 1, time.Duration(res[1]) * time.Millisecond, nil
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiters(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client, err := NewRedisClient(Config{Addr: mr.Addr(), Prefix: "test:"})
+	require.NoError(t, err)
+	defer client.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	memory := NewMemoryRateLimiter().(*MemoryRateLimiter)
+	memory.now = clock
+	redisLimiter := NewRedisRateLimiter(client).(*RedisRateLimiter)
+	redisLimiter.now = clock
+
+	limit := domain.RateLimit{Rate: 1, Burst: 2}
+
+	for name, limiter := range map[string]ports.RateLimiter{"memory": memory, "redis": redisLimiter} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			key := name + ":user:1"
+
+			for i := 0; i < 2; i++ {
+				allowed, _, err := limiter.Allow(ctx, key, limit)
+				require.NoError(t, err)
+				assert.True(t, allowed, "burst request %d", i)
+			}
+
+			allowed, retryAfter, err := limiter.Allow(ctx, key, limit)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+			assert.Equal(t, time.Second, retryAfter)
+
+			// Other keys have their own bucket
+			allowed, _, err = limiter.Allow(ctx, name+":user:2", limit)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+
+			now = now.Add(time.Second)
+			allowed, _, err = limiter.Allow(ctx, key, limit)
+			require.NoError(t, err)
+			assert.True(t, allowed, "refilled after one second")
+		})
+	}
+}
@@ -57,22 +57,28 @@ func NewRedisClient(config Config) (*RedisClient, error) {
 
 // Get retrieves a value from cache
 func (r *RedisClient) Get(ctx context.Context, key string, dest interface{}) error {
+	_, err := r.Lookup(ctx, key, dest)
+	return err
+}
+
+// Lookup retrieves a value from cache and reports whether the key was present
+func (r *RedisClient) Lookup(ctx context.Context, key string, dest interface{}) (bool, error) {
 	fullKey := r.prefix + key
 
 	data, err := r.client.Get(ctx, fullKey).Bytes()
 	if err == redis.Nil {
-		return nil // Cache miss
+		return false, nil // Cache miss
 	}
 	if err != nil {
-		return fmt.Errorf("redis get failed: %w", err)
+		return false, fmt.Errorf("redis get failed: %w", err)
 	}
 
 	if err := json.Unmarshal(data, dest); err != nil {
-		return fmt.Errorf("unmarshal failed: %w", err)
+		return false, fmt.Errorf("unmarshal failed: %w", err)
 	}
 
 	log.Debug().Str("key", fullKey).Msg("Cache hit")
-	return nil
+	return true, nil
 }
 
 // Set stores a value in cache
@@ -108,6 +114,21 @@ func (r *RedisClient) Delete(ctx context.Context, key string) error {
 func (r *RedisClient) DeletePattern(ctx context.Context, pattern string) error {
 	fullPattern := r.prefix + pattern
 
+	if err := r.scan(ctx, fullPattern, func(keys []string) error {
+		if err := r.client.Del(ctx, keys...).Err(); err != nil {
+			return fmt.Errorf("redis delete failed: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	log.Debug().Str("pattern", fullPattern).Msg("Cache pattern deleted")
+	return nil
+}
+
+// scan iterates over all keys matching a full (already prefixed) pattern in batches
+func (r *RedisClient) scan(ctx context.Context, fullPattern string, fn func(keys []string) error) error {
 	var cursor uint64
 	for {
 		var keys []string
@@ -119,18 +140,15 @@ func (r *RedisClient) DeletePattern(ctx context.Context, pattern string) error {
 		}
 
 		if len(keys) > 0 {
-			if err := r.client.Del(ctx, keys...).Err(); err != nil {
-				return fmt.Errorf("redis delete failed: %w", err)
+			if err := fn(keys); err != nil {
+				return err
 			}
 		}
 
 		if cursor == 0 {
-			break
+			return nil
 		}
 	}
-
-	log.Debug().Str("pattern", fullPattern).Msg("Cache pattern deleted")
-	return nil
 }
 
 // HealthCheck checks Redis health
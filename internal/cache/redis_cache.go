@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/ports"
+)
+
+// tagMarkerPrefix namespaces the marker keys that record tag membership.
+// A key K stored with tag T gets a marker "tag:T:K" sharing K's TTL, so a tag
+// can be invalidated by scanning "tag:T:*" without a separate index structure.
+const tagMarkerPrefix = "tag:"
+
+// RedisCache implements ports.Cache on top of RedisClient, so cached data and
+// invalidations are shared by every instance of the server.
+type RedisCache struct {
+	client *RedisClient
+}
+
+// NewRedisCache creates a shared cache backed by Redis
+func NewRedisCache(client *RedisClient) ports.Cache {
+	return &RedisCache{client: client}
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string, dest interface{}) (bool, error) {
+	return c.client.Lookup(ctx, key, dest)
+}
+
+func (c *RedisCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration, tags ...string) error {
+	if err := c.client.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		marker := c.client.prefix + tagMarkerPrefix + tag + ":" + key
+		if err := c.client.client.Set(ctx, marker, "", ttl).Err(); err != nil {
+			return fmt.Errorf("redis tag failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *RedisCache) Delete(ctx context.Context, key string) error {
+	return c.client.Delete(ctx, key)
+}
+
+func (c *RedisCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		markerPrefix := c.client.prefix + tagMarkerPrefix + tag + ":"
+
+		err := c.client.scan(ctx, markerPrefix+"*", func(markers []string) error {
+			keys := make([]string, 0, len(markers))
+			for _, m := range markers {
+				keys = append(keys, c.client.prefix+strings.TrimPrefix(m, markerPrefix))
+			}
+			if err := c.client.client.Del(ctx, keys...).Err(); err != nil {
+				return fmt.Errorf("redis delete failed: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := c.client.DeletePattern(ctx, tagMarkerPrefix+tag+":*"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -16,17 +17,37 @@ type Config struct {
 	// Server
 	Port             string
 	DatabaseURL      string
-	APIKey           string
+	APIKey           string // internal API key; when set, unauthenticated requests are rejected
+	GitHubToken      string // default GitHub token for users without their own
+
+	// GitHub webhooks; deliveries are rejected while the secret is unset
+	GitHubWebhookSecret string
+
+	// GitHub App; when the ID is set, API calls use installation tokens instead of GitHubToken
+	GitHubAppID             int64
+	GitHubAppPrivateKey     string // PEM
+	GitHubAppPrivateKeyFile string // path to the PEM, used when GitHubAppPrivateKey is empty
+	GitHubAPIURL            string // GitHub Enterprise API endpoint used by the App; empty for github.com
+
+	// Other source hosts: GitLab is enabled by its token, Gitea by its URL
+	GitLabURL   string // empty for gitlab.com
+	GitLabToken string
+	GiteaURL    string
+	GiteaToken  string
+	// LocalReposRoot enables importing git repositories from this directory
+	LocalReposRoot   string
 	LogLevel         string
+	LogFormat        string // "json" (default) or "console"
 	SkipBackendCheck bool
 
 	// CORS
 	AllowedOrigins []string
 
 	// Timeouts
-	ServerTimeout   time.Duration
-	BackendTimeout  time.Duration
-	ShutdownTimeout time.Duration
+	ServerTimeout      time.Duration
+	BackendTimeout     time.Duration
+	ShutdownTimeout    time.Duration
+	ShutdownDrainDelay time.Duration // readiness fails for this long before the server stops accepting connections
 
 	// Redis (optional)
 	RedisEnabled  bool
@@ -39,6 +60,37 @@ type Config struct {
 	MaxRequestSize int64
 	RateLimitRPS   int
 	MaxBulkRepos   int
+
+	// Stricter quota for expensive endpoints (analysis, sync), per user
+	ExpensiveRateLimitPerMinute int
+
+	// Bulk analysis
+	BulkWorkers             int // workers per batch
+	BulkProviderConcurrency int // concurrent analyses per source host, across batches
+
+	// AI cost accounting (USD per million tokens)
+	AIInputPricePerMTok  float64
+	AIOutputPricePerMTok float64
+	AICachedPricePerMTok float64
+	AIMonthlyBudget      float64 // 0 disables the budget
+
+	// Code ingestion: shallow clones analysis reads repository files from
+	IngestDisabled      bool
+	IngestDir           string
+	IngestMaxRepoMB     int64
+	IngestMaxWorkspaces int
+	IngestMaxIdle       time.Duration
+	IngestConcurrency   int
+	IngestCloneTimeout  time.Duration
+
+	// Directory of OSV advisory dumps; empty disables vulnerability checks
+	AdvisoryDir string
+	// JSON snapshot of package registries; empty compares dependency versions within the portfolio only
+	RegistrySnapshot string
+
+	// Tracing: "none", "stdout" or "otlp" (endpoint from OTEL_EXPORTER_OTLP_ENDPOINT)
+	TracingExporter    string
+	TracingSampleRatio float64
 }
 
 // Load loads configuration from environment and .env file
@@ -53,16 +105,32 @@ func Load() *Config {
 		Port:             getEnvOrDefault("PORT", "8080"),
 		DatabaseURL:      getEnvOrDefault("DATABASE_URL", ""),
 		APIKey:           os.Getenv("API_KEY"),
+		GitHubToken:      os.Getenv("GITHUB_TOKEN"),
+
+		GitHubWebhookSecret: os.Getenv("GITHUB_WEBHOOK_SECRET"),
+
+		GitHubAppID:             getEnvInt64("GITHUB_APP_ID", 0),
+		GitHubAppPrivateKey:     os.Getenv("GITHUB_APP_PRIVATE_KEY"),
+		GitHubAppPrivateKeyFile: os.Getenv("GITHUB_APP_PRIVATE_KEY_FILE"),
+		GitHubAPIURL:            os.Getenv("GITHUB_API_URL"),
+
+		GitLabURL:        os.Getenv("GITLAB_URL"),
+		GitLabToken:      os.Getenv("GITLAB_TOKEN"),
+		GiteaURL:         os.Getenv("GITEA_URL"),
+		GiteaToken:       os.Getenv("GITEA_TOKEN"),
+		LocalReposRoot:   os.Getenv("LOCAL_REPOS_ROOT"),
 		LogLevel:         getEnvOrDefault("LOG_LEVEL", "info"),
+		LogFormat:        getEnvOrDefault("LOG_FORMAT", "json"),
 		SkipBackendCheck: getEnvBool("SKIP_BACKEND_CHECK"),
 
 		// CORS
 		AllowedOrigins: getEnvSlice("ALLOWED_ORIGINS", []string{"http://localhost:5173", "http://localhost:3000"}),
 
 		// Timeouts
-		ServerTimeout:   getEnvDuration("SERVER_TIMEOUT", 30*time.Second),
-		BackendTimeout:  getEnvDuration("BACKEND_TIMEOUT", 30*time.Second),
-		ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT", 5*time.Second),
+		ServerTimeout:      getEnvDuration("SERVER_TIMEOUT", 30*time.Second),
+		BackendTimeout:     getEnvDuration("BACKEND_TIMEOUT", 30*time.Second),
+		ShutdownTimeout:    getEnvDuration("SHUTDOWN_TIMEOUT", 5*time.Second),
+		ShutdownDrainDelay: getEnvDuration("SHUTDOWN_DRAIN_DELAY", 0),
 
 		// Redis
 		RedisEnabled:  os.Getenv("REDIS_ADDR") != "",
@@ -75,6 +143,31 @@ func Load() *Config {
 		MaxRequestSize: getEnvInt64("MAX_REQUEST_SIZE", 10*1024*1024),
 		RateLimitRPS:   getEnvInt("RATE_LIMIT_RPS", 100),
 		MaxBulkRepos:   getEnvInt("MAX_BULK_REPOS", 100),
+
+		ExpensiveRateLimitPerMinute: getEnvInt("EXPENSIVE_RATE_LIMIT_PER_MINUTE", 10),
+
+		BulkWorkers:             getEnvInt("BULK_WORKERS", 4),
+		BulkProviderConcurrency: getEnvInt("BULK_PROVIDER_CONCURRENCY", 2),
+
+		// AI cost accounting, defaults are Gemini 1.5 Flash list prices
+		AIInputPricePerMTok:  getEnvFloat("AI_INPUT_PRICE_PER_MTOK", 0.075),
+		AIOutputPricePerMTok: getEnvFloat("AI_OUTPUT_PRICE_PER_MTOK", 0.30),
+		AICachedPricePerMTok: getEnvFloat("AI_CACHED_PRICE_PER_MTOK", 0.01875),
+		AIMonthlyBudget:      getEnvFloat("AI_MONTHLY_BUDGET_USD", 0),
+
+		IngestDisabled:      getEnvBool("INGEST_DISABLED"),
+		IngestDir:           getEnvOrDefault("INGEST_DIR", filepath.Join(os.TempDir(), "ghrego-ingest")),
+		IngestMaxRepoMB:     getEnvInt64("INGEST_MAX_REPO_MB", 500),
+		IngestMaxWorkspaces: getEnvInt("INGEST_MAX_WORKSPACES", 20),
+		IngestMaxIdle:       getEnvDuration("INGEST_MAX_IDLE", 24*time.Hour),
+		IngestConcurrency:   getEnvInt("INGEST_CONCURRENCY", 2),
+		IngestCloneTimeout:  getEnvDuration("INGEST_CLONE_TIMEOUT", 5*time.Minute),
+
+		AdvisoryDir:      os.Getenv("ADVISORY_DIR"),
+		RegistrySnapshot: os.Getenv("REGISTRY_SNAPSHOT"),
+
+		TracingExporter:    getEnvOrDefault("TRACING_EXPORTER", "none"),
+		TracingSampleRatio: getEnvFloat("TRACING_SAMPLE_RATIO", 1),
 	}
 }
 
@@ -112,6 +205,19 @@ func getEnvInt64(key string, defaultValue int64) int64 {
 	return i
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Warn().Str("key", key).Str("value", value).Msg("Invalid float format")
+		return defaultValue
+	}
+	return f
+}
+
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	value := os.Getenv(key)
 	if value == "" {
@@ -147,6 +253,20 @@ func (c *Config) Validate() error {
 	if c.MaxRequestSize <= 0 {
 		return ErrInvalidConfig("MAX_REQUEST_SIZE must be positive")
 	}
+	if c.AIMonthlyBudget < 0 {
+		return ErrInvalidConfig("AI_MONTHLY_BUDGET_USD cannot be negative")
+	}
+	if c.GitHubAppID != 0 && c.GitHubAppPrivateKey == "" && c.GitHubAppPrivateKeyFile == "" {
+		return ErrInvalidConfig("GITHUB_APP_ID requires GITHUB_APP_PRIVATE_KEY or GITHUB_APP_PRIVATE_KEY_FILE")
+	}
+	switch c.TracingExporter {
+	case "", "none", "stdout", "otlp":
+	default:
+		return ErrInvalidConfig("TRACING_EXPORTER must be one of none, stdout, otlp")
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		return ErrInvalidConfig("TRACING_SAMPLE_RATIO must be between 0 and 1")
+	}
 	return nil
 }
 
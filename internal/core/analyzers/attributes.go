@@ -0,0 +1,161 @@
+package analyzers
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// attributeRule is one line of a .gitattributes file that sets linguist attributes
+type attributeRule struct {
+	pattern *regexp.Regexp
+	// vendored, generated and documentation are nil when the line leaves them unspecified
+	vendored      *bool
+	generated     *bool
+	documentation *bool
+	language      string
+}
+
+// overrides are the linguist attributes that apply to one file
+type overrides struct {
+	vendored, generated, documentation *bool
+	language                           string
+}
+
+// attributes holds the linguist rules of the .gitattributes files of a tree,
+// in the order git applies them: outer files first, later lines winning
+type attributes []attributeRule
+
+// parseAttributes reads the linguist attributes of a .gitattributes file in
+// directory dir ("" for the root). Other attributes, macros and invalid
+// patterns are ignored.
+func parseAttributes(dir, content string) attributes {
+	var rules attributes
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "[attr]") {
+			continue
+		}
+		rule := attributeRule{}
+		relevant := false
+		for _, attr := range fields[1:] {
+			name, value, hasValue := strings.Cut(attr, "=")
+			set := true
+			switch {
+			case strings.HasPrefix(name, "-"):
+				name, set = name[1:], false
+			case strings.HasPrefix(name, "!"):
+				// Unspecified, which is what no rule at all means too
+				continue
+			case hasValue:
+				set = value != "false"
+			}
+			switch name {
+			case "linguist-vendored":
+				rule.vendored = &set
+			case "linguist-generated":
+				rule.generated = &set
+			case "linguist-documentation":
+				rule.documentation = &set
+			case "linguist-language":
+				if !hasValue {
+					continue
+				}
+				rule.language = value
+			default:
+				continue
+			}
+			relevant = true
+		}
+		if !relevant {
+			continue
+		}
+		if rule.pattern = gitPattern(dir, fields[0]); rule.pattern != nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// lookup merges the rules matching a file; later rules override earlier ones
+func (a attributes) lookup(p string) overrides {
+	var o overrides
+	for _, rule := range a {
+		if !rule.pattern.MatchString(p) {
+			continue
+		}
+		if rule.vendored != nil {
+			o.vendored = rule.vendored
+		}
+		if rule.generated != nil {
+			o.generated = rule.generated
+		}
+		if rule.documentation != nil {
+			o.documentation = rule.documentation
+		}
+		if rule.language != "" {
+			o.language = rule.language
+		}
+	}
+	return o
+}
+
+// gitPattern compiles a gitattributes pattern of a file in directory dir. As
+// in git, a pattern without a slash matches the file name at any depth below
+// dir, otherwise the path relative to dir; patterns never match directories,
+// so "vendor/**" is needed to cover a whole tree.
+func gitPattern(dir, pattern string) *regexp.Regexp {
+	if strings.HasSuffix(pattern, "/") {
+		return nil
+	}
+	var re strings.Builder
+	re.WriteString("^")
+	if dir != "" {
+		re.WriteString(regexp.QuoteMeta(dir + "/"))
+	}
+	if !strings.Contains(pattern, "/") {
+		re.WriteString("(?:.*/)?")
+	}
+	pattern = strings.TrimPrefix(pattern, "/")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+	compiled, err := regexp.Compile(re.String())
+	if err != nil {
+		return nil
+	}
+	return compiled
+}
+
+// attributesDir is the directory a .gitattributes file applies to
+func attributesDir(p string) string {
+	if dir := path.Dir(p); dir != "." {
+		return dir
+	}
+	return ""
+}
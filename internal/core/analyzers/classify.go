@@ -0,0 +1,193 @@
+package analyzers
+
+import (
+	"context"
+	"io/fs"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+)
+
+// maxShebangReads bounds the files read to look for a shebang, since hosts
+// like GitHub serve each one with an API request
+const maxShebangReads = 20
+
+// The path rules follow linguist's vendor.yml, generated.rb and documentation.yml
+var (
+	vendoredPaths = regexp.MustCompile(`(^|/)(vendor|node_modules|bower_components|third_party|3rdparty|Godeps/_workspace|Pods|Carthage/Build|\.yarn/(releases|plugins|sdks))/` +
+		`|(^|/)(gradlew|gradlew\.bat|mvnw|mvnw\.cmd)$` +
+		`|(^|/)(gradle/wrapper|\.mvn/wrapper)/` +
+		`|[.-]min\.(js|css)$` +
+		`|(^|/)jquery([.-][\d.]+)?(\.min)?\.js$`)
+	generatedPaths = regexp.MustCompile(`(^|/)(package-lock\.json|npm-shrinkwrap\.json|yarn\.lock|pnpm-lock\.yaml|bun\.lockb|composer\.lock|Gemfile\.lock|Cargo\.lock|poetry\.lock|Pipfile\.lock|uv\.lock|go\.sum|flake\.lock)$` +
+		`|\.pb\.(go|cc|h)$|_pb2(_grpc)?\.pyi?$|\.pb\.gw\.go$|_grpc\.pb\.go$` +
+		`|(^|/)zz_generated[^/]*\.go$|_generated\.go$|\.gen\.go$` +
+		`|\.designer\.cs$|\.g\.dart$|\.freezed\.dart$` +
+		`|\.(js|css)\.map$` +
+		`|(^|/)(__generated__|dist)/`)
+	documentationPaths = regexp.MustCompile(`^(docs?|[Dd]ocumentation|man|[Ee]xamples?|samples?)/` +
+		`|(^|/)(README|CHANGELOG|CHANGES|HISTORY|CONTRIBUTING|CODE_OF_CONDUCT|SECURITY|AUTHORS|COPYING|INSTALL|LICEN[CS]E|NOTICE)(\.[^/]*)?$`)
+)
+
+// classification is what the classifier decides for one file
+type classification struct {
+	language string // empty when not recognised
+	category domain.FileCategory
+}
+
+// classifier assigns files a language and a category, linguist style
+type classifier struct {
+	attrs attributes
+}
+
+// classify decides a file from its path, the first bytes of its content when
+// known (head, for shebangs) and the .gitattributes overrides
+func (c classifier) classify(p string, head []byte) classification {
+	o := c.attrs.lookup(p)
+	var cl classification
+	if o.language != "" {
+		cl.language = o.language
+	} else if l, ok := languageOf(p); ok {
+		cl.language = l.name
+	} else if l, ok := languageOfShebang(head); ok {
+		cl.language = l.name
+	}
+
+	switch {
+	case decide(o.vendored, vendoredPaths.MatchString(p)):
+		cl.category = domain.FileVendored
+	case decide(o.generated, generatedPaths.MatchString(p)):
+		cl.category = domain.FileGenerated
+	case decide(o.documentation, documentationPaths.MatchString(p)):
+		cl.category = domain.FileDocumentation
+	case cl.language == "":
+		cl.category = domain.FileOther
+	default:
+		switch kindOf(cl.language) {
+		case data:
+			cl.category = domain.FileConfiguration
+		case prose:
+			cl.category = domain.FileDocumentation
+		default:
+			if isTestFile(p) {
+				cl.category = domain.FileTest
+			} else {
+				cl.category = domain.FileSource
+			}
+		}
+	}
+	return cl
+}
+
+// rootClassifier classifies the files of a checkout with the overrides of
+// its root .gitattributes
+func rootClassifier(fsys fs.FS) classifier {
+	var c classifier
+	if content, err := fs.ReadFile(fsys, ".gitattributes"); err == nil {
+		c.attrs = parseAttributes("", string(content))
+	}
+	return c
+}
+
+// needsHead reports whether a file can only be recognised by its shebang
+func (c classifier) needsHead(p string) bool {
+	return path.Ext(p) == "" && c.classify(p, nil).category == domain.FileOther
+}
+
+// decide applies a .gitattributes override to what the path rules detected
+func decide(override *bool, detected bool) bool {
+	if override != nil {
+		return *override
+	}
+	return detected
+}
+
+// kindOf returns the linguist type of a language; languages named only by a
+// linguist-language override are taken as programming languages
+func kindOf(name string) languageKind {
+	return languageKinds[name]
+}
+
+// Structure classifies a repository tree the way GitHub's linguist does:
+// files are recognised by name, extension or shebang, and vendored, generated
+// and documentation files are set apart from the source. read returns a file
+// of the tree; it is used for the .gitattributes files, whose linguist
+// attributes override detection, and for the shebang of extensionless files.
+// A nil read classifies by path only.
+func Structure(ctx context.Context, entries []domain.TreeEntry, read func(ctx context.Context, path string) (string, error)) (*domain.RepositoryStructure, error) {
+	s := &domain.RepositoryStructure{
+		Directories: []string{},
+		Categories:  make(map[domain.FileCategory]int),
+		Languages:   make(map[string]domain.LanguageShare),
+	}
+
+	var c classifier
+	if read != nil {
+		var attrFiles []string
+		for _, entry := range entries {
+			if entry.Type == "blob" && path.Base(entry.Path) == ".gitattributes" {
+				attrFiles = append(attrFiles, entry.Path)
+			}
+		}
+		// Outer files first, so that nested ones override them
+		sort.SliceStable(attrFiles, func(i, j int) bool {
+			return strings.Count(attrFiles[i], "/") < strings.Count(attrFiles[j], "/")
+		})
+		for _, p := range attrFiles {
+			content, err := read(ctx, p)
+			if err != nil {
+				return nil, err
+			}
+			c.attrs = append(c.attrs, parseAttributes(attributesDir(p), content)...)
+		}
+	}
+
+	var total int64
+	reads := 0
+	for _, entry := range entries {
+		if entry.Type == "tree" {
+			s.Directories = append(s.Directories, entry.Path)
+			continue
+		}
+		if entry.Type != "blob" {
+			continue
+		}
+		var head []byte
+		if read != nil && reads < maxShebangReads && c.needsHead(entry.Path) {
+			reads++
+			content, err := read(ctx, entry.Path)
+			if err != nil {
+				return nil, err
+			}
+			head = []byte(content[:min(len(content), 256)])
+		}
+
+		cl := c.classify(entry.Path, head)
+		s.Files++
+		s.Categories[cl.category]++
+		if cl.category != domain.FileSource && cl.category != domain.FileTest {
+			continue
+		}
+		share := s.Languages[cl.language]
+		share.Files++
+		share.Bytes += entry.Size
+		s.Languages[cl.language] = share
+		total += entry.Size
+	}
+
+	for lang, share := range s.Languages {
+		if total > 0 {
+			share.Percentage = math.Round(float64(share.Bytes)/float64(total)*1000) / 10
+		}
+		s.Languages[lang] = share
+		best := s.Languages[s.PrimaryLanguage]
+		if s.PrimaryLanguage == "" || share.Bytes > best.Bytes || (share.Bytes == best.Bytes && lang < s.PrimaryLanguage) {
+			s.PrimaryLanguage = lang
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,131 @@
+package analyzers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		path     string
+		head     string
+		language string
+		category domain.FileCategory
+	}{
+		{"Dockerfile", "", "Dockerfile", domain.FileSource},
+		{"deploy/Dockerfile.prod", "", "Dockerfile", domain.FileSource},
+		{"Makefile", "", "Makefile", domain.FileSource},
+		{".gitignore", "", "Ignore List", domain.FileConfiguration},
+		{"CMakeLists.txt", "", "CMake", domain.FileSource},
+		{"web/src/foo.test.ts", "", "TypeScript", domain.FileTest},
+		{"internal/api/server_test.go", "", "Go", domain.FileTest},
+		{"config/app.yaml", "", "YAML", domain.FileConfiguration},
+		{"bin/release", "#!/usr/bin/env -S python3.12 -u\n", "Python", domain.FileSource},
+		{"scripts/setup", "#!/bin/bash\nset -e\n", "Shell", domain.FileSource},
+		{"scripts/notes", "just text\n", "", domain.FileOther},
+		{"vendor/github.com/pkg/errors/errors.go", "", "Go", domain.FileVendored},
+		{"web/node_modules/react/index.js", "", "JavaScript", domain.FileVendored},
+		{"static/app.min.js", "", "JavaScript", domain.FileVendored},
+		{"package-lock.json", "", "JSON", domain.FileGenerated},
+		{"go.sum", "", "Go Checksums", domain.FileGenerated},
+		{"api/v1/service.pb.go", "", "Go", domain.FileGenerated},
+		{"docs/guide/install.sh", "", "Shell", domain.FileDocumentation},
+		{"README.md", "", "Markdown", domain.FileDocumentation},
+		{"LICENSE", "", "", domain.FileDocumentation},
+		{"CHANGELOG", "", "", domain.FileDocumentation},
+		{"assets/logo.png", "", "", domain.FileOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			cl := classifier{}.classify(tt.path, []byte(tt.head))
+			assert.Equal(t, tt.language, cl.language)
+			assert.Equal(t, tt.category, cl.category)
+		})
+	}
+}
+
+func TestStructure(t *testing.T) {
+	files := map[string]string{
+		".gitattributes": "# overrides\n" +
+			"third/** linguist-vendored\n" +
+			"*.tmpl linguist-language=Go\n" +
+			"vendor/** -linguist-vendored\n" +
+			"docs/**/*.go linguist-documentation=false\n",
+		"web/.gitattributes": "*.js linguist-generated\n",
+		"bin/run":            "#!/usr/bin/env node\nconsole.log(1)\n",
+	}
+	entries := []domain.TreeEntry{
+		{Path: ".gitattributes", Type: "blob", Size: 120},
+		{Path: "cmd", Type: "tree"},
+		{Path: "cmd/main.go", Type: "blob", Size: 600},
+		{Path: "cmd/main_test.go", Type: "blob", Size: 200},
+		{Path: "gen.tmpl", Type: "blob", Size: 100},
+		{Path: "third/lib.go", Type: "blob", Size: 5000},
+		{Path: "vendor/patched/patch.go", Type: "blob", Size: 50},
+		{Path: "docs/examples/demo.go", Type: "blob", Size: 50},
+		{Path: "web/.gitattributes", Type: "blob", Size: 20},
+		{Path: "web/bundle.js", Type: "blob", Size: 9000},
+		{Path: "bin/run", Type: "blob", Size: 1000},
+		{Path: "README.md", Type: "blob", Size: 300},
+	}
+	var reads []string
+	read := func(_ context.Context, p string) (string, error) {
+		reads = append(reads, p)
+		return files[p], nil
+	}
+
+	s, err := Structure(context.Background(), entries, read)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{".gitattributes", "web/.gitattributes", "bin/run"}, reads)
+	assert.Equal(t, 11, s.Files)
+	assert.Equal(t, []string{"cmd"}, s.Directories)
+	assert.Equal(t, map[domain.FileCategory]int{
+		domain.FileSource:        5,
+		domain.FileTest:          1,
+		domain.FileConfiguration: 2,
+		domain.FileVendored:      1,
+		domain.FileGenerated:     1,
+		domain.FileDocumentation: 1,
+	}, s.Categories)
+	assert.Equal(t, map[string]domain.LanguageShare{
+		"Go":         {Files: 5, Bytes: 1000, Percentage: 50},
+		"JavaScript": {Files: 1, Bytes: 1000, Percentage: 50},
+	}, s.Languages)
+	assert.Equal(t, "Go", s.PrimaryLanguage)
+
+	s, err = Structure(context.Background(), entries, nil)
+	require.NoError(t, err)
+	// Without .gitattributes third/ and web/ are source, vendor/ and docs/ are left out
+	assert.Equal(t, domain.LanguageShare{Files: 3, Bytes: 5800, Percentage: 39.2}, s.Languages["Go"])
+	assert.Equal(t, 2, s.Categories[domain.FileOther])
+}
+
+func TestGitPattern(t *testing.T) {
+	tests := []struct {
+		dir, pattern, path string
+		match              bool
+	}{
+		{"", "*.js", "a/b/c.js", true},
+		{"", "/*.js", "a/c.js", false},
+		{"", "src/*.js", "src/a.js", true},
+		{"", "src/*.js", "src/a/b.js", false},
+		{"", "src/**/*.js", "src/a/b.js", true},
+		{"", "src/**/*.js", "src/b.js", true},
+		{"", "gen/**", "gen/a/b.go", true},
+		{"web", "*.js", "web/x/y.js", true},
+		{"web", "*.js", "api/y.js", false},
+		{"", "file[0-9].txt", "file7.txt", true},
+		{"", "file[!0-9].txt", "file7.txt", false},
+	}
+	for _, tt := range tests {
+		re := gitPattern(tt.dir, tt.pattern)
+		require.NotNil(t, re, tt.pattern)
+		assert.Equal(t, tt.match, re.MatchString(tt.path), "%s %s", tt.pattern, tt.path)
+	}
+	assert.Nil(t, gitPattern("", "build/"))
+}
@@ -0,0 +1,149 @@
+package analyzers
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/versions"
+)
+
+// dependency is a package declared in a manifest
+type dependency struct {
+	name, version, packageManager string
+}
+
+// isManifest reports whether a file declares dependencies that are recorded
+// as library technologies
+func isManifest(p string) bool {
+	base := path.Base(p)
+	return base == "go.mod" || base == "package.json" ||
+		strings.HasPrefix(base, "requirements") && strings.HasSuffix(base, ".txt")
+}
+
+// parseManifest lists the dependencies of a manifest. Versions are kept only
+// when the manifest pins one; for npm ranges like "^18.2.0" that is the lower
+// bound, which is what an advisory check should assume.
+func parseManifest(p string, src []byte) []dependency {
+	switch base := path.Base(p); {
+	case base == "go.mod":
+		return parseGoMod(src)
+	case base == "package.json":
+		return parsePackageJSON(src)
+	default:
+		return parseRequirements(src)
+	}
+}
+
+func parseGoMod(src []byte) []dependency {
+	var deps []dependency
+	inRequire := false
+	for _, line := range strings.Split(string(src), "\n") {
+		line, _, _ = strings.Cut(line, "//")
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case inRequire && fields[0] == ")":
+			inRequire = false
+			continue
+		case fields[0] == "require" && len(fields) == 2 && fields[1] == "(":
+			inRequire = true
+			continue
+		case fields[0] == "require" && len(fields) == 3:
+			fields = fields[1:]
+		case !inRequire || len(fields) != 2:
+			continue
+		}
+		deps = append(deps, dependency{name: fields[0], version: strings.TrimPrefix(fields[1], "v"), packageManager: "go"})
+	}
+	return deps
+}
+
+func parsePackageJSON(src []byte) []dependency {
+	var manifest struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if err := json.Unmarshal(src, &manifest); err != nil {
+		return nil
+	}
+	var deps []dependency
+	for _, group := range []map[string]string{manifest.Dependencies, manifest.DevDependencies, manifest.OptionalDependencies} {
+		names := make([]string, 0, len(group))
+		for name := range group {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			version := strings.TrimLeft(strings.TrimSpace(group[name]), "^~=>v ")
+			if !versions.Valid(versions.EcosystemNPM, version) {
+				version = ""
+			}
+			deps = append(deps, dependency{name: name, version: version, packageManager: "npm"})
+		}
+	}
+	return deps
+}
+
+var requirementLine = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(\[[^\]]*\])?\s*(.*)$`)
+
+func parseRequirements(src []byte) []dependency {
+	var deps []dependency
+	for _, line := range strings.Split(string(src), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		line, _, _ = strings.Cut(line, ";")
+		line = strings.TrimSpace(line)
+		m := requirementLine.FindStringSubmatch(line)
+		if m == nil {
+			continue // blank lines, options like -r and URLs
+		}
+		version := ""
+		if spec, ok := strings.CutPrefix(m[3], "=="); ok && !strings.HasPrefix(spec, "=") {
+			spec, _, _ = strings.Cut(spec, ",")
+			if spec = strings.TrimSpace(spec); versions.Valid(versions.EcosystemPyPI, spec) {
+				version = spec
+			}
+		}
+		deps = append(deps, dependency{name: m[1], version: version, packageManager: "pip"})
+	}
+	return deps
+}
+
+// dependencySet keeps one entry per package; the shallowest manifest wins, so
+// the root go.mod beats one of an example module
+type dependencySet struct {
+	techs []domain.Technology
+	index map[[2]string]int
+	depth []int
+}
+
+func (s *dependencySet) add(manifest string, deps []dependency) {
+	if s.index == nil {
+		s.index = make(map[[2]string]int)
+	}
+	depth := strings.Count(manifest, "/")
+	for _, d := range deps {
+		tech := domain.Technology{
+			Name:           d.name,
+			Version:        domain.SQLNullString(d.version),
+			Type:           domain.TechnologyTypeLibrary,
+			PackageManager: domain.SQLNullString(d.packageManager),
+			Evidence:       domain.SQLNullString(manifest),
+		}
+		key := [2]string{d.packageManager, d.name}
+		if i, ok := s.index[key]; ok {
+			if depth < s.depth[i] {
+				s.techs[i], s.depth[i] = tech, depth
+			}
+			continue
+		}
+		s.index[key] = len(s.techs)
+		s.techs = append(s.techs, tech)
+		s.depth = append(s.depth, depth)
+	}
+}
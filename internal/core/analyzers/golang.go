@@ -0,0 +1,134 @@
+package analyzers
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+)
+
+// complexFunctionsKept is how many of the most complex functions are reported
+const complexFunctionsKept = 10
+
+// goMeter accumulates Go metrics file by file
+type goMeter struct {
+	fset        *token.FileSet
+	packages    map[string]bool // directories with Go code
+	functions   []domain.FunctionComplexity
+	parseErrors int
+}
+
+func newGoMeter() *goMeter {
+	return &goMeter{fset: token.NewFileSet(), packages: make(map[string]bool)}
+}
+
+func (g *goMeter) add(p string, src []byte) {
+	f, err := parser.ParseFile(g.fset, p, src, parser.SkipObjectResolution)
+	if err != nil {
+		g.parseErrors++
+		return
+	}
+	g.packages[path.Dir(p)] = true
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		g.functions = append(g.functions, domain.FunctionComplexity{
+			Name:       funcName(fn),
+			Path:       p,
+			Line:       g.fset.Position(fn.Pos()).Line,
+			Complexity: cyclomatic(fn.Body),
+		})
+	}
+}
+
+// result returns nil when the checkout has no Go code
+func (g *goMeter) result() *domain.GoMetrics {
+	if len(g.packages) == 0 && g.parseErrors == 0 {
+		return nil
+	}
+	m := &domain.GoMetrics{
+		Packages:         len(g.packages),
+		Functions:        len(g.functions),
+		ParseErrors:      g.parseErrors,
+		ComplexFunctions: []domain.FunctionComplexity{},
+	}
+	sort.Slice(g.functions, func(i, j int) bool {
+		a, b := g.functions[i], g.functions[j]
+		if a.Complexity != b.Complexity {
+			return a.Complexity > b.Complexity
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Line < b.Line
+	})
+	total := 0
+	for _, fn := range g.functions {
+		total += fn.Complexity
+	}
+	if len(g.functions) > 0 {
+		m.MaxComplexity = g.functions[0].Complexity
+		m.AverageComplexity = round(float64(total)/float64(len(g.functions)), 2)
+		m.ComplexFunctions = g.functions[:min(len(g.functions), complexFunctionsKept)]
+	}
+	return m
+}
+
+// cyclomatic counts the decision points of a function body plus one, as
+// gocyclo does: if, for, non-default case, && and ||. Function literals count
+// towards the enclosing function.
+func cyclomatic(body *ast.BlockStmt) int {
+	c := 1
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			c++
+		case *ast.CaseClause:
+			if n.List != nil {
+				c++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				c++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				c++
+			}
+		}
+		return true
+	})
+	return c
+}
+
+// funcName names methods Recv.Method, without pointer or type parameters
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	recv := fn.Recv.List[0].Type
+	for {
+		switch t := recv.(type) {
+		case *ast.StarExpr:
+			recv = t.X
+			continue
+		case *ast.ParenExpr:
+			recv = t.X
+			continue
+		case *ast.IndexExpr:
+			recv = t.X
+			continue
+		case *ast.IndexListExpr:
+			recv = t.X
+			continue
+		case *ast.Ident:
+			return t.Name + "." + fn.Name.Name
+		}
+		return fn.Name.Name
+	}
+}
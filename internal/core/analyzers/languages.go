@@ -0,0 +1,268 @@
+package analyzers
+
+import (
+	"path"
+	"strings"
+)
+
+// language is how lines of a language are counted
+type language struct {
+	name  string
+	line  []string    // line comment prefixes
+	block [][2]string // block comment delimiters
+}
+
+var (
+	cBlock    = [][2]string{{"/*", "*/"}}
+	hashLine  = []string{"#"}
+	slashLine = []string{"//"}
+)
+
+var languagesByExt = map[string]language{
+	".go":         {"Go", slashLine, cBlock},
+	".js":         {"JavaScript", slashLine, cBlock},
+	".mjs":        {"JavaScript", slashLine, cBlock},
+	".cjs":        {"JavaScript", slashLine, cBlock},
+	".jsx":        {"JavaScript", slashLine, cBlock},
+	".ts":         {"TypeScript", slashLine, cBlock},
+	".tsx":        {"TypeScript", slashLine, cBlock},
+	".py":         {"Python", hashLine, [][2]string{{`"""`, `"""`}, {"'''", "'''"}}},
+	".java":       {"Java", slashLine, cBlock},
+	".kt":         {"Kotlin", slashLine, cBlock},
+	".kts":        {"Kotlin", slashLine, cBlock},
+	".scala":      {"Scala", slashLine, cBlock},
+	".c":          {"C", slashLine, cBlock},
+	".h":          {"C", slashLine, cBlock},
+	".cc":         {"C++", slashLine, cBlock},
+	".cpp":        {"C++", slashLine, cBlock},
+	".cxx":        {"C++", slashLine, cBlock},
+	".hpp":        {"C++", slashLine, cBlock},
+	".hh":         {"C++", slashLine, cBlock},
+	".cs":         {"C#", slashLine, cBlock},
+	".rs":         {"Rust", slashLine, cBlock},
+	".swift":      {"Swift", slashLine, cBlock},
+	".php":        {"PHP", []string{"//", "#"}, cBlock},
+	".rb":         {"Ruby", hashLine, [][2]string{{"=begin", "=end"}}},
+	".sh":         {"Shell", hashLine, nil},
+	".bash":       {"Shell", hashLine, nil},
+	".zsh":        {"Shell", hashLine, nil},
+	".sql":        {"SQL", []string{"--"}, cBlock},
+	".html":       {"HTML", nil, [][2]string{{"<!--", "-->"}}},
+	".vue":        {"Vue", slashLine, [][2]string{{"<!--", "-->"}, {"/*", "*/"}}},
+	".css":        {"CSS", nil, cBlock},
+	".scss":       {"SCSS", slashLine, cBlock},
+	".yml":        {"YAML", hashLine, nil},
+	".yaml":       {"YAML", hashLine, nil},
+	".toml":       {"TOML", hashLine, nil},
+	".md":         {"Markdown", nil, nil},
+	".pl":         {"Perl", hashLine, nil},
+	".pm":         {"Perl", hashLine, nil},
+	".lua":        {"Lua", []string{"--"}, [][2]string{{"--[[", "]]"}}},
+	".dart":       {"Dart", slashLine, cBlock},
+	".ex":         {"Elixir", hashLine, nil},
+	".exs":        {"Elixir", hashLine, nil},
+	".erl":        {"Erlang", []string{"%"}, nil},
+	".hs":         {"Haskell", []string{"--"}, [][2]string{{"{-", "-}"}}},
+	".clj":        {"Clojure", []string{";"}, nil},
+	".r":          {"R", hashLine, nil},
+	".groovy":     {"Groovy", slashLine, cBlock},
+	".gradle":     {"Gradle", slashLine, cBlock},
+	".ps1":        {"PowerShell", hashLine, [][2]string{{"<#", "#>"}}},
+	".tf":         {"HCL", []string{"#", "//"}, cBlock},
+	".hcl":        {"HCL", []string{"#", "//"}, cBlock},
+	".proto":      {"Protocol Buffer", slashLine, cBlock},
+	".mk":         {"Makefile", hashLine, nil},
+	".cmake":      {"CMake", hashLine, nil},
+	".dockerfile": {"Dockerfile", hashLine, nil},
+	".less":       {"Less", slashLine, cBlock},
+	".svelte":     {"Svelte", slashLine, [][2]string{{"<!--", "-->"}, {"/*", "*/"}}},
+	".json":       {"JSON", nil, nil},
+	".xml":        {"XML", nil, [][2]string{{"<!--", "-->"}}},
+	".ini":        {"INI", []string{";", "#"}, nil},
+	".cfg":        {"INI", []string{";", "#"}, nil},
+	".rst":        {"reStructuredText", nil, nil},
+	".txt":        {"Text", nil, nil},
+}
+
+// languagesByName are files recognised by name, which takes precedence over
+// the extension: CMakeLists.txt is CMake, not text
+var languagesByName = map[string]language{
+	"Dockerfile":     {"Dockerfile", hashLine, nil},
+	"Containerfile":  {"Dockerfile", hashLine, nil},
+	"Makefile":       {"Makefile", hashLine, nil},
+	"makefile":       {"Makefile", hashLine, nil},
+	"GNUmakefile":    {"Makefile", hashLine, nil},
+	"CMakeLists.txt": {"CMake", hashLine, nil},
+	"Jenkinsfile":    {"Groovy", slashLine, cBlock},
+	"Gemfile":        {"Ruby", hashLine, nil},
+	"Rakefile":       {"Ruby", hashLine, nil},
+	"Vagrantfile":    {"Ruby", hashLine, nil},
+	"BUILD":          {"Starlark", hashLine, nil},
+	"BUILD.bazel":    {"Starlark", hashLine, nil},
+	"WORKSPACE":      {"Starlark", hashLine, nil},
+	"go.mod":         {"Go Module", slashLine, nil},
+	"go.sum":         {"Go Checksums", nil, nil},
+	".gitignore":     {"Ignore List", hashLine, nil},
+	".dockerignore":  {"Ignore List", hashLine, nil},
+	".gitattributes": {"Git Attributes", hashLine, nil},
+	".editorconfig":  {"EditorConfig", []string{"#", ";"}, nil},
+}
+
+// languagesByInterpreter recognise extensionless scripts by their shebang
+var languagesByInterpreter = map[string]language{
+	"sh":      languagesByExt[".sh"],
+	"bash":    languagesByExt[".sh"],
+	"zsh":     languagesByExt[".sh"],
+	"dash":    languagesByExt[".sh"],
+	"ksh":     languagesByExt[".sh"],
+	"python":  languagesByExt[".py"],
+	"node":    languagesByExt[".js"],
+	"deno":    languagesByExt[".ts"],
+	"ts-node": languagesByExt[".ts"],
+	"ruby":    languagesByExt[".rb"],
+	"perl":    languagesByExt[".pl"],
+	"php":     languagesByExt[".php"],
+	"lua":     languagesByExt[".lua"],
+	"Rscript": languagesByExt[".r"],
+	"pwsh":    languagesByExt[".ps1"],
+}
+
+// languageKind is the linguist type of a language. Only programming and
+// markup languages count towards the language statistics.
+type languageKind int
+
+const (
+	programming languageKind = iota
+	markup
+	data
+	prose
+)
+
+// languageKinds lists the languages that are not programming languages
+var languageKinds = map[string]languageKind{
+	"HTML": markup, "CSS": markup, "SCSS": markup, "Less": markup, "Vue": markup, "Svelte": markup,
+	"YAML": data, "TOML": data, "JSON": data, "XML": data, "INI": data, "Go Module": data,
+	"Go Checksums": data, "Ignore List": data, "Git Attributes": data, "EditorConfig": data,
+	"Markdown": prose, "reStructuredText": prose, "Text": prose,
+}
+
+// languageOf returns the language of a file, if it is one that is recognised
+// by name or extension
+func languageOf(p string) (language, bool) {
+	base := path.Base(p)
+	if l, ok := languagesByName[base]; ok {
+		return l, true
+	}
+	if strings.HasPrefix(base, "Dockerfile.") || strings.HasPrefix(base, "Containerfile.") {
+		return languagesByName["Dockerfile"], true
+	}
+	l, ok := languagesByExt[strings.ToLower(path.Ext(base))]
+	return l, ok
+}
+
+// languageOfShebang recognises a script by the interpreter on its first line:
+// "#!/bin/sh", "#!/usr/bin/env python3" or "#!/usr/bin/env -S node --flag"
+func languageOfShebang(src []byte) (language, bool) {
+	first, _, _ := strings.Cut(string(src[:min(len(src), 256)]), "\n")
+	rest, ok := strings.CutPrefix(first, "#!")
+	if !ok {
+		return language{}, false
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return language{}, false
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+				interpreter = path.Base(f)
+				break
+			}
+		}
+	}
+	// python3.12 is python
+	interpreter = strings.TrimRight(interpreter, "0123456789.")
+	l, ok := languagesByInterpreter[interpreter]
+	return l, ok
+}
+
+type lineCounts struct {
+	code, comment, blank int
+}
+
+// countLines classifies each line as code, comment or blank. A line holding
+// both code and a comment is code. Comment markers inside string literals are
+// not recognised, which is close enough for metrics.
+func countLines(src []byte, lang language) lineCounts {
+	var c lineCounts
+	if len(src) == 0 {
+		return c
+	}
+	var closing string // end delimiter of the open block comment
+	// A trailing newline does not start another line
+	for _, raw := range strings.Split(strings.TrimSuffix(string(src), "\n"), "\n") {
+		line := strings.TrimSpace(raw)
+		switch {
+		case closing != "":
+			c.comment++
+			if strings.Contains(line, closing) {
+				closing = ""
+			}
+		case line == "":
+			c.blank++
+		case hasAnyPrefix(line, lang.line):
+			c.comment++
+		default:
+			if open, end, ok := blockStart(line, lang.block); ok {
+				c.comment++
+				if !strings.Contains(line[len(open):], end) {
+					closing = end
+				}
+				continue
+			}
+			c.code++
+		}
+	}
+	return c
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func blockStart(line string, blocks [][2]string) (string, string, bool) {
+	for _, b := range blocks {
+		if strings.HasPrefix(line, b[0]) {
+			return b[0], b[1], true
+		}
+	}
+	return "", "", false
+}
+
+// isTestFile recognises test files by the conventions of the common languages
+func isTestFile(p string) bool {
+	base := path.Base(p)
+	switch {
+	case strings.HasSuffix(base, "_test.go"),
+		strings.Contains(base, ".test."), strings.Contains(base, ".spec."),
+		strings.HasPrefix(base, "test_") && strings.HasSuffix(base, ".py"),
+		strings.HasSuffix(base, "_test.py"),
+		strings.HasSuffix(base, "Test.java"), strings.HasSuffix(base, "Tests.java"),
+		strings.HasSuffix(base, "_spec.rb"):
+		return true
+	}
+	for _, dir := range strings.Split(path.Dir(p), "/") {
+		switch dir {
+		case "test", "tests", "__tests__", "spec":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,263 @@
+package analyzers
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/licenses"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed licenses.yaml
+var licenseTemplatesYAML []byte
+
+// licenseTemplates are tokenized once; the embedded file is checked by the tests
+var licenseTemplates = mustLoadLicenseTemplates(licenseTemplatesYAML)
+
+// licenseThreshold is the similarity a file needs to a license text to match it
+const licenseThreshold = 0.8
+
+// licenseTemplate is one entry of licenses.yaml
+type licenseTemplate struct {
+	ID      string `yaml:"id"`
+	Excerpt bool   `yaml:"excerpt"`
+	Text    string `yaml:"text"`
+
+	bigrams map[string]struct{}
+}
+
+func mustLoadLicenseTemplates(src []byte) []licenseTemplate {
+	var templates []licenseTemplate
+	if err := yaml.Unmarshal(src, &templates); err != nil {
+		panic(fmt.Errorf("license templates: %w", err))
+	}
+	for i := range templates {
+		t := &templates[i]
+		if licenses.KindOf(t.ID) == licenses.KindUnknown {
+			panic(fmt.Errorf("license template %d: unknown license %q", i, t.ID))
+		}
+		t.bigrams = wordBigrams(t.Text)
+	}
+	return templates
+}
+
+var (
+	// licenseFileName matches LICENSE, LICENSE.md, LICENSE-MIT, COPYING.LESSER and the like
+	licenseFileName = regexp.MustCompile(`(?i)^(licen[cs]e|copying|unlicense)([-.][a-z0-9-]+)?(\.(md|markdown|txt|rst))?$`)
+	// copyrightLine matches the notices that differ between copies of a license
+	copyrightLine  = regexp.MustCompile(`(?im)^[\s#*/]*(copyright\s*(\(c\)|©|[0-9])|\(c\)\s*[0-9]|©).*$`)
+	spdxIdentifier = regexp.MustCompile(`(?m)SPDX-License-Identifier:\s*(.+?)\s*(\*/|-->)?\s*$`)
+	licenseWord    = regexp.MustCompile(`[a-z0-9]+`)
+)
+
+// sourceExtensions rule out files named like a license, e.g. license.go
+var sourceExtensions = map[string]bool{
+	".go": true, ".js": true, ".ts": true, ".py": true, ".rb": true, ".rs": true, ".java": true, ".php": true,
+	".json": true, ".yaml": true, ".yml": true, ".html": true,
+}
+
+// wordBigrams is the set of consecutive word pairs of a text, ignoring case,
+// punctuation, layout and copyright lines
+func wordBigrams(text string) map[string]struct{} {
+	words := licenseWord.FindAllString(strings.ToLower(copyrightLine.ReplaceAllString(text, "")), -1)
+	set := make(map[string]struct{}, len(words))
+	for i := 1; i < len(words); i++ {
+		set[words[i-1]+" "+words[i]] = struct{}{}
+	}
+	return set
+}
+
+// matchLicenseText finds the license a file is a copy of. Full texts are
+// compared by Dice similarity of their word bigrams, excerpts by how much of
+// them the file contains; the best score over the threshold wins.
+func matchLicenseText(src []byte) (string, float64) {
+	file := wordBigrams(string(src))
+	best, bestScore := "", 0.0
+	for _, t := range licenseTemplates {
+		common := 0
+		for b := range t.bigrams {
+			if _, ok := file[b]; ok {
+				common++
+			}
+		}
+		var score float64
+		if t.Excerpt {
+			score = float64(common) / float64(len(t.bigrams))
+		} else {
+			score = 2 * float64(common) / float64(len(t.bigrams)+len(file))
+		}
+		if score > bestScore {
+			best, bestScore = t.ID, score
+		}
+	}
+	if bestScore < licenseThreshold {
+		return "", bestScore
+	}
+	return best, math.Round(bestScore*100) / 100
+}
+
+// DetectLicenses finds the licenses a checkout is distributed under, from
+// the license files and the license fields of the manifests at its root.
+// RepositoryID and CreatedAt are left to the caller.
+func DetectLicenses(fsys fs.FS) ([]domain.RepositoryLicense, error) {
+	found, err := licenseFiles(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	for _, manifest := range []string{"package.json", "composer.json", "Cargo.toml", "pyproject.toml"} {
+		if id := manifestLicense(fsys, manifest); id != "" {
+			found = append(found, domain.RepositoryLicense{SPDXID: id, Source: manifest, Method: domain.LicenseMethodManifest, Confidence: 1})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Source < found[j].Source })
+	return found, nil
+}
+
+// licenseFiles matches the license files of a directory. A GPL text next to
+// the LGPL of the same version is part of the LGPL, which only adds
+// permissions to it, and is dropped.
+func licenseFiles(fsys fs.FS, dir string) ([]domain.RepositoryLicense, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	found := []domain.RepositoryLicense{}
+	ids := make(map[string]bool)
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !licenseFileName.MatchString(e.Name()) || sourceExtensions[strings.ToLower(path.Ext(e.Name()))] {
+			continue
+		}
+		p := path.Join(dir, e.Name())
+		if info, err := e.Info(); err != nil || info.Size() > maxMeasuredBytes {
+			continue
+		}
+		src, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+		l := domain.RepositoryLicense{Source: p, Method: domain.LicenseMethodIdentifier, Confidence: 1}
+		if m := spdxIdentifier.FindSubmatch(src); m != nil {
+			l.SPDXID = licenses.NormalizeExpression(string(m[1]))
+		} else if id, score := matchLicenseText(src); id != "" {
+			l.SPDXID, l.Method, l.Confidence = id, domain.LicenseMethodText, score
+		} else {
+			continue
+		}
+		found = append(found, l)
+		ids[l.SPDXID] = true
+	}
+
+	kept := found[:0]
+	for _, l := range found {
+		if l.Method == domain.LicenseMethodText && (l.SPDXID == "GPL-3.0" && ids["LGPL-3.0"] || l.SPDXID == "GPL-2.0" && ids["LGPL-2.1"]) {
+			continue
+		}
+		kept = append(kept, l)
+	}
+	return kept, nil
+}
+
+var (
+	tomlLicense     = regexp.MustCompile(`(?m)^license\s*=\s*"([^"]+)"`)
+	tomlLicenseText = regexp.MustCompile(`(?m)^license\s*=\s*\{\s*text\s*=\s*"([^"]+)"`)
+)
+
+// manifestLicense reads the license field of a package.json, composer.json,
+// Cargo.toml or pyproject.toml as an SPDX expression, "" when there is none
+func manifestLicense(fsys fs.FS, p string) string {
+	src, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return ""
+	}
+	var names []string
+	switch path.Base(p) {
+	case "package.json":
+		var manifest struct {
+			License  json.RawMessage `json:"license"`
+			Licenses []struct {
+				Type string `json:"type"`
+			} `json:"licenses"`
+		}
+		if json.Unmarshal(src, &manifest) != nil {
+			return ""
+		}
+		var obj struct {
+			Type string `json:"type"`
+		}
+		var s string
+		if json.Unmarshal(manifest.License, &s) == nil {
+			names = append(names, s)
+		} else if json.Unmarshal(manifest.License, &obj) == nil {
+			names = append(names, obj.Type)
+		}
+		for _, l := range manifest.Licenses {
+			names = append(names, l.Type)
+		}
+	case "composer.json":
+		var manifest struct {
+			License json.RawMessage `json:"license"`
+		}
+		if json.Unmarshal(src, &manifest) != nil {
+			return ""
+		}
+		var s string
+		if json.Unmarshal(manifest.License, &s) == nil {
+			names = append(names, s)
+		} else {
+			_ = json.Unmarshal(manifest.License, &names) // a list is a choice
+		}
+	default:
+		if m := tomlLicense.FindSubmatch(src); m != nil {
+			names = append(names, string(m[1]))
+		} else if m := tomlLicenseText.FindSubmatch(src); m != nil && len(m[1]) < 100 {
+			names = append(names, string(m[1]))
+		}
+	}
+
+	var ids []string
+	for _, name := range names {
+		// "SEE LICENSE IN <file>" and "UNLICENSED" point away from SPDX
+		if name = strings.TrimSpace(name); name != "" && !strings.HasPrefix(strings.ToUpper(name), "SEE ") && !strings.EqualFold(name, "UNLICENSED") {
+			ids = append(ids, licenses.NormalizeExpression(name))
+		}
+	}
+	return licenses.Expression(ids)
+}
+
+// vendoredLicenses fills in the license of the libraries whose copy is in
+// the checkout: Go modules under vendor/ and npm packages under node_modules/
+func vendoredLicenses(fsys fs.FS, techs []domain.Technology) {
+	for i := range techs {
+		t := &techs[i]
+		var dir string
+		switch t.PackageManager.String {
+		case "go":
+			dir = path.Join("vendor", t.Name)
+		case "npm":
+			dir = path.Join("node_modules", t.Name)
+		default:
+			continue
+		}
+		id := manifestLicense(fsys, path.Join(dir, "package.json"))
+		if id == "" {
+			found, err := licenseFiles(fsys, dir)
+			if err != nil {
+				continue // not vendored
+			}
+			var ids []string
+			for _, l := range found {
+				ids = append(ids, l.SPDXID)
+			}
+			id = licenses.Expression(ids)
+		}
+		t.License = domain.SQLNullString(id)
+	}
+}
@@ -0,0 +1,139 @@
+package analyzers
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// templateText is the text of the first template for a license
+func templateText(t *testing.T, id string) string {
+	t.Helper()
+	for _, tmpl := range licenseTemplates {
+		if tmpl.ID == id {
+			return tmpl.Text
+		}
+	}
+	t.Fatalf("no template for %s", id)
+	return ""
+}
+
+const mitLicense = `MIT License
+
+Copyright (c) 2021-2024 Acme Corp.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this
+software and associated documentation files (the “Software”), to deal in the Software
+without restriction, including without limitation the rights to use, copy, modify, merge,
+publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons
+to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or
+substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+DEALINGS IN THE SOFTWARE.
+`
+
+func TestMatchLicenseText(t *testing.T) {
+	gpl3 := "Copyright (C) 2007 Free Software Foundation, Inc. <https://fsf.org/>\n" + templateText(t, "GPL-3.0") +
+		"\nTERMS AND CONDITIONS\n\n0. Definitions.\n\n\"This License\" refers to version 3 of the GNU General Public License.\n"
+
+	for _, tt := range []struct {
+		name, text, want string
+	}{
+		{"MIT rewrapped", mitLicense, "MIT"},
+		{"BSD-3-Clause", "Copyright 2020 The Authors\n\n" + templateText(t, "BSD-3-Clause"), "BSD-3-Clause"},
+		{"BSD-2-Clause", templateText(t, "BSD-2-Clause"), "BSD-2-Clause"},
+		{"ISC", "ISC License\n\nCopyright (c) 2019, Someone\n\n" + templateText(t, "ISC"), "ISC"},
+		{"0BSD", templateText(t, "0BSD"), "0BSD"},
+		{"GPL-3.0", gpl3, "GPL-3.0"},
+		{"AGPL-3.0", templateText(t, "AGPL-3.0") + "\nTERMS AND CONDITIONS\n", "AGPL-3.0"},
+		{"proprietary", "Copyright (c) 2024 Acme Corp. All rights reserved.\nDo not redistribute.\n", ""},
+		{"MIT with a modified warranty", strings.Split(mitLicense, "THE SOFTWARE")[0], ""},
+	} {
+		got, score := matchLicenseText([]byte(tt.text))
+		assert.Equal(t, tt.want, got, "%s (%.2f)", tt.name, score)
+	}
+}
+
+func TestDetectLicenses(t *testing.T) {
+	apache := templateText(t, "Apache-2.0") + "\n2. Grant of Copyright License. Subject to the terms and conditions of this License...\n"
+
+	t.Run("dual licensed", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"LICENSE-MIT":    {Data: []byte(mitLicense)},
+			"LICENSE-APACHE": {Data: []byte(apache)},
+			"Cargo.toml":     {Data: []byte("[package]\nname = \"acme\"\nlicense = \"MIT/Apache-2.0\"\n")},
+			"README.md":      {Data: []byte(mitLicense)},
+			"docs/LICENSE":   {Data: []byte(templateText(t, "GPL-2.0"))},
+		}
+
+		found, err := DetectLicenses(fsys)
+
+		require.NoError(t, err)
+		assert.Equal(t, []domain.RepositoryLicense{
+			{SPDXID: "MIT OR Apache-2.0", Source: "Cargo.toml", Method: domain.LicenseMethodManifest, Confidence: 1},
+			{SPDXID: "Apache-2.0", Source: "LICENSE-APACHE", Method: domain.LicenseMethodText, Confidence: 1},
+			{SPDXID: "MIT", Source: "LICENSE-MIT", Method: domain.LicenseMethodText, Confidence: 0.99},
+		}, found)
+	})
+
+	t.Run("LGPL with the GPL it amends", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"COPYING":        {Data: []byte(templateText(t, "GPL-3.0"))},
+			"COPYING.LESSER": {Data: []byte(templateText(t, "LGPL-3.0"))},
+		}
+
+		found, err := DetectLicenses(fsys)
+
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, "LGPL-3.0", found[0].SPDXID)
+	})
+
+	t.Run("identifier and manifest field", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"LICENSE.txt":   {Data: []byte("SPDX-License-Identifier: gpl-2.0-or-later\n\nSee the COPYING file.\n")},
+			"package.json":  {Data: []byte(`{"name": "acme", "license": "SEE LICENSE IN LICENSE.txt"}`)},
+			"composer.json": {Data: []byte(`{"name": "acme/acme", "license": ["mit", "GPL-3.0-or-later"]}`)},
+		}
+
+		found, err := DetectLicenses(fsys)
+
+		require.NoError(t, err)
+		assert.Equal(t, []domain.RepositoryLicense{
+			{SPDXID: "GPL-2.0-or-later", Source: "LICENSE.txt", Method: domain.LicenseMethodIdentifier, Confidence: 1},
+			{SPDXID: "GPL-3.0-or-later OR MIT", Source: "composer.json", Method: domain.LicenseMethodManifest, Confidence: 1},
+		}, found)
+	})
+}
+
+func TestDetectTechnologies_VendoredLicenses(t *testing.T) {
+	fsys := fstest.MapFS{
+		"go.mod":                             {Data: []byte("module example.com/shop\n\nrequire github.com/acme/kit v1.2.0\n")},
+		"vendor/github.com/acme/kit/LICENSE": {Data: []byte(templateText(t, "BSD-3-Clause"))},
+		"vendor/modules.txt":                 {Data: []byte("# github.com/acme/kit v1.2.0\n")},
+		"package.json":                       {Data: []byte(`{"dependencies": {"left-pad": "1.3.0", "react": "^18.3.1"}}`)},
+		"node_modules/left-pad/package.json": {Data: []byte(`{"name": "left-pad", "license": "WTFPL"}`)},
+	}
+
+	techs, err := DetectTechnologies(fsys)
+	require.NoError(t, err)
+
+	got := make(map[string]string)
+	for _, tech := range techs {
+		if tech.Type == domain.TechnologyTypeLibrary {
+			got[tech.Name] = tech.License.String
+		}
+	}
+	assert.Equal(t, map[string]string{"github.com/acme/kit": "BSD-3-Clause", "left-pad": "WTFPL", "react": ""}, got)
+}
@@ -0,0 +1,136 @@
+// Package analyzers holds the deterministic analyses run on a repository
+// checkout. Unlike the AI analysis their results are reproducible, so they can
+// be compared across commits.
+package analyzers
+
+import (
+	"bytes"
+	"io/fs"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+)
+
+const (
+	// maxMeasuredBytes skips larger files, which are data or generated code
+	maxMeasuredBytes = 1 << 20
+	largestFilesKept = 10
+)
+
+// skippedDirs hold dependencies and build output rather than the project's own code
+var skippedDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "third_party": true,
+	"dist": true, "build": true, "target": true,
+}
+
+// Metrics measures the code and configuration files of a checkout, leaving
+// out vendored, generated and documentation files as Structure classifies
+// them: lines per language, comment and test ratios, the largest files and,
+// for Go, package count and cyclomatic complexity
+func Metrics(fsys fs.FS, commit string) (*domain.CodeMetrics, error) {
+	m := &domain.CodeMetrics{
+		Commit:       commit,
+		Languages:    make(map[string]domain.LanguageMetrics),
+		LargestFiles: []domain.FileMetric{},
+	}
+	c := rootClassifier(fsys)
+	goMeter := newGoMeter()
+	var testCode, sourceCode int
+
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != "." && (skippedDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		cl := c.classify(p, nil)
+		switch {
+		case cl.category == domain.FileOther && !c.needsHead(p),
+			cl.category == domain.FileVendored, cl.category == domain.FileGenerated, cl.category == domain.FileDocumentation,
+			!d.Type().IsRegular():
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > maxMeasuredBytes {
+			return nil
+		}
+		src, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		if bytes.IndexByte(src[:min(len(src), 8000)], 0) >= 0 {
+			return nil
+		}
+		if cl.category == domain.FileOther {
+			if cl = c.classify(p, src); cl.category == domain.FileOther {
+				return nil
+			}
+		}
+		// Comment syntax comes from detection; a linguist-language override only renames
+		lang, ok := languageOf(p)
+		if !ok {
+			lang, _ = languageOfShebang(src)
+		}
+		lang.name = cl.language
+
+		c := countLines(src, lang)
+		m.Files++
+		m.CodeLines += c.code
+		m.CommentLines += c.comment
+		m.BlankLines += c.blank
+		lm := m.Languages[lang.name]
+		lm.Files++
+		lm.CodeLines += c.code
+		lm.CommentLines += c.comment
+		lm.BlankLines += c.blank
+		m.Languages[lang.name] = lm
+		m.LargestFiles = append(m.LargestFiles, domain.FileMetric{Path: p, Lines: c.code + c.comment + c.blank, Bytes: info.Size()})
+
+		if isTestFile(p) {
+			m.TestFiles++
+			testCode += c.code
+		} else {
+			sourceCode += c.code
+			if lang.name == "Go" {
+				goMeter.add(p, src)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if total := m.CodeLines + m.CommentLines; total > 0 {
+		m.CommentRatio = round(float64(m.CommentLines)/float64(total), 3)
+	}
+	if sourceCode > 0 {
+		m.TestRatio = round(float64(testCode)/float64(sourceCode), 3)
+	}
+	sort.Slice(m.LargestFiles, func(i, j int) bool {
+		a, b := m.LargestFiles[i], m.LargestFiles[j]
+		if a.Lines != b.Lines {
+			return a.Lines > b.Lines
+		}
+		return a.Path < b.Path
+	})
+	if len(m.LargestFiles) > largestFilesKept {
+		m.LargestFiles = m.LargestFiles[:largestFilesKept]
+	}
+	m.Go = goMeter.result()
+	return m, nil
+}
+
+func round(x float64, digits int) float64 {
+	p := math.Pow(10, float64(digits))
+	return math.Round(x*p) / p
+}
@@ -0,0 +1,89 @@
+package analyzers
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const serverGo = `// Package server serves the API.
+package server
+
+/*
+Handlers are registered in routes.
+*/
+
+type Server struct{}
+
+func (s *Server) Route(method string, admin bool) string {
+	if method == "GET" && !admin {
+		return "read"
+	}
+	switch method {
+	case "POST", "PUT":
+		return "write"
+	default:
+		return "other"
+	}
+}
+
+func New() *Server { return &Server{} }
+`
+
+const serverTestGo = `package server
+
+import "testing"
+
+func TestRoute(t *testing.T) {
+	if New().Route("GET", false) != "read" {
+		t.Fatal("unexpected")
+	}
+}
+`
+
+func TestMetrics(t *testing.T) {
+	fsys := fstest.MapFS{
+		"server/server.go":      {Data: []byte(serverGo)},
+		"server/server_test.go": {Data: []byte(serverTestGo)},
+		"cmd/main.go":           {Data: []byte("package main\n\nfunc main() {}\n")},
+		"broken/broken.go":      {Data: []byte("package broken\n\nfunc {\n")},
+		"scripts/deploy.py":     {Data: []byte("\"\"\"Deploy\nthe service.\n\"\"\"\nimport os  # env\n\nprint(os.environ)\n")},
+		"Dockerfile":            {Data: []byte("# build\nFROM golang:1.25\n")},
+		"vendor/lib/lib.go":     {Data: []byte("package lib\n")},
+		".github/ci.yml":        {Data: []byte("on: push\n")},
+		"logo.png":              {Data: []byte("\x89PNG\x00")},
+	}
+
+	m, err := Metrics(fsys, "abc123")
+	require.NoError(t, err)
+
+	assert.Equal(t, "abc123", m.Commit)
+	assert.Equal(t, 6, m.Files)
+	assert.Equal(t, domain.LanguageMetrics{Files: 1, CodeLines: 2, CommentLines: 3, BlankLines: 1}, m.Languages["Python"])
+	assert.Equal(t, domain.LanguageMetrics{Files: 1, CodeLines: 1, CommentLines: 1}, m.Languages["Dockerfile"])
+	assert.Equal(t, domain.LanguageMetrics{Files: 4, CodeLines: 25, CommentLines: 4, BlankLines: 8}, m.Languages["Go"])
+	assert.Equal(t, 1, m.TestFiles)
+	// 7 lines of test code over 14 + 2 + 2 of Go, 2 of Python and 1 of Dockerfile
+	assert.Equal(t, 0.333, m.TestRatio)
+	assert.Equal(t, 0.222, m.CommentRatio)
+	assert.Equal(t, "server/server.go", m.LargestFiles[0].Path)
+	assert.Equal(t, 22, m.LargestFiles[0].Lines)
+
+	require.NotNil(t, m.Go)
+	assert.Equal(t, 2, m.Go.Packages)
+	assert.Equal(t, 3, m.Go.Functions)
+	assert.Equal(t, 1, m.Go.ParseErrors)
+	assert.Equal(t, 4, m.Go.MaxComplexity)
+	assert.Equal(t, domain.FunctionComplexity{Name: "Server.Route", Path: "server/server.go", Line: 10, Complexity: 4}, m.Go.ComplexFunctions[0])
+	assert.Equal(t, 2.0, m.Go.AverageComplexity)
+}
+
+func TestMetrics_NoGo(t *testing.T) {
+	m, err := Metrics(fstest.MapFS{"index.js": {Data: []byte("console.log(1)\n")}}, "")
+	require.NoError(t, err)
+	assert.Nil(t, m.Go)
+	assert.Equal(t, 0.0, m.TestRatio)
+}
@@ -0,0 +1,265 @@
+package analyzers
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed technologies.yaml
+var technologyRulesYAML []byte
+
+// technologyRules are compiled once; the embedded file is checked by the tests
+var technologyRules = mustLoadTechnologyRules(technologyRulesYAML)
+
+// technologyRule is one entry of technologies.yaml
+type technologyRule struct {
+	Name           string                `yaml:"name"`
+	Type           domain.TechnologyType `yaml:"type"`
+	PackageManager string                `yaml:"packageManager"`
+	Files          []string              `yaml:"files"`
+	Match          []string              `yaml:"match"`
+	Version        *struct {
+		Files []string `yaml:"files"`
+		Match string   `yaml:"match"`
+	} `yaml:"version"`
+
+	files        []*regexp.Regexp
+	match        []*regexp.Regexp
+	versionFiles []*regexp.Regexp
+	versionMatch *regexp.Regexp
+}
+
+func mustLoadTechnologyRules(src []byte) []technologyRule {
+	rules, err := loadTechnologyRules(src)
+	if err != nil {
+		panic(err)
+	}
+	return rules
+}
+
+func loadTechnologyRules(src []byte) ([]technologyRule, error) {
+	var rules []technologyRule
+	if err := yaml.Unmarshal(src, &rules); err != nil {
+		return nil, fmt.Errorf("technology rules: %w", err)
+	}
+	for i := range rules {
+		r := &rules[i]
+		switch r.Type {
+		case domain.TechnologyTypeLanguage, domain.TechnologyTypeFramework, domain.TechnologyTypeLibrary,
+			domain.TechnologyTypeTool, domain.TechnologyTypeDatabase, domain.TechnologyTypePlatform:
+		default:
+			return nil, fmt.Errorf("technology rule %q: unknown type %q", r.Name, r.Type)
+		}
+		if r.Name == "" || len(r.Files) == 0 {
+			return nil, fmt.Errorf("technology rule %d: name and files are required", i)
+		}
+		var err error
+		if r.files, err = compilePatterns(r.Files); err != nil {
+			return nil, fmt.Errorf("technology rule %q: %w", r.Name, err)
+		}
+		for _, expr := range r.Match {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("technology rule %q: %w", r.Name, err)
+			}
+			r.match = append(r.match, re)
+		}
+		if r.Version == nil {
+			continue
+		}
+		if r.versionFiles, err = compilePatterns(r.Version.Files); err != nil {
+			return nil, fmt.Errorf("technology rule %q: %w", r.Name, err)
+		}
+		if r.versionMatch, err = regexp.Compile(r.Version.Match); err != nil {
+			return nil, fmt.Errorf("technology rule %q: %w", r.Name, err)
+		}
+		if r.versionMatch.SubexpIndex("version") < 0 {
+			return nil, fmt.Errorf("technology rule %q: version match has no version group", r.Name)
+		}
+	}
+	return rules, nil
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, p := range patterns {
+		re := gitPattern("", p)
+		if re == nil {
+			return nil, fmt.Errorf("invalid file pattern %q", p)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+func matchesAny(patterns []*regexp.Regexp, p string) bool {
+	for _, re := range patterns {
+		if re.MatchString(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// detection is what a rule found in a checkout
+type detection struct {
+	evidence, version string
+}
+
+// versionFound is a version read from a manifest; the shallowest file wins,
+// so the root package.json beats one of an example app
+type versionFound struct {
+	version string
+	depth   int
+}
+
+// DetectTechnologies applies the technology rules to a checkout. It returns
+// one Technology per detected rule, in rule order, with the evidence path and
+// the version when a manifest pins one, followed by the libraries declared in
+// go.mod, package.json and requirements files, with their license when a
+// vendored copy declares it. RepositoryID and CreatedAt are left to the caller.
+func DetectTechnologies(fsys fs.FS) ([]domain.Technology, error) {
+	return detectTechnologies(fsys, technologyRules)
+}
+
+func detectTechnologies(fsys fs.FS, rules []technologyRule) ([]domain.Technology, error) {
+	c := rootClassifier(fsys)
+	found := make([]*detection, len(rules))
+	versions := make([]*versionFound, len(rules))
+	var deps dependencySet
+
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != "." && (d.Name() == ".git" || skippedDir(c, p)) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		switch c.classify(p, nil).category {
+		case domain.FileVendored, domain.FileGenerated, domain.FileDocumentation:
+			return nil
+		}
+
+		var content []byte
+		loaded := false
+		load := func() ([]byte, error) {
+			if loaded {
+				return content, nil
+			}
+			loaded = true
+			info, err := d.Info()
+			if err != nil || info.Size() > maxMeasuredBytes {
+				return nil, err
+			}
+			src, err := fs.ReadFile(fsys, p)
+			if err != nil {
+				return nil, err
+			}
+			if bytes.IndexByte(src[:min(len(src), 8000)], 0) < 0 {
+				content = src
+			}
+			return content, nil
+		}
+
+		if isManifest(p) {
+			src, err := load()
+			if err != nil {
+				return err
+			}
+			deps.add(p, parseManifest(p, src))
+		}
+
+		depth := strings.Count(p, "/")
+		for i := range rules {
+			r := &rules[i]
+			if found[i] == nil && matchesAny(r.files, p) {
+				var src []byte
+				if len(r.match) > 0 {
+					if src, err = load(); err != nil {
+						return err
+					}
+				}
+				if det, ok := r.detect(p, src); ok {
+					found[i] = det
+				}
+			}
+			if r.versionMatch != nil && (versions[i] == nil || depth < versions[i].depth) && matchesAny(r.versionFiles, p) {
+				src, err := load()
+				if err != nil {
+					return err
+				}
+				if m := r.versionMatch.FindSubmatch(src); m != nil {
+					versions[i] = &versionFound{version: string(m[r.versionMatch.SubexpIndex("version")]), depth: depth}
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	techs := []domain.Technology{}
+	for i, det := range found {
+		if det == nil {
+			continue
+		}
+		r := rules[i]
+		version := det.version
+		if version == "" && versions[i] != nil {
+			version = versions[i].version
+		}
+		techs = append(techs, domain.Technology{
+			Name:           r.Name,
+			Version:        domain.SQLNullString(version),
+			Type:           r.Type,
+			PackageManager: domain.SQLNullString(r.PackageManager),
+			Evidence:       domain.SQLNullString(det.evidence),
+		})
+	}
+	vendoredLicenses(fsys, deps.techs)
+	return append(techs, deps.techs...), nil
+}
+
+// detect checks a file whose path matched the rule against its content
+// expressions; src is nil for binary or oversized files, which only match
+// rules without expressions
+func (r *technologyRule) detect(p string, src []byte) (*detection, bool) {
+	d := &detection{evidence: p}
+	for _, re := range r.match {
+		if src == nil {
+			return nil, false
+		}
+		m := re.FindSubmatch(src)
+		if m == nil {
+			return nil, false
+		}
+		if i := re.SubexpIndex("version"); i >= 0 && d.version == "" {
+			d.version = string(m[i])
+		}
+	}
+	return d, true
+}
+
+// skippedDir reports whether a whole directory is vendored or generated, so
+// the walk need not enter it
+func skippedDir(c classifier, dir string) bool {
+	switch c.classify(dir+"/x", nil).category {
+	case domain.FileVendored, domain.FileGenerated:
+		return true
+	}
+	return false
+}
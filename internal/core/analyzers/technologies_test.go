@@ -0,0 +1,116 @@
+package analyzers
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTechnologyRules_Embedded(t *testing.T) {
+	rules, err := loadTechnologyRules(technologyRulesYAML)
+	require.NoError(t, err)
+	assert.NotEmpty(t, rules)
+}
+
+func TestLoadTechnologyRules_Invalid(t *testing.T) {
+	for name, src := range map[string]string{
+		"unknown type":     "- {name: X, type: service, files: [x]}",
+		"no files":         "- {name: X, type: tool}",
+		"bad expression":   "- {name: X, type: tool, files: [x], match: ['(']}",
+		"no version group": "- {name: X, type: tool, files: [x], version: {files: [y], match: 'v\\d'}}",
+	} {
+		_, err := loadTechnologyRules([]byte(src))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestDetectTechnologies(t *testing.T) {
+	fsys := fstest.MapFS{
+		"go.mod":                    {Data: []byte("module example.com/shop\n\nrequire (\n\tgithub.com/go-chi/chi/v5 v5.2.3\n)\n")},
+		"cmd/server/main.go":        {Data: []byte("package main\n\nimport \"github.com/go-chi/chi/v5\"\n\nvar _ = chi.NewRouter\n")},
+		"build/Dockerfile":          {Data: []byte("FROM golang:1.25\n")},
+		"docker-compose.yml":        {Data: []byte("services:\n  db:\n    image: postgres:16.2\n  cache:\n    image: \"redis\"\n")},
+		"deploy/k8s/api.yaml":       {Data: []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: api\n")},
+		"deploy/values.yaml":        {Data: []byte("replicas: 2\n")},
+		"infra/main.tf":             {Data: []byte("terraform {\n  required_providers {\n    aws = {\n      source = \"hashicorp/aws\"\n    }\n  }\n}\n")},
+		".github/workflows/ci.yml":  {Data: []byte("on: push\njobs:\n  test:\n    runs-on: ubuntu-latest\n")},
+		"web/package.json":          {Data: []byte("{\"dependencies\": {\"react\": \"^18.3.1\"}}\n")},
+		"web/src/App.tsx":           {Data: []byte("import React from 'react'\n")},
+		"web/examples/package.json": {Data: []byte("{\"dependencies\": {\"react\": \"^17.0.0\"}}\n")},
+		"admin/manage.py":           {Data: []byte("from django.core.management import execute_from_command_line\n")},
+		// Vendored and documentation files are not evidence
+		"vendor/github.com/gin-gonic/gin/gin.go": {Data: []byte("package gin\n\nimport \"github.com/gin-gonic/gin\"\n")},
+		"docs/flask_example.py":                  {Data: []byte("from flask import Flask\n")},
+	}
+
+	techs, err := DetectTechnologies(fsys)
+	require.NoError(t, err)
+
+	got := make(map[string]domain.Technology)
+	for _, tech := range techs {
+		got[tech.Name] = tech
+	}
+	assert.ElementsMatch(t, []string{
+		"Docker", "Docker Compose", "Kubernetes", "PostgreSQL", "Redis", "Terraform", "AWS",
+		"GitHub Actions", "chi", "React", "Django",
+		// Libraries from the manifests
+		"github.com/go-chi/chi/v5", "react",
+	}, keys(got))
+
+	assert.Equal(t, domain.Technology{
+		Name: "chi", Type: domain.TechnologyTypeFramework, Version: domain.SQLNullString("5.2.3"),
+		PackageManager: domain.SQLNullString("go"), Evidence: domain.SQLNullString("cmd/server/main.go"),
+	}, got["chi"])
+	assert.Equal(t, "16.2", got["PostgreSQL"].Version.String)
+	assert.Equal(t, "docker-compose.yml", got["PostgreSQL"].Evidence.String)
+	assert.False(t, got["Redis"].Version.Valid)
+	assert.Equal(t, "build/Dockerfile", got["Docker"].Evidence.String)
+	assert.Equal(t, "deploy/k8s/api.yaml", got["Kubernetes"].Evidence.String)
+	assert.Equal(t, "infra/main.tf", got["AWS"].Evidence.String)
+	assert.Equal(t, ".github/workflows/ci.yml", got["GitHub Actions"].Evidence.String)
+	// The shallowest package.json pins the version
+	assert.Equal(t, "18.3.1", got["React"].Version.String)
+	assert.Equal(t, "web/src/App.tsx", got["React"].Evidence.String)
+	assert.False(t, got["Django"].Version.Valid)
+	assert.Equal(t, domain.Technology{
+		Name: "react", Type: domain.TechnologyTypeLibrary, Version: domain.SQLNullString("18.3.1"),
+		PackageManager: domain.SQLNullString("npm"), Evidence: domain.SQLNullString("web/package.json"),
+	}, got["react"])
+}
+
+func TestParseManifest(t *testing.T) {
+	goMod := "module example.com/api\n\ngo 1.25\n\nrequire github.com/google/uuid v1.6.0\n\nrequire (\n" +
+		"\tgithub.com/jackc/pgx/v5 v5.8.0\n\tgolang.org/x/text v0.40.0 // indirect\n)\n\nreplace example.com/x => ../x\n"
+	assert.Equal(t, []dependency{
+		{"github.com/google/uuid", "1.6.0", "go"},
+		{"github.com/jackc/pgx/v5", "5.8.0", "go"},
+		{"golang.org/x/text", "0.40.0", "go"},
+	}, parseManifest("go.mod", []byte(goMod)))
+
+	pkg := `{"dependencies": {"react": "^18.2.0", "lodash": "4.17.21", "local": "file:../local"}, "devDependencies": {"vite": "latest"}}`
+	assert.Equal(t, []dependency{
+		{"local", "", "npm"},
+		{"lodash", "4.17.21", "npm"},
+		{"react", "18.2.0", "npm"},
+		{"vite", "", "npm"},
+	}, parseManifest("web/package.json", []byte(pkg)))
+
+	reqs := "# runtime\nDjango==4.2.8\nrequests[socks] >= 2.31\nurllib3==1.26.5 ; python_version < '3.10'\n-r base.txt\nnumpy===1.0\n"
+	assert.Equal(t, []dependency{
+		{"Django", "4.2.8", "pip"},
+		{"requests", "", "pip"},
+		{"urllib3", "1.26.5", "pip"},
+		{"numpy", "", "pip"},
+	}, parseManifest("requirements-prod.txt", []byte(reqs)))
+}
+
+func keys(m map[string]domain.Technology) []string {
+	var out []string
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package domain
+
+// Advisory is a security advisory in the OSV format
+// (https://ossf.github.io/osv-schema/), as published by GitHub, PyPA, the Go
+// vulnerability database and others. Only the fields used for matching and
+// reporting are kept.
+type Advisory struct {
+	ID        string             `json:"id"`
+	Aliases   []string           `json:"aliases,omitempty"`
+	Summary   string             `json:"summary,omitempty"`
+	Withdrawn string             `json:"withdrawn,omitempty"`
+	Severity  []AdvisorySeverity `json:"severity,omitempty"`
+	Affected  []AffectedPackage  `json:"affected"`
+	// DatabaseSpecific carries the GitHub severity label: LOW, MODERATE, HIGH or CRITICAL
+	DatabaseSpecific struct {
+		Severity string `json:"severity,omitempty"`
+	} `json:"database_specific"`
+}
+
+// AdvisorySeverity is a severity score, e.g. a CVSS v3 vector
+type AdvisorySeverity struct {
+	Type  string `json:"type"` // CVSS_V2, CVSS_V3, CVSS_V4
+	Score string `json:"score"`
+}
+
+// AffectedPackage lists the affected versions of one package
+type AffectedPackage struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges   []AffectedRange `json:"ranges,omitempty"`
+	Versions []string        `json:"versions,omitempty"`
+}
+
+// AffectedRange is a sequence of events over ordered versions: each
+// "introduced" starts an affected span and the next "fixed" or
+// "last_affected" ends it
+type AffectedRange struct {
+	Type   string       `json:"type"` // SEMVER, ECOSYSTEM or GIT
+	Events []RangeEvent `json:"events"`
+}
+
+// RangeEvent has exactly one field set
+type RangeEvent struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+// EcosystemOf maps the package manager of a Technology to its OSV ecosystem,
+// or "" when there is none
+func EcosystemOf(packageManager string) string {
+	switch packageManager {
+	case "go", "gomod":
+		return "Go"
+	case "npm", "yarn", "pnpm":
+		return "npm"
+	case "pip", "pipenv", "poetry", "pypi":
+		return "PyPI"
+	case "cargo":
+		return "crates.io"
+	case "maven", "gradle":
+		return "Maven"
+	case "gem", "bundler", "rubygems":
+		return "RubyGems"
+	case "composer":
+		return "Packagist"
+	case "nuget":
+		return "NuGet"
+	}
+	return ""
+}
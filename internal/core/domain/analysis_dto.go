@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 )
 
@@ -36,6 +38,9 @@ type RepositoryAnalysisResponse struct {
 		Description string `json:"description"`
 		Priority    string `json:"priority"`
 	} `json:"suggestions"`
+
+	// Usage is filled by the AI client, not the model; it is not cached because a cache hit costs nothing
+	Usage TokenUsage `json:"-"`
 }
 
 func (r *RepositoryAnalysisResponse) ToDomain(repoID int) (*Analysis, []Feature, []Technology, []Suggestion) {
@@ -95,3 +100,21 @@ func (r *RepositoryAnalysisResponse) ToDomain(repoID int) (*Analysis, []Feature,
 
 	return analysis, features, techs, suggestions
 }
+
+// AnalysisFingerprint identifies the inputs of an AI analysis: the analysed
+// commit, the prompt template and the model. Two analyses with the same
+// fingerprint are expected to produce equivalent responses.
+func AnalysisFingerprint(commitSHA, promptVersion, model string) string {
+	sum := sha256.Sum256([]byte(commitSHA + "|" + promptVersion + "|" + model))
+	return hex.EncodeToString(sum[:])
+}
+
+// AnalysisReport aggregates everything stored about a repository's analyses
+type AnalysisReport struct {
+	RepositoryID int          `json:"repositoryId"`
+	Analyses     []Analysis   `json:"analyses"`
+	Metrics      *CodeMetrics `json:"metrics,omitempty"` // from the latest metrics analysis
+	Features     []Feature    `json:"features"`
+	Technologies []Technology `json:"technologies"`
+	Suggestions  []Suggestion `json:"suggestions"`
+}
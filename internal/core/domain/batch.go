@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrTooManyRepositories is returned when a bulk request selects more than MaxBulkRepos repositories
+var ErrTooManyRepositories error = &Error{Kind: ErrValidation, Message: "too many repositories for a bulk request"}
+
+// ErrUnknownRepository is returned when a bulk request names a repository the user does not have
+var ErrUnknownRepository error = &Error{Kind: ErrValidation, Message: "unknown repository"}
+
+// ErrEmptyBatch is returned when a bulk request selects no repositories
+var ErrEmptyBatch error = &Error{Kind: ErrValidation, Message: "bulk request matches no repositories"}
+
+type BatchItemStatus string
+
+const (
+	BatchItemStatusQueued  BatchItemStatus = "queued"
+	BatchItemStatusRunning BatchItemStatus = "running"
+	BatchItemStatusDone    BatchItemStatus = "done"
+	BatchItemStatusFailed  BatchItemStatus = "failed"
+)
+
+// BulkAnalysisRequest selects repositories either explicitly by ID or by filter
+type BulkAnalysisRequest struct {
+	RepositoryIDs []int        `json:"repositoryIds"`
+	Filter        RepoFilter   `json:"filter"`
+	AnalysisType  AnalysisType `json:"analysisType"`
+	Force         bool         `json:"force"`
+}
+
+// RepoFilter matches repositories by primary language and/or topic (case-insensitive)
+type RepoFilter struct {
+	Language string `json:"language"`
+	Topic    string `json:"topic"`
+}
+
+// IsEmpty reports whether the filter has no criteria
+func (f RepoFilter) IsEmpty() bool {
+	return f.Language == "" && f.Topic == ""
+}
+
+// Matches reports whether repo satisfies every criterion of the filter
+func (f RepoFilter) Matches(repo Repository) bool {
+	if f.Language != "" && !strings.EqualFold(repo.Language.String, f.Language) {
+		return false
+	}
+	if f.Topic != "" {
+		for _, t := range repo.Topics {
+			if strings.EqualFold(t, f.Topic) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// AnalysisBatch represents the analysisBatches table
+type AnalysisBatch struct {
+	ID           uuid.UUID    `json:"id" db:"id"`
+	UserID       int          `json:"userId" db:"userId"`
+	AnalysisType AnalysisType `json:"analysisType" db:"analysisType"`
+	Force        bool         `json:"force" db:"force"`
+	Total        int          `json:"total" db:"total"`
+	CreatedAt    time.Time    `json:"createdAt" db:"createdAt"`
+}
+
+// AnalysisBatchItem represents the analysisBatchItems table: one repository of a batch
+type AnalysisBatchItem struct {
+	BatchID      uuid.UUID       `json:"batchId" db:"batchId"`
+	RepositoryID int             `json:"repositoryId" db:"repositoryId"`
+	Status       BatchItemStatus `json:"status" db:"status"`
+	AnalysisID   sql.NullInt32   `json:"analysisId" db:"analysisId"`
+	ErrorMessage sql.NullString  `json:"errorMessage" db:"errorMessage"`
+	UpdatedAt    time.Time       `json:"updatedAt" db:"updatedAt"`
+}
+
+// BatchProgress is the aggregate state of a batch
+type BatchProgress struct {
+	AnalysisBatch
+	Queued  int                 `json:"queued"`
+	Running int                 `json:"running"`
+	Done    int                 `json:"done"`
+	Failed  int                 `json:"failed"`
+	Items   []AnalysisBatchItem `json:"items"`
+}
+
+// NewBatchProgress tallies item statuses for a batch
+func NewBatchProgress(batch AnalysisBatch, items []AnalysisBatchItem) *BatchProgress {
+	p := &BatchProgress{AnalysisBatch: batch, Items: items}
+	for _, item := range items {
+		switch item.Status {
+		case BatchItemStatusQueued:
+			p.Queued++
+		case BatchItemStatusRunning:
+			p.Running++
+		case BatchItemStatusDone:
+			p.Done++
+		case BatchItemStatusFailed:
+			p.Failed++
+		}
+	}
+	return p
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Cache tags group cached entries so they can be invalidated together
+// after a sync or an analysis changes the underlying data.
+
+// WorkspaceCacheTag tags entries derived from all of a workspace's repositories (e.g. stats)
+func WorkspaceCacheTag(workspaceID int) string {
+	return fmt.Sprintf("workspace:%d", workspaceID)
+}
+
+// RepositoryCacheTag tags entries derived from a single stored repository
+func RepositoryCacheTag(repoID int) string {
+	return fmt.Sprintf("repo:%d", repoID)
+}
+
+// GitHubAccountCacheTag tags GitHub API responses for an account (user or
+// org). Logins are case-insensitive, so the tag is too.
+func GitHubAccountCacheTag(login string) string {
+	return "github:" + strings.ToLower(login)
+}
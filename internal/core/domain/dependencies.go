@@ -0,0 +1,61 @@
+package domain
+
+import "time"
+
+// DependencyReport shows, for every package the repositories of a workspace
+// declare, which repositories pin which version and how far each is behind
+// the newest one
+type DependencyReport struct {
+	WorkspaceID int            `json:"workspaceId"`
+	Packages    []PackageDrift `json:"packages"`
+	GeneratedAt time.Time      `json:"generatedAt"`
+}
+
+// Sources of PackageDrift.Latest
+const (
+	LatestSourceRegistry  = "registry"
+	LatestSourcePortfolio = "portfolio"
+)
+
+// PackageDrift is one package across the portfolio
+type PackageDrift struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	// Latest is the newest stable version in the registry snapshot or,
+	// without one, in the portfolio; LatestSource says which
+	Latest       string `json:"latest"`
+	LatestSource string `json:"latestSource"`
+	// Repositories counts those declaring the package, Outdated those on a
+	// version below Latest
+	Repositories int            `json:"repositories"`
+	Outdated     int            `json:"outdated"`
+	Versions     []VersionUsage `json:"versions"` // newest first
+	// Unpinned are repositories declaring the package without a version
+	Unpinned []RepositoryRef `json:"unpinned,omitempty"`
+}
+
+// VersionUsage lists the repositories on one version of a package
+type VersionUsage struct {
+	Version      string          `json:"version"`
+	Behind       VersionLag      `json:"behind"`
+	Repositories []RepositoryRef `json:"repositories"`
+}
+
+// VersionLag is the difference in the first release number that differs from
+// the latest version: 17.0.2 is 1 major behind 18.3.1
+type VersionLag struct {
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+	Patch int `json:"patch"`
+}
+
+// IsZero reports whether the version is not behind
+func (l VersionLag) IsZero() bool {
+	return l == VersionLag{}
+}
+
+// RepositoryRef identifies a repository in reports
+type RepositoryRef struct {
+	ID       int    `json:"id"`
+	FullName string `json:"fullName"`
+}
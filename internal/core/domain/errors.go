@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Error kinds. Adapters and services return errors matching one of these with
+// errors.Is; the HTTP layer maps each kind to a status code. Anything else is
+// treated as an internal error.
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrUnauthenticated     = errors.New("unauthenticated")
+	ErrForbidden           = errors.New("forbidden")
+	ErrConflict            = errors.New("conflict")
+	ErrValidation          = errors.New("validation failed")
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+	ErrRateLimited         = errors.New("rate limited")
+)
+
+// Error is a domain error of a given Kind. Message is safe to show to clients;
+// Err, the underlying cause, is not.
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+	// RetryAt is when a rate limited request may succeed again, zero if unknown
+	RetryAt time.Time
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap exposes both the kind and the cause to errors.Is and errors.As
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// NotFound reports that resource with the given identifier does not exist
+func NotFound(resource string, id any) error {
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf("%s %v not found", resource, id)}
+}
+
+// Unauthenticated reports missing, invalid or expired credentials
+func Unauthenticated(message string) error {
+	return &Error{Kind: ErrUnauthenticated, Message: message}
+}
+
+// Forbidden reports that the caller may not access the resource
+func Forbidden(message string) error {
+	return &Error{Kind: ErrForbidden, Message: message}
+}
+
+// Conflict reports that the request conflicts with the current state
+func Conflict(message string) error {
+	return &Error{Kind: ErrConflict, Message: message}
+}
+
+// Validation reports invalid input
+func Validation(format string, args ...any) error {
+	return &Error{Kind: ErrValidation, Message: fmt.Sprintf(format, args...)}
+}
+
+// UpstreamUnavailable reports that an external dependency (GitHub, the AI provider) failed
+func UpstreamUnavailable(service string, err error) error {
+	return &Error{Kind: ErrUpstreamUnavailable, Message: service + " is unavailable", Err: err}
+}
+
+// ErrAIBudgetExceeded is returned when the monthly AI spend limit has been reached
+var ErrAIBudgetExceeded error = &Error{Kind: ErrRateLimited, Message: "monthly AI budget exceeded"}
+
+// AIBudgetExceeded is ErrAIBudgetExceeded for a budget window that ends at retryAt
+func AIBudgetExceeded(retryAt time.Time) error {
+	return &Error{Kind: ErrAIBudgetExceeded, Message: "monthly AI budget exceeded", RetryAt: retryAt}
+}
@@ -0,0 +1,84 @@
+package domain
+
+import "time"
+
+// How a RepositoryLicense was found
+const (
+	// LicenseMethodText is a license file matched against the license texts
+	LicenseMethodText = "text"
+	// LicenseMethodIdentifier is an SPDX-License-Identifier line
+	LicenseMethodIdentifier = "identifier"
+	// LicenseMethodManifest is the license field of a package manifest
+	LicenseMethodManifest = "manifest"
+)
+
+// RepositoryLicense represents the repositoryLicenses table: a license the
+// static analysis found at the root of a checkout
+type RepositoryLicense struct {
+	ID           int    `json:"id" db:"id"`
+	RepositoryID int    `json:"repositoryId" db:"repositoryId"`
+	SPDXID       string `json:"spdxId" db:"spdxId"` // an SPDX identifier or expression
+	Source       string `json:"source" db:"source"` // the file it was found in
+	Method       string `json:"method" db:"method"`
+	// Confidence is the similarity to the license text; identifiers and
+	// manifest fields are certain
+	Confidence float64   `json:"confidence" db:"confidence"`
+	CreatedAt  time.Time `json:"createdAt" db:"createdAt"`
+}
+
+// LicenseCompatibility is the verdict on using a dependency in a project
+type LicenseCompatibility string
+
+const (
+	LicenseCompatible   LicenseCompatibility = "compatible"
+	LicenseIncompatible LicenseCompatibility = "incompatible"
+	LicenseUnknown      LicenseCompatibility = "unknown"
+)
+
+// Sources of DependencyLicense.License
+const (
+	DependencyLicenseVendored = "vendored"
+	DependencyLicenseRegistry = "registry"
+)
+
+// LicenseReport checks the licenses of a repository's dependencies against its own
+type LicenseReport struct {
+	RepositoryID int `json:"repositoryId"`
+	// License is the SPDX expression the repository is distributed under, ""
+	// when none was found
+	License      string              `json:"license"`
+	Licenses     []RepositoryLicense `json:"licenses"`
+	Dependencies []DependencyLicense `json:"dependencies"` // incompatible first
+	Incompatible int                 `json:"incompatible"`
+	GeneratedAt  time.Time           `json:"generatedAt"`
+}
+
+// DependencyLicense is the license of one declared library
+type DependencyLicense struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	Version   string `json:"version,omitempty"`
+	License   string `json:"license,omitempty"`
+	// LicenseSource is vendored or registry, empty when the license is unknown
+	LicenseSource string               `json:"licenseSource,omitempty"`
+	Compatibility LicenseCompatibility `json:"compatibility"`
+	Reason        string               `json:"reason,omitempty"`
+}
+
+// UnificationLicenseCheck tells whether repositories can be unified into one
+// without breaking their licenses
+type UnificationLicenseCheck struct {
+	Repositories []RepositoryLicenseRef `json:"repositories"`
+	Compatible   bool                   `json:"compatible"`
+	// License is what the unified repository has to be distributed under,
+	// when there is one
+	License  string   `json:"license,omitempty"`
+	Warnings []string `json:"warnings"`
+}
+
+// RepositoryLicenseRef is a repository with the license it is distributed under
+type RepositoryLicenseRef struct {
+	ID       int    `json:"id"`
+	FullName string `json:"fullName"`
+	License  string `json:"license"`
+}
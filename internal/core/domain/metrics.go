@@ -0,0 +1,50 @@
+package domain
+
+// CodeMetrics are deterministic measurements of a repository checkout, stored
+// as the result of a metrics analysis
+type CodeMetrics struct {
+	Commit       string                     `json:"commit"`
+	Files        int                        `json:"files"` // text files measured
+	CodeLines    int                        `json:"codeLines"`
+	CommentLines int                        `json:"commentLines"`
+	BlankLines   int                        `json:"blankLines"`
+	Languages    map[string]LanguageMetrics `json:"languages"`
+	// CommentRatio is comment lines over code and comment lines
+	CommentRatio float64 `json:"commentRatio"`
+	TestFiles    int     `json:"testFiles"`
+	// TestRatio is lines of test code over lines of non-test code
+	TestRatio    float64      `json:"testToSourceRatio"`
+	LargestFiles []FileMetric `json:"largestFiles"`
+	Go           *GoMetrics   `json:"go,omitempty"`
+}
+
+type LanguageMetrics struct {
+	Files        int `json:"files"`
+	CodeLines    int `json:"codeLines"`
+	CommentLines int `json:"commentLines"`
+	BlankLines   int `json:"blankLines"`
+}
+
+type FileMetric struct {
+	Path  string `json:"path"`
+	Lines int    `json:"lines"`
+	Bytes int64  `json:"bytes"`
+}
+
+// GoMetrics are measured on the Go syntax tree, test files excluded
+type GoMetrics struct {
+	Packages          int                  `json:"packages"`
+	Functions         int                  `json:"functions"`
+	AverageComplexity float64              `json:"averageComplexity"`
+	MaxComplexity     int                  `json:"maxComplexity"`
+	ComplexFunctions  []FunctionComplexity `json:"complexFunctions"` // most complex first
+	ParseErrors       int                  `json:"parseErrors"`
+}
+
+// FunctionComplexity is the cyclomatic complexity of a function or method
+type FunctionComplexity struct {
+	Name       string `json:"name"` // Recv.Method for methods
+	Path       string `json:"path"`
+	Line       int    `json:"line"`
+	Complexity int    `json:"complexity"`
+}
@@ -24,8 +24,19 @@ const (
 	AnalysisTypeQuality      AnalysisType = "quality"
 	AnalysisTypePatterns     AnalysisType = "patterns"
 	AnalysisTypeSuggestions  AnalysisType = "suggestions"
+	AnalysisTypeMetrics      AnalysisType = "metrics" // static code metrics, computed without the AI provider
 )
 
+// Valid reports whether t is a known analysis type
+func (t AnalysisType) Valid() bool {
+	switch t {
+	case AnalysisTypeArchitecture, AnalysisTypeFeatures, AnalysisTypeDependencies, AnalysisTypeQuality,
+		AnalysisTypePatterns, AnalysisTypeSuggestions, AnalysisTypeMetrics:
+		return true
+	}
+	return false
+}
+
 type AnalysisStatus string
 
 const (
@@ -103,18 +114,23 @@ type User struct {
 // Repository represents the repositories table
 type Repository struct {
 	ID            int            `json:"id" db:"id"`
-	UserID        int            `json:"userId" db:"userId"`
-	GithubID      string         `json:"githubId" db:"githubId"`
+	UserID        int            `json:"userId" db:"userId"` // the member who synced it
+	WorkspaceID   int            `json:"workspaceId" db:"workspaceId"`
+	Provider      SourceProvider `json:"provider" db:"provider"`
+	GithubID      string         `json:"githubId" db:"githubId"` // the repository's ID on its provider
+	InstallationID sql.NullInt64 `json:"installationId" db:"installationId"` // GitHub App installation it was synced through
 	Name          string         `json:"name" db:"name"`
 	FullName      string         `json:"fullName" db:"fullName"`
 	Description   sql.NullString `json:"description" db:"description"`
 	URL           string         `json:"url" db:"url"`
 	Language      sql.NullString `json:"language" db:"language"`
 	IsPrivate     bool           `json:"isPrivate" db:"isPrivate"`
+	IsArchived    bool           `json:"isArchived" db:"isArchived"`
 	Stars         int            `json:"stars" db:"stars"`
 	Forks         int            `json:"forks" db:"forks"`
 	Size          int            `json:"size" db:"size"`
 	DefaultBranch string         `json:"defaultBranch" db:"defaultBranch"`
+	Topics        []string       `json:"topics" db:"topics"`
 	LastCommitAt  sql.NullTime   `json:"lastCommitAt" db:"lastCommitAt"`
 	LastSyncAt    sql.NullTime   `json:"lastSyncAt" db:"lastSyncAt"`
 	CreatedAt     time.Time      `json:"createdAt" db:"createdAt"`
@@ -131,6 +147,8 @@ type Analysis struct {
 	Summary      sql.NullString `json:"summary" db:"summary"`
 	Score        sql.NullInt32  `json:"score" db:"score"`
 	ErrorMessage sql.NullString `json:"errorMessage" db:"errorMessage"`
+	Fingerprint  sql.NullString `json:"fingerprint" db:"fingerprint"`
+	CacheHit     bool           `json:"cacheHit" db:"cacheHit"`
 	CreatedAt    time.Time      `json:"createdAt" db:"createdAt"`
 	CompletedAt  sql.NullTime   `json:"completedAt" db:"completedAt"`
 }
@@ -181,7 +199,11 @@ type Technology struct {
 	Version        sql.NullString `json:"version" db:"version"`
 	Type           TechnologyType `json:"type" db:"type"`
 	PackageManager sql.NullString `json:"packageManager" db:"packageManager"`
-	CreatedAt      time.Time      `json:"createdAt" db:"createdAt"`
+	// Evidence is the file a static detection rule matched; AI analysis rows have none
+	Evidence sql.NullString `json:"evidence" db:"evidence"`
+	// License is the SPDX license of a library, when a vendored copy declares it
+	License   sql.NullString `json:"license" db:"license"`
+	CreatedAt time.Time      `json:"createdAt" db:"createdAt"`
 }
 
 // UnificationOperation represents the unificationOperations table
@@ -0,0 +1,25 @@
+package domain
+
+import "math"
+
+// RateLimit describes a token bucket: Rate tokens per second are added, up to Burst
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled reports whether the limit should be enforced
+func (l RateLimit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// TakeToken refills a bucket holding tokens, last refilled elapsedSeconds ago,
+// and tries to take one token. It returns the remaining tokens, whether the
+// request is allowed and, if not, how many seconds until a token is available.
+func (l RateLimit) TakeToken(tokens, elapsedSeconds float64) (remaining float64, allowed bool, waitSeconds float64) {
+	tokens = math.Min(float64(l.Burst), tokens+elapsedSeconds*l.Rate)
+	if tokens >= 1 {
+		return tokens - 1, true, 0
+	}
+	return tokens, false, (1 - tokens) / l.Rate
+}
@@ -0,0 +1,25 @@
+package domain
+
+// SBOMFormat is a software bill of materials format
+type SBOMFormat string
+
+const (
+	// SBOMFormatCycloneDX is CycloneDX 1.5 JSON
+	SBOMFormatCycloneDX SBOMFormat = "cyclonedx"
+	// SBOMFormatSPDX is SPDX 2.3 JSON
+	SBOMFormatSPDX SBOMFormat = "spdx"
+)
+
+// ContentType is the media type of a document in the format
+func (f SBOMFormat) ContentType() string {
+	if f == SBOMFormatSPDX {
+		return "application/spdx+json"
+	}
+	return "application/vnd.cyclonedx+json"
+}
+
+// SBOM is a rendered bill of materials
+type SBOM struct {
+	Format   SBOMFormat
+	Document []byte
+}
@@ -0,0 +1,66 @@
+package domain
+
+// SourceProvider is the code hosting service a repository is synced from
+type SourceProvider string
+
+const (
+	ProviderGitHub SourceProvider = "github"
+	ProviderGitLab SourceProvider = "gitlab"
+	ProviderGitea  SourceProvider = "gitea"
+	ProviderLocal  SourceProvider = "local" // clones or bare repositories on the server's filesystem
+)
+
+// ProviderOf returns the provider of a stored repository; rows written before
+// providers existed are GitHub repositories
+func ProviderOf(repo *Repository) SourceProvider {
+	if repo.Provider == "" {
+		return ProviderGitHub
+	}
+	return repo.Provider
+}
+
+// TreeEntry is a file or directory in a repository tree
+type TreeEntry struct {
+	Path string `json:"path"`
+	Type string `json:"type"` // "blob" or "tree"
+	Size int64  `json:"size"` // bytes, for blobs when the host reports it
+}
+
+// FileCategory is what a file is for, which decides whether it counts
+// towards the language statistics of a repository
+type FileCategory string
+
+const (
+	FileSource        FileCategory = "source"
+	FileTest          FileCategory = "test"
+	FileDocumentation FileCategory = "documentation"
+	FileConfiguration FileCategory = "configuration" // data formats: YAML, JSON, ignore lists, ...
+	FileVendored      FileCategory = "vendored"      // third-party code checked into the repository
+	FileGenerated     FileCategory = "generated"     // lockfiles, minified bundles, generated code
+	FileOther         FileCategory = "other"         // no recognised language, e.g. images
+)
+
+// RepositoryStructure is the classified file tree of a repository
+type RepositoryStructure struct {
+	Files       int                  `json:"files"`
+	Directories []string             `json:"directories"`
+	Categories  map[FileCategory]int `json:"categories"` // files per category
+	// Languages counts source and test files only, as GitHub's language bar does
+	Languages       map[string]LanguageShare `json:"languages"`
+	PrimaryLanguage string                   `json:"primaryLanguage,omitempty"`
+}
+
+// LanguageShare is how much of a repository is written in one language
+type LanguageShare struct {
+	Files      int     `json:"files"`
+	Bytes      int64   `json:"bytes"`
+	Percentage float64 `json:"percentage"` // of the bytes of all languages
+}
+
+// CloneSource is where git fetches a repository from
+type CloneSource struct {
+	URL string
+	// AuthHeader is sent as the HTTP Authorization header; empty for public
+	// and local repositories. It is a credential and must not be logged.
+	AuthHeader string
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+)
+
+// TokenScope limits what a personal access token may do
+type TokenScope string
+
+const (
+	ScopeReposRead       TokenScope = "repos:read"
+	ScopeReposWrite      TokenScope = "repos:write"
+	ScopeAnalysisRead    TokenScope = "analysis:read"
+	ScopeAnalysisWrite   TokenScope = "analysis:write"
+	ScopeWorkspacesRead  TokenScope = "workspaces:read"
+	ScopeWorkspacesWrite TokenScope = "workspaces:write"
+)
+
+// TokenScopes lists every scope a token can be granted
+var TokenScopes = []TokenScope{
+	ScopeReposRead, ScopeReposWrite,
+	ScopeAnalysisRead, ScopeAnalysisWrite,
+	ScopeWorkspacesRead, ScopeWorkspacesWrite,
+}
+
+func (s TokenScope) Valid() bool {
+	for _, scope := range TokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APITokenPrefix marks personal access tokens so they are recognizable in
+// logs and secret scanners
+const APITokenPrefix = "ghr_"
+
+// APIToken is a personal access token. Only the SHA-256 hash of the secret
+// is stored; Prefix keeps its first characters so users can tell tokens apart.
+type APIToken struct {
+	ID         int          `json:"id" db:"id"`
+	UserID     int          `json:"userId" db:"userId"`
+	Name       string       `json:"name" db:"name"`
+	Prefix     string       `json:"prefix" db:"prefix"`
+	TokenHash  string       `json:"-" db:"tokenHash"`
+	Scopes     []TokenScope `json:"scopes" db:"scopes"`
+	ExpiresAt  time.Time    `json:"expiresAt" db:"expiresAt"`
+	LastUsedAt sql.NullTime `json:"lastUsedAt" db:"lastUsedAt"`
+	CreatedAt  time.Time    `json:"createdAt" db:"createdAt"`
+}
+
+func (t *APIToken) HasScope(scope TokenScope) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *APIToken) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// CreatedAPIToken carries the plaintext secret, returned once at creation
+type CreatedAPIToken struct {
+	APIToken
+	Token string `json:"token"`
+}
@@ -0,0 +1,93 @@
+package domain
+
+import "time"
+
+// TokenUsage reports the tokens consumed by a single AI call
+type TokenUsage struct {
+	Model        string `json:"model"`
+	PromptTokens int    `json:"promptTokens"` // includes CachedTokens
+	OutputTokens int    `json:"outputTokens"`
+	CachedTokens int    `json:"cachedTokens"`
+}
+
+// BilledError is a failed AI call whose tokens were billed anyway, such as a
+// response that could not be parsed; Usage is what it cost
+type BilledError struct {
+	Usage TokenUsage
+	Err   error
+}
+
+func (e *BilledError) Error() string { return e.Err.Error() }
+func (e *BilledError) Unwrap() error { return e.Err }
+
+// ModelPricing holds USD prices per million tokens
+type ModelPricing struct {
+	InputPerMTok  float64
+	OutputPerMTok float64
+	CachedPerMTok float64
+}
+
+// Cost returns the USD cost of a call. Cached prompt tokens are billed at the cached rate.
+func (p ModelPricing) Cost(u TokenUsage) float64 {
+	uncached := u.PromptTokens - u.CachedTokens
+	if uncached < 0 {
+		uncached = 0
+	}
+	return (float64(uncached)*p.InputPerMTok +
+		float64(u.CachedTokens)*p.CachedPerMTok +
+		float64(u.OutputTokens)*p.OutputPerMTok) / 1e6
+}
+
+// AIUsage represents the aiUsage table: one row per billed AI call
+type AIUsage struct {
+	ID           int       `json:"id" db:"id"`
+	AnalysisID   int       `json:"analysisId" db:"analysisId"`
+	RepositoryID int       `json:"repositoryId" db:"repositoryId"`
+	UserID       int       `json:"userId" db:"userId"`
+	Model        string    `json:"model" db:"model"`
+	PromptTokens int       `json:"promptTokens" db:"promptTokens"`
+	OutputTokens int       `json:"outputTokens" db:"outputTokens"`
+	CachedTokens int       `json:"cachedTokens" db:"cachedTokens"`
+	CostUSD      float64   `json:"costUsd" db:"costUsd"`
+	CreatedAt    time.Time `json:"createdAt" db:"createdAt"`
+}
+
+// UsageTotals aggregates AI usage over a set of calls
+type UsageTotals struct {
+	Calls        int     `json:"calls"`
+	PromptTokens int     `json:"promptTokens"`
+	OutputTokens int     `json:"outputTokens"`
+	CachedTokens int     `json:"cachedTokens"`
+	CostUSD      float64 `json:"costUsd"`
+}
+
+// Add accumulates other into t
+func (t *UsageTotals) Add(other UsageTotals) {
+	t.Calls += other.Calls
+	t.PromptTokens += other.PromptTokens
+	t.OutputTokens += other.OutputTokens
+	t.CachedTokens += other.CachedTokens
+	t.CostUSD += other.CostUSD
+}
+
+// DailyUsage is the usage for one calendar day (UTC, formatted YYYY-MM-DD)
+type DailyUsage struct {
+	Date string `json:"date"`
+	UsageTotals
+}
+
+// RepositoryUsage is the usage attributed to one repository
+type RepositoryUsage struct {
+	RepositoryID int `json:"repositoryId"`
+	UsageTotals
+}
+
+// UsageReport is the AI usage of a user over a period, with the global monthly budget status
+type UsageReport struct {
+	Since              time.Time         `json:"since"`
+	Total              UsageTotals       `json:"total"`
+	Daily              []DailyUsage      `json:"daily"`
+	ByRepository       []RepositoryUsage `json:"byRepository"`
+	MonthlyBudgetUSD   float64           `json:"monthlyBudgetUsd"` // 0 means unlimited
+	MonthToDateCostUSD float64           `json:"monthToDateCostUsd"`
+}
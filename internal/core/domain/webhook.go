@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// WebhookDeliveryStatus is the outcome of processing a webhook delivery
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryReceived  WebhookDeliveryStatus = "received"
+	WebhookDeliveryProcessed WebhookDeliveryStatus = "processed"
+	WebhookDeliveryIgnored   WebhookDeliveryStatus = "ignored" // event or action without effect here
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is a GitHub webhook delivery, stored verbatim so it can be
+// deduplicated by ID and replayed
+type WebhookDelivery struct {
+	ID          string                `json:"id" db:"id"` // X-GitHub-Delivery
+	Event       string                `json:"event" db:"event"`
+	Action      string                `json:"action" db:"action"`
+	Payload     json.RawMessage       `json:"-" db:"payload"`
+	Status      WebhookDeliveryStatus `json:"status" db:"status"`
+	Error       sql.NullString        `json:"error" db:"error"`
+	ReceivedAt  time.Time             `json:"receivedAt" db:"receivedAt"`
+	ProcessedAt sql.NullTime          `json:"processedAt" db:"processedAt"`
+}
+
+// GitHubInstallation is an installation of the GitHub App on an account
+type GitHubInstallation struct {
+	ID           int64        `json:"id" db:"id"`
+	AccountLogin string       `json:"accountLogin" db:"accountLogin"`
+	AccountType  string       `json:"accountType" db:"accountType"` // User or Organization
+	SuspendedAt  sql.NullTime `json:"suspendedAt" db:"suspendedAt"`
+	CreatedAt    time.Time    `json:"createdAt" db:"createdAt"`
+	UpdatedAt    time.Time    `json:"updatedAt" db:"updatedAt"`
+}
+
+// WebhookPayload is the subset of GitHub webhook payloads acted upon
+type WebhookPayload struct {
+	Action       string               `json:"action"`
+	Ref          string               `json:"ref"`
+	Deleted      bool                 `json:"deleted"`
+	After        string               `json:"after"` // the head after a push
+	HeadCommit   *WebhookCommit       `json:"head_commit"`
+	Repository   *WebhookRepository   `json:"repository"`
+	Installation *WebhookInstallation `json:"installation"`
+	Changes      struct {
+		Owner struct {
+			From struct {
+				User         *WebhookAccount `json:"user"`
+				Organization *WebhookAccount `json:"organization"`
+			} `json:"from"`
+		} `json:"owner"`
+	} `json:"changes"`
+}
+
+type WebhookCommit struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type WebhookRepository struct {
+	ID            int64          `json:"id"`
+	Name          string         `json:"name"`
+	FullName      string         `json:"full_name"`
+	HTMLURL       string         `json:"html_url"`
+	Description   *string        `json:"description"`
+	Private       bool           `json:"private"`
+	Archived      bool           `json:"archived"`
+	DefaultBranch string         `json:"default_branch"`
+	Owner         WebhookAccount `json:"owner"`
+}
+
+type WebhookInstallation struct {
+	ID      int64          `json:"id"`
+	Account WebhookAccount `json:"account"`
+}
+
+type WebhookAccount struct {
+	Login string `json:"login"`
+	Type  string `json:"type"`
+}
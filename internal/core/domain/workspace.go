@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+)
+
+// WorkspaceRole is a member's role within a workspace
+type WorkspaceRole string
+
+const (
+	WorkspaceRoleOwner      WorkspaceRole = "owner"
+	WorkspaceRoleMaintainer WorkspaceRole = "maintainer"
+	WorkspaceRoleViewer     WorkspaceRole = "viewer"
+)
+
+var workspaceRoleRank = map[WorkspaceRole]int{
+	WorkspaceRoleViewer:     1,
+	WorkspaceRoleMaintainer: 2,
+	WorkspaceRoleOwner:      3,
+}
+
+func (r WorkspaceRole) Valid() bool {
+	return workspaceRoleRank[r] > 0
+}
+
+// Allows reports whether r grants at least the permissions of required.
+// Viewers read, maintainers also sync, analyze and delete, owners also manage members.
+func (r WorkspaceRole) Allows(required WorkspaceRole) bool {
+	return r.Valid() && workspaceRoleRank[r] >= workspaceRoleRank[required]
+}
+
+// Workspace groups repositories shared by its members. Every user has a
+// personal workspace, created on first use, which cannot be shared.
+type Workspace struct {
+	ID                int           `json:"id" db:"id"`
+	Name              string        `json:"name" db:"name"`
+	PersonalForUserID sql.NullInt32 `json:"personalForUserId" db:"personalForUserId"`
+	CreatedAt         time.Time     `json:"createdAt" db:"createdAt"`
+	UpdatedAt         time.Time     `json:"updatedAt" db:"updatedAt"`
+}
+
+func (w Workspace) IsPersonal() bool {
+	return w.PersonalForUserID.Valid
+}
+
+// WorkspaceMembership is a workspace as seen by one of its members
+type WorkspaceMembership struct {
+	Workspace
+	Role WorkspaceRole `json:"role" db:"role"`
+}
+
+// WorkspaceMember represents the workspaceMembers table
+type WorkspaceMember struct {
+	WorkspaceID    int            `json:"workspaceId" db:"workspaceId"`
+	UserID         int            `json:"userId" db:"userId"`
+	Role           WorkspaceRole  `json:"role" db:"role"`
+	GithubUsername sql.NullString `json:"githubUsername" db:"githubUsername"` // joined from users
+	CreatedAt      time.Time      `json:"createdAt" db:"createdAt"`
+}
+
+type InvitationStatus string
+
+const (
+	InvitationStatusPending  InvitationStatus = "pending"
+	InvitationStatusAccepted InvitationStatus = "accepted"
+)
+
+// WorkspaceInvitation invites a GitHub user to join a workspace with a role
+type WorkspaceInvitation struct {
+	ID             int              `json:"id" db:"id"`
+	WorkspaceID    int              `json:"workspaceId" db:"workspaceId"`
+	GithubUsername string           `json:"githubUsername" db:"githubUsername"` // stored lowercase
+	Role           WorkspaceRole    `json:"role" db:"role"`
+	InvitedBy      int              `json:"invitedBy" db:"invitedBy"`
+	Status         InvitationStatus `json:"status" db:"status"`
+	CreatedAt      time.Time        `json:"createdAt" db:"createdAt"`
+	AcceptedAt     sql.NullTime     `json:"acceptedAt" db:"acceptedAt"`
+}
@@ -0,0 +1,471 @@
+// Package licenses knows the common open source licenses by SPDX identifier:
+// how permissive they are and which can be combined in one work. It answers
+// the usual questions, such as whether a GPL library may ship in an MIT
+// project; it is not legal advice.
+package licenses
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Kind orders licenses by how much they require of the work they end up in
+type Kind int
+
+const (
+	KindUnknown Kind = iota
+	KindPermissive
+	// KindWeakCopyleft covers only the licensed files or library
+	KindWeakCopyleft
+	// KindStrongCopyleft covers the whole work it is combined into
+	KindStrongCopyleft
+	// KindNetworkCopyleft also covers use over a network
+	KindNetworkCopyleft
+)
+
+var kinds = map[string]Kind{
+	"0BSD":          KindPermissive,
+	"Apache-2.0":    KindPermissive,
+	"BlueOak-1.0.0": KindPermissive,
+	"BSD-2-Clause":  KindPermissive,
+	"BSD-3-Clause":  KindPermissive,
+	"BSL-1.0":       KindPermissive,
+	"CC0-1.0":       KindPermissive,
+	"ISC":           KindPermissive,
+	"MIT":           KindPermissive,
+	"MIT-0":         KindPermissive,
+	"Python-2.0":    KindPermissive,
+	"Unlicense":     KindPermissive,
+	"Zlib":          KindPermissive,
+	"CDDL-1.0":      KindWeakCopyleft,
+	"EPL-1.0":       KindWeakCopyleft,
+	"EPL-2.0":       KindWeakCopyleft,
+	"LGPL-2.1":      KindWeakCopyleft,
+	"LGPL-3.0":      KindWeakCopyleft,
+	"MPL-2.0":       KindWeakCopyleft,
+	"GPL-2.0":       KindStrongCopyleft,
+	"GPL-3.0":       KindStrongCopyleft,
+	"AGPL-3.0":      KindNetworkCopyleft,
+}
+
+// aliases maps the free-form names found in manifests to SPDX identifiers
+var aliases = map[string]string{
+	"apache 2":                          "Apache-2.0",
+	"apache 2.0":                        "Apache-2.0",
+	"apache license 2.0":                "Apache-2.0",
+	"apache license, version 2.0":       "Apache-2.0",
+	"apache-2":                          "Apache-2.0",
+	"apache2":                           "Apache-2.0",
+	"bsd-2":                             "BSD-2-Clause",
+	"bsd-3":                             "BSD-3-Clause",
+	"new bsd":                           "BSD-3-Clause",
+	"simplified bsd":                    "BSD-2-Clause",
+	"gplv2":                             "GPL-2.0",
+	"gplv3":                             "GPL-3.0",
+	"lgplv3":                            "LGPL-3.0",
+	"agplv3":                            "AGPL-3.0",
+	"mit license":                       "MIT",
+	"mozilla public license 2.0":        "MPL-2.0",
+	"the unlicense":                     "Unlicense",
+	"public domain":                     "Unlicense",
+	"gnu general public license v3":     "GPL-3.0",
+	"gnu general public license v2":     "GPL-2.0",
+	"gnu affero general public license": "AGPL-3.0",
+}
+
+var canonical = func() map[string]string {
+	m := make(map[string]string, len(kinds))
+	for id := range kinds {
+		m[strings.ToLower(id)] = id
+	}
+	return m
+}()
+
+// license is one identifier of an expression
+type license struct {
+	id      string // without the -only or -or-later suffix
+	orLater bool
+}
+
+func (l license) String() string {
+	if l.orLater {
+		return l.id + "-or-later"
+	}
+	return l.id
+}
+
+func parseLicense(s string) license {
+	s = strings.TrimSpace(s)
+	s, _, _ = strings.Cut(s, " WITH ") // exceptions only relax the terms
+	var l license
+	switch lower := strings.ToLower(s); {
+	case strings.HasSuffix(lower, "-or-later"):
+		s, l.orLater = s[:len(s)-len("-or-later")], true
+	case strings.HasSuffix(lower, "+"):
+		s, l.orLater = s[:len(s)-1], true
+	case strings.HasSuffix(lower, "-only"):
+		s = s[:len(s)-len("-only")]
+	}
+	l.id = Normalize(s)
+	return l
+}
+
+// Normalize returns the SPDX identifier for a license name, or the name
+// itself when it is not one this package knows
+func Normalize(name string) string {
+	name = strings.TrimSpace(name)
+	lower := strings.ToLower(name)
+	for _, suffix := range []string{"-or-later", "-only", "+"} {
+		if base, ok := strings.CutSuffix(lower, suffix); ok {
+			if id, ok := canonical[base]; ok {
+				return id + suffix
+			}
+		}
+	}
+	if id, ok := canonical[lower]; ok {
+		return id
+	}
+	if id, ok := aliases[lower]; ok {
+		return id
+	}
+	return name
+}
+
+// NormalizeExpression normalizes the identifiers of an SPDX expression. A
+// value without operators is a single name, which may contain spaces, like
+// "Apache License 2.0"; the legacy "MIT/Apache-2.0" form is read as a choice.
+func NormalizeExpression(expr string) string {
+	expr = strings.TrimSpace(expr)
+	if !strings.Contains(expr, " OR ") && !strings.Contains(expr, " AND ") && !strings.Contains(expr, "/") {
+		return Normalize(strings.Trim(expr, "()"))
+	}
+	expr = strings.ReplaceAll(expr, "/", " OR ")
+	fields := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr))
+	for i, f := range fields {
+		switch f {
+		case "OR", "AND", "WITH", "(", ")":
+		default:
+			if i == 0 || fields[i-1] != "WITH" {
+				fields[i] = Normalize(f)
+			}
+		}
+	}
+	return strings.NewReplacer("( ", "(", " )", ")").Replace(strings.Join(fields, " "))
+}
+
+// KindOf classifies an SPDX identifier; "-only" and "-or-later" suffixes are ignored
+func KindOf(id string) Kind {
+	return kinds[parseLicense(id).id]
+}
+
+// IsSPDXID tells whether id is exactly an identifier of the SPDX license
+// list, unlike KindOf which accepts suffixes and exceptions. Only the GNU
+// licenses are listed with "-only" and "-or-later".
+func IsSPDXID(id string) bool {
+	base, ok := strings.CutSuffix(id, "-only")
+	if !ok {
+		base, ok = strings.CutSuffix(id, "-or-later")
+	}
+	if ok {
+		return gplVersion(base) != 0
+	}
+	_, ok = kinds[id]
+	return ok
+}
+
+// alternatives parses an SPDX expression into the choices it offers, each a
+// set of licenses that all apply. AND binds tighter than OR, and a
+// conjunction distributes over a parenthesised choice: "(MIT OR Apache-2.0)
+// AND Zlib" offers MIT and Zlib, or Apache-2.0 and Zlib.
+func alternatives(expr string) [][]license {
+	p := &expressionParser{tokens: strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr))}
+	return p.or()
+}
+
+// expressionParser is a recursive descent parser of SPDX expressions that
+// tolerates what manifests get wrong, such as unbalanced parentheses
+type expressionParser struct {
+	tokens []string
+	pos    int
+}
+
+// or parses choices separated by OR
+func (p *expressionParser) or() [][]license {
+	alts := p.and()
+	for p.accept("OR") {
+		alts = append(alts, p.and()...)
+	}
+	return alts
+}
+
+// and parses terms separated by AND, combining every choice of a term with
+// every choice of the others
+func (p *expressionParser) and() [][]license {
+	alts := p.term()
+	for p.accept("AND") {
+		next := p.term()
+		switch {
+		case len(next) == 0:
+		case len(alts) == 0:
+			alts = next
+		default:
+			product := make([][]license, 0, len(alts)*len(next))
+			for _, a := range alts {
+				for _, b := range next {
+					product = append(product, slices.Concat(a, b))
+				}
+			}
+			alts = product
+		}
+	}
+	return alts
+}
+
+// term parses a parenthesised expression or a single license, which may be a
+// name with spaces or carry a WITH exception
+func (p *expressionParser) term() [][]license {
+	if p.accept("(") {
+		alts := p.or()
+		p.accept(")")
+		return alts
+	}
+	var words []string
+	for ; p.pos < len(p.tokens); p.pos++ {
+		t := p.tokens[p.pos]
+		if t == "(" || t == ")" || strings.EqualFold(t, "OR") || strings.EqualFold(t, "AND") {
+			break
+		}
+		words = append(words, t)
+	}
+	if len(words) == 0 {
+		return nil
+	}
+	return [][]license{{parseLicense(strings.Join(words, " "))}}
+}
+
+// accept consumes the next token if it is tok
+func (p *expressionParser) accept(tok string) bool {
+	if p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], tok) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// Compatibility is the verdict on using a dependency in a project
+type Compatibility int
+
+const (
+	Incompatible Compatibility = iota
+	Unknown
+	Compatible
+)
+
+func (c Compatibility) String() string {
+	switch c {
+	case Compatible:
+		return "compatible"
+	case Incompatible:
+		return "incompatible"
+	}
+	return "unknown"
+}
+
+// Check tells whether code under the dependency's license expression may be
+// combined into a project distributed under the project's. A project or
+// dependency offering a choice (OR) is compatible when one of its choices is;
+// every license of a conjunction (AND) has to be. The reason explains a
+// verdict other than compatible.
+func Check(project, dependency string) (Compatibility, string) {
+	best, reason := Incompatible, ""
+	for _, p := range alternatives(project) {
+		for _, d := range alternatives(dependency) {
+			c, r := checkAll(p, d)
+			if c > best || reason == "" {
+				best, reason = c, r
+			}
+			if best == Compatible {
+				return Compatible, ""
+			}
+		}
+	}
+	if reason == "" {
+		return Unknown, "no license"
+	}
+	return best, reason
+}
+
+func checkAll(project, dependency []license) (Compatibility, string) {
+	worst, reason := Compatible, ""
+	for _, p := range project {
+		for _, d := range dependency {
+			if c, r := checkOne(p, d); c < worst {
+				worst, reason = c, r
+			}
+		}
+	}
+	return worst, reason
+}
+
+// gplVersion is the GNU license version of an identifier, 0 for others
+func gplVersion(id string) int {
+	switch id {
+	case "GPL-2.0", "LGPL-2.1":
+		return 2
+	case "GPL-3.0", "LGPL-3.0", "AGPL-3.0":
+		return 3
+	}
+	return 0
+}
+
+// checkOne is the compatibility matrix for two single licenses
+func checkOne(p, d license) (Compatibility, string) {
+	pk, dk := kinds[p.id], kinds[d.id]
+	switch {
+	case dk == KindUnknown:
+		return Unknown, fmt.Sprintf("%s is not a known license", d)
+	case pk == KindUnknown:
+		return Unknown, fmt.Sprintf("%s is not a known license", p)
+	}
+
+	// GPL-2.0 without "or later" cannot take the additional terms of the
+	// Apache License or anything under version 3 of the GNU licenses
+	gpl2Only := p.id == "GPL-2.0" && !p.orLater
+	if gpl2Only && (d.id == "Apache-2.0" || gplVersion(d.id) == 3) {
+		return Incompatible, fmt.Sprintf("%s is incompatible with %s, which has no \"or later\" option", d, p)
+	}
+
+	switch dk {
+	case KindPermissive:
+		return Compatible, ""
+	case KindWeakCopyleft:
+		if (strings.HasPrefix(d.id, "EPL-") || d.id == "CDDL-1.0") && pk >= KindStrongCopyleft {
+			return Incompatible, fmt.Sprintf("%s is incompatible with the GNU licenses", d)
+		}
+		return Compatible, ""
+	case KindStrongCopyleft:
+		switch {
+		case pk < KindStrongCopyleft:
+			return Incompatible, fmt.Sprintf("%s requires the whole work to be distributed under %s, not %s", d, d.id, p)
+		case gplVersion(d.id) == 2 && !d.orLater && gplVersion(p.id) == 3:
+			return Incompatible, fmt.Sprintf("%s without \"or later\" cannot be distributed under version 3", d)
+		}
+		return Compatible, ""
+	case KindNetworkCopyleft:
+		if p.id == "AGPL-3.0" || p.id == "GPL-3.0" {
+			return Compatible, ""
+		}
+		return Incompatible, fmt.Sprintf("%s requires the whole work, including its network use, to be under %s, not %s", d, d.id, p)
+	}
+	return Unknown, ""
+}
+
+// Combine finds a license a work merging the given license expressions can be
+// distributed under: one of their own licenses that every expression is
+// compatible with, preferring the least demanding. A copyleft license that
+// covers the whole work sets a floor, since its parts keep their terms. It
+// returns "" and the reasons against each candidate when there is none.
+func Combine(exprs []string) (string, []string) {
+	var candidates []license
+	seen := make(map[license]bool)
+	floor := KindUnknown
+	for _, expr := range exprs {
+		least := KindNetworkCopyleft
+		for _, alt := range alternatives(expr) {
+			least = min(least, demand(alt))
+			for _, l := range alt {
+				if !seen[l] {
+					seen[l] = true
+					candidates = append(candidates, l)
+				}
+			}
+		}
+		if least >= KindStrongCopyleft {
+			floor = max(floor, least)
+		}
+	}
+	candidates = slices.DeleteFunc(candidates, func(l license) bool { return kinds[l.id] < floor })
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return kinds[candidates[i].id] < kinds[candidates[j].id]
+	})
+
+	var reasons []string
+	for _, c := range candidates {
+		ok := true
+		for _, expr := range exprs {
+			if v, reason := Check(c.String(), expr); v != Compatible {
+				ok = false
+				if v == Incompatible {
+					reasons = append(reasons, reason)
+				}
+				break
+			}
+		}
+		if ok {
+			return c.String(), nil
+		}
+	}
+	return "", reasons
+}
+
+// Conflict tells whether works under two license expressions cannot be
+// combined at all: neither can take in the other. The reason is the one from
+// the more demanding side, which is the one that would have had to give way.
+func Conflict(a, b string) (bool, string) {
+	ab, reasonAB := Check(a, b)
+	ba, reasonBA := Check(b, a)
+	if ab != Incompatible || ba != Incompatible {
+		return false, ""
+	}
+	if demand(slices.Concat(alternatives(b)...)) > demand(slices.Concat(alternatives(a)...)) {
+		return true, reasonBA
+	}
+	return true, reasonAB
+}
+
+// demand is the most demanding kind of license among some
+func demand(all []license) Kind {
+	k := KindUnknown
+	for _, l := range all {
+		k = max(k, kinds[l.id])
+	}
+	return k
+}
+
+// Choices lists the alternatives an expression offers, e.g. MIT and
+// Apache-2.0 for "MIT OR Apache-2.0"
+func Choices(expr string) []string {
+	var choices []string
+	for _, alt := range alternatives(expr) {
+		terms := make([]string, len(alt))
+		for i, l := range alt {
+			terms[i] = l.String()
+		}
+		choices = append(choices, strings.Join(terms, " AND "))
+	}
+	return choices
+}
+
+// Expression joins the licenses of a work offering a choice, e.g. dual
+// licensed under MIT and Apache-2.0, into an SPDX expression
+func Expression(ids []string) string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	sort.Strings(out)
+	if len(out) > 1 {
+		for i, id := range out {
+			if strings.Contains(id, " ") {
+				out[i] = "(" + id + ")"
+			}
+		}
+	}
+	return strings.Join(out, " OR ")
+}
@@ -0,0 +1,110 @@
+package licenses
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	for _, tt := range []struct {
+		project, dependency string
+		want                Compatibility
+	}{
+		{"MIT", "BSD-3-Clause", Compatible},
+		{"MIT", "Apache-2.0", Compatible},
+		{"MIT", "MPL-2.0", Compatible},
+		{"MIT", "LGPL-3.0-only", Compatible},
+		{"MIT", "GPL-3.0-only", Incompatible},
+		{"Apache-2.0", "GPL-2.0-or-later", Incompatible},
+		{"MIT", "AGPL-3.0", Incompatible},
+		{"GPL-3.0-or-later", "MIT", Compatible},
+		{"GPL-3.0", "Apache-2.0", Compatible},
+		{"GPL-2.0-only", "Apache-2.0", Incompatible},
+		{"GPL-2.0-or-later", "Apache-2.0", Compatible},
+		{"GPL-2.0-only", "LGPL-3.0", Incompatible},
+		{"GPL-3.0", "GPL-2.0-only", Incompatible},
+		{"GPL-3.0", "GPL-2.0+", Compatible},
+		{"GPL-3.0", "AGPL-3.0", Compatible},
+		{"AGPL-3.0", "GPL-3.0", Compatible},
+		{"GPL-3.0", "EPL-2.0", Incompatible},
+		{"GPL-2.0-or-later", "CDDL-1.0", Incompatible},
+		{"MIT", "CDDL-1.0", Compatible},
+		{"MIT", "GPL-2.0 OR MIT", Compatible},
+		{"MIT", "(MIT AND GPL-3.0)", Incompatible},
+		{"MIT", "(MIT OR GPL-3.0) AND AGPL-3.0", Incompatible},
+		{"MIT", "(MIT OR Apache-2.0) AND (GPL-3.0 OR BSD-3-Clause)", Compatible},
+		{"GPL-2.0-only", "(GPL-3.0 OR Apache-2.0) AND MIT", Incompatible},
+		{"GPL-2.0-only OR MIT", "Apache-2.0", Compatible},
+		{"MIT", "LicenseRef-proprietary", Unknown},
+		{"MIT", "", Unknown},
+	} {
+		got, reason := Check(tt.project, tt.dependency)
+		assert.Equal(t, tt.want, got, "%s in %s", tt.dependency, tt.project)
+		if got != Compatible {
+			assert.NotEmpty(t, reason, "%s in %s", tt.dependency, tt.project)
+		}
+	}
+
+	_, reason := Check("MIT", "GPL-3.0-only")
+	assert.Equal(t, "GPL-3.0 requires the whole work to be distributed under GPL-3.0, not MIT", reason)
+}
+
+func TestCombine(t *testing.T) {
+	for _, tt := range []struct {
+		exprs []string
+		want  string
+	}{
+		{[]string{"MIT", "BSD-3-Clause"}, "MIT"},
+		{[]string{"MIT", "Apache-2.0"}, "MIT"},
+		{[]string{"MIT", "GPL-3.0"}, "GPL-3.0"},
+		{[]string{"Apache-2.0", "GPL-3.0", "AGPL-3.0"}, "AGPL-3.0"},
+		{[]string{"MIT OR Apache-2.0", "GPL-2.0-only"}, "GPL-2.0"},
+		{[]string{"Apache-2.0", "GPL-2.0-only"}, ""},
+	} {
+		got, reasons := Combine(tt.exprs)
+		assert.Equal(t, tt.want, got, "%v", tt.exprs)
+		assert.Equal(t, tt.want == "", len(reasons) > 0, "%v", tt.exprs)
+	}
+}
+
+func TestConflict(t *testing.T) {
+	conflict, reason := Conflict("Apache-2.0", "GPL-2.0-only")
+	assert.True(t, conflict)
+	assert.Equal(t, `Apache-2.0 is incompatible with GPL-2.0, which has no "or later" option`, reason)
+
+	conflict, _ = Conflict("MIT", "GPL-3.0")
+	assert.False(t, conflict, "the MIT code can go into the GPL work")
+}
+
+func TestNormalizeExpression(t *testing.T) {
+	for in, want := range map[string]string{
+		"mit":                 "MIT",
+		"gpl-3.0-or-later":    "GPL-3.0-or-later",
+		"Apache License 2.0":  "Apache-2.0",
+		"MIT/Apache-2.0":      "MIT OR Apache-2.0",
+		"(mit OR apache-2.0)": "(MIT OR Apache-2.0)",
+		"GPL-2.0 WITH Classpath-exception-2.0 OR bsd-3-clause": "GPL-2.0 WITH Classpath-exception-2.0 OR BSD-3-Clause",
+		"LicenseRef-acme": "LicenseRef-acme",
+	} {
+		assert.Equal(t, want, NormalizeExpression(in), in)
+	}
+	assert.Equal(t, "Apache-2.0 OR MIT", Expression([]string{"MIT", "Apache-2.0", "MIT", ""}))
+	assert.Equal(t, []string{"MIT", "GPL-2.0-or-later AND BSD-3-Clause"}, Choices("MIT OR (GPL-2.0+ AND BSD-3-Clause)"))
+}
+
+func TestChoices(t *testing.T) {
+	for expr, want := range map[string][]string{
+		"(MIT OR Apache-2.0) AND Unicode-DFS-2016": {"MIT AND Unicode-DFS-2016", "Apache-2.0 AND Unicode-DFS-2016"},
+		"MIT OR Apache-2.0 AND Zlib":               {"MIT", "Apache-2.0 AND Zlib"},
+		"((MIT OR ISC) AND (Apache-2.0 OR Zlib)) OR GPL-3.0": {
+			"MIT AND Apache-2.0", "MIT AND Zlib", "ISC AND Apache-2.0", "ISC AND Zlib", "GPL-3.0",
+		},
+		"Apache-2.0 WITH LLVM-exception AND (MIT OR BSD-2-Clause)": {"Apache-2.0 AND MIT", "Apache-2.0 AND BSD-2-Clause"},
+		"(MIT OR Apache-2.0": {"MIT", "Apache-2.0"},
+		"Apache License 2.0": {"Apache-2.0"},
+		"":                   nil,
+	} {
+		assert.Equal(t, want, Choices(expr), expr)
+	}
+}
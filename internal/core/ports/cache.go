@@ -0,0 +1,17 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// Cache is a key/value cache shared by adapters and services.
+// Values are stored JSON-encoded; tags group keys so they can be invalidated together.
+type Cache interface {
+	// Get decodes the cached value into dest and reports whether the key was found
+	Get(ctx context.Context, key string, dest interface{}) (bool, error)
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration, tags ...string) error
+	Delete(ctx context.Context, key string) error
+	// InvalidateTags removes every key stored with any of the given tags
+	InvalidateTags(ctx context.Context, tags ...string) error
+}
@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/biodoia/ghrego/internal/core/domain"
@@ -16,12 +17,55 @@ type UserRepository interface {
 
 // RepositoryStore defines operations for GitHub repository management
 type RepositoryStore interface {
-	GetByUserID(ctx context.Context, userID int) ([]domain.Repository, error)
+	GetByWorkspaceID(ctx context.Context, workspaceID int) ([]domain.Repository, error)
 	GetByID(ctx context.Context, id int) (*domain.Repository, error)
 	GetByIDs(ctx context.Context, ids []int) ([]domain.Repository, error)
+	GetByGithubID(ctx context.Context, githubID string) ([]domain.Repository, error)
+	// GetLatestByAccount returns, for each workspace that tracks the GitHub
+	// account owner or the App installation, its most recently synced
+	// repository of them
+	GetLatestByAccount(ctx context.Context, owner string, installationID int64) ([]domain.Repository, error)
 	Upsert(ctx context.Context, repo *domain.Repository) (int, error)
 	Delete(ctx context.Context, id int) error
-	GetStats(ctx context.Context, userID int) (map[string]interface{}, error)
+	GetStats(ctx context.Context, workspaceID int) (map[string]interface{}, error)
+}
+
+// WorkspaceRepository defines operations for workspaces, their members and invitations
+type WorkspaceRepository interface {
+	// Create inserts the workspace and makes ownerID its owner
+	Create(ctx context.Context, workspace *domain.Workspace, ownerID int) error
+	GetByID(ctx context.Context, id int) (*domain.Workspace, error)
+	GetPersonal(ctx context.Context, userID int) (*domain.Workspace, error)
+	ListByUser(ctx context.Context, userID int) ([]domain.WorkspaceMembership, error)
+	GetMember(ctx context.Context, workspaceID, userID int) (*domain.WorkspaceMember, error)
+	ListMembers(ctx context.Context, workspaceID int) ([]domain.WorkspaceMember, error)
+	RemoveMember(ctx context.Context, workspaceID, userID int) error
+	CreateInvitation(ctx context.Context, invitation *domain.WorkspaceInvitation) error
+	GetInvitation(ctx context.Context, id int) (*domain.WorkspaceInvitation, error)
+	ListPendingInvitations(ctx context.Context, githubUsername string) ([]domain.WorkspaceInvitation, error)
+	// AcceptInvitation marks the invitation accepted and adds userID as a member
+	AcceptInvitation(ctx context.Context, invitation *domain.WorkspaceInvitation, userID int) error
+}
+
+// WebhookRepository stores webhook deliveries and the GitHub App installations they report
+type WebhookRepository interface {
+	// CreateDelivery stores a new delivery. It returns false, leaving the stored
+	// delivery untouched, when the ID was already received.
+	CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (bool, error)
+	GetDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error)
+	UpdateDeliveryStatus(ctx context.Context, id string, status domain.WebhookDeliveryStatus, errMsg string) error
+	UpsertInstallation(ctx context.Context, installation *domain.GitHubInstallation) error
+	DeleteInstallation(ctx context.Context, id int64) error
+}
+
+// APITokenRepository defines operations for personal access tokens
+type APITokenRepository interface {
+	Create(ctx context.Context, token *domain.APIToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*domain.APIToken, error)
+	ListByUser(ctx context.Context, userID int) ([]domain.APIToken, error)
+	// Delete removes the token if it belongs to userID
+	Delete(ctx context.Context, userID, id int) error
+	TouchLastUsed(ctx context.Context, id int, at time.Time) error
 }
 
 // AnalysisRepository defines operations for analysis results
@@ -32,11 +76,36 @@ type AnalysisRepository interface {
 	Update(ctx context.Context, id int, updates map[string]interface{}) error
 }
 
+// AnalysisCacheRepository stores AI responses keyed by content fingerprint
+type AnalysisCacheRepository interface {
+	Get(ctx context.Context, fingerprint string) (*domain.RepositoryAnalysisResponse, error)
+	Set(ctx context.Context, fingerprint string, repoID int, response *domain.RepositoryAnalysisResponse) error
+}
+
+// UsageRepository defines operations for AI token usage accounting
+type UsageRepository interface {
+	Create(ctx context.Context, usage *domain.AIUsage) (int, error)
+	SumCostSince(ctx context.Context, since time.Time) (float64, error)
+	GetDailyByUser(ctx context.Context, userID int, since time.Time) ([]domain.DailyUsage, error)
+	GetByRepositoryForUser(ctx context.Context, userID int, since time.Time) ([]domain.RepositoryUsage, error)
+}
+
+// BatchRepository defines operations for bulk analysis batches
+type BatchRepository interface {
+	Create(ctx context.Context, batch *domain.AnalysisBatch, repoIDs []int) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.AnalysisBatch, error)
+	GetItems(ctx context.Context, batchID uuid.UUID) ([]domain.AnalysisBatchItem, error)
+	GetUnfinishedItems(ctx context.Context) ([]domain.AnalysisBatchItem, error)
+	UpdateItem(ctx context.Context, item *domain.AnalysisBatchItem) error
+}
+
 // FeatureRepository defines operations for detected features
 type FeatureRepository interface {
 	GetByRepositoryID(ctx context.Context, repoID int) ([]domain.Feature, error)
 	Create(ctx context.Context, feature *domain.Feature) (int, error)
 	BulkCreate(ctx context.Context, features []domain.Feature) error
+	// Replace swaps all the features of a repository for features
+	Replace(ctx context.Context, repoID int, features []domain.Feature) error
 }
 
 // RelationRepository defines operations for repository relationships
@@ -48,7 +117,7 @@ type RelationRepository interface {
 // SuggestionRepository defines operations for AI suggestions
 type SuggestionRepository interface {
 	GetByRepositoryID(ctx context.Context, repoID int) ([]domain.Suggestion, error)
-	GetAllPending(ctx context.Context, userID int) ([]domain.Suggestion, error)
+	GetAllPending(ctx context.Context, workspaceID int) ([]domain.Suggestion, error)
 	GetByID(ctx context.Context, id int) (*domain.Suggestion, error)
 	Create(ctx context.Context, suggestion *domain.Suggestion) (int, error)
 	UpdateStatus(ctx context.Context, id int, status domain.SuggestionStatus) error
@@ -57,7 +126,22 @@ type SuggestionRepository interface {
 // TechnologyRepository defines operations for technology stacks
 type TechnologyRepository interface {
 	GetByRepositoryID(ctx context.Context, repoID int) ([]domain.Technology, error)
+	// GetByWorkspaceID returns the technologies of all the workspace's repositories
+	GetByWorkspaceID(ctx context.Context, workspaceID int) ([]domain.Technology, error)
 	BulkCreate(ctx context.Context, techs []domain.Technology) error
+	// ReplaceDetected swaps the statically detected technologies of a
+	// repository (those with evidence) for techs; AI analysis rows are kept
+	ReplaceDetected(ctx context.Context, repoID int, techs []domain.Technology) error
+	// ReplaceAnalyzed swaps the technologies found by AI analyses (those
+	// without evidence) for techs; static detections are kept
+	ReplaceAnalyzed(ctx context.Context, repoID int, techs []domain.Technology) error
+}
+
+// LicenseRepository defines operations for the licenses found in repositories
+type LicenseRepository interface {
+	GetByRepositoryIDs(ctx context.Context, repoIDs []int) ([]domain.RepositoryLicense, error)
+	// Replace swaps the licenses of a repository for those of the latest analysis
+	Replace(ctx context.Context, repoID int, licenses []domain.RepositoryLicense) error
 }
 
 // UnificationRepository defines operations for repo unification
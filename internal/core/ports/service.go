@@ -2,31 +2,193 @@ package ports
 
 import (
 	"context"
+	"io/fs"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/biodoia/ghrego/internal/core/domain"
 )
 
 // External Adapter Interfaces
-type GitHubClient interface {
-	GetUserRepositories(ctx context.Context, username string) ([]*domain.Repository, error)
+
+// SourceHost is a code hosting service (GitHub, GitLab, Gitea) repositories
+// are synced from and read during analysis. owner/repo is the repository's
+// full name split at the first slash.
+type SourceHost interface {
+	Provider() domain.SourceProvider
+	// ListRepositories returns the repositories of a user or organization (group on GitLab)
+	ListRepositories(ctx context.Context, account string) ([]*domain.Repository, error)
 	GetRepository(ctx context.Context, owner, repoName string) (*domain.Repository, error)
 	GetFileContent(ctx context.Context, owner, repo, path string) (string, error)
 	GetLanguages(ctx context.Context, owner, repo string) (map[string]int, error)
-	AnalyzeStructure(ctx context.Context, owner, repo string) (int, []string, map[string]int, error)
+	GetBranchSHA(ctx context.Context, owner, repo, branch string) (string, error)
+	// GetTree lists every file and directory at ref, recursively
+	GetTree(ctx context.Context, owner, repo, ref string) ([]domain.TreeEntry, error)
+	// CloneSource returns the git URL and credentials to fetch the repository with
+	CloneSource(ctx context.Context, owner, repo string) (domain.CloneSource, error)
+}
+
+// CodeSnapshot is a read-only checkout of a repository at one commit, without
+// the .git directory. Paths are slash-separated and relative to the repository root.
+type CodeSnapshot interface {
+	fs.FS
+	SHA() string
+	// Release hands the snapshot back to the ingester; it must not be read afterwards
+	Release()
+}
+
+// CodeIngester checks repositories out on disk for analysis
+type CodeIngester interface {
+	// Checkout returns the repository at commit sha, cloning it from host
+	// unless a workspace for that commit already exists
+	Checkout(ctx context.Context, host SourceHost, repo *domain.Repository, sha string) (CodeSnapshot, error)
+}
+
+// AdvisoryDatabase looks up security advisories without network access
+type AdvisoryDatabase interface {
+	// Advisories returns the advisories that affect some version of a package
+	Advisories(ctx context.Context, ecosystem, name string) ([]domain.Advisory, error)
+}
+
+// PackageRegistry knows the released versions of packages, e.g. from a
+// snapshot of the public registries
+type PackageRegistry interface {
+	// LatestVersion returns the newest stable version of a package, "" when unknown
+	LatestVersion(ctx context.Context, ecosystem, name string) (string, error)
+	// License returns the SPDX license the package declares, "" when unknown
+	License(ctx context.Context, ecosystem, name string) (string, error)
+}
+
+// GitHubClient is the GitHub source host plus the calls only GitHub supports
+type GitHubClient interface {
+	SourceHost
+	GetUserRepositories(ctx context.Context, username string) ([]*domain.Repository, error)
+	// AnalyzeStructure classifies the files of the default branch by language and purpose
+	AnalyzeStructure(ctx context.Context, owner, repo string) (*domain.RepositoryStructure, error)
+	IsOrgMember(ctx context.Context, org, username string) (bool, error)
+}
+
+// GitHubInstallations forgets what a GitHub App client remembers about an
+// installation, its accounts, access token and cached responses, once it is
+// deleted or suspended
+type GitHubInstallations interface {
+	ForgetInstallation(ctx context.Context, installationID int64)
 }
 
 type AIClient interface {
 	AnalyzeRepository(ctx context.Context, prompt string) (*domain.RepositoryAnalysisResponse, error)
+	ModelName() string
 }
 
 // Service Interfaces
 type GitHubService interface {
-	SyncUserRepositories(ctx context.Context, userID int, openID string) error
+	// SyncUserRepositories imports the repositories of an account into a
+	// workspace. On GitHub (provider "") that is the user's own account when
+	// account is empty, otherwise an organization the user is a member of. Other
+	// providers have no linked identity, so only admins may import from them.
+	SyncUserRepositories(ctx context.Context, userID, workspaceID int, provider domain.SourceProvider, account string) error
+	// RegisterLocalRepository imports one repository from the server's
+	// filesystem, given as <directory>/<repository> below the local root. Admin only.
+	RegisterLocalRepository(ctx context.Context, userID, workspaceID int, path string) (*domain.Repository, error)
+	ListRepositories(ctx context.Context, userID, workspaceID int) ([]domain.Repository, error)
 	GetRepositoryDetails(ctx context.Context, userID int, repoID int) (*domain.Repository, error)
+	DeleteRepository(ctx context.Context, userID int, repoID int) error
+	GetRepositoryStats(ctx context.Context, userID, workspaceID int) (map[string]interface{}, error)
 	AnalyzeDependencies(ctx context.Context, repoID int) error
 }
 
 type AIAnalysisService interface {
-	AnalyzeRepository(ctx context.Context, repoID int, analysisType domain.AnalysisType) (*domain.Analysis, error)
+	// StartAnalysis runs an analysis of repoID for userID, who must be a maintainer of its workspace
+	StartAnalysis(ctx context.Context, userID, repoID int, analysisType domain.AnalysisType, force bool) (*domain.Analysis, error)
 	GenerateSuggestions(ctx context.Context, repoID int) ([]domain.Suggestion, error)
-}
\ No newline at end of file
+	GetAnalysisReport(ctx context.Context, userID int, repoID int) (*domain.AnalysisReport, error)
+	// AuthorizeAnalysis checks that userID may start analyses of repoID, before one is queued
+	AuthorizeAnalysis(ctx context.Context, userID int, repoID int) error
+	ListPendingSuggestions(ctx context.Context, userID, workspaceID int) ([]domain.Suggestion, error)
+	UpdateSuggestionStatus(ctx context.Context, userID int, suggestionID int, status domain.SuggestionStatus) error
+}
+type BulkAnalysisService interface {
+	StartBatch(ctx context.Context, userID, workspaceID int, req domain.BulkAnalysisRequest) (*domain.AnalysisBatch, error)
+	GetProgress(ctx context.Context, userID int, batchID uuid.UUID) (*domain.BatchProgress, error)
+	ResumeInterrupted(ctx context.Context) error
+}
+
+type UsageService interface {
+	// CheckBudget returns domain.ErrAIBudgetExceeded once the monthly spend reaches the budget
+	CheckBudget(ctx context.Context) error
+	Record(ctx context.Context, analysis *domain.Analysis, userID int, usage domain.TokenUsage) error
+	GetUserUsage(ctx context.Context, userID int, since time.Time) (*domain.UsageReport, error)
+}
+
+// WorkspaceService manages workspaces, their members and invitations
+type WorkspaceService interface {
+	Create(ctx context.Context, userID int, name string) (*domain.Workspace, error)
+	List(ctx context.Context, userID int) ([]domain.WorkspaceMembership, error)
+	// Resolve returns the workspace a request acts on: workspaceID if the user
+	// may view it, or the user's personal workspace when workspaceID is 0
+	Resolve(ctx context.Context, userID, workspaceID int) (int, error)
+	ListMembers(ctx context.Context, userID, workspaceID int) ([]domain.WorkspaceMember, error)
+	RemoveMember(ctx context.Context, userID, workspaceID, memberID int) error
+	Invite(ctx context.Context, userID, workspaceID int, githubUsername string, role domain.WorkspaceRole) (*domain.WorkspaceInvitation, error)
+	ListInvitations(ctx context.Context, userID int) ([]domain.WorkspaceInvitation, error)
+	AcceptInvitation(ctx context.Context, userID, invitationID int) error
+}
+
+// WebhookService processes GitHub webhook deliveries
+type WebhookService interface {
+	// HandleDelivery stores and processes a verified delivery. Deliveries seen
+	// before are reported as duplicates and not processed again, unless they failed.
+	HandleDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (duplicate bool, err error)
+	// Replay processes a stored delivery again; admins only
+	Replay(ctx context.Context, userID int, deliveryID string) (*domain.WebhookDelivery, error)
+}
+
+// DependencyService reports on the dependencies declared across a workspace
+type DependencyService interface {
+	// GetDriftReport groups the workspace's dependencies by package and shows how far behind each repository is
+	GetDriftReport(ctx context.Context, userID, workspaceID int) (*domain.DependencyReport, error)
+	// SuggestConsolidation creates a suggestion for each repository behind the latest version of a package
+	SuggestConsolidation(ctx context.Context, userID, workspaceID int) ([]domain.Suggestion, error)
+	// GetLicenseReport checks the licenses of a repository's dependencies against its own
+	GetLicenseReport(ctx context.Context, userID, repoID int) (*domain.LicenseReport, error)
+	// CheckUnification tells whether the repositories' licenses allow unifying them into one
+	CheckUnification(ctx context.Context, userID int, repoIDs []int) (*domain.UnificationLicenseCheck, error)
+	// GetSBOM exports a repository's dependencies as a CycloneDX or SPDX bill of materials
+	GetSBOM(ctx context.Context, userID, repoID int, format domain.SBOMFormat) (*domain.SBOM, error)
+}
+
+// TokenService manages personal access tokens and authenticates requests made with them
+type TokenService interface {
+	Create(ctx context.Context, userID int, name string, scopes []domain.TokenScope, ttl time.Duration) (*domain.CreatedAPIToken, error)
+	List(ctx context.Context, userID int) ([]domain.APIToken, error)
+	Revoke(ctx context.Context, userID, tokenID int) error
+	// Authenticate returns the token matching the plaintext secret, or an
+	// Unauthenticated error if it is unknown or expired
+	Authenticate(ctx context.Context, secret string) (*domain.APIToken, error)
+}
+
+// Authorizer decides whether a user may act on a resource. Batches belong to
+// the user who started them; repositories belong to a workspace and require a
+// minimum role in it. Admins may access everything; anything else is refused
+// with a domain.ErrForbidden error.
+type Authorizer interface {
+	AuthorizeOwner(ctx context.Context, userID, ownerID int) error
+	AuthorizeAdmin(ctx context.Context, userID int) error
+	AuthorizeWorkspace(ctx context.Context, userID, workspaceID int, required domain.WorkspaceRole) error
+	// Repository loads repoID and authorizes userID against its workspace
+	Repository(ctx context.Context, userID, repoID int, required domain.WorkspaceRole) (*domain.Repository, error)
+}
+
+// RateLimiter enforces token-bucket limits on arbitrary keys (user, IP, endpoint)
+type RateLimiter interface {
+	// Allow takes a token for key; when denied it returns how long until a token is available
+	Allow(ctx context.Context, key string, limit domain.RateLimit) (bool, time.Duration, error)
+}
+
+// JobRunner runs background work that outlives the request which started it.
+// Jobs receive a context that is cancelled when the shutdown grace period ends.
+type JobRunner interface {
+	// Go starts fn in the background; it fails once shutdown has begun. ctx is
+	// only used to continue its trace in the job, its cancellation is ignored.
+	Go(ctx context.Context, name string, fn func(ctx context.Context)) error
+}
@@ -0,0 +1,122 @@
+package sbom
+
+import "github.com/biodoia/ghrego/internal/core/licenses"
+
+// The subset of the CycloneDX 1.5 JSON schema the documents use
+type (
+	cdxBOM struct {
+		BOMFormat    string          `json:"bomFormat"`
+		SpecVersion  string          `json:"specVersion"`
+		SerialNumber string          `json:"serialNumber"`
+		Version      int             `json:"version"`
+		Metadata     cdxMetadata     `json:"metadata"`
+		Components   []cdxComponent  `json:"components"`
+		Dependencies []cdxDependency `json:"dependencies"`
+	}
+
+	cdxMetadata struct {
+		Timestamp string       `json:"timestamp"`
+		Tools     cdxTools     `json:"tools"`
+		Component cdxComponent `json:"component"`
+	}
+
+	cdxTools struct {
+		Components []cdxComponent `json:"components"`
+	}
+
+	cdxComponent struct {
+		Type               string           `json:"type"`
+		BOMRef             string           `json:"bom-ref,omitempty"`
+		Name               string           `json:"name"`
+		Version            string           `json:"version,omitempty"`
+		Description        string           `json:"description,omitempty"`
+		Licenses           []cdxLicense     `json:"licenses,omitempty"`
+		PURL               string           `json:"purl,omitempty"`
+		ExternalReferences []cdxExternalRef `json:"externalReferences,omitempty"`
+	}
+
+	// cdxLicense holds either a license or an expression
+	cdxLicense struct {
+		License    *cdxLicenseID `json:"license,omitempty"`
+		Expression string        `json:"expression,omitempty"`
+	}
+
+	cdxLicenseID struct {
+		ID   string `json:"id,omitempty"`
+		Name string `json:"name,omitempty"`
+	}
+
+	cdxExternalRef struct {
+		Type string `json:"type"`
+		URL  string `json:"url"`
+	}
+
+	cdxDependency struct {
+		Ref       string   `json:"ref"`
+		DependsOn []string `json:"dependsOn,omitempty"`
+	}
+)
+
+// cdxLicenses is a listed SPDX identifier as an id, any other valid SPDX
+// expression, including exceptions and "+", as an expression and anything
+// else as a name
+func cdxLicenses(expr string) []cdxLicense {
+	if expr == "" {
+		return nil
+	}
+	if licenses.IsSPDXID(expr) {
+		return []cdxLicense{{License: &cdxLicenseID{ID: expr}}}
+	}
+	if _, refs := spdxLicense(expr); len(refs) == 0 {
+		return []cdxLicense{{Expression: expr}}
+	}
+	return []cdxLicense{{License: &cdxLicenseID{Name: expr}}}
+}
+
+// CycloneDX renders the document as CycloneDX 1.5 JSON, with the repository
+// as the metadata component that depends on every other component
+func CycloneDX(doc Document) ([]byte, error) {
+	repo := doc.Repository
+	root := cdxComponent{
+		Type:        "application",
+		BOMRef:      rootRef(repo),
+		Name:        repo.FullName,
+		Description: repo.Description.String,
+		Licenses:    cdxLicenses(doc.License),
+	}
+	if repo.URL != "" {
+		root.ExternalReferences = []cdxExternalRef{{Type: "vcs", URL: repo.URL}}
+	}
+
+	bom := cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + doc.uuid(),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: doc.timestamp(),
+			Tools:     cdxTools{Components: []cdxComponent{{Type: "application", Name: toolName}}},
+			Component: root,
+		},
+		Components: []cdxComponent{},
+	}
+	dependsOn := []string{}
+	for _, d := range doc.sorted() {
+		purl := PURL(d.Ecosystem, d.Name, d.Version)
+		ref := purl
+		if ref == "" {
+			ref = d.Ecosystem + ":" + d.Name + "@" + d.Version
+		}
+		bom.Components = append(bom.Components, cdxComponent{
+			Type:     "library",
+			BOMRef:   ref,
+			Name:     d.Name,
+			Version:  d.Version,
+			Licenses: cdxLicenses(d.License),
+			PURL:     purl,
+		})
+		dependsOn = append(dependsOn, ref)
+	}
+	bom.Dependencies = []cdxDependency{{Ref: root.BOMRef, DependsOn: dependsOn}}
+	return marshal(bom)
+}
@@ -0,0 +1,200 @@
+// Package sbom renders the dependencies of a repository as a software bill
+// of materials, in CycloneDX 1.5 and SPDX 2.3 JSON. The output only depends
+// on the Document, so the same input always gives the same bytes.
+package sbom
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/licenses"
+)
+
+// toolName is the creator recorded in the documents
+const toolName = "ghrego"
+
+// Document is what a bill of materials describes: a repository, the
+// license it is distributed under and the packages it depends on
+type Document struct {
+	Repository   *domain.Repository
+	License      string // an SPDX expression, "" when unknown
+	Dependencies []domain.DependencyLicense
+	Created      time.Time
+}
+
+// Render encodes the document in a format
+func Render(doc Document, format domain.SBOMFormat) ([]byte, error) {
+	switch format {
+	case domain.SBOMFormatCycloneDX:
+		return CycloneDX(doc)
+	case domain.SBOMFormatSPDX:
+		return SPDX(doc)
+	}
+	return nil, domain.Validation("unknown SBOM format %q, expected cyclonedx or spdx", format)
+}
+
+// sorted is the dependencies ordered by ecosystem, name and version
+func (doc Document) sorted() []domain.DependencyLicense {
+	deps := append([]domain.DependencyLicense(nil), doc.Dependencies...)
+	sort.Slice(deps, func(i, j int) bool {
+		a, b := deps[i], deps[j]
+		switch {
+		case a.Ecosystem != b.Ecosystem:
+			return a.Ecosystem < b.Ecosystem
+		case a.Name != b.Name:
+			return a.Name < b.Name
+		}
+		return a.Version < b.Version
+	})
+	return deps
+}
+
+// uuid is a name-based (version 5 style) UUID of the document, used where
+// the formats ask for a unique identifier
+func (doc Document) uuid() string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%s\x00%s\n", doc.Repository.ID, doc.Repository.FullName, doc.License, doc.Created.UTC().Format(time.RFC3339))
+	for _, d := range doc.sorted() {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\n", d.Ecosystem, d.Name, d.Version, d.License)
+	}
+	b := h.Sum(nil)[:16]
+	b[6] = b[6]&0x0f | 0x50
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// timestamp is the creation time in the form both formats accept
+func (doc Document) timestamp() string {
+	return doc.Created.UTC().Truncate(time.Second).Format(time.RFC3339)
+}
+
+// purlTypes maps the ecosystems to package URL types
+var purlTypes = map[string]string{
+	"Go":        "golang",
+	"npm":       "npm",
+	"PyPI":      "pypi",
+	"crates.io": "cargo",
+	"Maven":     "maven",
+	"RubyGems":  "gem",
+	"Packagist": "composer",
+	"NuGet":     "nuget",
+}
+
+// PURL is the package URL of a dependency, "" for an unknown ecosystem
+func PURL(ecosystem, name, version string) string {
+	typ, ok := purlTypes[ecosystem]
+	if !ok || name == "" {
+		return ""
+	}
+	switch ecosystem {
+	case "PyPI":
+		name = strings.ToLower(strings.NewReplacer("_", "-", ".", "-").Replace(name))
+	case "Maven":
+		name = strings.Replace(name, ":", "/", 1) // group:artifact
+	case "Go":
+		if version != "" && !strings.HasPrefix(version, "v") {
+			version = "v" + version
+		}
+	}
+	segments := strings.Split(name, "/")
+	for i, s := range segments {
+		segments[i] = purlEscape(s)
+	}
+	purl := "pkg:" + typ + "/" + strings.Join(segments, "/")
+	if version != "" {
+		purl += "@" + purlEscape(version)
+	}
+	return purl
+}
+
+// purlEscape percent-encodes a segment of a package URL, including the "@"
+// of npm scopes, which would otherwise read as the version separator
+func purlEscape(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), "@", "%40")
+}
+
+// notIDString matches what SPDX identifiers cannot contain
+var notIDString = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// licenseRef is the SPDX reference standing for a license that has no
+// SPDX identifier, or one this module does not know
+func licenseRef(name string) string {
+	return "LicenseRef-" + strings.Trim(notIDString.ReplaceAllString(strings.TrimPrefix(name, "LicenseRef-"), "-"), "-")
+}
+
+// spdxLicense turns a detected license into a valid SPDX expression. Unknown
+// identifiers are replaced by references, returned with the names they stand for.
+func spdxLicense(expr string) (string, map[string]string) {
+	refs := make(map[string]string)
+	if expr == "" {
+		return "NOASSERTION", refs
+	}
+	if !strings.Contains(expr, " OR ") && !strings.Contains(expr, " AND ") && !strings.Contains(expr, " WITH ") {
+		if validLicenseID(expr) {
+			return expr, refs
+		}
+		ref := licenseRef(expr)
+		refs[ref] = expr
+		return ref, refs
+	}
+	fields := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr))
+	var out []string
+	for i := 0; i < len(fields); {
+		if spdxOperator(fields[i]) {
+			out = append(out, fields[i])
+			i++
+			continue
+		}
+		// The words up to the next operator are one term, as licenses.Parse reads them
+		j := i + 1
+		for j < len(fields) && !spdxOperator(fields[j]) {
+			j++
+		}
+		term := strings.Join(fields[i:j], " ")
+		if (len(out) == 0 || out[len(out)-1] != "WITH") && !validLicenseID(term) {
+			ref := licenseRef(term)
+			refs[ref] = term
+			term = ref
+		}
+		out = append(out, term)
+		i = j
+	}
+	return strings.NewReplacer("( ", "(", " )", ")").Replace(strings.Join(out, " ")), refs
+}
+
+// spdxOperator tells whether a token of an expression is an operator or a parenthesis
+func spdxOperator(token string) bool {
+	switch token {
+	case "OR", "AND", "WITH", "(", ")":
+		return true
+	}
+	return false
+}
+
+// validLicenseID tells whether a term of an expression is a listed SPDX
+// identifier, possibly with the "+" operator
+func validLicenseID(term string) bool {
+	return licenses.IsSPDXID(strings.TrimSuffix(term, "+"))
+}
+
+// marshal indents like the documents published by the reference tools
+func marshal(v any) ([]byte, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// rootRef identifies the repository within a document
+func rootRef(repo *domain.Repository) string {
+	return "repository-" + strconv.Itoa(repo.ID)
+}
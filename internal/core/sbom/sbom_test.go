@@ -0,0 +1,114 @@
+package sbom
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func testDocument() Document {
+	return Document{
+		Repository: &domain.Repository{
+			ID:          42,
+			FullName:    "acme/shop",
+			Description: domain.SQLNullString("The shop backend"),
+			URL:         "https://github.com/acme/shop",
+		},
+		License: "MIT OR Apache-2.0",
+		Dependencies: []domain.DependencyLicense{
+			{Ecosystem: "npm", Name: "left-pad", License: "WTFPL"},
+			{Ecosystem: "Go", Name: "github.com/go-chi/chi/v5", Version: "5.2.3", License: "MIT"},
+			{Ecosystem: "npm", Name: "@babel/core", Version: "7.24.0", License: "MIT"},
+			{Ecosystem: "PyPI", Name: "Django", Version: "4.2.11", License: "BSD-3-Clause"},
+			{Ecosystem: "Go", Name: "github.com/acme/kit", Version: "1.2.0", License: "GPL-3.0-only OR LicenseRef-acme-commercial"},
+			{Ecosystem: "PyPI", Name: "internal_tools", Version: "0.3"},
+			{Ecosystem: "crates.io", Name: "llvm-sys", Version: "180.0.0", License: "Apache-2.0 WITH LLVM-exception"},
+			{Ecosystem: "npm", Name: "later", Version: "2.0.0", License: "MIT-or-later"},
+			{Ecosystem: "npm", Name: "custom", Version: "1.0.0", License: "(Some Custom) OR MIT"},
+		},
+		Created: time.Date(2026, 10, 18, 9, 30, 15, 500, time.UTC),
+	}
+}
+
+// golden compares a rendered document with testdata/name; go test -update rewrites it
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestCycloneDX(t *testing.T) {
+	got, err := CycloneDX(testDocument())
+	require.NoError(t, err)
+	golden(t, "cyclonedx.json", got)
+
+	var bom map[string]any
+	require.NoError(t, json.Unmarshal(got, &bom))
+	assert.Equal(t, "1.5", bom["specVersion"])
+}
+
+func TestSPDX(t *testing.T) {
+	got, err := SPDX(testDocument())
+	require.NoError(t, err)
+	golden(t, "spdx.json", got)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(got, &doc))
+	assert.Equal(t, "SPDX-2.3", doc["spdxVersion"])
+}
+
+func TestCdxLicenses(t *testing.T) {
+	for expr, want := range map[string]cdxLicense{
+		"GPL-3.0-or-later":                     {License: &cdxLicenseID{ID: "GPL-3.0-or-later"}},
+		"MIT-or-later":                         {License: &cdxLicenseID{Name: "MIT-or-later"}},
+		"Apache-2.0 WITH LLVM-exception":       {Expression: "Apache-2.0 WITH LLVM-exception"},
+		"GPL-2.0+":                             {Expression: "GPL-2.0+"},
+		"(MIT OR Apache-2.0) AND BSD-3-Clause": {Expression: "(MIT OR Apache-2.0) AND BSD-3-Clause"},
+		"MIT OR Commercial":                    {License: &cdxLicenseID{Name: "MIT OR Commercial"}},
+	} {
+		assert.Equal(t, []cdxLicense{want}, cdxLicenses(expr), expr)
+	}
+}
+
+func TestRender(t *testing.T) {
+	doc := testDocument()
+	a, err := Render(doc, domain.SBOMFormatSPDX)
+	require.NoError(t, err)
+	doc.Dependencies = append(doc.Dependencies[3:], doc.Dependencies[:3]...)
+	b, err := Render(doc, domain.SBOMFormatSPDX)
+	require.NoError(t, err)
+	assert.Equal(t, string(a), string(b), "the order of the dependencies does not matter")
+
+	_, err = Render(doc, "swid")
+	assert.ErrorIs(t, err, domain.ErrValidation)
+}
+
+func TestPURL(t *testing.T) {
+	for _, tt := range []struct {
+		ecosystem, name, version, want string
+	}{
+		{"Go", "github.com/go-chi/chi/v5", "5.2.3", "pkg:golang/github.com/go-chi/chi/v5@v5.2.3"},
+		{"npm", "@babel/core", "7.24.0", "pkg:npm/%40babel/core@7.24.0"},
+		{"npm", "left-pad", "", "pkg:npm/left-pad"},
+		{"PyPI", "Django_Rest.Framework", "3.15", "pkg:pypi/django-rest-framework@3.15"},
+		{"Maven", "org.slf4j:slf4j-api", "2.0.13", "pkg:maven/org.slf4j/slf4j-api@2.0.13"},
+		{"Packagist", "laravel/framework", "11.0.0", "pkg:composer/laravel/framework@11.0.0"},
+		{"Hex", "phoenix", "1.7.0", ""},
+	} {
+		assert.Equal(t, tt.want, PURL(tt.ecosystem, tt.name, tt.version), tt.name)
+	}
+}
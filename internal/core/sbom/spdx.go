@@ -0,0 +1,135 @@
+package sbom
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// The subset of the SPDX 2.3 JSON schema the documents use
+type (
+	spdxDocument struct {
+		SPDXVersion       string                 `json:"spdxVersion"`
+		DataLicense       string                 `json:"dataLicense"`
+		SPDXID            string                 `json:"SPDXID"`
+		Name              string                 `json:"name"`
+		DocumentNamespace string                 `json:"documentNamespace"`
+		CreationInfo      spdxCreationInfo       `json:"creationInfo"`
+		Packages          []spdxPackage          `json:"packages"`
+		Relationships     []spdxRelationship     `json:"relationships"`
+		ExtractedLicenses []spdxExtractedLicense `json:"hasExtractedLicensingInfos,omitempty"`
+	}
+
+	spdxCreationInfo struct {
+		Created  string   `json:"created"`
+		Creators []string `json:"creators"`
+	}
+
+	spdxPackage struct {
+		Name                  string            `json:"name"`
+		SPDXID                string            `json:"SPDXID"`
+		VersionInfo           string            `json:"versionInfo,omitempty"`
+		DownloadLocation      string            `json:"downloadLocation"`
+		FilesAnalyzed         bool              `json:"filesAnalyzed"`
+		LicenseConcluded      string            `json:"licenseConcluded"`
+		LicenseDeclared       string            `json:"licenseDeclared"`
+		CopyrightText         string            `json:"copyrightText"`
+		Description           string            `json:"description,omitempty"`
+		ExternalRefs          []spdxExternalRef `json:"externalRefs,omitempty"`
+		PrimaryPackagePurpose string            `json:"primaryPackagePurpose"`
+	}
+
+	spdxExternalRef struct {
+		ReferenceCategory string `json:"referenceCategory"`
+		ReferenceType     string `json:"referenceType"`
+		ReferenceLocator  string `json:"referenceLocator"`
+	}
+
+	spdxRelationship struct {
+		SPDXElementID      string `json:"spdxElementId"`
+		RelationshipType   string `json:"relationshipType"`
+		RelatedSPDXElement string `json:"relatedSpdxElement"`
+	}
+
+	spdxExtractedLicense struct {
+		LicenseID     string `json:"licenseId"`
+		Name          string `json:"name"`
+		ExtractedText string `json:"extractedText"`
+	}
+)
+
+const (
+	spdxNoAssertion = "NOASSERTION"
+	spdxRootID      = "SPDXRef-Repository"
+)
+
+// SPDX renders the document as SPDX 2.3 JSON: the document describes the
+// repository package, which depends on a package per dependency. Licenses
+// are declared, not concluded, since nobody reviewed them; the ones without
+// an SPDX identifier become LicenseRef- references.
+func SPDX(doc Document) ([]byte, error) {
+	repo := doc.Repository
+	refs := make(map[string]string)
+	declared := func(expr string) string {
+		license, found := spdxLicense(expr)
+		for ref, name := range found {
+			refs[ref] = name
+		}
+		return license
+	}
+
+	root := spdxPackage{
+		Name:                  repo.FullName,
+		SPDXID:                spdxRootID,
+		DownloadLocation:      spdxNoAssertion,
+		LicenseConcluded:      spdxNoAssertion,
+		LicenseDeclared:       declared(doc.License),
+		CopyrightText:         spdxNoAssertion,
+		Description:           repo.Description.String,
+		PrimaryPackagePurpose: "APPLICATION",
+	}
+	if repo.URL != "" {
+		root.DownloadLocation = "git+" + repo.URL
+	}
+
+	out := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              repo.FullName,
+		DocumentNamespace: fmt.Sprintf("https://%s/spdxdocs/repository-%d-%s", toolName, repo.ID, doc.uuid()),
+		CreationInfo: spdxCreationInfo{
+			Created:  doc.timestamp(),
+			Creators: []string{"Tool: " + toolName},
+		},
+		Packages:      []spdxPackage{root},
+		Relationships: []spdxRelationship{{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: spdxRootID}},
+	}
+	for i, d := range doc.sorted() {
+		pkg := spdxPackage{
+			Name:                  d.Name,
+			SPDXID:                "SPDXRef-Package-" + strconv.Itoa(i+1),
+			VersionInfo:           d.Version,
+			DownloadLocation:      spdxNoAssertion,
+			LicenseConcluded:      spdxNoAssertion,
+			LicenseDeclared:       declared(d.License),
+			CopyrightText:         spdxNoAssertion,
+			PrimaryPackagePurpose: "LIBRARY",
+		}
+		if purl := PURL(d.Ecosystem, d.Name, d.Version); purl != "" {
+			pkg.ExternalRefs = []spdxExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: purl}}
+		}
+		out.Packages = append(out.Packages, pkg)
+		out.Relationships = append(out.Relationships, spdxRelationship{SPDXElementID: spdxRootID, RelationshipType: "DEPENDS_ON", RelatedSPDXElement: pkg.SPDXID})
+	}
+
+	for ref, name := range refs {
+		out.ExtractedLicenses = append(out.ExtractedLicenses, spdxExtractedLicense{
+			LicenseID:     ref,
+			Name:          name,
+			ExtractedText: fmt.Sprintf("The license declared as %q", name),
+		})
+	}
+	sort.Slice(out.ExtractedLicenses, func(i, j int) bool { return out.ExtractedLicenses[i].LicenseID < out.ExtractedLicenses[j].LicenseID })
+	return marshal(out)
+}
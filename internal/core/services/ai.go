@@ -2,73 +2,179 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/biodoia/ghrego/internal/core/domain"
 	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/biodoia/ghrego/internal/telemetry"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// PromptVersion identifies the analysis prompt template. Bump it whenever the
+// prompt changes so cached responses produced by the old template are not reused.
+const PromptVersion = "v2"
+
+// reportCacheTTL bounds staleness of aggregated analysis reports between invalidations
+const reportCacheTTL = 10 * time.Minute
+
 type AIAnalysisServiceImpl struct {
 	aiClient       ports.AIClient
+	hosts          SourceHosts
+	ingester       ports.CodeIngester
+	advisories     ports.AdvisoryDatabase
+	cacheRepo      ports.AnalysisCacheRepository
 	repoStore      ports.RepositoryStore
 	analysisRepo   ports.AnalysisRepository
 	featureRepo    ports.FeatureRepository
 	technologyRepo ports.TechnologyRepository
+	licenseRepo    ports.LicenseRepository
 	suggestionRepo ports.SuggestionRepository
+	cache          ports.Cache
+	usage          ports.UsageService
+	authz          ports.Authorizer
 }
 
+var _ ports.AIAnalysisService = (*AIAnalysisServiceImpl)(nil)
+
 func NewAIAnalysisService(
 	aiClient ports.AIClient,
+	hosts SourceHosts,
+	ingester ports.CodeIngester,
+	advisories ports.AdvisoryDatabase,
+	cacheRepo ports.AnalysisCacheRepository,
 	repoStore ports.RepositoryStore,
 	analysisRepo ports.AnalysisRepository,
 	featureRepo ports.FeatureRepository,
 	technologyRepo ports.TechnologyRepository,
+	licenseRepo ports.LicenseRepository,
 	suggestionRepo ports.SuggestionRepository,
-) ports.AIAnalysisService {
+	cache ports.Cache,
+	usage ports.UsageService,
+	authz ports.Authorizer,
+) *AIAnalysisServiceImpl {
 	return &AIAnalysisServiceImpl{
 		aiClient:       aiClient,
+		hosts:          hosts,
+		ingester:       ingester,
+		advisories:     advisories,
+		cacheRepo:      cacheRepo,
 		repoStore:      repoStore,
 		analysisRepo:   analysisRepo,
 		featureRepo:    featureRepo,
 		technologyRepo: technologyRepo,
+		licenseRepo:    licenseRepo,
 		suggestionRepo: suggestionRepo,
+		cache:          cache,
+		usage:          usage,
+		authz:          authz,
+	}
+}
+
+// analyzer runs analyses without checking who asked for them. It serves the
+// webhook and bulk services, which act for GitHub or for a batch authorized
+// when it started; users go through StartAnalysis.
+type analyzer interface {
+	analyze(ctx context.Context, repoID int, analysisType domain.AnalysisType, force bool) (*domain.Analysis, error)
+}
+
+// StartAnalysis runs an analysis of repoID for userID, who must be a maintainer of its workspace
+func (s *AIAnalysisServiceImpl) StartAnalysis(ctx context.Context, userID, repoID int, analysisType domain.AnalysisType, force bool) (*domain.Analysis, error) {
+	if err := s.AuthorizeAnalysis(ctx, userID, repoID); err != nil {
+		return nil, err
+	}
+	return s.analyze(ctx, repoID, analysisType, force)
+}
+
+func (s *AIAnalysisServiceImpl) analyze(ctx context.Context, repoID int, analysisType domain.AnalysisType, force bool) (*domain.Analysis, error) {
+	ctx, span := tracer.Start(ctx, "AIAnalysisService.AnalyzeRepository", trace.WithAttributes(
+		attribute.Int("repository.id", repoID),
+		attribute.String("analysis.type", string(analysisType)),
+		attribute.Bool("analysis.force", force),
+	))
+	defer span.End()
+
+	analysis, err := s.analyzeRepository(ctx, repoID, analysisType, force)
+	telemetry.RecordError(span, err)
+	if analysis != nil {
+		span.SetAttributes(attribute.Bool("analysis.cache_hit", analysis.CacheHit))
 	}
+	return analysis, err
 }
 
-func (s *AIAnalysisServiceImpl) AnalyzeRepository(ctx context.Context, repoID int, analysisType domain.AnalysisType) (*domain.Analysis, error) {
+func (s *AIAnalysisServiceImpl) analyzeRepository(ctx context.Context, repoID int, analysisType domain.AnalysisType, force bool) (*domain.Analysis, error) {
 	// 1. Fetch Repository Details
 	repo, err := s.repoStore.GetByID(ctx, repoID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get repository: %w", err)
 	}
-	if repo == nil {
-		return nil, fmt.Errorf("repository not found")
-	}
-
-	// 2. Build Prompt (Simplified for now - in production would fetch files/readme via GitHubService)
-	// Ideally we need GitHubService here too to fetch README and Dependencies.
-	// For this refactor, I'll assume we pass enough info or fetch it.
-	// To keep "lean", I'll just use the repo metadata we have.
-	prompt := fmt.Sprintf(`
-Analyze this repository:
-Name: %s
-Description: %s
-Language: %s
-Stars: %d
-URL: %s
-`, repo.FullName, repo.Description.String, repo.Language.String, repo.Stars, repo.URL)
-
-	// 3. Call AI
-	log.Info().Str("repo", repo.FullName).Msg("Starting AI analysis...")
-	response, err := s.aiClient.AnalyzeRepository(ctx, prompt)
-	if err != nil {
-		return nil, fmt.Errorf("AI analysis failed: %w", err)
+	if analysisType == domain.AnalysisTypeMetrics {
+		return s.analyzeMetrics(ctx, repo)
+	}
+	if s.aiClient == nil {
+		return nil, domain.UpstreamUnavailable("AI provider", errors.New("not configured"))
+	}
+
+	// 2. Build the prompt from a checkout of the default branch head
+	sha := s.headSHA(ctx, repo)
+	snap := s.checkout(ctx, repo, sha)
+	if snap != nil {
+		defer snap.Release()
 	}
+	prompt := buildAnalysisPrompt(repo, snap)
 
-	// 4. Save Results
+	// 3. Reuse a cached response for unchanged content, unless forced. A
+	// prompt without the checkout is not cached, so a later run can do better.
+	fingerprint := ""
+	if s.cacheRepo != nil && sha != "" && (snap != nil || s.ingester == nil) {
+		fingerprint = domain.AnalysisFingerprint(sha, PromptVersion, s.aiClient.ModelName())
+	}
+	var response *domain.RepositoryAnalysisResponse
+	cacheHit := false
+	if fingerprint != "" && !force {
+		response, err = s.cacheRepo.Get(ctx, fingerprint)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("repo", repo.FullName).Msg("Analysis cache lookup failed")
+		}
+		cacheHit = response != nil
+	}
+
+	// 4. Call AI
+	if cacheHit {
+		log.Ctx(ctx).Info().Str("repo", repo.FullName).Str("fingerprint", fingerprint).Msg("Reusing cached AI analysis")
+	} else {
+		if err := s.usage.CheckBudget(ctx); err != nil {
+			return nil, err
+		}
+		log.Ctx(ctx).Info().Str("repo", repo.FullName).Msg("Starting AI analysis...")
+		response, err = s.aiClient.AnalyzeRepository(ctx, prompt)
+		if err != nil {
+			var billed *domain.BilledError
+			if errors.As(err, &billed) {
+				s.recordFailedAnalysis(ctx, repo, analysisType, billed)
+			}
+			return nil, fmt.Errorf("AI analysis failed: %w", err)
+		}
+		if fingerprint != "" {
+			if err := s.cacheRepo.Set(ctx, fingerprint, repoID, response); err != nil {
+				log.Ctx(ctx).Warn().Err(err).Str("repo", repo.FullName).Msg("Failed to cache AI analysis")
+			}
+		}
+	}
+
+	// 5. Save Results
 	analysis, features, techs, suggestions := response.ToDomain(repoID)
 	analysis.AnalysisType = analysisType
+	analysis.Fingerprint = domain.SQLNullString(fingerprint)
+	analysis.CacheHit = cacheHit
+	if raw, err := json.Marshal(response); err == nil {
+		analysis.Result = domain.SQLNullString(string(raw))
+	}
 	
 	// Transaction would be better here, but doing sequential for now
 	analysisID, err := s.analysisRepo.Create(ctx, analysis)
@@ -77,29 +183,131 @@ URL: %s
 	}
 	analysis.ID = analysisID
 
-	// Save Features
-	if len(features) > 0 {
-		if err := s.featureRepo.BulkCreate(ctx, features); err != nil {
-			log.Error().Err(err).Msg("Failed to save features")
+	// Cache hits cost nothing, only fresh AI calls are billed
+	if !cacheHit {
+		if err := s.usage.Record(ctx, analysis, repo.UserID, response.Usage); err != nil {
+			log.Ctx(ctx).Error().Err(err).Int("analysis_id", analysisID).Msg("Failed to record AI usage")
 		}
 	}
 
-	// Save Technologies
-	if len(techs) > 0 {
-		if err := s.technologyRepo.BulkCreate(ctx, techs); err != nil {
-			log.Error().Err(err).Msg("Failed to save technologies")
-		}
+	// Save Features and Technologies, replacing those of earlier analyses so
+	// that a cached response replayed for the same head adds no duplicates
+	if err := s.featureRepo.Replace(ctx, repoID, features); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to save features")
+	}
+	if err := s.technologyRepo.ReplaceAnalyzed(ctx, repoID, techs); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to save technologies")
 	}
 
 	// Save Suggestions
+	if err := s.saveSuggestions(ctx, repoID, suggestions); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to save suggestions")
+	}
+
+	// Static analyses of the same checkout, to follow alongside the AI results
+	if snap != nil {
+		if _, err := s.saveMetrics(ctx, repo, snap); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Int("repo_id", repoID).Msg("Failed to record code metrics")
+		}
+		s.saveDetections(ctx, repo, snap)
+	}
+
+	if err := s.cache.InvalidateTags(ctx, domain.RepositoryCacheTag(repoID), domain.WorkspaceCacheTag(repo.WorkspaceID)); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Int("repo_id", repoID).Msg("Failed to invalidate cache after analysis")
+	}
+
+	log.Ctx(ctx).Info().Int("analysis_id", analysisID).Msg("AI Analysis completed and saved")
+	return analysis, nil
+}
+
+// saveSuggestions stores the AI suggestions the repository does not have
+// yet. Suggestions are matched by title, so that a repeated analysis neither
+// duplicates them nor resets the status of those already reviewed.
+func (s *AIAnalysisServiceImpl) saveSuggestions(ctx context.Context, repoID int, suggestions []domain.Suggestion) error {
+	if len(suggestions) == 0 {
+		return nil
+	}
+	existing, err := s.suggestionRepo.GetByRepositoryID(ctx, repoID)
+	if err != nil {
+		return fmt.Errorf("failed to load suggestions: %w", err)
+	}
+	made := make(map[string]bool)
+	for _, sugg := range existing {
+		made[sugg.Title] = true
+	}
 	for _, sugg := range suggestions {
+		if made[sugg.Title] {
+			continue
+		}
+		made[sugg.Title] = true
 		if _, err := s.suggestionRepo.Create(ctx, &sugg); err != nil {
-			log.Error().Err(err).Msg("Failed to save suggestion")
+			log.Ctx(ctx).Error().Err(err).Msg("Failed to save suggestion")
 		}
 	}
+	return nil
+}
 
-	log.Info().Int("analysis_id", analysisID).Msg("AI Analysis completed and saved")
-	return analysis, nil
+// recordFailedAnalysis saves an AI call that failed after its tokens were
+// billed as a failed analysis, so that its cost counts against the budget
+func (s *AIAnalysisServiceImpl) recordFailedAnalysis(ctx context.Context, repo *domain.Repository, analysisType domain.AnalysisType, billed *domain.BilledError) {
+	now := time.Now()
+	analysis := &domain.Analysis{
+		RepositoryID: repo.ID,
+		AnalysisType: analysisType,
+		Status:       domain.AnalysisStatusFailed,
+		ErrorMessage: domain.SQLNullString(billed.Error()),
+		CreatedAt:    now,
+		CompletedAt:  domain.SQLNullTime(now),
+	}
+	var err error
+	if analysis.ID, err = s.analysisRepo.Create(ctx, analysis); err != nil {
+		log.Ctx(ctx).Error().Err(err).Int("repo_id", repo.ID).Msg("Failed to save failed analysis")
+		return
+	}
+	if err := s.usage.Record(ctx, analysis, repo.UserID, billed.Usage); err != nil {
+		log.Ctx(ctx).Error().Err(err).Int("analysis_id", analysis.ID).Msg("Failed to record AI usage")
+	}
+}
+
+// headSHA resolves the default branch head, which identifies the content
+// being analysed. It returns "" when that fails, which disables caching and
+// checkouts for this run.
+func (s *AIAnalysisServiceImpl) headSHA(ctx context.Context, repo *domain.Repository) string {
+	if s.cacheRepo == nil && s.ingester == nil {
+		return ""
+	}
+	host, err := s.hosts.For(domain.ProviderOf(repo))
+	if err != nil {
+		return ""
+	}
+	owner, name, ok := strings.Cut(repo.FullName, "/")
+	if !ok || repo.DefaultBranch == "" {
+		return ""
+	}
+	sha, err := host.GetBranchSHA(ctx, owner, name, repo.DefaultBranch)
+	if err != nil || sha == "" {
+		log.Ctx(ctx).Warn().Err(err).Str("repo", repo.FullName).Msg("Could not resolve default branch head, skipping analysis cache")
+		return ""
+	}
+	return sha
+}
+
+// checkout returns the repository's files at sha, or nil when ingestion is
+// disabled or fails; analysis then works from the metadata alone
+func (s *AIAnalysisServiceImpl) checkout(ctx context.Context, repo *domain.Repository, sha string) ports.CodeSnapshot {
+	if s.ingester == nil || sha == "" {
+		return nil
+	}
+	host, err := s.hosts.For(domain.ProviderOf(repo))
+	if err != nil {
+		return nil
+	}
+	snap, err := s.ingester.Checkout(ctx, host, repo, sha)
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("repo", repo.FullName).Msg("Could not check out repository, analysing metadata only")
+		return nil
+	}
+	return snap
 }
 
 func (s *AIAnalysisServiceImpl) GenerateSuggestions(ctx context.Context, repoID int) ([]domain.Suggestion, error) {
@@ -107,3 +315,76 @@ func (s *AIAnalysisServiceImpl) GenerateSuggestions(ctx context.Context, repoID
 	// For now, return what we have in DB
 	return s.suggestionRepo.GetByRepositoryID(ctx, repoID)
 }
+
+// GetAnalysisReport aggregates analyses, features, technologies and suggestions for a repository
+func (s *AIAnalysisServiceImpl) GetAnalysisReport(ctx context.Context, userID int, repoID int) (*domain.AnalysisReport, error) {
+	// Authorized before the cache lookup, so cached reports are not served to other users
+	if _, err := s.authz.Repository(ctx, userID, repoID, domain.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+
+	cacheKey := fmt.Sprintf("analysis:report:%d", repoID)
+	var report domain.AnalysisReport
+	if found, err := s.cache.Get(ctx, cacheKey, &report); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("key", cacheKey).Msg("Report cache read failed")
+	} else if found {
+		return &report, nil
+	}
+
+	report.RepositoryID = repoID
+	var err error
+	if report.Analyses, err = s.analysisRepo.GetByRepositoryID(ctx, repoID); err != nil {
+		return nil, fmt.Errorf("failed to get analyses: %w", err)
+	}
+	report.Metrics = latestMetrics(report.Analyses)
+	if report.Features, err = s.featureRepo.GetByRepositoryID(ctx, repoID); err != nil {
+		return nil, fmt.Errorf("failed to get features: %w", err)
+	}
+	if report.Technologies, err = s.technologyRepo.GetByRepositoryID(ctx, repoID); err != nil {
+		return nil, fmt.Errorf("failed to get technologies: %w", err)
+	}
+	if report.Suggestions, err = s.suggestionRepo.GetByRepositoryID(ctx, repoID); err != nil {
+		return nil, fmt.Errorf("failed to get suggestions: %w", err)
+	}
+
+	if err := s.cache.Set(ctx, cacheKey, &report, reportCacheTTL, domain.RepositoryCacheTag(repoID)); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("key", cacheKey).Msg("Report cache write failed")
+	}
+	return &report, nil
+}
+
+func (s *AIAnalysisServiceImpl) AuthorizeAnalysis(ctx context.Context, userID int, repoID int) error {
+	_, err := s.authz.Repository(ctx, userID, repoID, domain.WorkspaceRoleMaintainer)
+	return err
+}
+
+func (s *AIAnalysisServiceImpl) ListPendingSuggestions(ctx context.Context, userID, workspaceID int) ([]domain.Suggestion, error) {
+	if err := s.authz.AuthorizeWorkspace(ctx, userID, workspaceID, domain.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+	return s.suggestionRepo.GetAllPending(ctx, workspaceID)
+}
+
+func (s *AIAnalysisServiceImpl) UpdateSuggestionStatus(ctx context.Context, userID int, suggestionID int, status domain.SuggestionStatus) error {
+	switch status {
+	case domain.SuggestionStatusPending, domain.SuggestionStatusAccepted, domain.SuggestionStatusRejected, domain.SuggestionStatusApplied:
+	default:
+		return domain.Validation("invalid suggestion status %q", status)
+	}
+
+	sugg, err := s.suggestionRepo.GetByID(ctx, suggestionID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.authz.Repository(ctx, userID, sugg.RepositoryID, domain.WorkspaceRoleMaintainer); err != nil {
+		return err
+	}
+	if err := s.suggestionRepo.UpdateStatus(ctx, suggestionID, status); err != nil {
+		return err
+	}
+
+	if err := s.cache.InvalidateTags(ctx, domain.RepositoryCacheTag(sugg.RepositoryID)); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Int("repo_id", sugg.RepositoryID).Msg("Failed to invalidate cache after suggestion update")
+	}
+	return nil
+}
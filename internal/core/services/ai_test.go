@@ -2,15 +2,39 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
+	"testing/fstest"
 
+	"github.com/biodoia/ghrego/internal/cache"
 	"github.com/biodoia/ghrego/internal/core/domain"
 	"github.com/biodoia/ghrego/internal/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
+func TestAIAnalysisServiceImpl_StartAnalysis(t *testing.T) {
+	mockAIClient := new(mocks.AIClient)
+	mockRepoStore := new(mocks.RepositoryStore)
+	mockUsage := new(mocks.UsageService)
+	users := new(mocks.UserRepository)
+	workspaces := new(mocks.WorkspaceRepository)
+	svc := NewAIAnalysisService(mockAIClient, nil, nil, nil, nil, mockRepoStore, nil, nil, nil, nil, nil, cache.NewMemoryCache(), mockUsage, NewAuthorizer(users, mockRepoStore, workspaces))
+
+	mockRepoStore.On("GetByID", mock.Anything, 1).Return(&domain.Repository{ID: 1, WorkspaceID: 7}, nil)
+	users.On("GetByID", mock.Anything, 2).Return(&domain.User{ID: 2, Role: domain.UserRoleUser}, nil)
+	workspaces.On("GetMember", mock.Anything, 7, 2).Return(&domain.WorkspaceMember{WorkspaceID: 7, UserID: 2, Role: domain.WorkspaceRoleViewer}, nil)
+
+	_, err := svc.StartAnalysis(context.Background(), 2, 1, domain.AnalysisTypeArchitecture, false)
+
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	mockUsage.AssertNotCalled(t, "CheckBudget", mock.Anything)
+	mockAIClient.AssertNotCalled(t, "AnalyzeRepository", mock.Anything, mock.Anything)
+}
+
 func TestAIAnalysisServiceImpl_AnalyzeRepository(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockAIClient := new(mocks.AIClient)
@@ -20,7 +44,8 @@ func TestAIAnalysisServiceImpl_AnalyzeRepository(t *testing.T) {
 		mockTechRepo := new(mocks.TechnologyRepository)
 		mockSuggRepo := new(mocks.SuggestionRepository)
 
-		svc := NewAIAnalysisService(mockAIClient, mockRepoStore, mockAnalysisRepo, mockFeatureRepo, mockTechRepo, mockSuggRepo)
+		mockUsage := new(mocks.UsageService)
+		svc := NewAIAnalysisService(mockAIClient, nil, nil, nil, nil, mockRepoStore, mockAnalysisRepo, mockFeatureRepo, mockTechRepo, nil, mockSuggRepo, cache.NewMemoryCache(), mockUsage, NewAuthorizer(nil, mockRepoStore, nil))
 
 		// Setup Data
 		repo := &domain.Repository{
@@ -40,12 +65,14 @@ func TestAIAnalysisServiceImpl_AnalyzeRepository(t *testing.T) {
 		
 		// Expect saves
 		mockAnalysisRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Analysis")).Return(100, nil)
-		mockFeatureRepo.On("BulkCreate", mock.Anything, mock.AnythingOfType("[]domain.Feature")).Return(nil)
-		mockTechRepo.On("BulkCreate", mock.Anything, mock.AnythingOfType("[]domain.Technology")).Return(nil)
+		mockFeatureRepo.On("Replace", mock.Anything, 1, mock.AnythingOfType("[]domain.Feature")).Return(nil)
+		mockTechRepo.On("ReplaceAnalyzed", mock.Anything, 1, mock.AnythingOfType("[]domain.Technology")).Return(nil)
 		mockSuggRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Suggestion")).Return(1, nil).Maybe() // Depends if suggestions are present
+		mockUsage.On("CheckBudget", mock.Anything).Return(nil)
+		mockUsage.On("Record", mock.Anything, mock.AnythingOfType("*domain.Analysis"), 0, mock.Anything).Return(nil)
 
 		// Execute
-		res, err := svc.AnalyzeRepository(context.Background(), 1, domain.AnalysisTypeArchitecture)
+		res, err := svc.analyze(context.Background(), 1, domain.AnalysisTypeArchitecture, false)
 		
 		// Assert
 		assert.NoError(t, err)
@@ -55,27 +82,267 @@ func TestAIAnalysisServiceImpl_AnalyzeRepository(t *testing.T) {
 
 	t.Run("repo not found", func(t *testing.T) {
 		mockRepoStore := new(mocks.RepositoryStore)
-		svc := NewAIAnalysisService(nil, mockRepoStore, nil, nil, nil, nil)
+		mockUsage := new(mocks.UsageService)
+		svc := NewAIAnalysisService(nil, nil, nil, nil, nil, mockRepoStore, nil, nil, nil, nil, nil, cache.NewMemoryCache(), mockUsage, NewAuthorizer(nil, mockRepoStore, nil))
 		
-		mockRepoStore.On("GetByID", mock.Anything, 99).Return(nil, nil) // or error
-		// Note: implementation checks if repo == nil -> error "repository not found"
+		mockRepoStore.On("GetByID", mock.Anything, 99).Return(nil, domain.NotFound("repository", 99))
 		
-		_, err := svc.AnalyzeRepository(context.Background(), 99, domain.AnalysisTypeArchitecture)
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "not found")
+		_, err := svc.analyze(context.Background(), 99, domain.AnalysisTypeArchitecture, false)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
 	})
 
 	t.Run("ai client error", func(t *testing.T) {
 		mockAIClient := new(mocks.AIClient)
 		mockRepoStore := new(mocks.RepositoryStore)
-		svc := NewAIAnalysisService(mockAIClient, mockRepoStore, nil, nil, nil, nil)
+		mockUsage := new(mocks.UsageService)
+		svc := NewAIAnalysisService(mockAIClient, nil, nil, nil, nil, mockRepoStore, nil, nil, nil, nil, nil, cache.NewMemoryCache(), mockUsage, NewAuthorizer(nil, mockRepoStore, nil))
 
 		repo := &domain.Repository{ID: 1}
 		mockRepoStore.On("GetByID", mock.Anything, 1).Return(repo, nil)
 		mockAIClient.On("AnalyzeRepository", mock.Anything, mock.Anything).Return(nil, errors.New("ai error"))
+		mockUsage.On("CheckBudget", mock.Anything).Return(nil)
 		
-		_, err := svc.AnalyzeRepository(context.Background(), 1, domain.AnalysisTypeArchitecture)
+		_, err := svc.analyze(context.Background(), 1, domain.AnalysisTypeArchitecture, false)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "AI analysis failed")
 	})
-}
\ No newline at end of file
+
+	t.Run("unparseable response still records usage", func(t *testing.T) {
+		mockAIClient := new(mocks.AIClient)
+		mockRepoStore := new(mocks.RepositoryStore)
+		mockAnalysisRepo := new(mocks.AnalysisRepository)
+		mockUsage := new(mocks.UsageService)
+		svc := NewAIAnalysisService(mockAIClient, nil, nil, nil, nil, mockRepoStore, mockAnalysisRepo, nil, nil, nil, nil, cache.NewMemoryCache(), mockUsage, NewAuthorizer(nil, mockRepoStore, nil))
+
+		repo := &domain.Repository{ID: 1, UserID: 3}
+		usage := domain.TokenUsage{Model: "test-model", PromptTokens: 1000, OutputTokens: 50}
+		mockRepoStore.On("GetByID", mock.Anything, 1).Return(repo, nil)
+		mockUsage.On("CheckBudget", mock.Anything).Return(nil)
+		mockAIClient.On("AnalyzeRepository", mock.Anything, mock.Anything).Return(nil, &domain.BilledError{
+			Usage: usage,
+			Err:   domain.UpstreamUnavailable("AI provider", errors.New("failed to parse LLM response")),
+		})
+		mockAnalysisRepo.On("Create", mock.Anything, mock.MatchedBy(func(a *domain.Analysis) bool {
+			return a.Status == domain.AnalysisStatusFailed && a.ErrorMessage.Valid
+		})).Return(105, nil)
+		mockUsage.On("Record", mock.Anything, mock.MatchedBy(func(a *domain.Analysis) bool { return a.ID == 105 }), 3, usage).Return(nil)
+
+		_, err := svc.analyze(context.Background(), 1, domain.AnalysisTypeArchitecture, false)
+
+		assert.ErrorIs(t, err, domain.ErrUpstreamUnavailable)
+		mockUsage.AssertCalled(t, "Record", mock.Anything, mock.Anything, 3, usage)
+	})
+
+	t.Run("cache hit skips ai call", func(t *testing.T) {
+		mockAIClient := new(mocks.AIClient)
+		mockGHClient := new(mocks.GitHubClient)
+		mockCache := new(mocks.AnalysisCacheRepository)
+		mockRepoStore := new(mocks.RepositoryStore)
+		mockAnalysisRepo := new(mocks.AnalysisRepository)
+		mockFeatureRepo := new(mocks.FeatureRepository)
+		mockTechRepo := new(mocks.TechnologyRepository)
+		mockSuggRepo := new(mocks.SuggestionRepository)
+		mockUsage := new(mocks.UsageService)
+		svc := NewAIAnalysisService(mockAIClient, NewSourceHosts(mockGHClient), nil, nil, mockCache, mockRepoStore, mockAnalysisRepo, mockFeatureRepo, mockTechRepo, nil, mockSuggRepo, cache.NewMemoryCache(), mockUsage, NewAuthorizer(nil, mockRepoStore, nil))
+
+		repo := &domain.Repository{ID: 1, FullName: "owner/repo1", DefaultBranch: "main"}
+		fingerprint := domain.AnalysisFingerprint("abc123", PromptVersion, "test-model")
+		cached := &domain.RepositoryAnalysisResponse{}
+		require.NoError(t, json.Unmarshal([]byte(`{"architecture": "Monolith", "features": [{"name": "Auth"}], "suggestions": [{"type": "refactor", "title": "Split main"}]}`), cached))
+
+		mockRepoStore.On("GetByID", mock.Anything, 1).Return(repo, nil)
+		mockGHClient.On("GetBranchSHA", mock.Anything, "owner", "repo1", "main").Return("abc123", nil)
+		mockAIClient.On("ModelName").Return("test-model")
+		mockCache.On("Get", mock.Anything, fingerprint).Return(cached, nil)
+		mockAnalysisRepo.On("Create", mock.Anything, mock.MatchedBy(func(a *domain.Analysis) bool {
+			return a.CacheHit && a.Fingerprint.String == fingerprint
+		})).Return(101, nil)
+		mockFeatureRepo.On("Replace", mock.Anything, 1, mock.MatchedBy(func(features []domain.Feature) bool {
+			return len(features) == 1 && features[0].Name == "Auth"
+		})).Return(nil)
+		mockTechRepo.On("ReplaceAnalyzed", mock.Anything, 1, mock.Anything).Return(nil)
+		// The first analysis of this head already made the suggestion
+		mockSuggRepo.On("GetByRepositoryID", mock.Anything, 1).Return([]domain.Suggestion{
+			{RepositoryID: 1, SuggestionType: domain.SuggestionTypeRefactor, Title: "Split main", Status: domain.SuggestionStatusAccepted},
+		}, nil)
+
+		res, err := svc.analyze(context.Background(), 1, domain.AnalysisTypeArchitecture, false)
+
+		assert.NoError(t, err)
+		assert.True(t, res.CacheHit)
+		assert.Equal(t, "Monolith", res.Summary.String)
+		mockAIClient.AssertNotCalled(t, "AnalyzeRepository", mock.Anything, mock.Anything)
+		mockUsage.AssertNotCalled(t, "Record", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockFeatureRepo.AssertExpectations(t)
+		mockFeatureRepo.AssertNotCalled(t, "BulkCreate", mock.Anything, mock.Anything)
+		mockSuggRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("force bypasses cache", func(t *testing.T) {
+		mockAIClient := new(mocks.AIClient)
+		mockGHClient := new(mocks.GitHubClient)
+		mockCache := new(mocks.AnalysisCacheRepository)
+		mockRepoStore := new(mocks.RepositoryStore)
+		mockAnalysisRepo := new(mocks.AnalysisRepository)
+		mockFeatureRepo := new(mocks.FeatureRepository)
+		mockTechRepo := new(mocks.TechnologyRepository)
+		mockUsage := new(mocks.UsageService)
+		svc := NewAIAnalysisService(mockAIClient, NewSourceHosts(mockGHClient), nil, nil, mockCache, mockRepoStore, mockAnalysisRepo, mockFeatureRepo, mockTechRepo, nil, nil, cache.NewMemoryCache(), mockUsage, NewAuthorizer(nil, mockRepoStore, nil))
+
+		repo := &domain.Repository{ID: 1, FullName: "owner/repo1", DefaultBranch: "main"}
+		fingerprint := domain.AnalysisFingerprint("abc123", PromptVersion, "test-model")
+		response := &domain.RepositoryAnalysisResponse{Architecture: "Hexagonal"}
+
+		mockRepoStore.On("GetByID", mock.Anything, 1).Return(repo, nil)
+		mockGHClient.On("GetBranchSHA", mock.Anything, "owner", "repo1", "main").Return("abc123", nil)
+		mockAIClient.On("ModelName").Return("test-model")
+		mockAIClient.On("AnalyzeRepository", mock.Anything, mock.AnythingOfType("string")).Return(response, nil)
+		mockCache.On("Set", mock.Anything, fingerprint, 1, response).Return(nil)
+		mockAnalysisRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Analysis")).Return(102, nil)
+		mockFeatureRepo.On("Replace", mock.Anything, 1, mock.Anything).Return(nil)
+		mockTechRepo.On("ReplaceAnalyzed", mock.Anything, 1, mock.Anything).Return(nil)
+		mockUsage.On("CheckBudget", mock.Anything).Return(nil)
+		mockUsage.On("Record", mock.Anything, mock.AnythingOfType("*domain.Analysis"), 0, mock.Anything).Return(nil)
+
+		res, err := svc.analyze(context.Background(), 1, domain.AnalysisTypeArchitecture, true)
+
+		assert.NoError(t, err)
+		assert.False(t, res.CacheHit)
+		mockCache.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("prompt built from checkout", func(t *testing.T) {
+		mockAIClient := new(mocks.AIClient)
+		mockGHClient := new(mocks.GitHubClient)
+		mockIngester := new(mocks.CodeIngester)
+		mockCache := new(mocks.AnalysisCacheRepository)
+		mockRepoStore := new(mocks.RepositoryStore)
+		mockAnalysisRepo := new(mocks.AnalysisRepository)
+		mockUsage := new(mocks.UsageService)
+		mockFeatureRepo := new(mocks.FeatureRepository)
+		mockTechRepo := new(mocks.TechnologyRepository)
+		mockLicenseRepo := new(mocks.LicenseRepository)
+		svc := NewAIAnalysisService(mockAIClient, NewSourceHosts(mockGHClient), mockIngester, nil, mockCache, mockRepoStore, mockAnalysisRepo, mockFeatureRepo, mockTechRepo, mockLicenseRepo, nil, cache.NewMemoryCache(), mockUsage, NewAuthorizer(nil, mockRepoStore, nil))
+
+		repo := &domain.Repository{ID: 1, FullName: "owner/repo1", DefaultBranch: "main"}
+		snap := &mocks.CodeSnapshot{Commit: "abc123", MapFS: fstest.MapFS{
+			"Dockerfile":                 {Data: []byte("FROM golang:1.25\n")},
+			"README.md":                  {Data: []byte("# Repo one\nInventory service")},
+			"go.mod":                     {Data: []byte("module example.com/repo1\n")},
+			"cmd/server/main.go":         {Data: []byte("package main\n")},
+			"node_modules/left-pad/x.js": {Data: []byte("module.exports = 1\n")},
+			"assets/logo.png":            {Data: []byte("\x89PNG\x00\x00")},
+		}}
+		response := &domain.RepositoryAnalysisResponse{Architecture: "Monolith"}
+
+		mockRepoStore.On("GetByID", mock.Anything, 1).Return(repo, nil)
+		mockGHClient.On("GetBranchSHA", mock.Anything, "owner", "repo1", "main").Return("abc123", nil)
+		mockIngester.On("Checkout", mock.Anything, mockGHClient, repo, "abc123").Return(snap, nil)
+		mockAIClient.On("ModelName").Return("test-model")
+		mockCache.On("Get", mock.Anything, mock.Anything).Return(nil, nil)
+		mockCache.On("Set", mock.Anything, mock.Anything, 1, response).Return(nil)
+		mockAIClient.On("AnalyzeRepository", mock.Anything, mock.MatchedBy(func(prompt string) bool {
+			return strings.Contains(prompt, "Inventory service") &&
+				strings.Contains(prompt, "--- go.mod ---\nmodule example.com/repo1") &&
+				strings.Contains(prompt, "cmd/server/main.go") &&
+				!strings.Contains(prompt, "left-pad")
+		})).Return(response, nil)
+		mockAnalysisRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Analysis")).Return(103, nil)
+		mockFeatureRepo.On("Replace", mock.Anything, 1, mock.Anything).Return(nil)
+		mockTechRepo.On("ReplaceAnalyzed", mock.Anything, 1, mock.Anything).Return(nil)
+		mockUsage.On("CheckBudget", mock.Anything).Return(nil)
+		mockUsage.On("Record", mock.Anything, mock.AnythingOfType("*domain.Analysis"), 0, mock.Anything).Return(nil)
+		mockTechRepo.On("ReplaceDetected", mock.Anything, 1, mock.MatchedBy(func(techs []domain.Technology) bool {
+			return len(techs) == 1 && techs[0].Name == "Docker" && techs[0].Evidence.String == "Dockerfile" && techs[0].RepositoryID == 1
+		})).Return(nil)
+		mockLicenseRepo.On("Replace", mock.Anything, 1, []domain.RepositoryLicense{}).Return(nil)
+
+		_, err := svc.analyze(context.Background(), 1, domain.AnalysisTypeArchitecture, false)
+
+		assert.NoError(t, err)
+		assert.True(t, snap.Released)
+		mockAIClient.AssertExpectations(t)
+		mockTechRepo.AssertExpectations(t)
+		mockLicenseRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed checkout is not cached", func(t *testing.T) {
+		mockAIClient := new(mocks.AIClient)
+		mockGHClient := new(mocks.GitHubClient)
+		mockIngester := new(mocks.CodeIngester)
+		mockCache := new(mocks.AnalysisCacheRepository)
+		mockRepoStore := new(mocks.RepositoryStore)
+		mockAnalysisRepo := new(mocks.AnalysisRepository)
+		mockFeatureRepo := new(mocks.FeatureRepository)
+		mockTechRepo := new(mocks.TechnologyRepository)
+		mockUsage := new(mocks.UsageService)
+		svc := NewAIAnalysisService(mockAIClient, NewSourceHosts(mockGHClient), mockIngester, nil, mockCache, mockRepoStore, mockAnalysisRepo, mockFeatureRepo, mockTechRepo, nil, nil, cache.NewMemoryCache(), mockUsage, NewAuthorizer(nil, mockRepoStore, nil))
+
+		repo := &domain.Repository{ID: 1, FullName: "owner/repo1", DefaultBranch: "main"}
+		mockRepoStore.On("GetByID", mock.Anything, 1).Return(repo, nil)
+		mockGHClient.On("GetBranchSHA", mock.Anything, "owner", "repo1", "main").Return("abc123", nil)
+		mockIngester.On("Checkout", mock.Anything, mockGHClient, repo, "abc123").Return(nil, domain.Validation("too large"))
+		mockAIClient.On("AnalyzeRepository", mock.Anything, mock.AnythingOfType("string")).Return(&domain.RepositoryAnalysisResponse{}, nil)
+		mockAnalysisRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Analysis")).Return(104, nil)
+		mockFeatureRepo.On("Replace", mock.Anything, 1, mock.Anything).Return(nil)
+		mockTechRepo.On("ReplaceAnalyzed", mock.Anything, 1, mock.Anything).Return(nil)
+		mockUsage.On("CheckBudget", mock.Anything).Return(nil)
+		mockUsage.On("Record", mock.Anything, mock.AnythingOfType("*domain.Analysis"), 0, mock.Anything).Return(nil)
+
+		res, err := svc.analyze(context.Background(), 1, domain.AnalysisTypeArchitecture, false)
+
+		assert.NoError(t, err)
+		assert.False(t, res.Fingerprint.Valid)
+		mockCache.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+		mockCache.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("metrics without the AI provider", func(t *testing.T) {
+		mockGHClient := new(mocks.GitHubClient)
+		mockIngester := new(mocks.CodeIngester)
+		mockRepoStore := new(mocks.RepositoryStore)
+		mockAnalysisRepo := new(mocks.AnalysisRepository)
+		mockUsage := new(mocks.UsageService)
+		mockTechRepo := new(mocks.TechnologyRepository)
+		mockLicenseRepo := new(mocks.LicenseRepository)
+		svc := NewAIAnalysisService(nil, NewSourceHosts(mockGHClient), mockIngester, nil, nil, mockRepoStore, mockAnalysisRepo, nil, mockTechRepo, mockLicenseRepo, nil, cache.NewMemoryCache(), mockUsage, NewAuthorizer(nil, mockRepoStore, nil))
+
+		repo := &domain.Repository{ID: 1, FullName: "owner/repo1", DefaultBranch: "main"}
+		snap := &mocks.CodeSnapshot{Commit: "abc123", MapFS: fstest.MapFS{
+			"main.go":      {Data: []byte("package main\n\n// main does nothing\nfunc main() {}\n")},
+			"main_test.go": {Data: []byte("package main\n")},
+		}}
+		mockRepoStore.On("GetByID", mock.Anything, 1).Return(repo, nil)
+		mockGHClient.On("GetBranchSHA", mock.Anything, "owner", "repo1", "main").Return("abc123", nil)
+		mockIngester.On("Checkout", mock.Anything, mockGHClient, repo, "abc123").Return(snap, nil)
+		mockAnalysisRepo.On("Create", mock.Anything, mock.MatchedBy(func(a *domain.Analysis) bool {
+			return a.AnalysisType == domain.AnalysisTypeMetrics && strings.Contains(a.Result.String, `"commit":"abc123"`)
+		})).Return(105, nil)
+		mockTechRepo.On("ReplaceDetected", mock.Anything, 1, []domain.Technology{}).Return(nil)
+		mockLicenseRepo.On("Replace", mock.Anything, 1, []domain.RepositoryLicense{}).Return(nil)
+
+		res, err := svc.analyze(context.Background(), 1, domain.AnalysisTypeMetrics, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 105, res.ID)
+		assert.Equal(t, "3 lines of code in 2 files, 25% comments, test to source ratio 0.50; Go: 1 packages, average complexity 1.0, max 1", res.Summary.String)
+		assert.True(t, snap.Released)
+		mockUsage.AssertNotCalled(t, "CheckBudget", mock.Anything)
+	})
+
+	t.Run("budget exceeded", func(t *testing.T) {
+		mockAIClient := new(mocks.AIClient)
+		mockRepoStore := new(mocks.RepositoryStore)
+		mockUsage := new(mocks.UsageService)
+		svc := NewAIAnalysisService(mockAIClient, nil, nil, nil, nil, mockRepoStore, nil, nil, nil, nil, nil, cache.NewMemoryCache(), mockUsage, NewAuthorizer(nil, mockRepoStore, nil))
+
+		mockRepoStore.On("GetByID", mock.Anything, 1).Return(&domain.Repository{ID: 1}, nil)
+		mockUsage.On("CheckBudget", mock.Anything).Return(domain.ErrAIBudgetExceeded)
+
+		_, err := svc.analyze(context.Background(), 1, domain.AnalysisTypeArchitecture, false)
+
+		assert.ErrorIs(t, err, domain.ErrAIBudgetExceeded)
+		mockAIClient.AssertNotCalled(t, "AnalyzeRepository", mock.Anything, mock.Anything)
+	})
+}
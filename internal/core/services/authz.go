@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/rs/zerolog/log"
+)
+
+// OwnershipAuthorizer grants users access to their own batches, to repositories
+// of workspaces they belong to (subject to their role) and admins access to all of them
+type OwnershipAuthorizer struct {
+	userRepo      ports.UserRepository
+	repoStore     ports.RepositoryStore
+	workspaceRepo ports.WorkspaceRepository
+}
+
+func NewAuthorizer(userRepo ports.UserRepository, repoStore ports.RepositoryStore, workspaceRepo ports.WorkspaceRepository) ports.Authorizer {
+	return &OwnershipAuthorizer{
+		userRepo:      userRepo,
+		repoStore:     repoStore,
+		workspaceRepo: workspaceRepo,
+	}
+}
+
+func (a *OwnershipAuthorizer) AuthorizeOwner(ctx context.Context, userID, ownerID int) error {
+	// Owners are the common case and need no lookup
+	if userID == ownerID {
+		return nil
+	}
+	return a.adminOverride(ctx, userID, domain.Forbidden("access denied"))
+}
+
+func (a *OwnershipAuthorizer) AuthorizeAdmin(ctx context.Context, userID int) error {
+	return a.adminOverride(ctx, userID, domain.Forbidden("admin only"))
+}
+
+func (a *OwnershipAuthorizer) AuthorizeWorkspace(ctx context.Context, userID, workspaceID int, required domain.WorkspaceRole) error {
+	member, err := a.workspaceRepo.GetMember(ctx, workspaceID, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return a.adminOverride(ctx, userID, domain.Forbidden("not a member of this workspace"))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get workspace member: %w", err)
+	}
+	if !member.Role.Allows(required) {
+		return a.adminOverride(ctx, userID, domain.Forbidden(fmt.Sprintf("requires the %s role in this workspace", required)))
+	}
+	return nil
+}
+
+func (a *OwnershipAuthorizer) Repository(ctx context.Context, userID, repoID int, required domain.WorkspaceRole) (*domain.Repository, error) {
+	repo, err := a.repoStore.GetByID(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.AuthorizeWorkspace(ctx, userID, repo.WorkspaceID, required); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// adminOverride returns nil when userID is an admin and denied otherwise
+func (a *OwnershipAuthorizer) adminOverride(ctx context.Context, userID int, denied error) error {
+	user, err := a.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return denied
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.Role != domain.UserRoleAdmin {
+		return denied
+	}
+
+	log.Ctx(ctx).Info().Msg("Admin cross-user access")
+	return nil
+}
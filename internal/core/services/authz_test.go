@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// workspaceMembers returns a WorkspaceRepository in which every user has role in every workspace
+func workspaceMembers(role domain.WorkspaceRole) *mocks.WorkspaceRepository {
+	m := new(mocks.WorkspaceRepository)
+	m.On("GetMember", mock.Anything, mock.Anything, mock.Anything).Return(&domain.WorkspaceMember{Role: role}, nil)
+	return m
+}
+
+func TestOwnershipAuthorizer(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepository)
+	mockRepoStore := new(mocks.RepositoryStore)
+	mockWorkspaceRepo := new(mocks.WorkspaceRepository)
+	authz := NewAuthorizer(mockUserRepo, mockRepoStore, mockWorkspaceRepo)
+
+	mockUserRepo.On("GetByID", mock.Anything, 2).Return(&domain.User{ID: 2, Role: domain.UserRoleUser}, nil)
+	mockUserRepo.On("GetByID", mock.Anything, 3).Return(&domain.User{ID: 3, Role: domain.UserRoleAdmin}, nil)
+	mockUserRepo.On("GetByID", mock.Anything, 4).Return(nil, domain.NotFound("user", 4))
+	mockUserRepo.On("GetByID", mock.Anything, 5).Return(nil, errors.New("connection refused"))
+	mockUserRepo.On("GetByID", mock.Anything, 6).Return(&domain.User{ID: 6, Role: domain.UserRoleUser}, nil)
+	mockRepoStore.On("GetByID", mock.Anything, 10).Return(&domain.Repository{ID: 10, UserID: 1, WorkspaceID: 7}, nil)
+	mockRepoStore.On("GetByID", mock.Anything, 11).Return(nil, domain.NotFound("repository", 11))
+	mockWorkspaceRepo.On("GetMember", mock.Anything, 7, 1).Return(&domain.WorkspaceMember{Role: domain.WorkspaceRoleOwner}, nil)
+	mockWorkspaceRepo.On("GetMember", mock.Anything, 7, 6).Return(&domain.WorkspaceMember{Role: domain.WorkspaceRoleViewer}, nil)
+	mockWorkspaceRepo.On("GetMember", mock.Anything, 7, mock.Anything).Return(nil, domain.NotFound("workspace member", 0))
+
+	tests := []struct {
+		name     string
+		userID   int
+		repoID   int
+		required domain.WorkspaceRole
+		wantErr  error
+	}{
+		{"owner", 1, 10, domain.WorkspaceRoleOwner, nil},
+		{"viewer may read", 6, 10, domain.WorkspaceRoleViewer, nil},
+		{"viewer may not mutate", 6, 10, domain.WorkspaceRoleMaintainer, domain.ErrForbidden},
+		{"non-member", 2, 10, domain.WorkspaceRoleViewer, domain.ErrForbidden},
+		{"admin", 3, 10, domain.WorkspaceRoleOwner, nil},
+		{"unknown user", 4, 10, domain.WorkspaceRoleViewer, domain.ErrForbidden},
+		{"missing repository", 1, 11, domain.WorkspaceRoleViewer, domain.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := authz.Repository(context.Background(), tt.userID, tt.repoID, tt.required)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, repo)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.repoID, repo.ID)
+		})
+	}
+
+	t.Run("batch owners and admins", func(t *testing.T) {
+		assert.NoError(t, authz.AuthorizeOwner(context.Background(), 2, 2))
+		assert.NoError(t, authz.AuthorizeOwner(context.Background(), 3, 2))
+		assert.ErrorIs(t, authz.AuthorizeOwner(context.Background(), 6, 2), domain.ErrForbidden)
+	})
+
+	t.Run("lookup failures are not reported as forbidden", func(t *testing.T) {
+		err := authz.AuthorizeOwner(context.Background(), 5, 1)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, domain.ErrForbidden)
+	})
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/biodoia/ghrego/internal/metrics"
+	"github.com/rs/zerolog/log"
+)
+
+// BulkAnalysisServiceImpl fans a batch of analyses out over a bounded worker pool.
+// Every batch gets its own workers, while per-provider semaphores are shared by all
+// batches so concurrent batches cannot together overload a source host.
+// Item status is checkpointed in the batch repository, so batches interrupted by a
+// shutdown are picked up again by ResumeInterrupted.
+type BulkAnalysisServiceImpl struct {
+	aiService           analyzer
+	repoStore           ports.RepositoryStore
+	batchRepo           ports.BatchRepository
+	authz               ports.Authorizer
+	jobs                ports.JobRunner
+	maxRepos            int
+	workers             int
+	providerConcurrency int
+
+	mu        sync.Mutex
+	providers map[domain.SourceProvider]chan struct{}
+}
+
+func NewBulkAnalysisService(
+	aiService analyzer,
+	repoStore ports.RepositoryStore,
+	batchRepo ports.BatchRepository,
+	authz ports.Authorizer,
+	jobs ports.JobRunner,
+	maxRepos, workers, providerConcurrency int,
+) ports.BulkAnalysisService {
+	if workers < 1 {
+		workers = 1
+	}
+	if providerConcurrency < 1 {
+		providerConcurrency = 1
+	}
+	return &BulkAnalysisServiceImpl{
+		aiService:           aiService,
+		repoStore:           repoStore,
+		batchRepo:           batchRepo,
+		authz:               authz,
+		jobs:                jobs,
+		maxRepos:            maxRepos,
+		workers:             workers,
+		providerConcurrency: providerConcurrency,
+		providers:           make(map[domain.SourceProvider]chan struct{}),
+	}
+}
+
+func (s *BulkAnalysisServiceImpl) StartBatch(ctx context.Context, userID, workspaceID int, req domain.BulkAnalysisRequest) (*domain.AnalysisBatch, error) {
+	if req.AnalysisType != "" && !req.AnalysisType.Valid() {
+		return nil, domain.Validation("unknown analysis type %q", req.AnalysisType)
+	}
+	repos, err := s.selectRepositories(ctx, userID, workspaceID, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(repos) == 0 {
+		return nil, domain.ErrEmptyBatch
+	}
+	if s.maxRepos > 0 && len(repos) > s.maxRepos {
+		return nil, fmt.Errorf("%w: %d selected, limit is %d", domain.ErrTooManyRepositories, len(repos), s.maxRepos)
+	}
+
+	analysisType := req.AnalysisType
+	if analysisType == "" {
+		analysisType = domain.AnalysisTypeArchitecture
+	}
+	batch := &domain.AnalysisBatch{
+		ID:           uuid.New(),
+		UserID:       userID,
+		AnalysisType: analysisType,
+		Force:        req.Force,
+		Total:        len(repos),
+	}
+	repoIDs := make([]int, 0, len(repos))
+	for _, r := range repos {
+		repoIDs = append(repoIDs, r.ID)
+	}
+	if err := s.batchRepo.Create(ctx, batch, repoIDs); err != nil {
+		return nil, err
+	}
+
+	log.Ctx(ctx).Info().Str("batch_id", batch.ID.String()).Int("repos", batch.Total).Msg("Bulk analysis started")
+
+	// The batch outlives the HTTP request that created it. If the server is
+	// shutting down the items stay queued and are resumed on the next start.
+	if err := s.jobs.Go(ctx, "bulk-analysis", func(ctx context.Context) {
+		s.run(ctx, batch, repos)
+	}); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("batch_id", batch.ID.String()).Msg("Bulk analysis deferred to next start")
+	}
+
+	return batch, nil
+}
+
+// ResumeInterrupted restarts the queued and running items of batches interrupted by a shutdown
+func (s *BulkAnalysisServiceImpl) ResumeInterrupted(ctx context.Context) error {
+	items, err := s.batchRepo.GetUnfinishedItems(ctx)
+	if err != nil {
+		return err
+	}
+
+	pending := make(map[uuid.UUID][]int)
+	for _, item := range items {
+		pending[item.BatchID] = append(pending[item.BatchID], item.RepositoryID)
+	}
+
+	for batchID, repoIDs := range pending {
+		batch, err := s.batchRepo.GetByID(ctx, batchID)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("batch_id", batchID.String()).Msg("Cannot resume batch")
+			continue
+		}
+		repos, err := s.repoStore.GetByIDs(ctx, repoIDs)
+		if err != nil {
+			return err
+		}
+		s.failDeleted(ctx, batchID, repoIDs, repos)
+		if len(repos) == 0 {
+			continue
+		}
+
+		log.Ctx(ctx).Info().Str("batch_id", batchID.String()).Int("remaining", len(repos)).Msg("Resuming interrupted bulk analysis")
+		if err := s.jobs.Go(ctx, "bulk-analysis", func(ctx context.Context) {
+			s.run(ctx, batch, repos)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *BulkAnalysisServiceImpl) GetProgress(ctx context.Context, userID int, batchID uuid.UUID) (*domain.BatchProgress, error) {
+	batch, err := s.batchRepo.GetByID(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authz.AuthorizeOwner(ctx, userID, batch.UserID); err != nil {
+		return nil, err
+	}
+	items, err := s.batchRepo.GetItems(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewBatchProgress(*batch, items), nil
+}
+
+// failDeleted fails the items of repositories deleted while their batch was
+// interrupted, which would otherwise stay queued and keep the batch from finishing
+func (s *BulkAnalysisServiceImpl) failDeleted(ctx context.Context, batchID uuid.UUID, repoIDs []int, repos []domain.Repository) {
+	found := make(map[int]bool, len(repos))
+	for _, r := range repos {
+		found[r.ID] = true
+	}
+	for _, id := range repoIDs {
+		if found[id] {
+			continue
+		}
+		item := &domain.AnalysisBatchItem{BatchID: batchID, RepositoryID: id, Status: domain.BatchItemStatusFailed, ErrorMessage: domain.SQLNullString("repository deleted")}
+		if err := s.batchRepo.UpdateItem(ctx, item); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("batch_id", batchID.String()).Int("repo_id", id).Msg("Failed to fail batch item of a deleted repository")
+		}
+	}
+}
+
+// selectRepositories resolves explicit IDs (which the user must maintain) or applies
+// the filter to the repositories of the current workspace. Repeated IDs count once.
+func (s *BulkAnalysisServiceImpl) selectRepositories(ctx context.Context, userID, workspaceID int, req domain.BulkAnalysisRequest) ([]domain.Repository, error) {
+	if len(req.RepositoryIDs) > 0 {
+		ids := make([]int, 0, len(req.RepositoryIDs))
+		seen := make(map[int]bool, len(req.RepositoryIDs))
+		for _, id := range req.RepositoryIDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		repos, err := s.repoStore.GetByIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		found := make(map[int]domain.Repository, len(repos))
+		for _, r := range repos {
+			found[r.ID] = r
+		}
+		selected := make([]domain.Repository, 0, len(ids))
+		authorized := map[int]bool{}
+		for _, id := range ids {
+			r, ok := found[id]
+			if !ok {
+				return nil, fmt.Errorf("%w: %d", domain.ErrUnknownRepository, id)
+			}
+			if !authorized[r.WorkspaceID] {
+				if err := s.authz.AuthorizeWorkspace(ctx, userID, r.WorkspaceID, domain.WorkspaceRoleMaintainer); err != nil {
+					return nil, err
+				}
+				authorized[r.WorkspaceID] = true
+			}
+			if req.Filter.Matches(r) {
+				selected = append(selected, r)
+			}
+		}
+		return selected, nil
+	}
+
+	if req.Filter.IsEmpty() {
+		return nil, domain.ErrEmptyBatch
+	}
+	if err := s.authz.AuthorizeWorkspace(ctx, userID, workspaceID, domain.WorkspaceRoleMaintainer); err != nil {
+		return nil, err
+	}
+	repos, err := s.repoStore.GetByWorkspaceID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	var selected []domain.Repository
+	for _, r := range repos {
+		if req.Filter.Matches(r) {
+			selected = append(selected, r)
+		}
+	}
+	return selected, nil
+}
+
+func (s *BulkAnalysisServiceImpl) run(ctx context.Context, batch *domain.AnalysisBatch, repos []domain.Repository) {
+	metrics.AnalysisQueueDepth.Add(float64(len(repos)))
+
+	jobs := make(chan domain.Repository)
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers && i < len(repos); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for repo := range jobs {
+				s.analyze(ctx, batch, repo)
+			}
+		}()
+	}
+	fed := 0
+feed:
+	for _, repo := range repos {
+		select {
+		case jobs <- repo:
+			fed++
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	metrics.AnalysisQueueDepth.Sub(float64(len(repos) - fed))
+	close(jobs)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		log.Ctx(ctx).Info().Str("batch_id", batch.ID.String()).Msg("Bulk analysis interrupted, remaining items stay queued")
+		return
+	}
+	log.Ctx(ctx).Info().Str("batch_id", batch.ID.String()).Msg("Bulk analysis finished")
+}
+
+// analyze runs one item of the batch; its failure is recorded but never aborts the batch
+func (s *BulkAnalysisServiceImpl) analyze(ctx context.Context, batch *domain.AnalysisBatch, repo domain.Repository) {
+	sem := s.providerSemaphore(domain.ProviderOf(&repo))
+	select {
+	case sem <- struct{}{}:
+		metrics.AnalysisQueueDepth.Dec()
+		defer func() { <-sem }()
+	case <-ctx.Done():
+		metrics.AnalysisQueueDepth.Dec()
+		return // never started, the item stays queued
+	}
+
+	// Checkpoints must be written even while the job context is being cancelled
+	storeCtx := context.WithoutCancel(ctx)
+
+	item := &domain.AnalysisBatchItem{BatchID: batch.ID, RepositoryID: repo.ID, Status: domain.BatchItemStatusRunning}
+	if err := s.batchRepo.UpdateItem(storeCtx, item); err != nil {
+		log.Ctx(ctx).Error().Err(err).Int("repo_id", repo.ID).Msg("Failed to mark batch item running")
+	}
+
+	analysis, err := s.aiService.analyze(ctx, repo.ID, batch.AnalysisType, batch.Force)
+	if err != nil && ctx.Err() != nil {
+		// Interrupted by shutdown: requeue so the item is resumed rather than failed
+		item.Status = domain.BatchItemStatusQueued
+	} else if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("batch_id", batch.ID.String()).Int("repo_id", repo.ID).Msg("Bulk analysis item failed")
+		item.Status = domain.BatchItemStatusFailed
+		item.ErrorMessage = domain.SQLNullString(err.Error())
+	} else {
+		item.Status = domain.BatchItemStatusDone
+		item.AnalysisID = domain.SQLNullInt32(analysis.ID)
+	}
+
+	if err := s.batchRepo.UpdateItem(storeCtx, item); err != nil {
+		log.Ctx(ctx).Error().Err(err).Int("repo_id", repo.ID).Msg("Failed to update batch item")
+	}
+}
+
+func (s *BulkAnalysisServiceImpl) providerSemaphore(provider domain.SourceProvider) chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sem, ok := s.providers[provider]
+	if !ok {
+		sem = make(chan struct{}, s.providerConcurrency)
+		s.providers[provider] = sem
+	}
+	return sem
+}
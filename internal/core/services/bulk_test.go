@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkAnalysisServiceImpl_StartBatch(t *testing.T) {
+	repos := []domain.Repository{
+		{ID: 1, UserID: 1, WorkspaceID: 1, Language: domain.SQLNullString("Go"), Topics: []string{"cli"}},
+		{ID: 2, UserID: 1, WorkspaceID: 1, Language: domain.SQLNullString("go")},
+		{ID: 3, UserID: 1, WorkspaceID: 1, Language: domain.SQLNullString("Python"), Topics: []string{"CLI"}},
+		{ID: 4, UserID: 2, WorkspaceID: 2, Language: domain.SQLNullString("Go")},
+	}
+	users := new(mocks.UserRepository)
+	users.On("GetByID", mock.Anything, 1).Return(&domain.User{ID: 1, Role: domain.UserRoleUser}, nil)
+	users.On("GetByID", mock.Anything, 9).Return(&domain.User{ID: 9, Role: domain.UserRoleAdmin}, nil)
+	workspaces := new(mocks.WorkspaceRepository)
+	workspaces.On("GetMember", mock.Anything, 1, 1).Return(&domain.WorkspaceMember{WorkspaceID: 1, UserID: 1, Role: domain.WorkspaceRoleMaintainer}, nil)
+	workspaces.On("GetMember", mock.Anything, mock.Anything, mock.Anything).Return(nil, domain.NotFound("workspace member", 0))
+	authz := NewAuthorizer(users, nil, workspaces)
+
+	t.Run("individual failures do not abort the batch", func(t *testing.T) {
+		mockAI := new(mockAnalyzer)
+		mockRepoStore := new(mocks.RepositoryStore)
+		mockBatchRepo := new(mocks.BatchRepository)
+		jobs := NewBackgroundJobs()
+		svc := NewBulkAnalysisService(mockAI, mockRepoStore, mockBatchRepo, authz, jobs, 10, 2, 1)
+
+		mockRepoStore.On("GetByWorkspaceID", mock.Anything, 1).Return(repos[:3], nil)
+		mockBatchRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.AnalysisBatch"), []int{1, 2}).Return(nil)
+		mockAI.On("analyze", mock.Anything, 1, domain.AnalysisTypeArchitecture, false).Return(nil, errors.New("boom"))
+		mockAI.On("analyze", mock.Anything, 2, domain.AnalysisTypeArchitecture, false).Return(&domain.Analysis{ID: 20}, nil)
+
+		var mu sync.Mutex
+		final := map[int]domain.AnalysisBatchItem{}
+		mockBatchRepo.On("UpdateItem", mock.Anything, mock.AnythingOfType("*domain.AnalysisBatchItem")).
+			Run(func(args mock.Arguments) {
+				item := args.Get(1).(*domain.AnalysisBatchItem)
+				mu.Lock()
+				final[item.RepositoryID] = *item
+				mu.Unlock()
+			}).Return(nil)
+
+		batch, err := svc.StartBatch(context.Background(), 1, 1, domain.BulkAnalysisRequest{Filter: domain.RepoFilter{Language: "Go"}})
+		require.NoError(t, err)
+		assert.Equal(t, 2, batch.Total)
+
+		require.NoError(t, jobs.Shutdown(context.Background()))
+
+		assert.Equal(t, domain.BatchItemStatusFailed, final[1].Status)
+		assert.Equal(t, "boom", final[1].ErrorMessage.String)
+		assert.Equal(t, domain.BatchItemStatusDone, final[2].Status)
+		assert.EqualValues(t, 20, final[2].AnalysisID.Int32)
+	})
+
+	t.Run("topic filter", func(t *testing.T) {
+		mockRepoStore := new(mocks.RepositoryStore)
+		mockBatchRepo := new(mocks.BatchRepository)
+		mockAI := new(mockAnalyzer)
+		jobs := NewBackgroundJobs()
+		svc := NewBulkAnalysisService(mockAI, mockRepoStore, mockBatchRepo, authz, jobs, 10, 1, 1)
+
+		mockRepoStore.On("GetByWorkspaceID", mock.Anything, 1).Return(repos[:3], nil)
+		mockBatchRepo.On("Create", mock.Anything, mock.Anything, []int{1, 3}).Return(nil)
+		mockBatchRepo.On("UpdateItem", mock.Anything, mock.Anything).Return(nil)
+		mockAI.On("analyze", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&domain.Analysis{}, nil)
+
+		_, err := svc.StartBatch(context.Background(), 1, 1, domain.BulkAnalysisRequest{Filter: domain.RepoFilter{Topic: "cli"}})
+		require.NoError(t, err)
+		require.NoError(t, jobs.Shutdown(context.Background()))
+		mockBatchRepo.AssertExpectations(t)
+	})
+
+	t.Run("respects max bulk repos", func(t *testing.T) {
+		mockRepoStore := new(mocks.RepositoryStore)
+		svc := NewBulkAnalysisService(nil, mockRepoStore, nil, authz, nil, 1, 1, 1)
+		mockRepoStore.On("GetByIDs", mock.Anything, []int{1, 2}).Return(repos[:2], nil)
+
+		_, err := svc.StartBatch(context.Background(), 1, 1, domain.BulkAnalysisRequest{RepositoryIDs: []int{1, 2}})
+		assert.ErrorIs(t, err, domain.ErrTooManyRepositories)
+	})
+
+	t.Run("repeated ids count once", func(t *testing.T) {
+		mockRepoStore := new(mocks.RepositoryStore)
+		mockBatchRepo := new(mocks.BatchRepository)
+		svc := NewBulkAnalysisService(nil, mockRepoStore, mockBatchRepo, authz, nil, 10, 1, 1)
+		mockRepoStore.On("GetByIDs", mock.Anything, []int{2, 1}).Return(repos[:2], nil)
+		mockBatchRepo.On("Create", mock.Anything, mock.MatchedBy(func(b *domain.AnalysisBatch) bool { return b.Total == 2 }), []int{2, 1}).Return(errors.New("stop here"))
+
+		_, err := svc.StartBatch(context.Background(), 1, 1, domain.BulkAnalysisRequest{RepositoryIDs: []int{2, 1, 2, 2}})
+		assert.EqualError(t, err, "stop here")
+		mockBatchRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects repositories of other users", func(t *testing.T) {
+		mockRepoStore := new(mocks.RepositoryStore)
+		svc := NewBulkAnalysisService(nil, mockRepoStore, nil, authz, nil, 10, 1, 1)
+		mockRepoStore.On("GetByIDs", mock.Anything, []int{1, 4}).Return([]domain.Repository{repos[0], repos[3]}, nil)
+
+		_, err := svc.StartBatch(context.Background(), 1, 1, domain.BulkAnalysisRequest{RepositoryIDs: []int{1, 4}})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("admins may include repositories of other users", func(t *testing.T) {
+		mockRepoStore := new(mocks.RepositoryStore)
+		mockBatchRepo := new(mocks.BatchRepository)
+		svc := NewBulkAnalysisService(nil, mockRepoStore, mockBatchRepo, authz, nil, 10, 1, 1)
+		mockRepoStore.On("GetByIDs", mock.Anything, []int{1, 4}).Return([]domain.Repository{repos[0], repos[3]}, nil)
+		mockBatchRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.AnalysisBatch"), []int{1, 4}).Return(errors.New("stop here"))
+
+		_, err := svc.StartBatch(context.Background(), 9, 1, domain.BulkAnalysisRequest{RepositoryIDs: []int{1, 4}})
+		assert.EqualError(t, err, "stop here")
+	})
+
+	t.Run("empty selection", func(t *testing.T) {
+		svc := NewBulkAnalysisService(nil, nil, nil, authz, nil, 10, 1, 1)
+		_, err := svc.StartBatch(context.Background(), 1, 1, domain.BulkAnalysisRequest{})
+		assert.ErrorIs(t, err, domain.ErrEmptyBatch)
+	})
+}
+
+func TestBulkAnalysisServiceImpl_ResumeInterrupted(t *testing.T) {
+	mockAI := new(mockAnalyzer)
+	mockRepoStore := new(mocks.RepositoryStore)
+	mockBatchRepo := new(mocks.BatchRepository)
+	jobs := NewBackgroundJobs()
+	svc := NewBulkAnalysisService(mockAI, mockRepoStore, mockBatchRepo, nil, jobs, 10, 1, 1)
+
+	batch := &domain.AnalysisBatch{ID: uuid.New(), AnalysisType: domain.AnalysisTypeArchitecture}
+	mockBatchRepo.On("GetUnfinishedItems", mock.Anything).Return([]domain.AnalysisBatchItem{
+		{BatchID: batch.ID, RepositoryID: 1, Status: domain.BatchItemStatusRunning},
+		{BatchID: batch.ID, RepositoryID: 2, Status: domain.BatchItemStatusQueued},
+	}, nil)
+	mockBatchRepo.On("GetByID", mock.Anything, batch.ID).Return(batch, nil)
+	// Repository 2 was deleted in the meantime
+	mockRepoStore.On("GetByIDs", mock.Anything, []int{1, 2}).Return([]domain.Repository{{ID: 1}}, nil)
+	mockBatchRepo.On("UpdateItem", mock.Anything, mock.AnythingOfType("*domain.AnalysisBatchItem")).Return(nil)
+	mockAI.On("analyze", mock.Anything, 1, domain.AnalysisTypeArchitecture, false).Return(&domain.Analysis{ID: 30}, nil)
+
+	require.NoError(t, svc.ResumeInterrupted(context.Background()))
+	require.NoError(t, jobs.Shutdown(context.Background()))
+
+	mockBatchRepo.AssertCalled(t, "UpdateItem", mock.Anything, mock.MatchedBy(func(item *domain.AnalysisBatchItem) bool {
+		return item.RepositoryID == 2 && item.Status == domain.BatchItemStatusFailed && item.ErrorMessage.String == "repository deleted"
+	}))
+	mockAI.AssertNumberOfCalls(t, "analyze", 1)
+}
+
+// mockAnalyzer stands in for the AI service's unauthorized entry point,
+// which the shared mocks cannot implement
+type mockAnalyzer struct {
+	mock.Mock
+}
+
+func (m *mockAnalyzer) analyze(ctx context.Context, repoID int, analysisType domain.AnalysisType, force bool) (*domain.Analysis, error) {
+	args := m.Called(ctx, repoID, analysisType, force)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Analysis), args.Error(1)
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/analyzers"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/rs/zerolog/log"
+)
+
+// analyzeMetrics measures a checkout of the default branch head. It does not
+// use the AI provider, so it costs nothing and is not budgeted.
+func (s *AIAnalysisServiceImpl) analyzeMetrics(ctx context.Context, repo *domain.Repository) (*domain.Analysis, error) {
+	if s.ingester == nil {
+		return nil, domain.UpstreamUnavailable("code ingestion", errors.New("not configured"))
+	}
+	sha := s.headSHA(ctx, repo)
+	if sha == "" {
+		return nil, domain.UpstreamUnavailable(string(domain.ProviderOf(repo)), errors.New("default branch head could not be resolved"))
+	}
+	host, err := s.hosts.For(domain.ProviderOf(repo))
+	if err != nil {
+		return nil, err
+	}
+	snap, err := s.ingester.Checkout(ctx, host, repo, sha)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check out repository: %w", err)
+	}
+	defer snap.Release()
+
+	analysis, err := s.saveMetrics(ctx, repo, snap)
+	if err != nil {
+		return nil, err
+	}
+	s.saveDetections(ctx, repo, snap)
+	if err := s.cache.InvalidateTags(ctx, domain.RepositoryCacheTag(repo.ID), domain.WorkspaceCacheTag(repo.WorkspaceID)); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Int("repo_id", repo.ID).Msg("Failed to invalidate cache after analysis")
+	}
+	log.Ctx(ctx).Info().Int("analysis_id", analysis.ID).Str("sha", sha).Msg("Code metrics recorded")
+	return analysis, nil
+}
+
+// saveMetrics stores the metrics of a checkout as a metrics analysis
+func (s *AIAnalysisServiceImpl) saveMetrics(ctx context.Context, repo *domain.Repository, snap ports.CodeSnapshot) (*domain.Analysis, error) {
+	m, err := analyzers.Metrics(snap, snap.SHA())
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure repository: %w", err)
+	}
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	analysis := &domain.Analysis{
+		RepositoryID: repo.ID,
+		AnalysisType: domain.AnalysisTypeMetrics,
+		Status:       domain.AnalysisStatusCompleted,
+		Result:       domain.SQLNullString(string(raw)),
+		Summary:      domain.SQLNullString(metricsSummary(m)),
+		CreatedAt:    now,
+		CompletedAt:  domain.SQLNullTime(now),
+	}
+	if analysis.ID, err = s.analysisRepo.Create(ctx, analysis); err != nil {
+		return nil, fmt.Errorf("failed to save analysis: %w", err)
+	}
+	return analysis, nil
+}
+
+// saveDetections records what the static rules find in a checkout. Failures
+// are logged, as they should not fail the analysis that made the checkout.
+func (s *AIAnalysisServiceImpl) saveDetections(ctx context.Context, repo *domain.Repository, snap ports.CodeSnapshot) {
+	if err := s.saveDetectedTechnologies(ctx, repo, snap); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Int("repo_id", repo.ID).Msg("Failed to record detected technologies")
+	} else if err := s.saveVulnerabilitySuggestions(ctx, repo); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Int("repo_id", repo.ID).Msg("Failed to check dependencies for vulnerabilities")
+	}
+	if err := s.saveDetectedLicenses(ctx, repo, snap); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Int("repo_id", repo.ID).Msg("Failed to record detected licenses")
+	}
+}
+
+// saveDetectedTechnologies records the technologies the static rules find in
+// a checkout, replacing those found by earlier runs
+func (s *AIAnalysisServiceImpl) saveDetectedTechnologies(ctx context.Context, repo *domain.Repository, snap ports.CodeSnapshot) error {
+	techs, err := analyzers.DetectTechnologies(snap)
+	if err != nil {
+		return fmt.Errorf("failed to detect technologies: %w", err)
+	}
+	now := time.Now()
+	for i := range techs {
+		techs[i].RepositoryID = repo.ID
+		techs[i].CreatedAt = now
+	}
+	return s.technologyRepo.ReplaceDetected(ctx, repo.ID, techs)
+}
+
+func metricsSummary(m *domain.CodeMetrics) string {
+	summary := fmt.Sprintf("%d lines of code in %d files, %.0f%% comments, test to source ratio %.2f",
+		m.CodeLines, m.Files, m.CommentRatio*100, m.TestRatio)
+	if m.Go != nil {
+		summary += fmt.Sprintf("; Go: %d packages, average complexity %.1f, max %d", m.Go.Packages, m.Go.AverageComplexity, m.Go.MaxComplexity)
+	}
+	return summary
+}
+
+// latestMetrics decodes the newest metrics analysis; analyses are newest first
+func latestMetrics(analyses []domain.Analysis) *domain.CodeMetrics {
+	for _, a := range analyses {
+		if a.AnalysisType != domain.AnalysisTypeMetrics || !a.Result.Valid {
+			continue
+		}
+		var m domain.CodeMetrics
+		if err := json.Unmarshal([]byte(a.Result.String), &m); err == nil {
+			return &m
+		}
+	}
+	return nil
+}
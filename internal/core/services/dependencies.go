@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/biodoia/ghrego/internal/core/versions"
+	"github.com/rs/zerolog/log"
+)
+
+// dependencyReportTTL bounds staleness of the drift report between invalidations
+const dependencyReportTTL = 10 * time.Minute
+
+type DependencyServiceImpl struct {
+	repoStore      ports.RepositoryStore
+	technologyRepo ports.TechnologyRepository
+	licenseRepo    ports.LicenseRepository
+	suggestionRepo ports.SuggestionRepository
+	registry       ports.PackageRegistry
+	cache          ports.Cache
+	authz          ports.Authorizer
+}
+
+// NewDependencyService builds the dependency and license reports; registry
+// may be nil, in which case versions are compared with the newest one in the
+// portfolio and only vendored dependencies have a license
+func NewDependencyService(
+	repoStore ports.RepositoryStore,
+	technologyRepo ports.TechnologyRepository,
+	licenseRepo ports.LicenseRepository,
+	suggestionRepo ports.SuggestionRepository,
+	registry ports.PackageRegistry,
+	cache ports.Cache,
+	authz ports.Authorizer,
+) ports.DependencyService {
+	return &DependencyServiceImpl{
+		repoStore:      repoStore,
+		technologyRepo: technologyRepo,
+		licenseRepo:    licenseRepo,
+		suggestionRepo: suggestionRepo,
+		registry:       registry,
+		cache:          cache,
+		authz:          authz,
+	}
+}
+
+// GetDriftReport is cached until the next analysis in the workspace
+func (s *DependencyServiceImpl) GetDriftReport(ctx context.Context, userID, workspaceID int) (*domain.DependencyReport, error) {
+	if err := s.authz.AuthorizeWorkspace(ctx, userID, workspaceID, domain.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+
+	cacheKey := fmt.Sprintf("dependencies:workspace:%d", workspaceID)
+	var report domain.DependencyReport
+	if found, err := s.cache.Get(ctx, cacheKey, &report); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("key", cacheKey).Msg("Dependency report cache read failed")
+	} else if found {
+		return &report, nil
+	}
+
+	r, err := s.driftReport(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cache.Set(ctx, cacheKey, r, dependencyReportTTL, domain.WorkspaceCacheTag(workspaceID)); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("key", cacheKey).Msg("Dependency report cache write failed")
+	}
+	return r, nil
+}
+
+// SuggestConsolidation suggests aligning every repository behind the latest
+// version of a package on it. Suggestions already made for the same
+// package and version, in any status, are not repeated.
+func (s *DependencyServiceImpl) SuggestConsolidation(ctx context.Context, userID, workspaceID int) ([]domain.Suggestion, error) {
+	if err := s.authz.AuthorizeWorkspace(ctx, userID, workspaceID, domain.WorkspaceRoleMaintainer); err != nil {
+		return nil, err
+	}
+	report, err := s.driftReport(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	made := make(map[int]map[string]bool)
+	created := []domain.Suggestion{}
+	for _, pkg := range report.Packages {
+		for _, usage := range pkg.Versions {
+			if versions.Compare(pkg.Ecosystem, usage.Version, pkg.Latest) >= 0 {
+				continue
+			}
+			for _, repo := range usage.Repositories {
+				if made[repo.ID] == nil {
+					if made[repo.ID], err = s.madeSuggestions(ctx, repo.ID); err != nil {
+						return nil, err
+					}
+				}
+				sugg := consolidationSuggestion(repo, pkg, usage)
+				if made[repo.ID][sugg.Title] {
+					continue
+				}
+				if sugg.ID, err = s.suggestionRepo.Create(ctx, &sugg); err != nil {
+					return nil, fmt.Errorf("failed to save suggestion: %w", err)
+				}
+				made[repo.ID][sugg.Title] = true
+				created = append(created, sugg)
+			}
+		}
+	}
+
+	for repoID := range made {
+		if err := s.cache.InvalidateTags(ctx, domain.RepositoryCacheTag(repoID)); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Int("repo_id", repoID).Msg("Failed to invalidate cache after suggestions")
+		}
+	}
+	log.Ctx(ctx).Info().Int("workspace_id", workspaceID).Int("suggestions", len(created)).Msg("Dependency consolidation suggested")
+	return created, nil
+}
+
+// madeSuggestions returns the titles of the repository's consolidation suggestions
+func (s *DependencyServiceImpl) madeSuggestions(ctx context.Context, repoID int) (map[string]bool, error) {
+	existing, err := s.suggestionRepo.GetByRepositoryID(ctx, repoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load suggestions: %w", err)
+	}
+	titles := make(map[string]bool)
+	for _, sugg := range existing {
+		if sugg.SuggestionType == domain.SuggestionTypeConsolidate {
+			titles[sugg.Title] = true
+		}
+	}
+	return titles, nil
+}
+
+type dependencyKey struct {
+	ecosystem, name string
+}
+
+// driftReport groups the technologies that have a package manager by
+// package. A repository counts once per package: a statically detected row
+// beats one from the AI analysis, and a pinned version beats none.
+func (s *DependencyServiceImpl) driftReport(ctx context.Context, workspaceID int) (*domain.DependencyReport, error) {
+	repos, err := s.repoStore.GetByWorkspaceID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string, len(repos))
+	for _, r := range repos {
+		names[r.ID] = r.FullName
+	}
+	techs, err := s.technologyRepo.GetByWorkspaceID(ctx, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load technologies: %w", err)
+	}
+
+	byPackage := make(map[dependencyKey]map[int]domain.Technology)
+	displayName := make(map[dependencyKey]string)
+	for _, tech := range techs {
+		ecosystem := domain.EcosystemOf(tech.PackageManager.String)
+		if ecosystem == "" {
+			continue
+		}
+		if !versions.Valid(ecosystem, tech.Version.String) {
+			tech.Version.String = ""
+		}
+		key := dependencyKey{ecosystem, versions.NormalizeName(ecosystem, tech.Name)}
+		if byPackage[key] == nil {
+			byPackage[key] = make(map[int]domain.Technology)
+			displayName[key] = tech.Name
+		}
+		if prev, ok := byPackage[key][tech.RepositoryID]; !ok || preferredTechnology(tech, prev) {
+			byPackage[key][tech.RepositoryID] = tech
+		}
+	}
+
+	report := &domain.DependencyReport{WorkspaceID: workspaceID, Packages: []domain.PackageDrift{}, GeneratedAt: time.Now()}
+	for key, byRepo := range byPackage {
+		pkg, err := s.packageDrift(ctx, key.ecosystem, displayName[key], byRepo, names)
+		if err != nil {
+			return nil, err
+		}
+		if pkg != nil {
+			report.Packages = append(report.Packages, *pkg)
+		}
+	}
+	sort.Slice(report.Packages, func(i, j int) bool {
+		a, b := report.Packages[i], report.Packages[j]
+		switch {
+		case a.Outdated != b.Outdated:
+			return a.Outdated > b.Outdated
+		case len(a.Versions) != len(b.Versions):
+			return len(a.Versions) > len(b.Versions)
+		case a.Ecosystem != b.Ecosystem:
+			return a.Ecosystem < b.Ecosystem
+		}
+		return a.Name < b.Name
+	})
+	return report, nil
+}
+
+func preferredTechnology(t, than domain.Technology) bool {
+	if (t.Version.String != "") != (than.Version.String != "") {
+		return t.Version.String != ""
+	}
+	return t.Evidence.Valid && !than.Evidence.Valid
+}
+
+// packageDrift is nil for packages no repository pins and the registry does not know
+func (s *DependencyServiceImpl) packageDrift(ctx context.Context, ecosystem, name string, byRepo map[int]domain.Technology, names map[int]string) (*domain.PackageDrift, error) {
+	pkg := &domain.PackageDrift{Ecosystem: ecosystem, Name: name, Repositories: len(byRepo)}
+	for repoID, tech := range byRepo {
+		ref := domain.RepositoryRef{ID: repoID, FullName: names[repoID]}
+		v := tech.Version.String
+		if v == "" {
+			pkg.Unpinned = append(pkg.Unpinned, ref)
+			continue
+		}
+		i := sort.Search(len(pkg.Versions), func(i int) bool {
+			return versions.Compare(ecosystem, pkg.Versions[i].Version, v) <= 0
+		})
+		if i == len(pkg.Versions) || versions.Compare(ecosystem, pkg.Versions[i].Version, v) != 0 {
+			pkg.Versions = append(pkg.Versions, domain.VersionUsage{})
+			copy(pkg.Versions[i+1:], pkg.Versions[i:])
+			pkg.Versions[i] = domain.VersionUsage{Version: v}
+		}
+		pkg.Versions[i].Repositories = append(pkg.Versions[i].Repositories, ref)
+	}
+
+	// The newest stable version in use, unless only pre-releases are
+	for _, usage := range pkg.Versions {
+		if !versions.IsPrerelease(ecosystem, usage.Version) {
+			pkg.Latest = usage.Version
+			break
+		}
+	}
+	if pkg.Latest == "" && len(pkg.Versions) > 0 {
+		pkg.Latest = pkg.Versions[0].Version
+	}
+	pkg.LatestSource = domain.LatestSourcePortfolio
+	if s.registry != nil {
+		latest, err := s.registry.LatestVersion(ctx, ecosystem, name)
+		if err != nil {
+			return nil, err
+		}
+		// A stale snapshot does not hide newer versions already in use
+		if latest != "" && (pkg.Latest == "" || versions.Compare(ecosystem, latest, pkg.Latest) >= 0) {
+			pkg.Latest, pkg.LatestSource = latest, domain.LatestSourceRegistry
+		}
+	}
+	if pkg.Latest == "" {
+		return nil, nil
+	}
+
+	for i := range pkg.Versions {
+		usage := &pkg.Versions[i]
+		sort.Slice(usage.Repositories, func(a, b int) bool {
+			return usage.Repositories[a].FullName < usage.Repositories[b].FullName
+		})
+		major, minor, patch := versions.Behind(ecosystem, usage.Version, pkg.Latest)
+		usage.Behind = domain.VersionLag{Major: major, Minor: minor, Patch: patch}
+		if versions.Compare(ecosystem, usage.Version, pkg.Latest) < 0 {
+			pkg.Outdated += len(usage.Repositories)
+		}
+	}
+	sort.Slice(pkg.Unpinned, func(a, b int) bool {
+		return pkg.Unpinned[a].FullName < pkg.Unpinned[b].FullName
+	})
+	if pkg.Versions == nil {
+		pkg.Versions = []domain.VersionUsage{}
+	}
+	return pkg, nil
+}
+
+func consolidationSuggestion(repo domain.RepositoryRef, pkg domain.PackageDrift, usage domain.VersionUsage) domain.Suggestion {
+	where := "the newest version in the workspace"
+	if pkg.LatestSource == domain.LatestSourceRegistry {
+		where = "the latest release"
+	}
+	var inUse []string
+	for _, u := range pkg.Versions {
+		inUse = append(inUse, fmt.Sprintf("%s (%s)", u.Version, plural(len(u.Repositories), "repository", "repositories")))
+	}
+	desc := fmt.Sprintf("%s uses %s %s, %s behind %s, %s. Versions in the workspace: %s.",
+		repo.FullName, pkg.Name, usage.Version, lagText(usage.Behind), pkg.Latest, where, strings.Join(inUse, ", "))
+
+	priority := domain.SuggestionPriorityLow
+	if usage.Behind.Major > 0 {
+		priority = domain.SuggestionPriorityMedium
+	}
+	now := time.Now()
+	return domain.Suggestion{
+		RepositoryID:   repo.ID,
+		SuggestionType: domain.SuggestionTypeConsolidate,
+		Title:          fmt.Sprintf("Align %s on %s", pkg.Name, pkg.Latest),
+		Description:    desc,
+		Priority:       priority,
+		Status:         domain.SuggestionStatusPending,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+func lagText(l domain.VersionLag) string {
+	switch {
+	case l.Major > 0:
+		return plural(l.Major, "major version", "major versions")
+	case l.Minor > 0:
+		return plural(l.Minor, "minor version", "minor versions")
+	case l.Patch > 0:
+		return plural(l.Patch, "patch version", "patch versions")
+	}
+	return "a pre-release"
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/biodoia/ghrego/internal/cache"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/biodoia/ghrego/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func library(repoID int, name, version, packageManager, evidence string) domain.Technology {
+	return domain.Technology{
+		RepositoryID:   repoID,
+		Name:           name,
+		Version:        domain.SQLNullString(version),
+		Type:           domain.TechnologyTypeLibrary,
+		PackageManager: domain.SQLNullString(packageManager),
+		Evidence:       domain.SQLNullString(evidence),
+	}
+}
+
+func newDependencyTestService(t *testing.T, registry *mocks.PackageRegistry) (*DependencyServiceImpl, *mocks.SuggestionRepository) {
+	t.Helper()
+	mockRepoStore := new(mocks.RepositoryStore)
+	mockTechRepo := new(mocks.TechnologyRepository)
+	mockLicenseRepo := new(mocks.LicenseRepository)
+	mockSuggRepo := new(mocks.SuggestionRepository)
+	mockUserRepo := new(mocks.UserRepository)
+	mockWorkspaceRepo := new(mocks.WorkspaceRepository)
+	mockUserRepo.On("GetByID", mock.Anything, 1).Return(&domain.User{ID: 1, Role: domain.UserRoleUser}, nil)
+	mockWorkspaceRepo.On("GetMember", mock.Anything, 7, 1).Return(&domain.WorkspaceMember{WorkspaceID: 7, UserID: 1, Role: domain.WorkspaceRoleMaintainer}, nil)
+
+	mockRepoStore.On("GetByWorkspaceID", mock.Anything, 7).Return([]domain.Repository{
+		{ID: 1, FullName: "acme/api"}, {ID: 2, FullName: "acme/web"}, {ID: 3, FullName: "acme/admin"},
+	}, nil)
+	mockTechRepo.On("GetByWorkspaceID", mock.Anything, 7).Return([]domain.Technology{
+		library(1, "react", "18.3.1", "npm", "package.json"),
+		library(2, "react", "17.0.2", "npm", "package.json"),
+		{RepositoryID: 2, Name: "React", Type: domain.TechnologyTypeFramework, PackageManager: domain.SQLNullString("npm")}, // from the AI analysis
+		library(3, "react", "", "npm", "package.json"),
+		library(1, "github.com/go-chi/chi/v5", "5.0.12", "go", "go.mod"),
+		library(2, "github.com/go-chi/chi/v5", "5.2.1", "go", "go.mod"),
+		library(3, "github.com/go-chi/chi/v5", "5.2.1", "go", "go.mod"),
+		{RepositoryID: 1, Name: "Docker", Type: domain.TechnologyTypePlatform, Evidence: domain.SQLNullString("Dockerfile")},
+	}, nil)
+
+	var reg ports.PackageRegistry
+	if registry != nil {
+		reg = registry
+	}
+	svc := NewDependencyService(mockRepoStore, mockTechRepo, mockLicenseRepo, mockSuggRepo, reg, cache.NewMemoryCache(), NewAuthorizer(mockUserRepo, mockRepoStore, mockWorkspaceRepo))
+	return svc.(*DependencyServiceImpl), mockSuggRepo
+}
+
+func TestDependencyServiceImpl_GetDriftReport(t *testing.T) {
+	t.Run("latest in the portfolio", func(t *testing.T) {
+		svc, _ := newDependencyTestService(t, nil)
+
+		report, err := svc.GetDriftReport(context.Background(), 1, 7)
+
+		require.NoError(t, err)
+		require.Len(t, report.Packages, 2)
+		chi, react := report.Packages[0], report.Packages[1]
+		assert.Equal(t, "github.com/go-chi/chi/v5", chi.Name)
+		assert.Equal(t, "5.2.1", chi.Latest)
+		assert.Equal(t, domain.LatestSourcePortfolio, chi.LatestSource)
+		assert.Equal(t, 3, chi.Repositories)
+		assert.Equal(t, 1, chi.Outdated)
+		assert.Equal(t, []domain.VersionUsage{
+			{Version: "5.2.1", Repositories: []domain.RepositoryRef{{ID: 3, FullName: "acme/admin"}, {ID: 2, FullName: "acme/web"}}},
+			{Version: "5.0.12", Behind: domain.VersionLag{Minor: 2}, Repositories: []domain.RepositoryRef{{ID: 1, FullName: "acme/api"}}},
+		}, chi.Versions)
+
+		assert.Equal(t, "react", react.Name)
+		assert.Equal(t, "18.3.1", react.Latest)
+		assert.Equal(t, 1, react.Outdated)
+		assert.Equal(t, domain.VersionLag{Major: 1}, react.Versions[1].Behind)
+		assert.Equal(t, []domain.RepositoryRef{{ID: 2, FullName: "acme/web"}}, react.Versions[1].Repositories, "the pinned row wins over the AI one")
+		assert.Equal(t, []domain.RepositoryRef{{ID: 3, FullName: "acme/admin"}}, react.Unpinned)
+	})
+
+	t.Run("latest from the registry snapshot", func(t *testing.T) {
+		registry := new(mocks.PackageRegistry)
+		registry.On("LatestVersion", mock.Anything, "npm", "react").Return("19.1.0", nil)
+		registry.On("LatestVersion", mock.Anything, "Go", "github.com/go-chi/chi/v5").Return("5.1.0", nil) // stale
+		svc, _ := newDependencyTestService(t, registry)
+
+		report, err := svc.GetDriftReport(context.Background(), 1, 7)
+
+		require.NoError(t, err)
+		react, chi := report.Packages[0], report.Packages[1]
+		assert.Equal(t, "19.1.0", react.Latest)
+		assert.Equal(t, domain.LatestSourceRegistry, react.LatestSource)
+		assert.Equal(t, 2, react.Outdated)
+		assert.Equal(t, "5.2.1", chi.Latest, "versions in use newer than the snapshot win")
+		assert.Equal(t, domain.LatestSourcePortfolio, chi.LatestSource)
+	})
+}
+
+func TestDependencyServiceImpl_SuggestConsolidation(t *testing.T) {
+	svc, mockSuggRepo := newDependencyTestService(t, nil)
+	mockSuggRepo.On("GetByRepositoryID", mock.Anything, 1).Return([]domain.Suggestion{}, nil)
+	mockSuggRepo.On("GetByRepositoryID", mock.Anything, 2).Return([]domain.Suggestion{
+		{SuggestionType: domain.SuggestionTypeConsolidate, Title: "Align react on 18.3.1", Status: domain.SuggestionStatusRejected},
+	}, nil)
+	mockSuggRepo.On("Create", mock.Anything, mock.MatchedBy(func(s *domain.Suggestion) bool {
+		return s.RepositoryID == 1 && s.SuggestionType == domain.SuggestionTypeConsolidate &&
+			s.Title == "Align github.com/go-chi/chi/v5 on 5.2.1" && s.Priority == domain.SuggestionPriorityLow &&
+			s.Description == "acme/api uses github.com/go-chi/chi/v5 5.0.12, 2 minor versions behind 5.2.1, the newest version in the workspace. "+
+				"Versions in the workspace: 5.2.1 (2 repositories), 5.0.12 (1 repository)."
+	})).Return(21, nil).Once()
+
+	suggs, err := svc.SuggestConsolidation(context.Background(), 1, 7)
+
+	require.NoError(t, err)
+	require.Len(t, suggs, 1)
+	assert.Equal(t, 21, suggs[0].ID)
+	mockSuggRepo.AssertExpectations(t)
+}
@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/biodoia/ghrego/internal/core/domain"
@@ -10,67 +12,205 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// statsCacheTTL bounds staleness of the stats endpoint between invalidations
+const statsCacheTTL = 10 * time.Minute
+
 type GitHubServiceImpl struct {
 	ghClient  ports.GitHubClient
+	hosts     SourceHosts
 	repoStore ports.RepositoryStore
 	userRepo  ports.UserRepository
+	cache     ports.Cache
+	authz     ports.Authorizer
 }
 
-func NewGitHubService(ghClient ports.GitHubClient, repoStore ports.RepositoryStore, userRepo ports.UserRepository) ports.GitHubService {
+func NewGitHubService(ghClient ports.GitHubClient, hosts SourceHosts, repoStore ports.RepositoryStore, userRepo ports.UserRepository, cache ports.Cache, authz ports.Authorizer) ports.GitHubService {
 	return &GitHubServiceImpl{
 		ghClient:  ghClient,
+		hosts:     hosts,
 		repoStore: repoStore,
 		userRepo:  userRepo,
+		cache:     cache,
+		authz:     authz,
 	}
 }
 
-func (s *GitHubServiceImpl) SyncUserRepositories(ctx context.Context, userID int, openID string) error {
-	// 1. Get User to get (potentially) the stored token? 
-	// In the original TS code, the token was passed to the constructor or derived.
-	// Here we assume the client is initialized with a token, BUT in a multi-user app,
-	// the client needs to be created per-request or the token passed here.
-	// For now, I'll assume the ghClient passed is generic or the service handles token retrieval.
-	// Actually, the original 'GitHubService' was instantiated with a token.
-	// So we might need a Factory or pass the token to methods.
-	// For simplicity in this step, I'll fetch the user and assume we have a way to get their token.
-	// If the token is NOT stored in DB (OAuth), we might need to pass it from the controller.
-	
-	// Refactoring note: better to pass token or use an OAuth provider service.
-	// I will assume for now that we get the repos via the existing client which might use a system token or user token.
-	// TO FIX: Pass token or username logic.
-	
-	user, err := s.userRepo.GetByID(ctx, userID)
-	if err != nil {
-		return fmt.Errorf("user not found: %w", err)
+func (s *GitHubServiceImpl) SyncUserRepositories(ctx context.Context, userID, workspaceID int, provider domain.SourceProvider, account string) error {
+	if err := s.authz.AuthorizeWorkspace(ctx, userID, workspaceID, domain.WorkspaceRoleMaintainer); err != nil {
+		return err
 	}
-	if !user.GithubUsername.Valid {
-		return fmt.Errorf("user has no github username linked")
+	if provider == "" {
+		provider = domain.ProviderGitHub
 	}
 
-	repos, err := s.ghClient.GetUserRepositories(ctx, user.GithubUsername.String)
+	var repos []*domain.Repository
+	var err error
+	if provider == domain.ProviderGitHub {
+		repos, account, err = s.githubRepositories(ctx, userID, account)
+	} else {
+		repos, err = s.hostRepositories(ctx, userID, provider, account)
+	}
 	if err != nil {
 		return err
 	}
 
-	log.Info().Int("count", len(repos)).Str("user", user.GithubUsername.String).Msg("Fetched repositories from GitHub")
+	log.Ctx(ctx).Info().Int("count", len(repos)).Str("provider", string(provider)).Str("account", account).Msg("Fetched repositories from source host")
 
 	for _, repo := range repos {
+		repo.Provider = provider
 		repo.UserID = userID
+		repo.WorkspaceID = workspaceID
 		repo.LastSyncAt.Time = time.Now()
 		repo.LastSyncAt.Valid = true
 		
 		_, err := s.repoStore.Upsert(ctx, repo)
 		if err != nil {
-			log.Error().Err(err).Str("repo", repo.Name).Msg("Failed to upsert repository")
+			log.Ctx(ctx).Error().Err(err).Str("repo", repo.Name).Msg("Failed to upsert repository")
 			// Continue with others
 		}
 	}
 
+	if err := s.cache.InvalidateTags(ctx, domain.WorkspaceCacheTag(workspaceID)); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Int("workspace_id", workspaceID).Msg("Failed to invalidate workspace cache after sync")
+	}
+
 	return nil
 }
 
+func (s *GitHubServiceImpl) RegisterLocalRepository(ctx context.Context, userID, workspaceID int, path string) (*domain.Repository, error) {
+	if err := s.authz.AuthorizeWorkspace(ctx, userID, workspaceID, domain.WorkspaceRoleMaintainer); err != nil {
+		return nil, err
+	}
+	owner, name, ok := strings.Cut(strings.Trim(path, "/"), "/")
+	if !ok {
+		return nil, domain.Validation("local repository path must be <directory>/<repository>")
+	}
+	host, err := s.hosts.For(domain.ProviderLocal)
+	if err != nil {
+		return nil, err
+	}
+	// Reads the server's filesystem
+	if err := s.authz.AuthorizeAdmin(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	repo, err := host.GetRepository(ctx, owner, name)
+	if err != nil {
+		return nil, err
+	}
+	repo.Provider = domain.ProviderLocal
+	repo.UserID = userID
+	repo.WorkspaceID = workspaceID
+	repo.LastSyncAt = sql.NullTime{Time: time.Now(), Valid: true}
+	if repo.ID, err = s.repoStore.Upsert(ctx, repo); err != nil {
+		return nil, fmt.Errorf("failed to store repository: %w", err)
+	}
+
+	if err := s.cache.InvalidateTags(ctx, domain.WorkspaceCacheTag(workspaceID)); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Int("workspace_id", workspaceID).Msg("Failed to invalidate workspace cache after registration")
+	}
+	log.Ctx(ctx).Info().Str("repo", repo.FullName).Int("workspace_id", workspaceID).Msg("Registered local repository")
+	return repo, nil
+}
+
+// githubRepositories lists the repositories of the user's GitHub account, or
+// of an organization they belong to, and returns the account listed
+func (s *GitHubServiceImpl) githubRepositories(ctx context.Context, userID int, account string) ([]*domain.Repository, string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, "", fmt.Errorf("user not found: %w", err)
+	}
+	if !user.GithubUsername.Valid {
+		return nil, "", domain.Validation("user has no github username linked")
+	}
+	if account == "" {
+		account = user.GithubUsername.String
+	} else if !strings.EqualFold(account, user.GithubUsername.String) {
+		// Organization repositories are only imported by the organization's members
+		member, err := s.ghClient.IsOrgMember(ctx, account, user.GithubUsername.String)
+		if err != nil {
+			return nil, "", err
+		}
+		if !member {
+			return nil, "", domain.Forbidden(fmt.Sprintf("not a member of GitHub organization %s", account))
+		}
+	}
+
+	// An explicit sync must see GitHub's current state, not a cached listing
+	if err := s.cache.InvalidateTags(ctx, domain.GitHubAccountCacheTag(account)); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("Failed to invalidate GitHub cache before sync")
+	}
+
+	repos, err := s.ghClient.GetUserRepositories(ctx, account)
+	return repos, account, err
+}
+
+// hostRepositories lists an account on GitLab or Gitea. Users are not linked to
+// accounts there, so membership cannot be checked and only admins may import.
+func (s *GitHubServiceImpl) hostRepositories(ctx context.Context, userID int, provider domain.SourceProvider, account string) ([]*domain.Repository, error) {
+	if account == "" {
+		return nil, domain.Validation("account is required to sync from %s", provider)
+	}
+	host, err := s.hosts.For(provider)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authz.AuthorizeAdmin(ctx, userID); err != nil {
+		return nil, err
+	}
+	return host.ListRepositories(ctx, account)
+}
+
+func (s *GitHubServiceImpl) ListRepositories(ctx context.Context, userID, workspaceID int) ([]domain.Repository, error) {
+	if err := s.authz.AuthorizeWorkspace(ctx, userID, workspaceID, domain.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+	return s.repoStore.GetByWorkspaceID(ctx, workspaceID)
+}
+
 func (s *GitHubServiceImpl) GetRepositoryDetails(ctx context.Context, userID int, repoID int) (*domain.Repository, error) {
-	return s.repoStore.GetByID(ctx, repoID)
+	return s.authz.Repository(ctx, userID, repoID, domain.WorkspaceRoleViewer)
+}
+
+func (s *GitHubServiceImpl) DeleteRepository(ctx context.Context, userID int, repoID int) error {
+	repo, err := s.authz.Repository(ctx, userID, repoID, domain.WorkspaceRoleMaintainer)
+	if err != nil {
+		return err
+	}
+	if err := s.repoStore.Delete(ctx, repoID); err != nil {
+		return err
+	}
+
+	// The workspace stats and the repository's reports must not outlive it
+	if err := s.cache.InvalidateTags(ctx, domain.WorkspaceCacheTag(repo.WorkspaceID), domain.RepositoryCacheTag(repoID)); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Int("repo_id", repoID).Msg("Failed to invalidate cache after delete")
+	}
+	return nil
+}
+
+// GetRepositoryStats returns aggregated repository statistics for a workspace, cached until the next sync or analysis
+func (s *GitHubServiceImpl) GetRepositoryStats(ctx context.Context, userID, workspaceID int) (map[string]interface{}, error) {
+	if err := s.authz.AuthorizeWorkspace(ctx, userID, workspaceID, domain.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+
+	cacheKey := fmt.Sprintf("stats:workspace:%d", workspaceID)
+	var stats map[string]interface{}
+	if found, err := s.cache.Get(ctx, cacheKey, &stats); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("key", cacheKey).Msg("Stats cache read failed")
+	} else if found {
+		return stats, nil
+	}
+
+	stats, err := s.repoStore.GetStats(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cache.Set(ctx, cacheKey, stats, statsCacheTTL, domain.WorkspaceCacheTag(workspaceID)); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("key", cacheKey).Msg("Stats cache write failed")
+	}
+	return stats, nil
 }
 
 func (s *GitHubServiceImpl) AnalyzeDependencies(ctx context.Context, repoID int) error {
@@ -87,7 +227,7 @@ func (s *GitHubServiceImpl) AnalyzeDependencies(ctx context.Context, repoID int)
 	
 	// Implementation to be added: dependency parsing logic similar to TS.
 	// For now, stubbed.
-	log.Info().Str("repo", repo.FullName).Msg("Analyzing dependencies...")
+	log.Ctx(ctx).Info().Str("repo", repo.FullName).Msg("Analyzing dependencies...")
 	
 	return nil
 }
@@ -6,10 +6,12 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/biodoia/ghrego/internal/cache"
 	"github.com/biodoia/ghrego/internal/core/domain"
 	"github.com/biodoia/ghrego/internal/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGitHubServiceImpl_SyncUserRepositories(t *testing.T) {
@@ -17,7 +19,7 @@ func TestGitHubServiceImpl_SyncUserRepositories(t *testing.T) {
 		mockUserRepo := new(mocks.UserRepository)
 		mockRepoStore := new(mocks.RepositoryStore)
 		mockGHClient := new(mocks.GitHubClient)
-		svc := NewGitHubService(mockGHClient, mockRepoStore, mockUserRepo)
+		svc := NewGitHubService(mockGHClient, nil, mockRepoStore, mockUserRepo, cache.NewMemoryCache(), NewAuthorizer(mockUserRepo, mockRepoStore, workspaceMembers(domain.WorkspaceRoleMaintainer)))
 
 		user := &domain.User{
 			ID:             1,
@@ -32,10 +34,10 @@ func TestGitHubServiceImpl_SyncUserRepositories(t *testing.T) {
 		mockUserRepo.On("GetByID", mock.Anything, 1).Return(user, nil)
 		mockGHClient.On("GetUserRepositories", mock.Anything, "testuser").Return(ghRepos, nil)
 		mockRepoStore.On("Upsert", mock.Anything, mock.MatchedBy(func(r *domain.Repository) bool {
-			return r.Name == "repo1"
+			return r.Name == "repo1" && r.WorkspaceID == 5
 		})).Return(1, nil)
 
-		err := svc.SyncUserRepositories(context.Background(), 1, "test-openid")
+		err := svc.SyncUserRepositories(context.Background(), 1, 5, "", "")
 		assert.NoError(t, err)
 		
 		mockUserRepo.AssertExpectations(t)
@@ -47,11 +49,11 @@ func TestGitHubServiceImpl_SyncUserRepositories(t *testing.T) {
 		mockUserRepo := new(mocks.UserRepository)
 		mockRepoStore := new(mocks.RepositoryStore)
 		mockGHClient := new(mocks.GitHubClient)
-		svc := NewGitHubService(mockGHClient, mockRepoStore, mockUserRepo)
+		svc := NewGitHubService(mockGHClient, nil, mockRepoStore, mockUserRepo, cache.NewMemoryCache(), NewAuthorizer(mockUserRepo, mockRepoStore, workspaceMembers(domain.WorkspaceRoleMaintainer)))
 
 		mockUserRepo.On("GetByID", mock.Anything, 99).Return(nil, errors.New("not found"))
 		
-		err := svc.SyncUserRepositories(context.Background(), 99, "")
+		err := svc.SyncUserRepositories(context.Background(), 99, 5, "", "")
 		assert.Error(t, err)
 	})
 	
@@ -59,7 +61,7 @@ func TestGitHubServiceImpl_SyncUserRepositories(t *testing.T) {
 		mockUserRepo := new(mocks.UserRepository)
 		mockRepoStore := new(mocks.RepositoryStore)
 		mockGHClient := new(mocks.GitHubClient)
-		svc := NewGitHubService(mockGHClient, mockRepoStore, mockUserRepo)
+		svc := NewGitHubService(mockGHClient, nil, mockRepoStore, mockUserRepo, cache.NewMemoryCache(), NewAuthorizer(mockUserRepo, mockRepoStore, workspaceMembers(domain.WorkspaceRoleMaintainer)))
 
 		user := &domain.User{
 			ID:             1,
@@ -69,7 +71,7 @@ func TestGitHubServiceImpl_SyncUserRepositories(t *testing.T) {
 		mockUserRepo.On("GetByID", mock.Anything, 1).Return(user, nil)
 		mockGHClient.On("GetUserRepositories", mock.Anything, "testuser").Return(nil, errors.New("api error"))
 		
-		err := svc.SyncUserRepositories(context.Background(), 1, "")
+		err := svc.SyncUserRepositories(context.Background(), 1, 5, "", "")
 		assert.Error(t, err)
 		assert.Equal(t, "api error", err.Error())
 	})
@@ -78,7 +80,7 @@ func TestGitHubServiceImpl_SyncUserRepositories(t *testing.T) {
 		mockUserRepo := new(mocks.UserRepository)
 		mockRepoStore := new(mocks.RepositoryStore)
 		mockGHClient := new(mocks.GitHubClient)
-		svc := NewGitHubService(mockGHClient, mockRepoStore, mockUserRepo)
+		svc := NewGitHubService(mockGHClient, nil, mockRepoStore, mockUserRepo, cache.NewMemoryCache(), NewAuthorizer(mockUserRepo, mockRepoStore, workspaceMembers(domain.WorkspaceRoleMaintainer)))
 
 		user := &domain.User{
 			ID:             1,
@@ -86,8 +88,102 @@ func TestGitHubServiceImpl_SyncUserRepositories(t *testing.T) {
 		}
 		mockUserRepo.On("GetByID", mock.Anything, 1).Return(user, nil)
 		
-		err := svc.SyncUserRepositories(context.Background(), 1, "")
+		err := svc.SyncUserRepositories(context.Background(), 1, 5, "", "")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "no github username linked")
 	})
+
+	t.Run("organization account requires membership", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockRepoStore := new(mocks.RepositoryStore)
+		mockGHClient := new(mocks.GitHubClient)
+		svc := NewGitHubService(mockGHClient, nil, mockRepoStore, mockUserRepo, cache.NewMemoryCache(), NewAuthorizer(mockUserRepo, mockRepoStore, workspaceMembers(domain.WorkspaceRoleMaintainer)))
+
+		user := &domain.User{ID: 1, GithubUsername: domain.SQLNullString("testuser")}
+		mockUserRepo.On("GetByID", mock.Anything, 1).Return(user, nil)
+		mockGHClient.On("IsOrgMember", mock.Anything, "octo-org", "testuser").Return(true, nil)
+		mockGHClient.On("IsOrgMember", mock.Anything, "other-org", "testuser").Return(false, nil)
+		mockGHClient.On("GetUserRepositories", mock.Anything, "octo-org").Return([]*domain.Repository{{Name: "org-repo", GithubID: "202"}}, nil)
+		mockRepoStore.On("Upsert", mock.Anything, mock.MatchedBy(func(r *domain.Repository) bool {
+			return r.Name == "org-repo" && r.WorkspaceID == 5
+		})).Return(2, nil)
+
+		assert.NoError(t, svc.SyncUserRepositories(context.Background(), 1, 5, "", "octo-org"))
+		assert.ErrorIs(t, svc.SyncUserRepositories(context.Background(), 1, 5, "", "other-org"), domain.ErrForbidden)
+		mockGHClient.AssertNotCalled(t, "GetUserRepositories", mock.Anything, "other-org")
+		mockRepoStore.AssertExpectations(t)
+	})
+}
+
+func TestGitHubServiceImpl_SyncFromOtherProviders(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepository)
+	mockRepoStore := new(mocks.RepositoryStore)
+	gitlab := &mocks.SourceHost{Name: domain.ProviderGitLab}
+	svc := NewGitHubService(new(mocks.GitHubClient), NewSourceHosts(gitlab), mockRepoStore, mockUserRepo, cache.NewMemoryCache(), NewAuthorizer(mockUserRepo, mockRepoStore, workspaceMembers(domain.WorkspaceRoleMaintainer)))
+
+	mockUserRepo.On("GetByID", mock.Anything, 1).Return(&domain.User{ID: 1, Role: domain.UserRoleUser}, nil)
+	mockUserRepo.On("GetByID", mock.Anything, 2).Return(&domain.User{ID: 2, Role: domain.UserRoleAdmin}, nil)
+	gitlab.On("ListRepositories", mock.Anything, "octo").Return([]*domain.Repository{{Name: "api", GithubID: "101"}}, nil)
+	mockRepoStore.On("Upsert", mock.Anything, mock.MatchedBy(func(r *domain.Repository) bool {
+		return r.Provider == domain.ProviderGitLab && r.GithubID == "101" && r.WorkspaceID == 5
+	})).Return(1, nil)
+
+	// No linked GitLab identity to check membership against
+	assert.ErrorIs(t, svc.SyncUserRepositories(context.Background(), 1, 5, domain.ProviderGitLab, "octo"), domain.ErrForbidden)
+	assert.ErrorIs(t, svc.SyncUserRepositories(context.Background(), 2, 5, domain.ProviderGitLab, ""), domain.ErrValidation)
+	assert.ErrorIs(t, svc.SyncUserRepositories(context.Background(), 2, 5, domain.ProviderGitea, "octo"), domain.ErrValidation)
+
+	assert.NoError(t, svc.SyncUserRepositories(context.Background(), 2, 5, domain.ProviderGitLab, "octo"))
+	mockRepoStore.AssertExpectations(t)
+}
+
+func TestGitHubServiceImpl_RegisterLocalRepository(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepository)
+	mockRepoStore := new(mocks.RepositoryStore)
+	local := &mocks.SourceHost{Name: domain.ProviderLocal}
+	svc := NewGitHubService(new(mocks.GitHubClient), NewSourceHosts(local), mockRepoStore, mockUserRepo, cache.NewMemoryCache(), NewAuthorizer(mockUserRepo, mockRepoStore, workspaceMembers(domain.WorkspaceRoleMaintainer)))
+
+	mockUserRepo.On("GetByID", mock.Anything, 1).Return(&domain.User{ID: 1, Role: domain.UserRoleUser}, nil)
+	mockUserRepo.On("GetByID", mock.Anything, 2).Return(&domain.User{ID: 2, Role: domain.UserRoleAdmin}, nil)
+	local.On("GetRepository", mock.Anything, "team", "api").Return(&domain.Repository{Name: "api", FullName: "team/api"}, nil)
+	mockRepoStore.On("Upsert", mock.Anything, mock.MatchedBy(func(r *domain.Repository) bool {
+		return r.Provider == domain.ProviderLocal && r.UserID == 2 && r.WorkspaceID == 5
+	})).Return(12, nil)
+
+	_, err := svc.RegisterLocalRepository(context.Background(), 1, 5, "team/api")
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = svc.RegisterLocalRepository(context.Background(), 2, 5, "api")
+	assert.ErrorIs(t, err, domain.ErrValidation)
+
+	repo, err := svc.RegisterLocalRepository(context.Background(), 2, 5, "/team/api/")
+	require.NoError(t, err)
+	assert.Equal(t, 12, repo.ID)
+	assert.True(t, repo.LastSyncAt.Valid)
+}
+
+func TestGitHubServiceImpl_GetRepositoryStats(t *testing.T) {
+	t.Run("cached until sync invalidates", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockRepoStore := new(mocks.RepositoryStore)
+		mockGHClient := new(mocks.GitHubClient)
+		svc := NewGitHubService(mockGHClient, nil, mockRepoStore, mockUserRepo, cache.NewMemoryCache(), NewAuthorizer(mockUserRepo, mockRepoStore, workspaceMembers(domain.WorkspaceRoleMaintainer)))
+
+		user := &domain.User{ID: 1, GithubUsername: domain.SQLNullString("testuser")}
+		mockUserRepo.On("GetByID", mock.Anything, 1).Return(user, nil)
+		mockGHClient.On("GetUserRepositories", mock.Anything, "testuser").Return([]*domain.Repository{}, nil)
+		mockRepoStore.On("GetStats", mock.Anything, 5).Return(map[string]interface{}{"totalRepositories": 3}, nil)
+
+		_, err := svc.GetRepositoryStats(context.Background(), 1, 5)
+		assert.NoError(t, err)
+		_, err = svc.GetRepositoryStats(context.Background(), 1, 5)
+		assert.NoError(t, err)
+		mockRepoStore.AssertNumberOfCalls(t, "GetStats", 1)
+
+		assert.NoError(t, svc.SyncUserRepositories(context.Background(), 1, 5, "", ""))
+
+		stats, err := svc.GetRepositoryStats(context.Background(), 1, 5)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 3, stats["totalRepositories"])
+		mockRepoStore.AssertNumberOfCalls(t, "GetStats", 2)
+	})
 }
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/biodoia/ghrego/internal/metrics"
+	"github.com/biodoia/ghrego/internal/telemetry"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = telemetry.Tracer("github.com/biodoia/ghrego/internal/core/services")
+
+// ErrShuttingDown is returned when a job is submitted after shutdown has begun
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// BackgroundJobs tracks background work so the server can drain it on shutdown
+type BackgroundJobs struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func NewBackgroundJobs() *BackgroundJobs {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &BackgroundJobs{ctx: ctx, cancel: cancel}
+}
+
+func (j *BackgroundJobs) Go(ctx context.Context, name string, fn func(ctx context.Context)) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.closed {
+		return ErrShuttingDown
+	}
+
+	// The job belongs to the caller's trace and logger but not to its lifetime
+	jobCtx := trace.ContextWithSpanContext(j.ctx, trace.SpanContextFromContext(ctx))
+	parent := zerolog.Ctx(ctx)
+
+	j.wg.Add(1)
+	metrics.BackgroundJobsRunning.Inc()
+	go func() {
+		defer j.wg.Done()
+		defer metrics.BackgroundJobsRunning.Dec()
+
+		ctx, span := tracer.Start(jobCtx, "job "+name)
+		defer span.End()
+		logger := parent.With().Ctx(ctx).Str("job", name).Logger()
+		ctx = logger.WithContext(ctx)
+
+		fn(ctx)
+		logger.Debug().Msg("Background job finished")
+	}()
+	return nil
+}
+
+// Shutdown stops accepting jobs and waits for running ones. When ctx expires
+// first, running jobs are cancelled so they can checkpoint, and Shutdown waits
+// for them to return before reporting ctx's error.
+func (j *BackgroundJobs) Shutdown(ctx context.Context) error {
+	j.mu.Lock()
+	j.closed = true
+	j.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		j.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		j.cancel()
+		return nil
+	case <-ctx.Done():
+		log.Warn().Msg("Shutdown grace period expired, cancelling background jobs")
+		j.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
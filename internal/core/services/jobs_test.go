@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestBackgroundJobs_Shutdown(t *testing.T) {
+	t.Run("waits for running jobs and rejects new ones", func(t *testing.T) {
+		jobs := NewBackgroundJobs()
+		finished := make(chan struct{})
+		require.NoError(t, jobs.Go(context.Background(), "test", func(ctx context.Context) {
+			time.Sleep(10 * time.Millisecond)
+			close(finished)
+		}))
+
+		require.NoError(t, jobs.Shutdown(context.Background()))
+		select {
+		case <-finished:
+		default:
+			t.Fatal("Shutdown returned before the job finished")
+		}
+		assert.ErrorIs(t, jobs.Go(context.Background(), "late", func(ctx context.Context) {}), ErrShuttingDown)
+	})
+
+	t.Run("cancels jobs when the grace period expires", func(t *testing.T) {
+		jobs := NewBackgroundJobs()
+		cancelled := false
+		require.NoError(t, jobs.Go(context.Background(), "test", func(ctx context.Context) {
+			<-ctx.Done()
+			cancelled = true
+		}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, jobs.Shutdown(ctx), context.DeadlineExceeded)
+		assert.True(t, cancelled)
+	})
+}
+
+func TestBackgroundJobs_propagatesTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	reqCtx, reqSpan := otel.Tracer("test").Start(context.Background(), "request")
+	reqCancelled, cancel := context.WithCancel(reqCtx)
+
+	jobs := NewBackgroundJobs()
+	var jobErr error
+	require.NoError(t, jobs.Go(reqCancelled, "test", func(ctx context.Context) {
+		jobErr = ctx.Err()
+	}))
+	// The request finishing must not cancel the job
+	cancel()
+	reqSpan.End()
+	require.NoError(t, jobs.Shutdown(context.Background()))
+
+	assert.NoError(t, jobErr)
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	job := spans[1]
+	if job.Name() != "job test" {
+		job = spans[0]
+	}
+	assert.Equal(t, "job test", job.Name())
+	assert.Equal(t, reqSpan.SpanContext().TraceID(), job.SpanContext().TraceID())
+	assert.Equal(t, reqSpan.SpanContext().SpanID(), job.Parent().SpanID())
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/analyzers"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/licenses"
+	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/biodoia/ghrego/internal/core/versions"
+)
+
+// saveDetectedLicenses records the licenses found at the root of a checkout,
+// replacing those found by earlier runs
+func (s *AIAnalysisServiceImpl) saveDetectedLicenses(ctx context.Context, repo *domain.Repository, snap ports.CodeSnapshot) error {
+	found, err := analyzers.DetectLicenses(snap)
+	if err != nil {
+		return fmt.Errorf("failed to detect licenses: %w", err)
+	}
+	now := time.Now()
+	for i := range found {
+		found[i].RepositoryID = repo.ID
+		found[i].CreatedAt = now
+	}
+	return s.licenseRepo.Replace(ctx, repo.ID, found)
+}
+
+// repositoryLicense is the SPDX expression a repository is distributed
+// under. License files win over manifest fields; several license files, as
+// in LICENSE-MIT next to LICENSE-APACHE, offer a choice.
+func repositoryLicense(found []domain.RepositoryLicense) string {
+	var files, manifests []string
+	for _, l := range found {
+		if l.Method == domain.LicenseMethodManifest {
+			manifests = append(manifests, l.SPDXID)
+		} else {
+			files = append(files, l.SPDXID)
+		}
+	}
+	if len(files) > 0 {
+		return licenses.Expression(files)
+	}
+	return licenses.Expression(manifests)
+}
+
+var compatibilityOrder = map[domain.LicenseCompatibility]int{
+	domain.LicenseIncompatible: 0,
+	domain.LicenseUnknown:      1,
+	domain.LicenseCompatible:   2,
+}
+
+// GetLicenseReport checks every declared library once; its license comes
+// from a vendored copy or, failing that, from the registry snapshot
+func (s *DependencyServiceImpl) GetLicenseReport(ctx context.Context, userID, repoID int) (*domain.LicenseReport, error) {
+	if _, err := s.authz.Repository(ctx, userID, repoID, domain.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+	found, err := s.licenseRepo.GetByRepositoryIDs(ctx, []int{repoID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load licenses: %w", err)
+	}
+	techs, err := s.technologyRepo.GetByRepositoryID(ctx, repoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load technologies: %w", err)
+	}
+
+	report := &domain.LicenseReport{
+		RepositoryID: repoID,
+		License:      repositoryLicense(found),
+		Licenses:     found,
+		Dependencies: []domain.DependencyLicense{},
+		GeneratedAt:  time.Now(),
+	}
+	if report.Licenses == nil {
+		report.Licenses = []domain.RepositoryLicense{}
+	}
+
+	for _, lib := range declaredLibraries(techs) {
+		dep, err := s.dependencyLicense(ctx, lib, report.License)
+		if err != nil {
+			return nil, err
+		}
+		if dep.Compatibility == domain.LicenseIncompatible {
+			report.Incompatible++
+		}
+		report.Dependencies = append(report.Dependencies, dep)
+	}
+	sort.SliceStable(report.Dependencies, func(i, j int) bool {
+		a, b := report.Dependencies[i], report.Dependencies[j]
+		switch {
+		case a.Compatibility != b.Compatibility:
+			return compatibilityOrder[a.Compatibility] < compatibilityOrder[b.Compatibility]
+		case a.Ecosystem != b.Ecosystem:
+			return a.Ecosystem < b.Ecosystem
+		}
+		return a.Name < b.Name
+	})
+	return report, nil
+}
+
+// declaredLibrary is a package a repository declares, in its ecosystem
+type declaredLibrary struct {
+	ecosystem string
+	tech      domain.Technology
+}
+
+// declaredLibraries lists each library of a repository once, preferring a
+// row that knows the license
+func declaredLibraries(techs []domain.Technology) []declaredLibrary {
+	byPackage := make(map[dependencyKey]domain.Technology)
+	var keys []dependencyKey
+	for _, tech := range techs {
+		ecosystem := domain.EcosystemOf(tech.PackageManager.String)
+		if ecosystem == "" || tech.Type != domain.TechnologyTypeLibrary {
+			continue
+		}
+		key := dependencyKey{ecosystem, versions.NormalizeName(ecosystem, tech.Name)}
+		prev, ok := byPackage[key]
+		if !ok {
+			keys = append(keys, key)
+		}
+		if !ok || tech.License.Valid && !prev.License.Valid {
+			byPackage[key] = tech
+		}
+	}
+	libs := make([]declaredLibrary, len(keys))
+	for i, key := range keys {
+		libs[i] = declaredLibrary{key.ecosystem, byPackage[key]}
+	}
+	return libs
+}
+
+// libraryLicense looks up the license of a library: its vendored copy or,
+// failing that, the registry snapshot
+func (s *DependencyServiceImpl) libraryLicense(ctx context.Context, lib declaredLibrary) (domain.DependencyLicense, error) {
+	dep := domain.DependencyLicense{
+		Ecosystem: lib.ecosystem,
+		Name:      lib.tech.Name,
+		Version:   lib.tech.Version.String,
+		License:   lib.tech.License.String,
+	}
+	if dep.License != "" {
+		dep.LicenseSource = domain.DependencyLicenseVendored
+	} else if s.registry != nil {
+		license, err := s.registry.License(ctx, lib.ecosystem, lib.tech.Name)
+		if err != nil {
+			return dep, err
+		}
+		if license != "" {
+			dep.License, dep.LicenseSource = license, domain.DependencyLicenseRegistry
+		}
+	}
+	return dep, nil
+}
+
+func (s *DependencyServiceImpl) dependencyLicense(ctx context.Context, lib declaredLibrary, project string) (domain.DependencyLicense, error) {
+	dep, err := s.libraryLicense(ctx, lib)
+	if err != nil {
+		return dep, err
+	}
+	switch {
+	case dep.License == "":
+		dep.Compatibility, dep.Reason = domain.LicenseUnknown, "the license of the dependency is unknown"
+	case project == "":
+		dep.Compatibility, dep.Reason = domain.LicenseUnknown, "the repository has no license"
+	default:
+		c, reason := licenses.Check(project, dep.License)
+		dep.Compatibility, dep.Reason = domain.LicenseCompatibility(c.String()), reason
+	}
+	return dep, nil
+}
+
+// CheckUnification looks for a license the repositories can be unified
+// under. Pairs of repositories whose licenses exclude each other make the
+// unification incompatible; repositories without a license, or with one the
+// check does not know, only warn, since no verdict is possible for them.
+func (s *DependencyServiceImpl) CheckUnification(ctx context.Context, userID int, repoIDs []int) (*domain.UnificationLicenseCheck, error) {
+	ids := slices.Compact(slices.Sorted(slices.Values(repoIDs)))
+	if len(ids) < 2 {
+		return nil, domain.Validation("at least two repositories are required")
+	}
+	check := &domain.UnificationLicenseCheck{Compatible: true, Warnings: []string{}}
+	for _, id := range ids {
+		repo, err := s.authz.Repository(ctx, userID, id, domain.WorkspaceRoleViewer)
+		if err != nil {
+			return nil, err
+		}
+		check.Repositories = append(check.Repositories, domain.RepositoryLicenseRef{ID: repo.ID, FullName: repo.FullName})
+	}
+
+	found, err := s.licenseRepo.GetByRepositoryIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load licenses: %w", err)
+	}
+	byRepo := make(map[int][]domain.RepositoryLicense)
+	for _, l := range found {
+		byRepo[l.RepositoryID] = append(byRepo[l.RepositoryID], l)
+	}
+
+	var licensed []domain.RepositoryLicenseRef
+	for i := range check.Repositories {
+		ref := &check.Repositories[i]
+		ref.License = repositoryLicense(byRepo[ref.ID])
+		if ref.License == "" {
+			check.Warnings = append(check.Warnings, fmt.Sprintf("%s has no license: unifying it needs the permission of its copyright holders", ref.FullName))
+			continue
+		}
+		licensed = append(licensed, *ref)
+	}
+
+	for i, a := range licensed {
+		for _, b := range licensed[i+1:] {
+			if conflict, reason := licenses.Conflict(a.License, b.License); conflict {
+				check.Compatible = false
+				check.Warnings = append(check.Warnings, fmt.Sprintf("%s (%s) and %s (%s) cannot be unified: %s", a.FullName, a.License, b.FullName, b.License, reason))
+			}
+		}
+	}
+	if !check.Compatible || len(licensed) == 0 {
+		return check, nil
+	}
+
+	exprs := make([]string, len(licensed))
+	for i, ref := range licensed {
+		exprs[i] = ref.License
+	}
+	license, reasons := licenses.Combine(exprs)
+	switch {
+	case license != "":
+		check.License = license
+		for _, ref := range licensed {
+			if !slices.Contains(licenses.Choices(ref.License), license) {
+				check.Warnings = append(check.Warnings, fmt.Sprintf("%s (%s) would be distributed under %s", ref.FullName, ref.License, license))
+			}
+		}
+	case len(reasons) > 0:
+		check.Compatible = false
+		check.Warnings = append(check.Warnings, "no license allows unifying all the repositories: "+reasons[len(reasons)-1])
+	default:
+		check.Warnings = append(check.Warnings, "the licenses could not be checked, some of them are not known")
+	}
+	return check, nil
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/biodoia/ghrego/internal/cache"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newLicenseTestService(t *testing.T, registry *mocks.PackageRegistry) (*DependencyServiceImpl, *mocks.TechnologyRepository, *mocks.LicenseRepository) {
+	t.Helper()
+	mockRepoStore := new(mocks.RepositoryStore)
+	mockTechRepo := new(mocks.TechnologyRepository)
+	mockLicenseRepo := new(mocks.LicenseRepository)
+	mockUserRepo := new(mocks.UserRepository)
+	mockWorkspaceRepo := new(mocks.WorkspaceRepository)
+	mockUserRepo.On("GetByID", mock.Anything, 1).Return(&domain.User{ID: 1, Role: domain.UserRoleUser}, nil)
+	mockWorkspaceRepo.On("GetMember", mock.Anything, 7, 1).Return(&domain.WorkspaceMember{WorkspaceID: 7, UserID: 1, Role: domain.WorkspaceRoleViewer}, nil)
+	for id, name := range map[int]string{1: "acme/api", 2: "acme/web", 3: "acme/admin", 4: "acme/tools"} {
+		mockRepoStore.On("GetByID", mock.Anything, id).Return(&domain.Repository{ID: id, WorkspaceID: 7, FullName: name}, nil)
+	}
+
+	svc := NewDependencyService(mockRepoStore, mockTechRepo, mockLicenseRepo, nil, registry, cache.NewMemoryCache(), NewAuthorizer(mockUserRepo, mockRepoStore, mockWorkspaceRepo))
+	return svc.(*DependencyServiceImpl), mockTechRepo, mockLicenseRepo
+}
+
+func repoLicense(repoID int, spdxID, source, method string) domain.RepositoryLicense {
+	return domain.RepositoryLicense{RepositoryID: repoID, SPDXID: spdxID, Source: source, Method: method, Confidence: 1}
+}
+
+func TestDependencyServiceImpl_GetLicenseReport(t *testing.T) {
+	registry := new(mocks.PackageRegistry)
+	registry.On("License", mock.Anything, "npm", "react").Return("MIT", nil)
+	registry.On("License", mock.Anything, "npm", "left-pad").Return("", nil)
+	svc, mockTechRepo, mockLicenseRepo := newLicenseTestService(t, registry)
+
+	mockLicenseRepo.On("GetByRepositoryIDs", mock.Anything, []int{1}).Return([]domain.RepositoryLicense{
+		repoLicense(1, "MIT", "LICENSE", domain.LicenseMethodText),
+		repoLicense(1, "ISC", "package.json", domain.LicenseMethodManifest), // the file wins
+	}, nil)
+	gplKit := library(1, "github.com/acme/kit", "1.2.0", "go", "go.mod")
+	gplKit.License = domain.SQLNullString("GPL-3.0-only")
+	mockTechRepo.On("GetByRepositoryID", mock.Anything, 1).Return([]domain.Technology{
+		library(1, "react", "18.3.1", "npm", "package.json"),
+		{RepositoryID: 1, Name: "React", Type: domain.TechnologyTypeFramework, PackageManager: domain.SQLNullString("npm")}, // from the AI analysis
+		library(1, "left-pad", "1.3.0", "npm", "package.json"),
+		gplKit,
+		{RepositoryID: 1, Name: "Docker", Type: domain.TechnologyTypePlatform, Evidence: domain.SQLNullString("Dockerfile")},
+	}, nil)
+
+	report, err := svc.GetLicenseReport(context.Background(), 1, 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, "MIT", report.License)
+	assert.Len(t, report.Licenses, 2)
+	assert.Equal(t, 1, report.Incompatible)
+	assert.Equal(t, []domain.DependencyLicense{
+		{Ecosystem: "Go", Name: "github.com/acme/kit", Version: "1.2.0", License: "GPL-3.0-only", LicenseSource: domain.DependencyLicenseVendored,
+			Compatibility: domain.LicenseIncompatible, Reason: "GPL-3.0 requires the whole work to be distributed under GPL-3.0, not MIT"},
+		{Ecosystem: "npm", Name: "left-pad", Version: "1.3.0", Compatibility: domain.LicenseUnknown, Reason: "the license of the dependency is unknown"},
+		{Ecosystem: "npm", Name: "react", Version: "18.3.1", License: "MIT", LicenseSource: domain.DependencyLicenseRegistry, Compatibility: domain.LicenseCompatible},
+	}, report.Dependencies)
+	registry.AssertNotCalled(t, "License", mock.Anything, "Go", "github.com/acme/kit")
+}
+
+func TestDependencyServiceImpl_CheckUnification(t *testing.T) {
+	licensesOf := []domain.RepositoryLicense{
+		repoLicense(1, "MIT", "LICENSE", domain.LicenseMethodText),
+		repoLicense(2, "GPL-3.0-or-later", "COPYING", domain.LicenseMethodText),
+		repoLicense(3, "Apache-2.0", "LICENSE", domain.LicenseMethodText),
+		repoLicense(4, "GPL-2.0-only", "package.json", domain.LicenseMethodManifest),
+	}
+
+	t.Run("compatible under the strongest copyleft", func(t *testing.T) {
+		svc, _, mockLicenseRepo := newLicenseTestService(t, nil)
+		mockLicenseRepo.On("GetByRepositoryIDs", mock.Anything, []int{1, 2, 3}).Return(licensesOf[:3], nil)
+
+		check, err := svc.CheckUnification(context.Background(), 1, []int{3, 1, 2, 1})
+
+		require.NoError(t, err)
+		assert.True(t, check.Compatible)
+		assert.Equal(t, "GPL-3.0-or-later", check.License)
+		assert.Equal(t, []domain.RepositoryLicenseRef{
+			{ID: 1, FullName: "acme/api", License: "MIT"},
+			{ID: 2, FullName: "acme/web", License: "GPL-3.0-or-later"},
+			{ID: 3, FullName: "acme/admin", License: "Apache-2.0"},
+		}, check.Repositories)
+		assert.Equal(t, []string{
+			"acme/api (MIT) would be distributed under GPL-3.0-or-later",
+			"acme/admin (Apache-2.0) would be distributed under GPL-3.0-or-later",
+		}, check.Warnings)
+	})
+
+	t.Run("incompatible licenses", func(t *testing.T) {
+		svc, _, mockLicenseRepo := newLicenseTestService(t, nil)
+		mockLicenseRepo.On("GetByRepositoryIDs", mock.Anything, []int{3, 4}).Return(licensesOf[2:], nil)
+
+		check, err := svc.CheckUnification(context.Background(), 1, []int{3, 4})
+
+		require.NoError(t, err)
+		assert.False(t, check.Compatible)
+		assert.Empty(t, check.License)
+		assert.Equal(t, []string{
+			`acme/admin (Apache-2.0) and acme/tools (GPL-2.0-only) cannot be unified: Apache-2.0 is incompatible with GPL-2.0, which has no "or later" option`,
+		}, check.Warnings)
+	})
+
+	t.Run("repository without a license", func(t *testing.T) {
+		svc, _, mockLicenseRepo := newLicenseTestService(t, nil)
+		mockLicenseRepo.On("GetByRepositoryIDs", mock.Anything, []int{1, 3}).Return(licensesOf[:1], nil)
+
+		check, err := svc.CheckUnification(context.Background(), 1, []int{1, 3})
+
+		require.NoError(t, err)
+		assert.True(t, check.Compatible)
+		assert.Equal(t, "MIT", check.License)
+		assert.Equal(t, []string{"acme/admin has no license: unifying it needs the permission of its copyright holders"}, check.Warnings)
+	})
+
+	t.Run("a single repository", func(t *testing.T) {
+		svc, _, _ := newLicenseTestService(t, nil)
+
+		_, err := svc.CheckUnification(context.Background(), 1, []int{1, 1})
+
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	})
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+)
+
+// Prompt budgets keep the request well inside the model's context window
+const (
+	promptMaxPaths     = 300
+	promptMaxFileBytes = 4000
+	promptMaxManifests = 8
+)
+
+// promptManifests are files describing how a project is built and what it
+// depends on, in the order they are included
+var promptManifests = []string{
+	"go.mod", "package.json", "requirements.txt", "pyproject.toml", "setup.py",
+	"Cargo.toml", "pom.xml", "build.gradle", "build.gradle.kts", "Gemfile",
+	"composer.json", "Dockerfile", "docker-compose.yml", "docker-compose.yaml", "Makefile",
+}
+
+// promptSkipDirs are not listed in the file layout
+var promptSkipDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "dist": true, "build": true, ".git": true,
+}
+
+// buildAnalysisPrompt describes a repository to the model: its metadata and,
+// when a checkout is available, the file layout, README and manifests
+func buildAnalysisPrompt(repo *domain.Repository, snap ports.CodeSnapshot) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+Analyze this repository:
+Name: %s
+Description: %s
+Language: %s
+Stars: %d
+URL: %s
+`, repo.FullName, repo.Description.String, repo.Language.String, repo.Stars, repo.URL)
+	if snap == nil {
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "Commit: %s\n", snap.SHA())
+	paths, truncated := listPaths(snap)
+	b.WriteString("\nFiles:\n")
+	for _, p := range paths {
+		b.WriteString(p + "\n")
+	}
+	if truncated {
+		b.WriteString("...\n")
+	}
+
+	if name, content := readme(snap); content != "" {
+		fmt.Fprintf(&b, "\n--- %s ---\n%s\n", name, content)
+	}
+	included := 0
+	for _, name := range promptManifests {
+		if included == promptMaxManifests {
+			break
+		}
+		if content := readExcerpt(snap, name); content != "" {
+			fmt.Fprintf(&b, "\n--- %s ---\n%s\n", name, content)
+			included++
+		}
+	}
+	return b.String()
+}
+
+// listPaths returns up to promptMaxPaths file paths, shallowest directories first
+func listPaths(fsys fs.FS) ([]string, bool) {
+	var paths []string
+	truncated := false
+	dirs := []string{"."}
+	for len(dirs) > 0 && !truncated {
+		dir := dirs[0]
+		dirs = dirs[1:]
+		entries, err := fs.ReadDir(fsys, dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			p := path.Join(dir, e.Name())
+			if e.IsDir() {
+				if !promptSkipDirs[e.Name()] {
+					dirs = append(dirs, p)
+				}
+				continue
+			}
+			if len(paths) == promptMaxPaths {
+				truncated = true
+				break
+			}
+			paths = append(paths, p)
+		}
+	}
+	return paths, truncated
+}
+
+func readme(fsys fs.FS) (string, string) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return "", ""
+	}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(strings.ToUpper(e.Name()), "README") {
+			return e.Name(), readExcerpt(fsys, e.Name())
+		}
+	}
+	return "", ""
+}
+
+// readExcerpt returns the start of a text file, or "" when it is missing or binary
+func readExcerpt(fsys fs.FS, name string) string {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	buf := make([]byte, promptMaxFileBytes)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return ""
+	}
+	buf = buf[:n]
+	if bytes.IndexByte(buf, 0) >= 0 {
+		return ""
+	}
+	// Do not cut a multi-byte character in half
+	for i := 0; i < utf8.UTFMax && !utf8.Valid(buf); i++ {
+		buf = buf[:len(buf)-1]
+	}
+	return strings.TrimSpace(string(buf))
+}
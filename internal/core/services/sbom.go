@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/sbom"
+)
+
+// GetSBOM renders the declared libraries of a repository as a bill of
+// materials, with the licenses the license report would show. The format is
+// checked before anything is loaded.
+func (s *DependencyServiceImpl) GetSBOM(ctx context.Context, userID, repoID int, format domain.SBOMFormat) (*domain.SBOM, error) {
+	if format != domain.SBOMFormatCycloneDX && format != domain.SBOMFormatSPDX {
+		return nil, domain.Validation("unknown SBOM format %q, expected cyclonedx or spdx", format)
+	}
+	repo, err := s.authz.Repository(ctx, userID, repoID, domain.WorkspaceRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	found, err := s.licenseRepo.GetByRepositoryIDs(ctx, []int{repoID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load licenses: %w", err)
+	}
+	techs, err := s.technologyRepo.GetByRepositoryID(ctx, repoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load technologies: %w", err)
+	}
+
+	doc := sbom.Document{Repository: repo, License: repositoryLicense(found), Created: time.Now()}
+	for _, lib := range declaredLibraries(techs) {
+		dep, err := s.libraryLicense(ctx, lib)
+		if err != nil {
+			return nil, err
+		}
+		doc.Dependencies = append(doc.Dependencies, dep)
+	}
+	out, err := sbom.Render(doc, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render SBOM: %w", err)
+	}
+	return &domain.SBOM{Format: format, Document: out}, nil
+}
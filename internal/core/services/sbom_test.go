@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencyServiceImpl_GetSBOM(t *testing.T) {
+	registry := new(mocks.PackageRegistry)
+	registry.On("License", mock.Anything, "npm", "react").Return("MIT", nil)
+	svc, mockTechRepo, mockLicenseRepo := newLicenseTestService(t, registry)
+	mockLicenseRepo.On("GetByRepositoryIDs", mock.Anything, []int{1}).Return([]domain.RepositoryLicense{
+		repoLicense(1, "Apache-2.0", "LICENSE", domain.LicenseMethodText),
+	}, nil)
+	mockTechRepo.On("GetByRepositoryID", mock.Anything, 1).Return([]domain.Technology{
+		library(1, "react", "18.3.1", "npm", "package.json"),
+		{RepositoryID: 1, Name: "Docker", Type: domain.TechnologyTypePlatform, Evidence: domain.SQLNullString("Dockerfile")},
+	}, nil)
+
+	t.Run("cyclonedx", func(t *testing.T) {
+		out, err := svc.GetSBOM(context.Background(), 1, 1, domain.SBOMFormatCycloneDX)
+
+		require.NoError(t, err)
+		var bom struct {
+			Metadata struct {
+				Component struct {
+					Name     string `json:"name"`
+					Licenses []struct {
+						License struct {
+							ID string `json:"id"`
+						} `json:"license"`
+					} `json:"licenses"`
+				} `json:"component"`
+			} `json:"metadata"`
+			Components []struct {
+				PURL     string `json:"purl"`
+				Licenses []struct {
+					License struct {
+						ID string `json:"id"`
+					} `json:"license"`
+				} `json:"licenses"`
+			} `json:"components"`
+		}
+		require.NoError(t, json.Unmarshal(out.Document, &bom))
+		assert.Equal(t, "acme/api", bom.Metadata.Component.Name)
+		assert.Equal(t, "Apache-2.0", bom.Metadata.Component.Licenses[0].License.ID)
+		require.Len(t, bom.Components, 1)
+		assert.Equal(t, "pkg:npm/react@18.3.1", bom.Components[0].PURL)
+		assert.Equal(t, "MIT", bom.Components[0].Licenses[0].License.ID)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := svc.GetSBOM(context.Background(), 1, 1, "swid")
+
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	})
+}
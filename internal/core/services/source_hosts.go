@@ -0,0 +1,29 @@
+package services
+
+import (
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+)
+
+// SourceHosts resolves the source host adapter of a repository's provider
+type SourceHosts map[domain.SourceProvider]ports.SourceHost
+
+// NewSourceHosts indexes the configured hosts by provider; nil hosts are skipped
+func NewSourceHosts(hosts ...ports.SourceHost) SourceHosts {
+	h := make(SourceHosts, len(hosts))
+	for _, host := range hosts {
+		if host != nil {
+			h[host.Provider()] = host
+		}
+	}
+	return h
+}
+
+// For returns the host of a provider, or a validation error when it is not configured
+func (h SourceHosts) For(provider domain.SourceProvider) (ports.SourceHost, error) {
+	host, ok := h[provider]
+	if !ok {
+		return nil, domain.Validation("source provider %q is not configured", provider)
+	}
+	return host, nil
+}
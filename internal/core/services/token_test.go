@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenServiceImpl_Create(t *testing.T) {
+	now := time.Date(2026, 3, 17, 12, 0, 0, 0, time.UTC)
+
+	t.Run("stores only the hash", func(t *testing.T) {
+		mockRepo := new(mocks.APITokenRepository)
+		svc := NewTokenService(mockRepo).(*TokenServiceImpl)
+		svc.now = func() time.Time { return now }
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.APIToken")).Return(nil)
+
+		created, err := svc.Create(context.Background(), 1, " ci ", []domain.TokenScope{domain.ScopeReposRead}, 0)
+		require.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(created.Token, domain.APITokenPrefix))
+		assert.Equal(t, "ci", created.Name)
+		assert.True(t, strings.HasPrefix(created.Token, created.Prefix))
+		assert.Equal(t, now.Add(DefaultTokenTTL), created.ExpiresAt)
+		stored := mockRepo.Calls[0].Arguments.Get(1).(*domain.APIToken)
+		assert.Equal(t, hashToken(created.Token), stored.TokenHash)
+	})
+
+	for _, tt := range []struct {
+		name   string
+		scopes []domain.TokenScope
+		ttl    time.Duration
+	}{
+		{"no scopes", nil, 0},
+		{"unknown scope", []domain.TokenScope{"admin"}, 0},
+		{"lifetime too long", []domain.TokenScope{domain.ScopeReposRead}, MaxTokenTTL + time.Hour},
+		{"negative lifetime", []domain.TokenScope{domain.ScopeReposRead}, -time.Hour},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewTokenService(new(mocks.APITokenRepository))
+			_, err := svc.Create(context.Background(), 1, "ci", tt.scopes, tt.ttl)
+			assert.ErrorIs(t, err, domain.ErrValidation)
+		})
+	}
+}
+
+func TestTokenServiceImpl_Authenticate(t *testing.T) {
+	now := time.Date(2026, 3, 17, 12, 0, 0, 0, time.UTC)
+	secret := domain.APITokenPrefix + "abc"
+
+	tests := []struct {
+		name      string
+		token     *domain.APIToken
+		wantErr   error
+		wantTouch bool
+	}{
+		{name: "unknown", wantErr: domain.ErrUnauthenticated},
+		{name: "expired", token: &domain.APIToken{ID: 3, ExpiresAt: now}, wantErr: domain.ErrUnauthenticated},
+		{name: "first use", token: &domain.APIToken{ID: 3, ExpiresAt: now.Add(time.Hour)}, wantTouch: true},
+		{
+			name:  "used recently",
+			token: &domain.APIToken{ID: 3, ExpiresAt: now.Add(time.Hour), LastUsedAt: sql.NullTime{Time: now.Add(-time.Second), Valid: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.APITokenRepository)
+			svc := NewTokenService(mockRepo).(*TokenServiceImpl)
+			svc.now = func() time.Time { return now }
+			if tt.token != nil {
+				mockRepo.On("GetByHash", mock.Anything, hashToken(secret)).Return(tt.token, nil)
+			} else {
+				mockRepo.On("GetByHash", mock.Anything, hashToken(secret)).Return(nil, domain.NotFound("api token", ""))
+			}
+			mockRepo.On("TouchLastUsed", mock.Anything, 3, now).Return(nil)
+
+			token, err := svc.Authenticate(context.Background(), secret)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.token, token)
+			}
+			if tt.wantTouch {
+				mockRepo.AssertCalled(t, "TouchLastUsed", mock.Anything, 3, now)
+			} else {
+				mockRepo.AssertNotCalled(t, "TouchLastUsed", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+
+	t.Run("rejects non-token secrets without a lookup", func(t *testing.T) {
+		mockRepo := new(mocks.APITokenRepository)
+		_, err := NewTokenService(mockRepo).Authenticate(context.Background(), "not-a-token")
+		assert.ErrorIs(t, err, domain.ErrUnauthenticated)
+		mockRepo.AssertNotCalled(t, "GetByHash", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/rs/zerolog/log"
+)
+
+type UsageServiceImpl struct {
+	usageRepo     ports.UsageRepository
+	pricing       domain.ModelPricing
+	monthlyBudget float64
+	now           func() time.Time
+}
+
+// NewUsageService creates the AI cost accounting service. A monthlyBudget of 0 disables the budget.
+func NewUsageService(usageRepo ports.UsageRepository, pricing domain.ModelPricing, monthlyBudget float64) ports.UsageService {
+	return &UsageServiceImpl{
+		usageRepo:     usageRepo,
+		pricing:       pricing,
+		monthlyBudget: monthlyBudget,
+		now:           time.Now,
+	}
+}
+
+func (s *UsageServiceImpl) CheckBudget(ctx context.Context) error {
+	if s.monthlyBudget <= 0 {
+		return nil
+	}
+	spent, err := s.usageRepo.SumCostSince(ctx, s.monthStart())
+	if err != nil {
+		return fmt.Errorf("failed to check AI budget: %w", err)
+	}
+	if spent >= s.monthlyBudget {
+		log.Ctx(ctx).Warn().Float64("spent", spent).Float64("budget", s.monthlyBudget).Msg("Monthly AI budget exhausted")
+		return domain.AIBudgetExceeded(s.monthStart().AddDate(0, 1, 0))
+	}
+	return nil
+}
+
+func (s *UsageServiceImpl) Record(ctx context.Context, analysis *domain.Analysis, userID int, usage domain.TokenUsage) error {
+	_, err := s.usageRepo.Create(ctx, &domain.AIUsage{
+		AnalysisID:   analysis.ID,
+		RepositoryID: analysis.RepositoryID,
+		UserID:       userID,
+		Model:        usage.Model,
+		PromptTokens: usage.PromptTokens,
+		OutputTokens: usage.OutputTokens,
+		CachedTokens: usage.CachedTokens,
+		CostUSD:      s.pricing.Cost(usage),
+		CreatedAt:    s.now().UTC(),
+	})
+	return err
+}
+
+func (s *UsageServiceImpl) GetUserUsage(ctx context.Context, userID int, since time.Time) (*domain.UsageReport, error) {
+	daily, err := s.usageRepo.GetDailyByUser(ctx, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	byRepo, err := s.usageRepo.GetByRepositoryForUser(ctx, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	monthToDate, err := s.usageRepo.SumCostSince(ctx, s.monthStart())
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.UsageReport{
+		Since:              since,
+		Daily:              daily,
+		ByRepository:       byRepo,
+		MonthlyBudgetUSD:   s.monthlyBudget,
+		MonthToDateCostUSD: monthToDate,
+	}
+	for _, d := range daily {
+		report.Total.Add(d.UsageTotals)
+	}
+	return report, nil
+}
+
+// monthStart is the beginning of the current calendar month in UTC
+func (s *UsageServiceImpl) monthStart() time.Time {
+	now := s.now().UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageServiceImpl_CheckBudget(t *testing.T) {
+	monthStart := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	now := func() time.Time { return time.Date(2026, 3, 17, 12, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name    string
+		budget  float64
+		spent   float64
+		wantErr error
+	}{
+		{name: "no budget", budget: 0},
+		{name: "under budget", budget: 10, spent: 9.99},
+		{name: "budget reached", budget: 10, spent: 10, wantErr: domain.ErrAIBudgetExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.UsageRepository)
+			svc := NewUsageService(mockRepo, domain.ModelPricing{}, tt.budget).(*UsageServiceImpl)
+			svc.now = now
+			mockRepo.On("SumCostSince", mock.Anything, monthStart).Return(tt.spent, nil).Maybe()
+
+			err := svc.CheckBudget(context.Background())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				var de *domain.Error
+				require.ErrorAs(t, err, &de)
+				assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), de.RetryAt, "the next budget window")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUsageServiceImpl_Record(t *testing.T) {
+	mockRepo := new(mocks.UsageRepository)
+	pricing := domain.ModelPricing{InputPerMTok: 1, OutputPerMTok: 4, CachedPerMTok: 0.25}
+	svc := NewUsageService(mockRepo, pricing, 0).(*UsageServiceImpl)
+	svc.now = func() time.Time { return time.Date(2026, 3, 31, 23, 30, 0, 0, time.FixedZone("CET", -3600)) }
+
+	usage := domain.TokenUsage{Model: "gemini", PromptTokens: 1_000_000, CachedTokens: 400_000, OutputTokens: 500_000}
+	// 600k uncached * 1 + 400k cached * 0.25 + 500k output * 4 = 0.6 + 0.1 + 2.0
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *domain.AIUsage) bool {
+		return u.AnalysisID == 7 && u.RepositoryID == 3 && u.UserID == 2 && u.Model == "gemini" &&
+			u.CreatedAt.Equal(time.Date(2026, 4, 1, 0, 30, 0, 0, time.UTC)) && u.CreatedAt.Location() == time.UTC &&
+			assert.InDelta(t, 2.7, u.CostUSD, 1e-9)
+	})).Return(1, nil)
+
+	err := svc.Record(context.Background(), &domain.Analysis{ID: 7, RepositoryID: 3}, 2, usage)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/versions"
+)
+
+// vulnerability is an advisory that affects the version in use
+type vulnerability struct {
+	advisory domain.Advisory
+	fixed    string // lowest fixed version above the one in use, "" if there is none
+	priority domain.SuggestionPriority
+}
+
+// saveVulnerabilitySuggestions matches the repository's technologies against
+// the advisory database and suggests updating each vulnerable dependency.
+// A suggestion already made for the same update, in any status, is not
+// repeated.
+func (s *AIAnalysisServiceImpl) saveVulnerabilitySuggestions(ctx context.Context, repo *domain.Repository) error {
+	if s.advisories == nil {
+		return nil
+	}
+	techs, err := s.technologyRepo.GetByRepositoryID(ctx, repo.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load technologies: %w", err)
+	}
+	existing, err := s.suggestionRepo.GetByRepositoryID(ctx, repo.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load suggestions: %w", err)
+	}
+	made := make(map[string]bool)
+	for _, sugg := range existing {
+		if sugg.SuggestionType == domain.SuggestionTypeUpdateDependency {
+			made[sugg.Title] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, tech := range techs {
+		ecosystem := domain.EcosystemOf(tech.PackageManager.String)
+		key := ecosystem + "/" + tech.Name
+		if ecosystem == "" || !tech.Version.Valid || tech.Version.String == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		advisories, err := s.advisories.Advisories(ctx, ecosystem, tech.Name)
+		if err != nil {
+			return err
+		}
+		vulns := matchAdvisories(advisories, ecosystem, tech.Name, tech.Version.String)
+		if len(vulns) == 0 {
+			continue
+		}
+		sugg := vulnerabilitySuggestion(repo.ID, tech, ecosystem, vulns)
+		if made[sugg.Title] {
+			continue
+		}
+		if _, err := s.suggestionRepo.Create(ctx, sugg); err != nil {
+			return fmt.Errorf("failed to save suggestion: %w", err)
+		}
+		made[sugg.Title] = true
+	}
+	return nil
+}
+
+// matchAdvisories returns the advisories affecting version, most severe first
+func matchAdvisories(advisories []domain.Advisory, ecosystem, name, version string) []vulnerability {
+	var vulns []vulnerability
+	for _, adv := range advisories {
+		for _, pkg := range adv.Affected {
+			if !samePackage(ecosystem, name, pkg) {
+				continue
+			}
+			if affected, fixed := affects(pkg, ecosystem, version); affected {
+				vulns = append(vulns, vulnerability{advisory: adv, fixed: fixed, priority: advisoryPriority(adv)})
+				break
+			}
+		}
+	}
+	sort.SliceStable(vulns, func(i, j int) bool {
+		return vulns[i].priority == domain.SuggestionPriorityCritical && vulns[j].priority != domain.SuggestionPriorityCritical
+	})
+	return vulns
+}
+
+func samePackage(ecosystem, name string, pkg domain.AffectedPackage) bool {
+	pkgEcosystem, _, _ := strings.Cut(pkg.Package.Ecosystem, ":")
+	return pkgEcosystem == ecosystem &&
+		versions.NormalizeName(ecosystem, pkg.Package.Name) == versions.NormalizeName(ecosystem, name)
+}
+
+// affects evaluates the OSV ranges of a package for version: the events of a
+// range, in version order, switch it between affected and not affected. It
+// also returns the lowest fixed version above version.
+func affects(pkg domain.AffectedPackage, ecosystem, version string) (bool, string) {
+	affected := false
+	for _, v := range pkg.Versions {
+		if versions.Compare(ecosystem, v, version) == 0 {
+			affected = true
+		}
+	}
+	fixed := ""
+	for _, r := range pkg.Ranges {
+		if r.Type != "SEMVER" && r.Type != "ECOSYSTEM" {
+			continue // GIT ranges are commits, not versions
+		}
+		events := append([]domain.RangeEvent(nil), r.Events...)
+		sort.SliceStable(events, func(i, j int) bool {
+			return compareEvent(ecosystem, eventVersion(events[i]), eventVersion(events[j])) < 0
+		})
+		inRange := false
+		for _, e := range events {
+			switch {
+			case e.Introduced != "":
+				if e.Introduced == "0" || versions.Compare(ecosystem, version, e.Introduced) >= 0 {
+					inRange = true
+				}
+			case e.Fixed != "":
+				if versions.Compare(ecosystem, version, e.Fixed) >= 0 {
+					inRange = false
+				} else if inRange && (fixed == "" || versions.Compare(ecosystem, e.Fixed, fixed) < 0) {
+					fixed = e.Fixed
+				}
+			case e.LastAffected != "":
+				if versions.Compare(ecosystem, version, e.LastAffected) > 0 {
+					inRange = false
+				}
+			}
+		}
+		affected = affected || inRange
+	}
+	return affected, fixed
+}
+
+func eventVersion(e domain.RangeEvent) string {
+	switch {
+	case e.Introduced != "":
+		return e.Introduced
+	case e.Fixed != "":
+		return e.Fixed
+	case e.LastAffected != "":
+		return e.LastAffected
+	}
+	return e.Limit
+}
+
+// compareEvent orders event versions; "0" is below every version
+func compareEvent(ecosystem, a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "0":
+		return -1
+	case b == "0":
+		return 1
+	}
+	return versions.Compare(ecosystem, a, b)
+}
+
+// advisoryPriority is critical for advisories rated critical or scoring at
+// least 9.0 in CVSS v3, and high for every other vulnerability in use
+func advisoryPriority(adv domain.Advisory) domain.SuggestionPriority {
+	if strings.EqualFold(adv.DatabaseSpecific.Severity, "critical") {
+		return domain.SuggestionPriorityCritical
+	}
+	for _, sev := range adv.Severity {
+		if sev.Type != "CVSS_V3" {
+			continue
+		}
+		if score, ok := cvss3BaseScore(sev.Score); ok && score >= 9.0 {
+			return domain.SuggestionPriorityCritical
+		}
+	}
+	return domain.SuggestionPriorityHigh
+}
+
+func vulnerabilitySuggestion(repoID int, tech domain.Technology, ecosystem string, vulns []vulnerability) *domain.Suggestion {
+	// The update has to fix every advisory that has a fix
+	target := ""
+	for _, v := range vulns {
+		if v.fixed != "" && (target == "" || versions.Compare(ecosystem, v.fixed, target) > 0) {
+			target = v.fixed
+		}
+	}
+
+	var title string
+	if target != "" {
+		title = fmt.Sprintf("Update %s from %s to %s", tech.Name, tech.Version.String, target)
+	} else {
+		title = fmt.Sprintf("Replace %s %s, which has unfixed vulnerabilities", tech.Name, tech.Version.String)
+	}
+	var desc strings.Builder
+	fmt.Fprintf(&desc, "%s %s (%s) is affected by %d known vulnerabilities:\n", tech.Name, tech.Version.String, ecosystem, len(vulns))
+	for _, v := range vulns {
+		id := v.advisory.ID
+		if len(v.advisory.Aliases) > 0 {
+			id += " (" + strings.Join(v.advisory.Aliases, ", ") + ")"
+		}
+		fix := "no fixed version"
+		if v.fixed != "" {
+			fix = "fixed in " + v.fixed
+		}
+		fmt.Fprintf(&desc, "- %s: %s; %s\n", id, v.advisory.Summary, fix)
+	}
+
+	now := time.Now()
+	return &domain.Suggestion{
+		RepositoryID:   repoID,
+		SuggestionType: domain.SuggestionTypeUpdateDependency,
+		Title:          title,
+		Description:    strings.TrimSuffix(desc.String(), "\n"),
+		Priority:       vulns[0].priority,
+		Status:         domain.SuggestionStatusPending,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+// cvss3BaseScore computes the base score of a CVSS v3.0 or v3.1 vector such
+// as "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"
+func cvss3BaseScore(vector string) (float64, bool) {
+	weights := map[string]map[string]float64{
+		"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+		"AC": {"L": 0.77, "H": 0.44},
+		"UI": {"N": 0.85, "R": 0.62},
+		"C":  {"H": 0.56, "L": 0.22, "N": 0},
+		"I":  {"H": 0.56, "L": 0.22, "N": 0},
+		"A":  {"H": 0.56, "L": 0.22, "N": 0},
+	}
+	if !strings.HasPrefix(vector, "CVSS:3.") {
+		return 0, false
+	}
+	metrics := make(map[string]string)
+	for _, part := range strings.Split(vector, "/")[1:] {
+		k, v, _ := strings.Cut(part, ":")
+		metrics[k] = v
+	}
+	scopeChanged := metrics["S"] == "C"
+	if metrics["S"] != "U" && !scopeChanged {
+		return 0, false
+	}
+	value := make(map[string]float64)
+	for metric, w := range weights {
+		x, ok := w[metrics[metric]]
+		if !ok {
+			return 0, false
+		}
+		value[metric] = x
+	}
+	var pr float64
+	switch metrics["PR"] {
+	case "N":
+		pr = 0.85
+	case "L":
+		pr = 0.62
+		if scopeChanged {
+			pr = 0.68
+		}
+	case "H":
+		pr = 0.27
+		if scopeChanged {
+			pr = 0.5
+		}
+	default:
+		return 0, false
+	}
+
+	iss := 1 - (1-value["C"])*(1-value["I"])*(1-value["A"])
+	impact := 6.42 * iss
+	if scopeChanged {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+	exploitability := 8.22 * value["AV"] * value["AC"] * pr * value["UI"]
+	if scopeChanged {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10)), true
+	}
+	return roundUp(math.Min(impact+exploitability, 10)), true
+}
+
+// roundUp is the CVSS v3.1 Roundup: the smallest one-decimal number not below x
+func roundUp(x float64) float64 {
+	i := int(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return float64(i/10000+1) / 10
+}
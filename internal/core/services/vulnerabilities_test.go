@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const advisoriesJSON = `[
+{"id": "GHSA-1111", "aliases": ["CVE-2024-0001"], "summary": "Path traversal",
+ "database_specific": {"severity": "HIGH"},
+ "affected": [{"package": {"ecosystem": "Go", "name": "github.com/acme/web"},
+  "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.4.2"}, {"introduced": "2.0.0"}, {"fixed": "2.1.0"}]}]}]},
+{"id": "GO-2024-0002", "summary": "Remote code execution",
+ "severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}],
+ "affected": [{"package": {"ecosystem": "Go", "name": "github.com/acme/web"},
+  "ranges": [{"type": "SEMVER", "events": [{"introduced": "1.3.0"}, {"fixed": "1.5.0"}]}]}]},
+{"id": "GHSA-3333", "summary": "Only in old releases",
+ "affected": [{"package": {"ecosystem": "Go", "name": "github.com/acme/web"},
+  "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"last_affected": "1.2.9"}]}]}]}
+]`
+
+func loadAdvisories(t *testing.T) []domain.Advisory {
+	var advisories []domain.Advisory
+	require.NoError(t, json.Unmarshal([]byte(advisoriesJSON), &advisories))
+	return advisories
+}
+
+func TestMatchAdvisories(t *testing.T) {
+	advisories := loadAdvisories(t)
+
+	tests := []struct {
+		version string
+		want    []string // advisory IDs, most severe first
+		fixed   []string
+	}{
+		{version: "1.2.0", want: []string{"GHSA-1111", "GHSA-3333"}, fixed: []string{"1.4.2", ""}},
+		{version: "1.4.0", want: []string{"GO-2024-0002", "GHSA-1111"}, fixed: []string{"1.5.0", "1.4.2"}},
+		{version: "1.4.2", want: []string{"GO-2024-0002"}, fixed: []string{"1.5.0"}},
+		{version: "1.5.0"},
+		{version: "2.0.5", want: []string{"GHSA-1111"}, fixed: []string{"2.1.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			vulns := matchAdvisories(advisories, "Go", "github.com/acme/web", tt.version)
+			var ids, fixed []string
+			for _, v := range vulns {
+				ids = append(ids, v.advisory.ID)
+				fixed = append(fixed, v.fixed)
+			}
+			assert.Equal(t, tt.want, ids)
+			assert.Equal(t, tt.fixed, fixed)
+		})
+	}
+}
+
+func TestCVSS3BaseScore(t *testing.T) {
+	tests := map[string]float64{
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H": 9.8,
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H": 10.0,
+		"CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:N/A:N": 6.5,
+		"CVSS:3.0/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N": 6.1,
+		"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:N/I:N/A:N": 0,
+	}
+	for vector, want := range tests {
+		score, ok := cvss3BaseScore(vector)
+		assert.True(t, ok, vector)
+		assert.Equal(t, want, score, vector)
+	}
+
+	_, ok := cvss3BaseScore("AV:N/AC:L/Au:N/C:P/I:P/A:P")
+	assert.False(t, ok, "CVSS v2 vectors are not scored")
+}
+
+func TestAIAnalysisServiceImpl_SaveVulnerabilitySuggestions(t *testing.T) {
+	mockTechRepo := new(mocks.TechnologyRepository)
+	mockSuggRepo := new(mocks.SuggestionRepository)
+	mockAdvisories := new(mocks.AdvisoryDatabase)
+	svc := NewAIAnalysisService(nil, nil, nil, mockAdvisories, nil, nil, nil, nil, mockTechRepo, nil, mockSuggRepo, nil, nil, nil)
+
+	repo := &domain.Repository{ID: 1}
+	mockTechRepo.On("GetByRepositoryID", mock.Anything, 1).Return([]domain.Technology{
+		{Name: "github.com/acme/web", Version: domain.SQLNullString("1.4.0"), Type: domain.TechnologyTypeLibrary, PackageManager: domain.SQLNullString("go")},
+		{Name: "left-pad", Version: domain.SQLNullString("1.3.0"), Type: domain.TechnologyTypeLibrary, PackageManager: domain.SQLNullString("npm")},
+		{Name: "requests", Version: domain.SQLNullString("2.31.0"), Type: domain.TechnologyTypeLibrary, PackageManager: domain.SQLNullString("pip")},
+		{Name: "flask", Type: domain.TechnologyTypeLibrary, PackageManager: domain.SQLNullString("pip")},
+		{Name: "Docker", Type: domain.TechnologyTypePlatform},
+	}, nil)
+	mockSuggRepo.On("GetByRepositoryID", mock.Anything, 1).Return([]domain.Suggestion{
+		{SuggestionType: domain.SuggestionTypeUpdateDependency, Title: "Update left-pad from 1.3.0 to 1.3.1", Status: domain.SuggestionStatusRejected},
+	}, nil)
+	leftPad := `[{"id": "GHSA-4444", "affected": [{"package": {"ecosystem": "npm", "name": "left-pad"},
+		"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.3.1"}]}]}]}]`
+	var leftPadAdvisories []domain.Advisory
+	require.NoError(t, json.Unmarshal([]byte(leftPad), &leftPadAdvisories))
+	mockAdvisories.On("Advisories", mock.Anything, "Go", "github.com/acme/web").Return(loadAdvisories(t), nil)
+	mockAdvisories.On("Advisories", mock.Anything, "npm", "left-pad").Return(leftPadAdvisories, nil)
+	mockAdvisories.On("Advisories", mock.Anything, "PyPI", "requests").Return(nil, nil)
+	mockSuggRepo.On("Create", mock.Anything, mock.MatchedBy(func(s *domain.Suggestion) bool {
+		return s.RepositoryID == 1 && s.SuggestionType == domain.SuggestionTypeUpdateDependency &&
+			s.Title == "Update github.com/acme/web from 1.4.0 to 1.5.0" &&
+			s.Priority == domain.SuggestionPriorityCritical && s.Status == domain.SuggestionStatusPending &&
+			assert.Contains(t, s.Description, "- GHSA-1111 (CVE-2024-0001): Path traversal; fixed in 1.4.2")
+	})).Return(9, nil).Once()
+
+	err := svc.saveVulnerabilitySuggestions(context.Background(), repo)
+
+	assert.NoError(t, err)
+	mockSuggRepo.AssertExpectations(t)
+	mockAdvisories.AssertNumberOfCalls(t, "Advisories", 3)
+}
@@ -0,0 +1,410 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/rs/zerolog/log"
+)
+
+type WebhookServiceImpl struct {
+	webhookRepo ports.WebhookRepository
+	repoStore   ports.RepositoryStore
+	aiService   analyzer
+	cache       ports.Cache
+	authz       ports.Authorizer
+	jobs        ports.JobRunner
+	// installations is nil unless GitHub is accessed as an App
+	installations ports.GitHubInstallations
+	now           func() time.Time
+
+	mu sync.Mutex
+	// analyzing holds the pushed heads whose analysis is queued or running
+	analyzing map[string]bool
+}
+
+// analyzedHeadTTL is how long the last analysed head of a repository is
+// remembered, to ignore redelivered pushes
+const analyzedHeadTTL = 7 * 24 * time.Hour
+
+func NewWebhookService(
+	webhookRepo ports.WebhookRepository,
+	repoStore ports.RepositoryStore,
+	aiService analyzer,
+	cache ports.Cache,
+	authz ports.Authorizer,
+	jobs ports.JobRunner,
+	installations ports.GitHubInstallations,
+) ports.WebhookService {
+	return &WebhookServiceImpl{
+		webhookRepo:   webhookRepo,
+		repoStore:     repoStore,
+		aiService:     aiService,
+		cache:         cache,
+		authz:         authz,
+		jobs:          jobs,
+		installations: installations,
+		now:           time.Now,
+		analyzing:     make(map[string]bool),
+	}
+}
+
+func (s *WebhookServiceImpl) HandleDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (bool, error) {
+	delivery.Status = domain.WebhookDeliveryReceived
+	created, err := s.webhookRepo.CreateDelivery(ctx, delivery)
+	if err != nil {
+		return false, err
+	}
+	if !created {
+		stored, err := s.webhookRepo.GetDelivery(ctx, delivery.ID)
+		if err != nil {
+			return false, err
+		}
+		// Redeliveries from GitHub reuse the ID; only a failed attempt is worth repeating
+		if stored.Status != domain.WebhookDeliveryFailed {
+			*delivery = *stored
+			log.Ctx(ctx).Info().Str("delivery_id", delivery.ID).Msg("Duplicate webhook delivery")
+			return true, nil
+		}
+	}
+	return false, s.process(ctx, delivery)
+}
+
+func (s *WebhookServiceImpl) Replay(ctx context.Context, userID int, deliveryID string) (*domain.WebhookDelivery, error) {
+	if err := s.authz.AuthorizeAdmin(ctx, userID); err != nil {
+		return nil, err
+	}
+	delivery, err := s.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	log.Ctx(ctx).Info().Str("delivery_id", deliveryID).Msg("Replaying webhook delivery")
+	return delivery, s.process(ctx, delivery)
+}
+
+// process applies the delivery and records the outcome on it
+func (s *WebhookServiceImpl) process(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	status, err := s.apply(ctx, delivery)
+	errMsg := ""
+	if err != nil {
+		status = domain.WebhookDeliveryFailed
+		errMsg = err.Error()
+	}
+	delivery.Status = status
+	delivery.Error = sql.NullString{String: errMsg, Valid: errMsg != ""}
+
+	if updateErr := s.webhookRepo.UpdateDeliveryStatus(ctx, delivery.ID, status, errMsg); updateErr != nil {
+		log.Ctx(ctx).Error().Err(updateErr).Str("delivery_id", delivery.ID).Msg("Failed to record webhook delivery status")
+	}
+	log.Ctx(ctx).Info().Str("delivery_id", delivery.ID).Str("event", delivery.Event).Str("action", delivery.Action).Str("status", string(status)).Msg("Webhook delivery processed")
+	return err
+}
+
+func (s *WebhookServiceImpl) apply(ctx context.Context, delivery *domain.WebhookDelivery) (domain.WebhookDeliveryStatus, error) {
+	var payload domain.WebhookPayload
+	if err := json.Unmarshal(delivery.Payload, &payload); err != nil {
+		return "", domain.Validation("invalid webhook payload: %v", err)
+	}
+
+	switch delivery.Event {
+	case "push":
+		return s.applyPush(ctx, &payload)
+	case "repository":
+		return s.applyRepository(ctx, &payload)
+	case "installation":
+		return s.applyInstallation(ctx, &payload)
+	default:
+		return domain.WebhookDeliveryIgnored, nil
+	}
+}
+
+// applyPush records pushes to the default branch and re-analyzes the new head
+func (s *WebhookServiceImpl) applyPush(ctx context.Context, payload *domain.WebhookPayload) (domain.WebhookDeliveryStatus, error) {
+	if payload.Repository == nil || payload.Deleted || payload.Ref != "refs/heads/"+payload.Repository.DefaultBranch {
+		return domain.WebhookDeliveryIgnored, nil
+	}
+	repos, err := s.repoStore.GetByGithubID(ctx, strconv.FormatInt(payload.Repository.ID, 10))
+	if err != nil {
+		return "", fmt.Errorf("failed to get repositories: %w", err)
+	}
+	if len(repos) == 0 {
+		return domain.WebhookDeliveryIgnored, nil
+	}
+
+	repoIDs := make([]int, len(repos))
+	for i := range repos {
+		repo := &repos[i]
+		if payload.HeadCommit != nil {
+			repo.LastCommitAt = sql.NullTime{Time: payload.HeadCommit.Timestamp, Valid: true}
+		}
+		if err := s.store(ctx, repo); err != nil {
+			return "", err
+		}
+		repoIDs[i] = repo.ID
+	}
+
+	head := payload.After
+	if head == "" && payload.HeadCommit != nil {
+		head = payload.HeadCommit.ID
+	}
+	s.enqueuePushAnalysis(ctx, payload.Repository.ID, head, repoIDs)
+	return domain.WebhookDeliveryProcessed, nil
+}
+
+// analyzedHeadCacheKey stores the last head of a GitHub repository analysed after a push
+func analyzedHeadCacheKey(githubID int64) string {
+	return "webhook:analyzed-head:" + strconv.FormatInt(githubID, 10)
+}
+
+// enqueuePushAnalysis queues at most one analysis per GitHub repository and
+// head, for all the workspace copies of the repository, and none when the
+// head was already analysed
+func (s *WebhookServiceImpl) enqueuePushAnalysis(ctx context.Context, githubID int64, head string, repoIDs []int) {
+	if head == "" {
+		s.enqueueAnalysis(ctx, repoIDs, nil)
+		return
+	}
+	logger := log.Ctx(ctx).With().Int64("github_id", githubID).Str("head", head).Logger()
+	var analyzed string
+	if found, err := s.cache.Get(ctx, analyzedHeadCacheKey(githubID), &analyzed); err != nil {
+		logger.Warn().Err(err).Msg("Failed to read the last analysed head")
+	} else if found && analyzed == head {
+		logger.Info().Msg("Head already analysed, push analysis skipped")
+		return
+	}
+
+	key := strconv.FormatInt(githubID, 10) + "@" + head
+	s.mu.Lock()
+	if s.analyzing[key] {
+		s.mu.Unlock()
+		logger.Info().Msg("Analysis of the head already queued")
+		return
+	}
+	s.analyzing[key] = true
+	s.mu.Unlock()
+
+	s.enqueueAnalysis(ctx, repoIDs, func(ctx context.Context, analyzed bool) {
+		if analyzed {
+			if err := s.cache.Set(ctx, analyzedHeadCacheKey(githubID), head, analyzedHeadTTL); err != nil {
+				logger.Warn().Err(err).Msg("Failed to remember the analysed head")
+			}
+		}
+		s.mu.Lock()
+		delete(s.analyzing, key)
+		s.mu.Unlock()
+	})
+}
+
+func (s *WebhookServiceImpl) applyRepository(ctx context.Context, payload *domain.WebhookPayload) (domain.WebhookDeliveryStatus, error) {
+	if payload.Repository == nil {
+		return domain.WebhookDeliveryIgnored, nil
+	}
+	switch payload.Action {
+	case "created":
+		return s.applyCreatedRepository(ctx, payload)
+	case "deleted", "renamed", "transferred", "archived", "unarchived", "edited", "privatized", "publicized":
+	default:
+		return domain.WebhookDeliveryIgnored, nil
+	}
+
+	gh := payload.Repository
+	repos, err := s.repoStore.GetByGithubID(ctx, strconv.FormatInt(gh.ID, 10))
+	if err != nil {
+		return "", fmt.Errorf("failed to get repositories: %w", err)
+	}
+	s.invalidateAccounts(ctx, payload)
+	if len(repos) == 0 {
+		return domain.WebhookDeliveryIgnored, nil
+	}
+
+	var reanalyze []int
+	for i := range repos {
+		repo := &repos[i]
+		if payload.Action == "deleted" {
+			if err := s.repoStore.Delete(ctx, repo.ID); err != nil {
+				return "", fmt.Errorf("failed to delete repository: %w", err)
+			}
+			s.invalidate(ctx, repo)
+			continue
+		}
+
+		branchChanged := gh.DefaultBranch != "" && gh.DefaultBranch != repo.DefaultBranch
+		repo.Name = gh.Name
+		repo.FullName = gh.FullName
+		repo.URL = gh.HTMLURL
+		repo.IsPrivate = gh.Private
+		repo.IsArchived = gh.Archived
+		repo.Description = sql.NullString{}
+		if gh.Description != nil {
+			repo.Description = sql.NullString{String: *gh.Description, Valid: true}
+		}
+		if gh.DefaultBranch != "" {
+			repo.DefaultBranch = gh.DefaultBranch
+		}
+		if err := s.store(ctx, repo); err != nil {
+			return "", err
+		}
+		if branchChanged {
+			reanalyze = append(reanalyze, repo.ID)
+		}
+	}
+	if len(reanalyze) > 0 {
+		s.enqueueAnalysis(ctx, reanalyze, nil)
+	}
+	return domain.WebhookDeliveryProcessed, nil
+}
+
+// applyCreatedRepository adds a new repository to the workspaces that track
+// its owner or installation, as their next sync would, on behalf of the
+// member who last synced it there
+func (s *WebhookServiceImpl) applyCreatedRepository(ctx context.Context, payload *domain.WebhookPayload) (domain.WebhookDeliveryStatus, error) {
+	gh := payload.Repository
+	var installationID int64
+	if payload.Installation != nil {
+		installationID = payload.Installation.ID
+	}
+	tracking, err := s.repoStore.GetLatestByAccount(ctx, gh.Owner.Login, installationID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get repositories: %w", err)
+	}
+	s.invalidateAccounts(ctx, payload)
+	if len(tracking) == 0 {
+		return domain.WebhookDeliveryIgnored, nil
+	}
+
+	for _, latest := range tracking {
+		repo := &domain.Repository{
+			UserID:         latest.UserID,
+			WorkspaceID:    latest.WorkspaceID,
+			Provider:       domain.ProviderGitHub,
+			GithubID:       strconv.FormatInt(gh.ID, 10),
+			InstallationID: latest.InstallationID,
+			Name:           gh.Name,
+			FullName:       gh.FullName,
+			URL:            gh.HTMLURL,
+			IsPrivate:      gh.Private,
+			IsArchived:     gh.Archived,
+			DefaultBranch:  gh.DefaultBranch,
+			LastSyncAt:     sql.NullTime{Time: s.now(), Valid: true},
+		}
+		if gh.Description != nil {
+			repo.Description = sql.NullString{String: *gh.Description, Valid: true}
+		}
+		if installationID != 0 {
+			repo.InstallationID = sql.NullInt64{Int64: installationID, Valid: true}
+		}
+		if err := s.store(ctx, repo); err != nil {
+			return "", err
+		}
+	}
+	return domain.WebhookDeliveryProcessed, nil
+}
+
+func (s *WebhookServiceImpl) applyInstallation(ctx context.Context, payload *domain.WebhookPayload) (domain.WebhookDeliveryStatus, error) {
+	if payload.Installation == nil {
+		return domain.WebhookDeliveryIgnored, nil
+	}
+	installation := &domain.GitHubInstallation{
+		ID:           payload.Installation.ID,
+		AccountLogin: payload.Installation.Account.Login,
+		AccountType:  payload.Installation.Account.Type,
+	}
+
+	var err error
+	switch payload.Action {
+	case "created", "unsuspend", "new_permissions_accepted":
+		err = s.webhookRepo.UpsertInstallation(ctx, installation)
+	case "suspend":
+		installation.SuspendedAt = sql.NullTime{Time: s.now().UTC(), Valid: true}
+		err = s.webhookRepo.UpsertInstallation(ctx, installation)
+	case "deleted":
+		err = s.webhookRepo.DeleteInstallation(ctx, installation.ID)
+	default:
+		return domain.WebhookDeliveryIgnored, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if s.installations != nil && (payload.Action == "suspend" || payload.Action == "deleted") {
+		s.installations.ForgetInstallation(ctx, installation.ID)
+	}
+	s.invalidateAccounts(ctx, payload)
+	return domain.WebhookDeliveryProcessed, nil
+}
+
+func (s *WebhookServiceImpl) store(ctx context.Context, repo *domain.Repository) error {
+	if _, err := s.repoStore.Upsert(ctx, repo); err != nil {
+		return fmt.Errorf("failed to update repository: %w", err)
+	}
+	s.invalidate(ctx, repo)
+	return nil
+}
+
+func (s *WebhookServiceImpl) invalidate(ctx context.Context, repo *domain.Repository) {
+	if err := s.cache.InvalidateTags(ctx, domain.WorkspaceCacheTag(repo.WorkspaceID), domain.RepositoryCacheTag(repo.ID)); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Int("repo_id", repo.ID).Msg("Failed to invalidate cache after webhook")
+	}
+}
+
+// invalidateAccounts drops cached GitHub listings of the accounts involved,
+// including the previous owner of a transferred repository
+func (s *WebhookServiceImpl) invalidateAccounts(ctx context.Context, payload *domain.WebhookPayload) {
+	var tags []string
+	if payload.Repository != nil && payload.Repository.Owner.Login != "" {
+		tags = append(tags, domain.GitHubAccountCacheTag(payload.Repository.Owner.Login))
+	}
+	if payload.Installation != nil && payload.Installation.Account.Login != "" {
+		tags = append(tags, domain.GitHubAccountCacheTag(payload.Installation.Account.Login))
+	}
+	for _, from := range []*domain.WebhookAccount{payload.Changes.Owner.From.User, payload.Changes.Owner.From.Organization} {
+		if from != nil && from.Login != "" {
+			tags = append(tags, domain.GitHubAccountCacheTag(from.Login))
+		}
+	}
+	if len(tags) == 0 {
+		return
+	}
+	if err := s.cache.InvalidateTags(ctx, tags...); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("Failed to invalidate GitHub cache after webhook")
+	}
+}
+
+// enqueueAnalysis analyses the workspace copies of a repository one after
+// the other in a single job: the first one calls the AI and fills the
+// analysis cache, which the others then reuse. done, if any, is called once
+// the job ends or could not be queued, with whether any copy was analysed.
+func (s *WebhookServiceImpl) enqueueAnalysis(ctx context.Context, repoIDs []int, done func(ctx context.Context, analyzed bool)) {
+	err := s.jobs.Go(ctx, "webhook-analysis", func(ctx context.Context) {
+		analyzed := false
+		for _, repoID := range repoIDs {
+			// Not forced: the analysis cache is keyed by the branch head, so a new head means a new analysis
+			if _, err := s.aiService.analyze(ctx, repoID, domain.AnalysisTypeArchitecture, false); err != nil {
+				log.Ctx(ctx).Error().Err(err).Int("repo_id", repoID).Msg("Webhook-triggered analysis failed")
+				continue
+			}
+			analyzed = true
+		}
+		if done != nil {
+			done(ctx, analyzed)
+		}
+	})
+	if err == nil {
+		return
+	}
+	if errors.Is(err, ErrShuttingDown) {
+		log.Ctx(ctx).Warn().Ints("repo_ids", repoIDs).Msg("Shutting down, webhook-triggered analysis skipped")
+	} else {
+		log.Ctx(ctx).Error().Err(err).Ints("repo_ids", repoIDs).Msg("Failed to enqueue webhook-triggered analysis")
+	}
+	if done != nil {
+		done(ctx, false)
+	}
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/biodoia/ghrego/internal/cache"
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookServiceImpl_HandleDelivery(t *testing.T) {
+	stored := func() []domain.Repository {
+		return []domain.Repository{{ID: 10, WorkspaceID: 7, GithubID: "42", Name: "repo", FullName: "octo/repo", DefaultBranch: "main"}}
+	}
+
+	tests := []struct {
+		name        string
+		event       string
+		payload     string
+		wantStatus  domain.WebhookDeliveryStatus
+		wantUpsert  func(t *testing.T, repo *domain.Repository)
+		wantDelete  bool
+		wantAnalyze bool
+	}{
+		{
+			name:        "push to the default branch re-analyzes",
+			event:       "push",
+			payload:     `{"ref": "refs/heads/main", "head_commit": {"id": "abc", "timestamp": "2026-03-17T12:00:00Z"}, "repository": {"id": 42, "default_branch": "main"}}`,
+			wantStatus:  domain.WebhookDeliveryProcessed,
+			wantUpsert:  func(t *testing.T, repo *domain.Repository) { assert.True(t, repo.LastCommitAt.Valid) },
+			wantAnalyze: true,
+		},
+		{
+			name:       "push to another branch is ignored",
+			event:      "push",
+			payload:    `{"ref": "refs/heads/feature", "repository": {"id": 42, "default_branch": "main"}}`,
+			wantStatus: domain.WebhookDeliveryIgnored,
+		},
+		{
+			name:       "renamed",
+			event:      "repository",
+			payload:    `{"action": "renamed", "repository": {"id": 42, "name": "renamed", "full_name": "octo/renamed", "html_url": "https://github.com/octo/renamed", "default_branch": "main", "owner": {"login": "octo"}}}`,
+			wantStatus: domain.WebhookDeliveryProcessed,
+			wantUpsert: func(t *testing.T, repo *domain.Repository) {
+				assert.Equal(t, "octo/renamed", repo.FullName)
+				assert.Equal(t, "https://github.com/octo/renamed", repo.URL)
+			},
+		},
+		{
+			name:       "archived",
+			event:      "repository",
+			payload:    `{"action": "archived", "repository": {"id": 42, "name": "repo", "full_name": "octo/repo", "archived": true, "default_branch": "main"}}`,
+			wantStatus: domain.WebhookDeliveryProcessed,
+			wantUpsert: func(t *testing.T, repo *domain.Repository) { assert.True(t, repo.IsArchived) },
+		},
+		{
+			name:        "default branch change re-analyzes",
+			event:       "repository",
+			payload:     `{"action": "edited", "repository": {"id": 42, "name": "repo", "full_name": "octo/repo", "default_branch": "trunk"}}`,
+			wantStatus:  domain.WebhookDeliveryProcessed,
+			wantUpsert:  func(t *testing.T, repo *domain.Repository) { assert.Equal(t, "trunk", repo.DefaultBranch) },
+			wantAnalyze: true,
+		},
+		{
+			name:       "deleted",
+			event:      "repository",
+			payload:    `{"action": "deleted", "repository": {"id": 42}}`,
+			wantStatus: domain.WebhookDeliveryProcessed,
+			wantDelete: true,
+		},
+		{
+			name:       "unhandled event",
+			event:      "star",
+			payload:    `{"action": "created"}`,
+			wantStatus: domain.WebhookDeliveryIgnored,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockWebhooks := new(mocks.WebhookRepository)
+			mockRepoStore := new(mocks.RepositoryStore)
+			mockAI := new(mockAnalyzer)
+			jobs := NewBackgroundJobs()
+			svc := NewWebhookService(mockWebhooks, mockRepoStore, mockAI, cache.NewMemoryCache(), nil, jobs, nil)
+
+			mockWebhooks.On("CreateDelivery", mock.Anything, mock.Anything).Return(true, nil)
+			mockWebhooks.On("UpdateDeliveryStatus", mock.Anything, "d-1", tt.wantStatus, "").Return(nil)
+			mockRepoStore.On("GetByGithubID", mock.Anything, "42").Return(stored(), nil)
+			mockRepoStore.On("Upsert", mock.Anything, mock.AnythingOfType("*domain.Repository")).Return(10, nil)
+			mockRepoStore.On("Delete", mock.Anything, 10).Return(nil)
+			mockAI.On("analyze", mock.Anything, 10, domain.AnalysisTypeArchitecture, false).Return(&domain.Analysis{}, nil)
+
+			delivery := &domain.WebhookDelivery{ID: "d-1", Event: tt.event, Payload: []byte(tt.payload)}
+			duplicate, err := svc.HandleDelivery(context.Background(), delivery)
+			require.NoError(t, err)
+			require.NoError(t, jobs.Shutdown(context.Background()))
+
+			assert.False(t, duplicate)
+			assert.Equal(t, tt.wantStatus, delivery.Status)
+			mockWebhooks.AssertExpectations(t)
+			if tt.wantUpsert != nil {
+				tt.wantUpsert(t, mockRepoStore.Calls[1].Arguments.Get(1).(*domain.Repository))
+			} else {
+				mockRepoStore.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+			}
+			if tt.wantDelete {
+				mockRepoStore.AssertCalled(t, "Delete", mock.Anything, 10)
+			}
+			if tt.wantAnalyze {
+				mockAI.AssertCalled(t, "analyze", mock.Anything, 10, domain.AnalysisTypeArchitecture, false)
+			} else {
+				mockAI.AssertNotCalled(t, "analyze", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+
+	t.Run("duplicate delivery is not processed again", func(t *testing.T) {
+		mockWebhooks := new(mocks.WebhookRepository)
+		svc := NewWebhookService(mockWebhooks, nil, nil, cache.NewMemoryCache(), nil, nil, nil)
+		mockWebhooks.On("CreateDelivery", mock.Anything, mock.Anything).Return(false, nil)
+		mockWebhooks.On("GetDelivery", mock.Anything, "d-1").Return(&domain.WebhookDelivery{ID: "d-1", Status: domain.WebhookDeliveryProcessed}, nil)
+
+		delivery := &domain.WebhookDelivery{ID: "d-1", Event: "push", Payload: []byte(`{}`)}
+		duplicate, err := svc.HandleDelivery(context.Background(), delivery)
+		require.NoError(t, err)
+		assert.True(t, duplicate)
+		assert.Equal(t, domain.WebhookDeliveryProcessed, delivery.Status)
+		mockWebhooks.AssertNotCalled(t, "UpdateDeliveryStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed delivery is processed again on redelivery", func(t *testing.T) {
+		mockWebhooks := new(mocks.WebhookRepository)
+		svc := NewWebhookService(mockWebhooks, nil, nil, cache.NewMemoryCache(), nil, nil, nil)
+		mockWebhooks.On("CreateDelivery", mock.Anything, mock.Anything).Return(false, nil)
+		mockWebhooks.On("GetDelivery", mock.Anything, "d-1").Return(&domain.WebhookDelivery{ID: "d-1", Status: domain.WebhookDeliveryFailed}, nil)
+		mockWebhooks.On("UpsertInstallation", mock.Anything, mock.MatchedBy(func(i *domain.GitHubInstallation) bool {
+			return i.ID == 5 && i.AccountLogin == "octo" && !i.SuspendedAt.Valid
+		})).Return(nil)
+		mockWebhooks.On("UpdateDeliveryStatus", mock.Anything, "d-1", domain.WebhookDeliveryProcessed, "").Return(nil)
+
+		delivery := &domain.WebhookDelivery{ID: "d-1", Event: "installation", Payload: []byte(`{"action": "created", "installation": {"id": 5, "account": {"login": "octo", "type": "Organization"}}}`)}
+		duplicate, err := svc.HandleDelivery(context.Background(), delivery)
+		require.NoError(t, err)
+		assert.False(t, duplicate)
+		mockWebhooks.AssertExpectations(t)
+	})
+
+	t.Run("created repository is added to the workspaces tracking its account", func(t *testing.T) {
+		mockWebhooks := new(mocks.WebhookRepository)
+		mockRepoStore := new(mocks.RepositoryStore)
+		memCache := cache.NewMemoryCache()
+		svc := NewWebhookService(mockWebhooks, mockRepoStore, nil, memCache, nil, nil, nil)
+		require.NoError(t, memCache.Set(context.Background(), "github:repos:octo", []string{"octo/repo"}, time.Hour, domain.GitHubAccountCacheTag("Octo")))
+
+		mockWebhooks.On("CreateDelivery", mock.Anything, mock.Anything).Return(true, nil)
+		mockWebhooks.On("UpdateDeliveryStatus", mock.Anything, "d-1", domain.WebhookDeliveryProcessed, "").Return(nil)
+		// Workspace 7 synced the owner, workspace 8 the installation
+		mockRepoStore.On("GetLatestByAccount", mock.Anything, "octo", int64(5)).Return([]domain.Repository{
+			{ID: 10, UserID: 1, WorkspaceID: 7, GithubID: "42", FullName: "octo/repo"},
+			{ID: 11, UserID: 2, WorkspaceID: 8, GithubID: "43", FullName: "octo/other"},
+		}, nil)
+		var upserted []domain.Repository
+		mockRepoStore.On("Upsert", mock.Anything, mock.AnythingOfType("*domain.Repository")).
+			Run(func(args mock.Arguments) { upserted = append(upserted, *args.Get(1).(*domain.Repository)) }).
+			Return(12, nil)
+
+		payload := `{"action": "created", "repository": {"id": 44, "name": "new", "full_name": "octo/new", "html_url": "https://github.com/octo/new", "private": true, "default_branch": "main", "owner": {"login": "octo"}}, "installation": {"id": 5}}`
+		delivery := &domain.WebhookDelivery{ID: "d-1", Event: "repository", Payload: []byte(payload)}
+		_, err := svc.HandleDelivery(context.Background(), delivery)
+		require.NoError(t, err)
+
+		assert.Equal(t, domain.WebhookDeliveryProcessed, delivery.Status)
+		require.Len(t, upserted, 2)
+		for i, workspaceID := range []int{7, 8} {
+			repo := upserted[i]
+			assert.Equal(t, workspaceID, repo.WorkspaceID)
+			assert.Equal(t, i+1, repo.UserID)
+			assert.Equal(t, "44", repo.GithubID)
+			assert.Equal(t, "octo/new", repo.FullName)
+			assert.True(t, repo.IsPrivate)
+			assert.EqualValues(t, 5, repo.InstallationID.Int64)
+		}
+		found, err := memCache.Get(context.Background(), "github:repos:octo", &[]string{})
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("deleted installation is forgotten by the GitHub client", func(t *testing.T) {
+		mockWebhooks := new(mocks.WebhookRepository)
+		mockInstallations := new(mocks.GitHubInstallations)
+		svc := NewWebhookService(mockWebhooks, nil, nil, cache.NewMemoryCache(), nil, nil, mockInstallations)
+		mockWebhooks.On("CreateDelivery", mock.Anything, mock.Anything).Return(true, nil)
+		mockWebhooks.On("DeleteInstallation", mock.Anything, int64(5)).Return(nil)
+		mockWebhooks.On("UpdateDeliveryStatus", mock.Anything, "d-1", domain.WebhookDeliveryProcessed, "").Return(nil)
+		mockInstallations.On("ForgetInstallation", mock.Anything, int64(5)).Return()
+
+		delivery := &domain.WebhookDelivery{ID: "d-1", Event: "installation", Payload: []byte(`{"action": "deleted", "installation": {"id": 5, "account": {"login": "octo"}}}`)}
+		_, err := svc.HandleDelivery(context.Background(), delivery)
+		require.NoError(t, err)
+		mockInstallations.AssertExpectations(t)
+	})
+}
+
+func TestWebhookServiceImpl_Replay(t *testing.T) {
+	mockUsers := new(mocks.UserRepository)
+	mockWebhooks := new(mocks.WebhookRepository)
+	authz := NewAuthorizer(mockUsers, nil, nil)
+	svc := NewWebhookService(mockWebhooks, nil, nil, cache.NewMemoryCache(), authz, nil, nil)
+	mockUsers.On("GetByID", mock.Anything, 1).Return(&domain.User{ID: 1, Role: domain.UserRoleUser}, nil)
+	mockUsers.On("GetByID", mock.Anything, 2).Return(&domain.User{ID: 2, Role: domain.UserRoleAdmin}, nil)
+	mockWebhooks.On("GetDelivery", mock.Anything, "d-1").Return(&domain.WebhookDelivery{ID: "d-1", Event: "ping", Payload: []byte(`{}`)}, nil)
+	mockWebhooks.On("UpdateDeliveryStatus", mock.Anything, "d-1", domain.WebhookDeliveryIgnored, "").Return(nil)
+
+	_, err := svc.Replay(context.Background(), 1, "d-1")
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+
+	delivery, err := svc.Replay(context.Background(), 2, "d-1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.WebhookDeliveryIgnored, delivery.Status)
+}
+
+func TestWebhookServiceImpl_PushAnalyzesOncePerHead(t *testing.T) {
+	mockWebhooks := new(mocks.WebhookRepository)
+	mockRepoStore := new(mocks.RepositoryStore)
+	mockAIClient := new(mocks.AIClient)
+	mockGHClient := new(mocks.GitHubClient)
+	mockAnalysisCache := new(mocks.AnalysisCacheRepository)
+	mockAnalysisRepo := new(mocks.AnalysisRepository)
+	mockUsage := new(mocks.UsageService)
+	mockFeatureRepo := new(mocks.FeatureRepository)
+	mockTechRepo := new(mocks.TechnologyRepository)
+	aiService := NewAIAnalysisService(mockAIClient, NewSourceHosts(mockGHClient), nil, nil, mockAnalysisCache, mockRepoStore, mockAnalysisRepo, mockFeatureRepo, mockTechRepo, nil, nil, cache.NewMemoryCache(), mockUsage, NewAuthorizer(nil, mockRepoStore, nil))
+	jobs := NewBackgroundJobs()
+	svc := NewWebhookService(mockWebhooks, mockRepoStore, aiService, cache.NewMemoryCache(), nil, jobs, nil)
+
+	// The same GitHub repository, synced into two workspaces
+	copies := []domain.Repository{
+		{ID: 10, WorkspaceID: 7, GithubID: "42", FullName: "octo/repo", DefaultBranch: "main"},
+		{ID: 11, WorkspaceID: 8, GithubID: "42", FullName: "octo/repo", DefaultBranch: "main"},
+	}
+	response := &domain.RepositoryAnalysisResponse{Architecture: "Monolith"}
+	fingerprint := domain.AnalysisFingerprint("abc", PromptVersion, "test-model")
+
+	mockWebhooks.On("CreateDelivery", mock.Anything, mock.Anything).Return(true, nil)
+	mockWebhooks.On("UpdateDeliveryStatus", mock.Anything, mock.Anything, domain.WebhookDeliveryProcessed, "").Return(nil)
+	mockRepoStore.On("GetByGithubID", mock.Anything, "42").Return(copies, nil)
+	mockRepoStore.On("Upsert", mock.Anything, mock.AnythingOfType("*domain.Repository")).Return(10, nil)
+	mockRepoStore.On("GetByID", mock.Anything, 10).Return(&copies[0], nil)
+	mockRepoStore.On("GetByID", mock.Anything, 11).Return(&copies[1], nil)
+	mockGHClient.On("GetBranchSHA", mock.Anything, "octo", "repo", "main").Return("abc", nil)
+	mockAIClient.On("ModelName").Return("test-model")
+	mockAIClient.On("AnalyzeRepository", mock.Anything, mock.AnythingOfType("string")).Return(response, nil)
+	mockAnalysisCache.On("Get", mock.Anything, fingerprint).Return(nil, nil).Once()
+	mockAnalysisCache.On("Get", mock.Anything, fingerprint).Return(response, nil)
+	mockAnalysisCache.On("Set", mock.Anything, fingerprint, 10, response).Return(nil)
+	mockAnalysisRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Analysis")).Return(100, nil)
+	mockFeatureRepo.On("Replace", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockTechRepo.On("ReplaceAnalyzed", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockUsage.On("CheckBudget", mock.Anything).Return(nil)
+	mockUsage.On("Record", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	push := []byte(`{"ref": "refs/heads/main", "after": "abc", "head_commit": {"id": "abc", "timestamp": "2026-03-17T12:00:00Z"}, "repository": {"id": 42, "default_branch": "main"}}`)
+	// The second push repeats the head, as a redelivery would; whether the
+	// first analysis is still running or done, it is not repeated
+	for _, id := range []string{"d-1", "d-2"} {
+		_, err := svc.HandleDelivery(context.Background(), &domain.WebhookDelivery{ID: id, Event: "push", Payload: push})
+		require.NoError(t, err)
+	}
+	require.NoError(t, jobs.Shutdown(context.Background()))
+
+	mockAIClient.AssertNumberOfCalls(t, "AnalyzeRepository", 1)
+	mockAnalysisRepo.AssertNumberOfCalls(t, "Create", 2)
+	mockAnalysisRepo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(a *domain.Analysis) bool {
+		return a.RepositoryID == 11 && a.CacheHit
+	}))
+}
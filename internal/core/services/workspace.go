@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
+	"github.com/rs/zerolog/log"
+)
+
+type WorkspaceServiceImpl struct {
+	workspaceRepo ports.WorkspaceRepository
+	userRepo      ports.UserRepository
+	authz         ports.Authorizer
+}
+
+func NewWorkspaceService(workspaceRepo ports.WorkspaceRepository, userRepo ports.UserRepository, authz ports.Authorizer) ports.WorkspaceService {
+	return &WorkspaceServiceImpl{
+		workspaceRepo: workspaceRepo,
+		userRepo:      userRepo,
+		authz:         authz,
+	}
+}
+
+func (s *WorkspaceServiceImpl) Create(ctx context.Context, userID int, name string) (*domain.Workspace, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, domain.Validation("workspace name is required")
+	}
+
+	workspace := &domain.Workspace{Name: name}
+	if err := s.workspaceRepo.Create(ctx, workspace, userID); err != nil {
+		return nil, err
+	}
+	log.Ctx(ctx).Info().Int("workspace_id", workspace.ID).Msg("Workspace created")
+	return workspace, nil
+}
+
+func (s *WorkspaceServiceImpl) List(ctx context.Context, userID int) ([]domain.WorkspaceMembership, error) {
+	// Make sure the personal workspace is listed even before the first scoped request
+	if _, err := s.personal(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.workspaceRepo.ListByUser(ctx, userID)
+}
+
+func (s *WorkspaceServiceImpl) Resolve(ctx context.Context, userID, workspaceID int) (int, error) {
+	if workspaceID == 0 {
+		ws, err := s.personal(ctx, userID)
+		if err != nil {
+			return 0, err
+		}
+		return ws.ID, nil
+	}
+	if err := s.authz.AuthorizeWorkspace(ctx, userID, workspaceID, domain.WorkspaceRoleViewer); err != nil {
+		return 0, err
+	}
+	return workspaceID, nil
+}
+
+// personal returns the user's personal workspace, creating it on first use
+func (s *WorkspaceServiceImpl) personal(ctx context.Context, userID int) (*domain.Workspace, error) {
+	ws, err := s.workspaceRepo.GetPersonal(ctx, userID)
+	if !errors.Is(err, domain.ErrNotFound) {
+		return ws, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	name := fmt.Sprintf("user %d", userID)
+	if user.GithubUsername.Valid {
+		name = user.GithubUsername.String
+	} else if user.Name.Valid {
+		name = user.Name.String
+	}
+
+	ws = &domain.Workspace{Name: name, PersonalForUserID: sql.NullInt32{Int32: int32(userID), Valid: true}}
+	if err := s.workspaceRepo.Create(ctx, ws, userID); err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+func (s *WorkspaceServiceImpl) ListMembers(ctx context.Context, userID, workspaceID int) ([]domain.WorkspaceMember, error) {
+	if err := s.authz.AuthorizeWorkspace(ctx, userID, workspaceID, domain.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+	return s.workspaceRepo.ListMembers(ctx, workspaceID)
+}
+
+func (s *WorkspaceServiceImpl) RemoveMember(ctx context.Context, userID, workspaceID, memberID int) error {
+	if err := s.authz.AuthorizeWorkspace(ctx, userID, workspaceID, domain.WorkspaceRoleOwner); err != nil {
+		return err
+	}
+
+	member, err := s.workspaceRepo.GetMember(ctx, workspaceID, memberID)
+	if err != nil {
+		return err
+	}
+	// Ownership transfer is not supported, so owners stay
+	if member.Role == domain.WorkspaceRoleOwner {
+		return domain.Conflict("workspace owners cannot be removed")
+	}
+	return s.workspaceRepo.RemoveMember(ctx, workspaceID, memberID)
+}
+
+func (s *WorkspaceServiceImpl) Invite(ctx context.Context, userID, workspaceID int, githubUsername string, role domain.WorkspaceRole) (*domain.WorkspaceInvitation, error) {
+	githubUsername = strings.ToLower(strings.TrimSpace(githubUsername))
+	if githubUsername == "" {
+		return nil, domain.Validation("githubUsername is required")
+	}
+	if !role.Valid() {
+		return nil, domain.Validation("role must be one of owner, maintainer, viewer")
+	}
+	if err := s.authz.AuthorizeWorkspace(ctx, userID, workspaceID, domain.WorkspaceRoleOwner); err != nil {
+		return nil, err
+	}
+
+	ws, err := s.workspaceRepo.GetByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if ws.IsPersonal() {
+		return nil, domain.Validation("personal workspaces cannot be shared")
+	}
+
+	invitation := &domain.WorkspaceInvitation{
+		WorkspaceID:    workspaceID,
+		GithubUsername: githubUsername,
+		Role:           role,
+		InvitedBy:      userID,
+		Status:         domain.InvitationStatusPending,
+	}
+	if err := s.workspaceRepo.CreateInvitation(ctx, invitation); err != nil {
+		return nil, err
+	}
+	log.Ctx(ctx).Info().Int("workspace_id", workspaceID).Str("github_username", githubUsername).Str("role", string(role)).Msg("Workspace invitation created")
+	return invitation, nil
+}
+
+func (s *WorkspaceServiceImpl) ListInvitations(ctx context.Context, userID int) ([]domain.WorkspaceInvitation, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.GithubUsername.Valid {
+		return []domain.WorkspaceInvitation{}, nil
+	}
+	return s.workspaceRepo.ListPendingInvitations(ctx, strings.ToLower(user.GithubUsername.String))
+}
+
+func (s *WorkspaceServiceImpl) AcceptInvitation(ctx context.Context, userID, invitationID int) error {
+	invitation, err := s.workspaceRepo.GetInvitation(ctx, invitationID)
+	if err != nil {
+		return err
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	// Invitations are addressed to a GitHub account; nobody else may redeem them
+	if !user.GithubUsername.Valid || !strings.EqualFold(user.GithubUsername.String, invitation.GithubUsername) {
+		return domain.Forbidden("invitation is addressed to another GitHub user")
+	}
+	if invitation.Status != domain.InvitationStatusPending {
+		return domain.Conflict("invitation is no longer pending")
+	}
+
+	if err := s.workspaceRepo.AcceptInvitation(ctx, invitation, userID); err != nil {
+		return err
+	}
+	log.Ctx(ctx).Info().Int("workspace_id", invitation.WorkspaceID).Msg("Workspace invitation accepted")
+	return nil
+}
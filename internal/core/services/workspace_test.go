@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceServiceImpl_Resolve(t *testing.T) {
+	t.Run("creates the personal workspace on first use", func(t *testing.T) {
+		mockWorkspaceRepo := new(mocks.WorkspaceRepository)
+		mockUserRepo := new(mocks.UserRepository)
+		svc := NewWorkspaceService(mockWorkspaceRepo, mockUserRepo, nil)
+
+		mockWorkspaceRepo.On("GetPersonal", mock.Anything, 1).Return(nil, domain.NotFound("personal workspace of user", 1))
+		mockUserRepo.On("GetByID", mock.Anything, 1).Return(&domain.User{ID: 1, GithubUsername: domain.SQLNullString("octocat")}, nil)
+		mockWorkspaceRepo.On("Create", mock.Anything, mock.MatchedBy(func(w *domain.Workspace) bool {
+			return w.Name == "octocat" && w.IsPersonal()
+		}), 1).Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.Workspace).ID = 12
+		}).Return(nil)
+
+		id, err := svc.Resolve(context.Background(), 1, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 12, id)
+	})
+
+	t.Run("explicit workspace requires membership", func(t *testing.T) {
+		mockWorkspaceRepo := new(mocks.WorkspaceRepository)
+		mockUserRepo := new(mocks.UserRepository)
+		svc := NewWorkspaceService(mockWorkspaceRepo, mockUserRepo, NewAuthorizer(mockUserRepo, nil, mockWorkspaceRepo))
+
+		mockWorkspaceRepo.On("GetMember", mock.Anything, 3, 1).Return(nil, domain.NotFound("workspace member", 1))
+		mockUserRepo.On("GetByID", mock.Anything, 1).Return(&domain.User{ID: 1, Role: domain.UserRoleUser}, nil)
+
+		_, err := svc.Resolve(context.Background(), 1, 3)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+}
+
+func TestWorkspaceServiceImpl_Invitations(t *testing.T) {
+	team := &domain.Workspace{ID: 3, Name: "team"}
+	personal := &domain.Workspace{ID: 4, Name: "octocat", PersonalForUserID: sql.NullInt32{Int32: 1, Valid: true}}
+
+	newService := func() (*WorkspaceServiceImpl, *mocks.WorkspaceRepository, *mocks.UserRepository) {
+		mockWorkspaceRepo := new(mocks.WorkspaceRepository)
+		mockUserRepo := new(mocks.UserRepository)
+		mockWorkspaceRepo.On("GetByID", mock.Anything, 3).Return(team, nil)
+		mockWorkspaceRepo.On("GetByID", mock.Anything, 4).Return(personal, nil)
+		mockWorkspaceRepo.On("GetMember", mock.Anything, mock.Anything, 1).Return(&domain.WorkspaceMember{UserID: 1, Role: domain.WorkspaceRoleOwner}, nil)
+		mockWorkspaceRepo.On("GetMember", mock.Anything, mock.Anything, 2).Return(&domain.WorkspaceMember{UserID: 2, Role: domain.WorkspaceRoleMaintainer}, nil)
+		mockUserRepo.On("GetByID", mock.Anything, 1).Return(&domain.User{ID: 1, Role: domain.UserRoleUser, GithubUsername: domain.SQLNullString("octocat")}, nil)
+		mockUserRepo.On("GetByID", mock.Anything, 2).Return(&domain.User{ID: 2, Role: domain.UserRoleUser, GithubUsername: domain.SQLNullString("Hubot")}, nil)
+		svc := NewWorkspaceService(mockWorkspaceRepo, mockUserRepo, NewAuthorizer(mockUserRepo, nil, mockWorkspaceRepo)).(*WorkspaceServiceImpl)
+		return svc, mockWorkspaceRepo, mockUserRepo
+	}
+
+	t.Run("owners invite by lowercased GitHub username", func(t *testing.T) {
+		svc, mockWorkspaceRepo, _ := newService()
+		mockWorkspaceRepo.On("CreateInvitation", mock.Anything, mock.AnythingOfType("*domain.WorkspaceInvitation")).Return(nil)
+
+		inv, err := svc.Invite(context.Background(), 1, 3, " Hubot ", domain.WorkspaceRoleViewer)
+		require.NoError(t, err)
+		assert.Equal(t, "hubot", inv.GithubUsername)
+		assert.Equal(t, domain.InvitationStatusPending, inv.Status)
+	})
+
+	t.Run("maintainers cannot invite", func(t *testing.T) {
+		svc, _, _ := newService()
+		_, err := svc.Invite(context.Background(), 2, 3, "someone", domain.WorkspaceRoleViewer)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("personal workspaces cannot be shared", func(t *testing.T) {
+		svc, _, _ := newService()
+		_, err := svc.Invite(context.Background(), 1, 4, "hubot", domain.WorkspaceRoleViewer)
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	})
+
+	t.Run("invalid role", func(t *testing.T) {
+		svc, _, _ := newService()
+		_, err := svc.Invite(context.Background(), 1, 3, "hubot", "admin")
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	})
+
+	t.Run("only the invited GitHub user may accept", func(t *testing.T) {
+		svc, mockWorkspaceRepo, _ := newService()
+		inv := &domain.WorkspaceInvitation{ID: 8, WorkspaceID: 3, GithubUsername: "hubot", Role: domain.WorkspaceRoleViewer, Status: domain.InvitationStatusPending}
+		mockWorkspaceRepo.On("GetInvitation", mock.Anything, 8).Return(inv, nil)
+		mockWorkspaceRepo.On("AcceptInvitation", mock.Anything, inv, 2).Return(nil)
+
+		assert.ErrorIs(t, svc.AcceptInvitation(context.Background(), 1, 8), domain.ErrForbidden)
+		assert.NoError(t, svc.AcceptInvitation(context.Background(), 2, 8))
+		mockWorkspaceRepo.AssertCalled(t, "AcceptInvitation", mock.Anything, inv, 2)
+	})
+
+	t.Run("owners cannot be removed", func(t *testing.T) {
+		svc, _, _ := newService()
+		assert.ErrorIs(t, svc.RemoveMember(context.Background(), 1, 3, 1), domain.ErrConflict)
+	})
+}
@@ -0,0 +1,434 @@
+// Package versions compares package versions by the rules of their ecosystem:
+// semantic versioning for Go, npm and crates.io, PEP 440 for PyPI, and a
+// dotted numeric comparison for everything else.
+package versions
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Ecosystems as OSV names them
+const (
+	EcosystemGo        = "Go"
+	EcosystemNPM       = "npm"
+	EcosystemPyPI      = "PyPI"
+	EcosystemCrates    = "crates.io"
+	EcosystemMaven     = "Maven"
+	EcosystemRubyGems  = "RubyGems"
+	EcosystemNuGet     = "NuGet"
+	EcosystemPackagist = "Packagist"
+)
+
+// Compare returns -1, 0 or 1 as a is lower than, equal to or higher than b.
+// Versions that do not parse under the ecosystem's scheme are compared as
+// dotted versions.
+func Compare(ecosystem, a, b string) int {
+	switch ecosystem {
+	case EcosystemGo, EcosystemNPM, EcosystemCrates:
+		va, okA := parseSemver(a)
+		vb, okB := parseSemver(b)
+		if okA && okB {
+			return va.compare(vb)
+		}
+	case EcosystemPyPI:
+		va, okA := parsePEP440(a)
+		vb, okB := parsePEP440(b)
+		if okA && okB {
+			return va.compare(vb)
+		}
+	}
+	return compareDotted(a, b)
+}
+
+// Valid reports whether v is a version under the ecosystem's scheme, rather
+// than a range, tag or path
+func Valid(ecosystem, v string) bool {
+	switch ecosystem {
+	case EcosystemGo, EcosystemNPM, EcosystemCrates:
+		_, ok := parseSemver(v)
+		return ok
+	case EcosystemPyPI:
+		_, ok := parsePEP440(v)
+		return ok
+	}
+	return v != "" && v[0] >= '0' && v[0] <= '9'
+}
+
+// IsPrerelease reports whether v is a pre-release, which is not offered as
+// an upgrade target
+func IsPrerelease(ecosystem, v string) bool {
+	switch ecosystem {
+	case EcosystemGo, EcosystemNPM, EcosystemCrates:
+		s, ok := parseSemver(v)
+		return ok && len(s.pre) > 0
+	case EcosystemPyPI:
+		p, ok := parsePEP440(v)
+		return ok && (p.preKind != 0 || p.dev >= 0)
+	}
+	lower := strings.ToLower(v)
+	for _, marker := range []string{"alpha", "beta", "rc", "snapshot", "-pre", ".pre", "milestone"} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+var pypiSeparators = regexp.MustCompile(`[-_.]+`)
+
+// NormalizeName returns the form of a package name under which the ecosystem
+// treats names as equal: PEP 503 for PyPI, lower case for npm, Packagist and
+// NuGet. Go module paths are case sensitive and kept as they are.
+func NormalizeName(ecosystem, name string) string {
+	switch ecosystem {
+	case EcosystemPyPI:
+		return pypiSeparators.ReplaceAllString(strings.ToLower(name), "-")
+	case EcosystemNPM, EcosystemPackagist, EcosystemNuGet:
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+// Behind reports how far from is behind to, as the difference of the first
+// release number that differs: 1.2.3 is 1 major behind 2.0.0 and 2 minor
+// behind 1.4.0. It is all zeros when from is not behind to, or only differs
+// in pre-release or later components.
+func Behind(ecosystem, from, to string) (major, minor, patch int) {
+	if Compare(ecosystem, from, to) >= 0 {
+		return 0, 0, 0
+	}
+	a, b := release(ecosystem, from), release(ecosystem, to)
+	for i := 0; i < 3; i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		if a[i] > b[i] {
+			return 0, 0, 0
+		}
+		d := b[i] - a[i]
+		switch i {
+		case 0:
+			return d, 0, 0
+		case 1:
+			return 0, d, 0
+		}
+		return 0, 0, d
+	}
+	return 0, 0, 0
+}
+
+// release returns the major, minor and patch numbers of a version
+func release(ecosystem, v string) [3]int {
+	var nums [3]int
+	switch ecosystem {
+	case EcosystemGo, EcosystemNPM, EcosystemCrates:
+		if s, ok := parseSemver(v); ok {
+			return [3]int{s.major, s.minor, s.patch}
+		}
+	case EcosystemPyPI:
+		if p, ok := parsePEP440(v); ok {
+			copy(nums[:], p.release)
+			return nums
+		}
+	}
+	for i, t := range tokens(v) {
+		n, err := strconv.Atoi(t)
+		if err != nil || i >= len(nums) {
+			break
+		}
+		nums[i] = n
+	}
+	return nums
+}
+
+func cmp(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// semver is a semantic version; build metadata is ignored
+type semver struct {
+	major, minor, patch int
+	pre                 []string
+}
+
+// parseSemver accepts an optional "v" prefix, as Go uses, and missing minor
+// or patch numbers
+func parseSemver(v string) (semver, bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, _, _ = strings.Cut(v, "+")
+	core, pre, hasPre := strings.Cut(v, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 || core == "" {
+		return semver{}, false
+	}
+	var nums [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return semver{}, false
+		}
+		nums[i] = n
+	}
+	s := semver{major: nums[0], minor: nums[1], patch: nums[2]}
+	if hasPre {
+		if pre == "" {
+			return semver{}, false
+		}
+		s.pre = strings.Split(pre, ".")
+	}
+	return s, true
+}
+
+func (s semver) compare(o semver) int {
+	if c := cmp(s.major, o.major); c != 0 {
+		return c
+	}
+	if c := cmp(s.minor, o.minor); c != 0 {
+		return c
+	}
+	if c := cmp(s.patch, o.patch); c != 0 {
+		return c
+	}
+	// A release is higher than its pre-releases
+	switch {
+	case len(s.pre) == 0 && len(o.pre) == 0:
+		return 0
+	case len(s.pre) == 0:
+		return 1
+	case len(o.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(s.pre) && i < len(o.pre); i++ {
+		a, b := s.pre[i], o.pre[i]
+		na, errA := strconv.Atoi(a)
+		nb, errB := strconv.Atoi(b)
+		switch {
+		case errA == nil && errB == nil:
+			if c := cmp(na, nb); c != 0 {
+				return c
+			}
+		case errA == nil:
+			return -1 // numeric identifiers sort before alphanumeric ones
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(a, b); c != 0 {
+				return c
+			}
+		}
+	}
+	return cmp(len(s.pre), len(o.pre))
+}
+
+// pep440 is a PEP 440 version; the local label is ignored
+type pep440 struct {
+	epoch   int
+	release []int
+	preKind int // 0 none, 1 a, 2 b, 3 rc
+	pre     int
+	post    int // -1 when absent
+	dev     int // -1 when absent
+}
+
+var pep440PreKinds = map[string]int{
+	"a": 1, "alpha": 1, "b": 2, "beta": 2, "c": 3, "rc": 3, "pre": 3, "preview": 3,
+}
+
+// parsePEP440 parses the normalised and the common alternative spellings:
+// "1.0rc1", "1.0-RC.1", "1.0.post2", "1.0-2", "2!1.0.dev3"
+func parsePEP440(v string) (pep440, bool) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "+")
+	p := pep440{post: -1, dev: -1}
+	if epoch, rest, ok := strings.Cut(v, "!"); ok {
+		n, err := strconv.Atoi(epoch)
+		if err != nil {
+			return pep440{}, false
+		}
+		p.epoch, v = n, rest
+	}
+
+	i := 0
+	for {
+		j := i
+		for j < len(v) && v[j] >= '0' && v[j] <= '9' {
+			j++
+		}
+		if j == i {
+			return pep440{}, false
+		}
+		n, _ := strconv.Atoi(v[i:j])
+		p.release = append(p.release, n)
+		i = j
+		if i+1 < len(v) && v[i] == '.' && v[i+1] >= '0' && v[i+1] <= '9' {
+			i++
+			continue
+		}
+		break
+	}
+
+	// Suffixes: optional separator, a label and an optional number
+	for i < len(v) {
+		if v[i] == '.' || v[i] == '-' || v[i] == '_' {
+			i++
+		}
+		j := i
+		for j < len(v) && v[j] >= 'a' && v[j] <= 'z' {
+			j++
+		}
+		label := v[i:j]
+		i = j
+		if i < len(v) && (v[i] == '.' || v[i] == '-' || v[i] == '_') && label != "" {
+			i++
+		}
+		j = i
+		for j < len(v) && v[j] >= '0' && v[j] <= '9' {
+			j++
+		}
+		n, hasNum := 0, j > i
+		if hasNum {
+			n, _ = strconv.Atoi(v[i:j])
+		}
+		i = j
+
+		switch {
+		case pep440PreKinds[label] != 0 && p.preKind == 0 && p.post < 0 && p.dev < 0:
+			p.preKind, p.pre = pep440PreKinds[label], n
+		case (label == "post" || label == "rev" || label == "r") && p.post < 0 && p.dev < 0:
+			p.post = n
+		case label == "" && hasNum && p.post < 0 && p.dev < 0 && p.preKind == 0:
+			// "1.0-2" is the implicit post-release 1.0.post2
+			p.post = n
+		case label == "dev" && p.dev < 0:
+			p.dev = n
+		default:
+			return pep440{}, false
+		}
+	}
+	for len(p.release) > 1 && p.release[len(p.release)-1] == 0 {
+		p.release = p.release[:len(p.release)-1]
+	}
+	return p, true
+}
+
+func (p pep440) compare(o pep440) int {
+	if c := cmp(p.epoch, o.epoch); c != 0 {
+		return c
+	}
+	for i := 0; i < len(p.release) || i < len(o.release); i++ {
+		var a, b int
+		if i < len(p.release) {
+			a = p.release[i]
+		}
+		if i < len(o.release) {
+			b = o.release[i]
+		}
+		if c := cmp(a, b); c != 0 {
+			return c
+		}
+	}
+	if c := cmp(p.preRank(), o.preRank()); c != 0 {
+		return c
+	}
+	if c := cmp(p.pre, o.pre); c != 0 && p.preKind != 0 {
+		return c
+	}
+	if c := cmp(p.post, o.post); c != 0 {
+		return c
+	}
+	// A version without .dev is higher than its dev releases
+	devA, devB := p.dev, o.dev
+	if devA < 0 {
+		devA = int(^uint(0) >> 1)
+	}
+	if devB < 0 {
+		devB = int(^uint(0) >> 1)
+	}
+	return cmp(devA, devB)
+}
+
+// preRank orders the pre-release phase: 1.0.dev1 < 1.0a1 < 1.0b1 < 1.0rc1 < 1.0
+func (p pep440) preRank() int {
+	switch {
+	case p.preKind != 0:
+		return p.preKind
+	case p.post < 0 && p.dev >= 0:
+		return 0
+	}
+	return 4
+}
+
+// compareDotted compares the numeric and alphabetic runs of two versions in
+// turn, numbers numerically: 1.10 > 1.9, 2.0.0-beta < 2.0.0.1
+func compareDotted(a, b string) int {
+	ta, tb := tokens(a), tokens(b)
+	for i := 0; i < len(ta) || i < len(tb); i++ {
+		switch {
+		case i >= len(ta):
+			return -trailing(tb[i:])
+		case i >= len(tb):
+			return trailing(ta[i:])
+		}
+		na, errA := strconv.Atoi(ta[i])
+		nb, errB := strconv.Atoi(tb[i])
+		var c int
+		switch {
+		case errA == nil && errB == nil:
+			c = cmp(na, nb)
+		case errA == nil:
+			c = 1
+		case errB == nil:
+			c = -1
+		default:
+			c = strings.Compare(strings.ToLower(ta[i]), strings.ToLower(tb[i]))
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// trailing is how the extra tokens of a longer version compare it to the
+// shorter one: a number makes it higher, a qualifier such as "beta" lower
+func trailing(extra []string) int {
+	for _, t := range extra {
+		if n, err := strconv.Atoi(t); err != nil {
+			return -1
+		} else if n != 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+func tokens(v string) []string {
+	var out []string
+	start := -1
+	digit := false
+	for i := 0; i <= len(v); i++ {
+		var c byte
+		if i < len(v) {
+			c = v[i]
+		}
+		isDigit := c >= '0' && c <= '9'
+		isAlpha := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+		if start >= 0 && (i == len(v) || !(isDigit || isAlpha) || isDigit != digit) {
+			out = append(out, v[start:i])
+			start = -1
+		}
+		if start < 0 && (isDigit || isAlpha) {
+			start, digit = i, isDigit
+		}
+	}
+	return out
+}
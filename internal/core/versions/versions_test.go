@@ -0,0 +1,76 @@
+package versions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		ecosystem, a, b string
+		want            int
+	}{
+		{EcosystemGo, "v1.2.3", "1.2.3", 0},
+		{EcosystemGo, "v1.10.0", "v1.9.9", 1},
+		{EcosystemGo, "v0.0.0-20240101120000-abcdef123456", "v0.0.1", -1},
+		{EcosystemNPM, "1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{EcosystemNPM, "1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{EcosystemNPM, "1.0.0-rc.1", "1.0.0", -1},
+		{EcosystemNPM, "1.0.0+build.5", "1.0.0", 0},
+		{EcosystemCrates, "0.10", "0.9.5", 1},
+		{EcosystemPyPI, "1.0", "1.0.0", 0},
+		{EcosystemPyPI, "1.0.dev1", "1.0a1", -1},
+		{EcosystemPyPI, "1.0a1", "1.0b2", -1},
+		{EcosystemPyPI, "1.0rc1", "1.0", -1},
+		{EcosystemPyPI, "1.0-RC.2", "1.0rc1", 1},
+		{EcosystemPyPI, "1.0", "1.0.post1", -1},
+		{EcosystemPyPI, "1.0-2", "1.0.post2", 0},
+		{EcosystemPyPI, "1.0.post1.dev1", "1.0.post1", -1},
+		{EcosystemPyPI, "1!0.5", "2.0", 1},
+		{EcosystemPyPI, "4.2.11", "4.2.8", 1},
+		{EcosystemMaven, "2.17.1", "2.9", 1},
+		{EcosystemMaven, "3.0.0-beta", "3.0.0", -1},
+		{EcosystemMaven, "1.0", "1.0.0", 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Compare(tt.ecosystem, tt.a, tt.b), "%s %s vs %s", tt.ecosystem, tt.a, tt.b)
+		assert.Equal(t, -tt.want, Compare(tt.ecosystem, tt.b, tt.a), "%s %s vs %s", tt.ecosystem, tt.b, tt.a)
+	}
+}
+
+func TestValid(t *testing.T) {
+	assert.True(t, Valid(EcosystemNPM, "18.3.1"))
+	assert.False(t, Valid(EcosystemNPM, "latest"))
+	assert.False(t, Valid(EcosystemNPM, "^18.3.1"))
+	assert.True(t, Valid(EcosystemPyPI, "2.0.post1"))
+	assert.False(t, Valid(EcosystemPyPI, ">=2.0"))
+	assert.True(t, IsPrerelease(EcosystemPyPI, "2.0rc1"))
+	assert.True(t, IsPrerelease(EcosystemGo, "v1.0.0-beta.2"))
+	assert.False(t, IsPrerelease(EcosystemMaven, "2.17.1"))
+}
+
+func TestBehind(t *testing.T) {
+	tests := []struct {
+		ecosystem, from, to string
+		major, minor, patch int
+	}{
+		{EcosystemNPM, "17.0.2", "18.3.1", 1, 0, 0},
+		{EcosystemGo, "v1.2.3", "v1.4.0", 0, 2, 0},
+		{EcosystemGo, "1.2.3", "1.2.9", 0, 0, 6},
+		{EcosystemNPM, "1.0.0-rc.1", "1.0.0", 0, 0, 0},
+		{EcosystemNPM, "2.0.0", "1.9.0", 0, 0, 0},
+		{EcosystemPyPI, "2.28", "2.31.0", 0, 3, 0},
+		{EcosystemMaven, "2.9", "2.17.1", 0, 8, 0},
+	}
+	for _, tt := range tests {
+		major, minor, patch := Behind(tt.ecosystem, tt.from, tt.to)
+		assert.Equal(t, [3]int{tt.major, tt.minor, tt.patch}, [3]int{major, minor, patch}, "%s %s to %s", tt.ecosystem, tt.from, tt.to)
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	assert.Equal(t, "zope-interface", NormalizeName(EcosystemPyPI, "Zope.Interface"))
+	assert.Equal(t, "@acme/ui", NormalizeName(EcosystemNPM, "@Acme/UI"))
+	assert.Equal(t, "github.com/BurntSushi/toml", NormalizeName(EcosystemGo, "github.com/BurntSushi/toml"))
+}
@@ -0,0 +1,42 @@
+// Package logging configures the global zerolog logger.
+//
+// Request and job scoped loggers travel in the context: use log.Ctx(ctx) in code
+// that has a context so its output is correlated with the request or job that
+// caused it. Without one, log.Ctx falls back to the global logger.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/biodoia/ghrego/internal/telemetry"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Setup installs the global logger: JSON by default, human-readable console
+// output with LOG_FORMAT=console, at the level given by LOG_LEVEL
+func Setup(level, format string) error {
+	return setup(os.Stderr, level, format)
+}
+
+func setup(out io.Writer, level, format string) error {
+	lvl, err := zerolog.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	if lvl == zerolog.NoLevel {
+		lvl = zerolog.InfoLevel
+	}
+	zerolog.SetGlobalLevel(lvl)
+
+	if format == "console" {
+		out = zerolog.ConsoleWriter{Out: out}
+	}
+	log.Logger = zerolog.New(out).With().Timestamp().Logger().Hook(telemetry.LogHook{})
+
+	// log.Ctx on a context without a logger returns the global one instead of discarding
+	zerolog.DefaultContextLogger = &log.Logger
+	return nil
+}
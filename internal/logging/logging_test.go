@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetup(t *testing.T) {
+	defer zerolog.SetGlobalLevel(zerolog.TraceLevel)
+
+	t.Run("json at configured level", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, setup(&buf, "warn", "json"))
+
+		log.Info().Msg("dropped")
+		log.Warn().Msg("kept")
+
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "kept", entry["message"])
+		assert.Equal(t, "warn", entry["level"])
+	})
+
+	t.Run("context without logger falls back to global", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, setup(&buf, "info", "json"))
+
+		log.Ctx(context.Background()).Info().Msg("fallback")
+		assert.Contains(t, buf.String(), "fallback")
+	})
+
+	t.Run("invalid level", func(t *testing.T) {
+		assert.Error(t, setup(&bytes.Buffer{}, "loud", "json"))
+	})
+}
@@ -0,0 +1,116 @@
+// Package metrics holds the Prometheus collectors shared by adapters and services,
+// so instrumented packages only depend on this package and not on each other.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "ghrego"
+
+// Registry is the registry served on /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequestDuration is labelled with the chi route pattern, never the raw path, to bound cardinality
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route pattern, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	GitHubRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "github_requests_total",
+		Help:      "GitHub API calls by operation and outcome.",
+	}, []string{"operation", "outcome"})
+
+	GitHubRateLimitRemaining = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "github_rate_limit_remaining",
+		Help:      "Remaining GitHub API requests in the current rate limit window.",
+	})
+
+	SourceHostRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "source_host_requests_total",
+		Help:      "GitLab and Gitea API calls by provider, operation and outcome.",
+	}, []string{"provider", "operation", "outcome"})
+
+	AIRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ai_request_duration_seconds",
+		Help:      "AI provider call latency by model and outcome.",
+		Buckets:   []float64{0.5, 1, 2.5, 5, 10, 20, 40, 60, 120},
+	}, []string{"model", "outcome"})
+
+	AITokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ai_tokens_total",
+		Help:      "Tokens consumed by the AI provider by model and kind (prompt, output, cached).",
+	}, []string{"model", "kind"})
+
+	AIParseFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ai_parse_failures_total",
+		Help:      "AI responses that could not be parsed as an analysis.",
+	}, []string{"model"})
+
+	AnalysisQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "analysis_queue_depth",
+		Help:      "Bulk analysis items waiting for a worker.",
+	})
+
+	BackgroundJobsRunning = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "background_jobs_running",
+		Help:      "Background jobs currently running.",
+	})
+
+	IngestCheckouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ingest_checkouts_total",
+		Help:      "Repository checkouts by outcome (reused, cloned, error).",
+	}, []string{"outcome"})
+
+	IngestWorkspaces = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ingest_workspaces",
+		Help:      "Checked out workspaces kept on disk.",
+	})
+)
+
+// Outcome label values
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		GitHubRequests,
+		GitHubRateLimitRemaining,
+		SourceHostRequests,
+		AIRequestDuration,
+		AITokens,
+		AIParseFailures,
+		AnalysisQueueDepth,
+		BackgroundJobsRunning,
+		IngestCheckouts,
+		IngestWorkspaces,
+	)
+}
+
+// Outcome maps an error to the outcome label
+func Outcome(err error) string {
+	if err != nil {
+		return OutcomeError
+	}
+	return OutcomeSuccess
+}
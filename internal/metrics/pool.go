@@ -0,0 +1,56 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// PoolStats is a snapshot of a database connection pool
+type PoolStats struct {
+	TotalConns      int32
+	IdleConns       int32
+	AcquiredConns   int32
+	MaxConns        int32
+	AcquireCount    int64
+	EmptyAcquires   int64
+	AcquireDuration float64 // cumulative seconds
+}
+
+// poolCollector reads pool statistics on every scrape
+type poolCollector struct {
+	stats func() PoolStats
+
+	total, idle, acquired, max           *prometheus.Desc
+	acquireCount, emptyAcquires, waiting *prometheus.Desc
+}
+
+// RegisterPoolStats exposes the pool returned by stats under ghrego_db_pool_*
+func RegisterPoolStats(stats func() PoolStats) error {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return Registry.Register(&poolCollector{
+		stats:         stats,
+		total:         desc("total_connections", "Connections currently in the pool."),
+		idle:          desc("idle_connections", "Idle connections in the pool."),
+		acquired:      desc("acquired_connections", "Connections currently in use."),
+		max:           desc("max_connections", "Maximum size of the pool."),
+		acquireCount:  desc("acquires_total", "Successful connection acquisitions."),
+		emptyAcquires: desc("empty_acquires_total", "Acquisitions that had to wait for a connection."),
+		waiting:       desc("acquire_duration_seconds_total", "Cumulative time spent acquiring connections."),
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.total, c.idle, c.acquired, c.max, c.acquireCount, c.emptyAcquires, c.waiting} {
+		ch <- d
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(s.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(s.AcquiredConns))
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(s.MaxConns))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(s.AcquireCount))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquires, prometheus.CounterValue, float64(s.EmptyAcquires))
+	ch <- prometheus.MustNewConstMetric(c.waiting, prometheus.CounterValue, s.AcquireDuration)
+}
@@ -2,8 +2,11 @@ package mocks
 
 import (
 	"context"
+	"testing/fstest"
 
+	"github.com/google/uuid"
 	"github.com/biodoia/ghrego/internal/core/domain"
+	"github.com/biodoia/ghrego/internal/core/ports"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -38,8 +41,8 @@ type RepositoryStore struct {
 	mock.Mock
 }
 
-func (m *RepositoryStore) GetByUserID(ctx context.Context, userID int) ([]domain.Repository, error) {
-	args := m.Called(ctx, userID)
+func (m *RepositoryStore) GetByWorkspaceID(ctx context.Context, workspaceID int) ([]domain.Repository, error) {
+	args := m.Called(ctx, workspaceID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -62,6 +65,22 @@ func (m *RepositoryStore) GetByIDs(ctx context.Context, ids []int) ([]domain.Rep
 	return args.Get(0).([]domain.Repository), args.Error(1)
 }
 
+func (m *RepositoryStore) GetByGithubID(ctx context.Context, githubID string) ([]domain.Repository, error) {
+	args := m.Called(ctx, githubID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Repository), args.Error(1)
+}
+
+func (m *RepositoryStore) GetLatestByAccount(ctx context.Context, owner string, installationID int64) ([]domain.Repository, error) {
+	args := m.Called(ctx, owner, installationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Repository), args.Error(1)
+}
+
 func (m *RepositoryStore) Upsert(ctx context.Context, repo *domain.Repository) (int, error) {
 	args := m.Called(ctx, repo)
 	return args.Int(0), args.Error(1)
@@ -72,8 +91,8 @@ func (m *RepositoryStore) Delete(ctx context.Context, id int) error {
 	return args.Error(0)
 }
 
-func (m *RepositoryStore) GetStats(ctx context.Context, userID int) (map[string]interface{}, error) {
-	args := m.Called(ctx, userID)
+func (m *RepositoryStore) GetStats(ctx context.Context, workspaceID int) (map[string]interface{}, error) {
+	args := m.Called(ctx, workspaceID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -85,11 +104,27 @@ type GitHubService struct {
 	mock.Mock
 }
 
-func (m *GitHubService) SyncUserRepositories(ctx context.Context, userID int, openID string) error {
-	args := m.Called(ctx, userID, openID)
+func (m *GitHubService) SyncUserRepositories(ctx context.Context, userID, workspaceID int, provider domain.SourceProvider, account string) error {
+	args := m.Called(ctx, userID, workspaceID, provider, account)
 	return args.Error(0)
 }
 
+func (m *GitHubService) RegisterLocalRepository(ctx context.Context, userID, workspaceID int, path string) (*domain.Repository, error) {
+	args := m.Called(ctx, userID, workspaceID, path)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Repository), args.Error(1)
+}
+
+func (m *GitHubService) ListRepositories(ctx context.Context, userID, workspaceID int) ([]domain.Repository, error) {
+	args := m.Called(ctx, userID, workspaceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Repository), args.Error(1)
+}
+
 func (m *GitHubService) GetRepositoryDetails(ctx context.Context, userID int, repoID int) (*domain.Repository, error) {
 	args := m.Called(ctx, userID, repoID)
 	if args.Get(0) == nil {
@@ -98,25 +133,43 @@ func (m *GitHubService) GetRepositoryDetails(ctx context.Context, userID int, re
 	return args.Get(0).(*domain.Repository), args.Error(1)
 }
 
+func (m *GitHubService) DeleteRepository(ctx context.Context, userID int, repoID int) error {
+	args := m.Called(ctx, userID, repoID)
+	return args.Error(0)
+}
+
+func (m *GitHubService) GetRepositoryStats(ctx context.Context, userID, workspaceID int) (map[string]interface{}, error) {
+	args := m.Called(ctx, userID, workspaceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]interface{}), args.Error(1)
+}
+
 func (m *GitHubService) AnalyzeDependencies(ctx context.Context, repoID int) error {
 	args := m.Called(ctx, repoID)
 	return args.Error(0)
 }
 
-// MockGitHubClient
-type GitHubClient struct {
+// MockSourceHost
+type SourceHost struct {
 	mock.Mock
+	Name domain.SourceProvider // returned by Provider
 }
 
-func (m *GitHubClient) GetUserRepositories(ctx context.Context, username string) ([]*domain.Repository, error) {
-	args := m.Called(ctx, username)
+func (m *SourceHost) Provider() domain.SourceProvider {
+	return m.Name
+}
+
+func (m *SourceHost) ListRepositories(ctx context.Context, account string) ([]*domain.Repository, error) {
+	args := m.Called(ctx, account)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*domain.Repository), args.Error(1)
 }
 
-func (m *GitHubClient) GetRepository(ctx context.Context, owner, repoName string) (*domain.Repository, error) {
+func (m *SourceHost) GetRepository(ctx context.Context, owner, repoName string) (*domain.Repository, error) {
 	args := m.Called(ctx, owner, repoName)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -124,12 +177,12 @@ func (m *GitHubClient) GetRepository(ctx context.Context, owner, repoName string
 	return args.Get(0).(*domain.Repository), args.Error(1)
 }
 
-func (m *GitHubClient) GetFileContent(ctx context.Context, owner, repo, path string) (string, error) {
+func (m *SourceHost) GetFileContent(ctx context.Context, owner, repo, path string) (string, error) {
 	args := m.Called(ctx, owner, repo, path)
 	return args.String(0), args.Error(1)
 }
 
-func (m *GitHubClient) GetLanguages(ctx context.Context, owner, repo string) (map[string]int, error) {
+func (m *SourceHost) GetLanguages(ctx context.Context, owner, repo string) (map[string]int, error) {
 	args := m.Called(ctx, owner, repo)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -137,9 +190,108 @@ func (m *GitHubClient) GetLanguages(ctx context.Context, owner, repo string) (ma
 	return args.Get(0).(map[string]int), args.Error(1)
 }
 
-func (m *GitHubClient) AnalyzeStructure(ctx context.Context, owner, repo string) (int, []string, map[string]int, error) {
+func (m *SourceHost) GetBranchSHA(ctx context.Context, owner, repo, branch string) (string, error) {
+	args := m.Called(ctx, owner, repo, branch)
+	return args.String(0), args.Error(1)
+}
+
+func (m *SourceHost) GetTree(ctx context.Context, owner, repo, ref string) ([]domain.TreeEntry, error) {
+	args := m.Called(ctx, owner, repo, ref)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.TreeEntry), args.Error(1)
+}
+
+func (m *SourceHost) CloneSource(ctx context.Context, owner, repo string) (domain.CloneSource, error) {
+	args := m.Called(ctx, owner, repo)
+	return args.Get(0).(domain.CloneSource), args.Error(1)
+}
+
+// MockGitHubClient
+type GitHubClient struct {
+	SourceHost
+}
+
+func (m *GitHubClient) Provider() domain.SourceProvider {
+	return domain.ProviderGitHub
+}
+
+func (m *GitHubClient) GetUserRepositories(ctx context.Context, username string) ([]*domain.Repository, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Repository), args.Error(1)
+}
+
+func (m *GitHubClient) AnalyzeStructure(ctx context.Context, owner, repo string) (*domain.RepositoryStructure, error) {
 	args := m.Called(ctx, owner, repo)
-	return args.Int(0), args.Get(1).([]string), args.Get(2).(map[string]int), args.Error(3)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RepositoryStructure), args.Error(1)
+}
+
+func (m *GitHubClient) IsOrgMember(ctx context.Context, org, username string) (bool, error) {
+	args := m.Called(ctx, org, username)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockCodeIngester
+type CodeIngester struct {
+	mock.Mock
+}
+
+func (m *CodeIngester) Checkout(ctx context.Context, host ports.SourceHost, repo *domain.Repository, sha string) (ports.CodeSnapshot, error) {
+	args := m.Called(ctx, host, repo, sha)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(ports.CodeSnapshot), args.Error(1)
+}
+
+// CodeSnapshot is an in-memory checkout
+type CodeSnapshot struct {
+	fstest.MapFS
+	Commit   string
+	Released bool
+}
+
+func (s *CodeSnapshot) SHA() string {
+	return s.Commit
+}
+
+func (s *CodeSnapshot) Release() {
+	s.Released = true
+}
+
+// MockAdvisoryDatabase
+type AdvisoryDatabase struct {
+	mock.Mock
+}
+
+func (m *AdvisoryDatabase) Advisories(ctx context.Context, ecosystem, name string) ([]domain.Advisory, error) {
+	args := m.Called(ctx, ecosystem, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Advisory), args.Error(1)
+}
+
+// MockPackageRegistry
+type PackageRegistry struct {
+	mock.Mock
+}
+
+func (m *PackageRegistry) LatestVersion(ctx context.Context, ecosystem, name string) (string, error) {
+	args := m.Called(ctx, ecosystem, name)
+	return args.String(0), args.Error(1)
+}
+
+func (m *PackageRegistry) License(ctx context.Context, ecosystem, name string) (string, error) {
+	args := m.Called(ctx, ecosystem, name)
+	return args.String(0), args.Error(1)
 }
 
 // MockAIClient
@@ -155,12 +307,17 @@ func (m *AIClient) AnalyzeRepository(ctx context.Context, prompt string) (*domai
 	return args.Get(0).(*domain.RepositoryAnalysisResponse), args.Error(1)
 }
 
+func (m *AIClient) ModelName() string {
+	args := m.Called()
+	return args.String(0)
+}
+
 type AIAnalysisService struct {
 	mock.Mock
 }
 
-func (m *AIAnalysisService) AnalyzeRepository(ctx context.Context, repoID int, analysisType domain.AnalysisType) (*domain.Analysis, error) {
-	args := m.Called(ctx, repoID, analysisType)
+func (m *AIAnalysisService) StartAnalysis(ctx context.Context, userID, repoID int, analysisType domain.AnalysisType, force bool) (*domain.Analysis, error) {
+	args := m.Called(ctx, userID, repoID, analysisType, force)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -174,3 +331,98 @@ func (m *AIAnalysisService) GenerateSuggestions(ctx context.Context, repoID int)
 	}
 	return args.Get(0).([]domain.Suggestion), args.Error(1)
 }
+
+func (m *AIAnalysisService) GetAnalysisReport(ctx context.Context, userID int, repoID int) (*domain.AnalysisReport, error) {
+	args := m.Called(ctx, userID, repoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AnalysisReport), args.Error(1)
+}
+
+func (m *AIAnalysisService) AuthorizeAnalysis(ctx context.Context, userID int, repoID int) error {
+	args := m.Called(ctx, userID, repoID)
+	return args.Error(0)
+}
+
+func (m *AIAnalysisService) ListPendingSuggestions(ctx context.Context, userID, workspaceID int) ([]domain.Suggestion, error) {
+	args := m.Called(ctx, userID, workspaceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Suggestion), args.Error(1)
+}
+
+func (m *AIAnalysisService) UpdateSuggestionStatus(ctx context.Context, userID int, suggestionID int, status domain.SuggestionStatus) error {
+	args := m.Called(ctx, userID, suggestionID, status)
+	return args.Error(0)
+}
+
+type BulkAnalysisService struct {
+	mock.Mock
+}
+
+func (m *BulkAnalysisService) StartBatch(ctx context.Context, userID, workspaceID int, req domain.BulkAnalysisRequest) (*domain.AnalysisBatch, error) {
+	args := m.Called(ctx, userID, workspaceID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AnalysisBatch), args.Error(1)
+}
+
+func (m *BulkAnalysisService) GetProgress(ctx context.Context, userID int, batchID uuid.UUID) (*domain.BatchProgress, error) {
+	args := m.Called(ctx, userID, batchID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BatchProgress), args.Error(1)
+}
+
+func (m *BulkAnalysisService) ResumeInterrupted(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+type DependencyService struct {
+	mock.Mock
+}
+
+func (m *DependencyService) GetDriftReport(ctx context.Context, userID, workspaceID int) (*domain.DependencyReport, error) {
+	args := m.Called(ctx, userID, workspaceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.DependencyReport), args.Error(1)
+}
+
+func (m *DependencyService) SuggestConsolidation(ctx context.Context, userID, workspaceID int) ([]domain.Suggestion, error) {
+	args := m.Called(ctx, userID, workspaceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Suggestion), args.Error(1)
+}
+
+func (m *DependencyService) GetLicenseReport(ctx context.Context, userID, repoID int) (*domain.LicenseReport, error) {
+	args := m.Called(ctx, userID, repoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LicenseReport), args.Error(1)
+}
+
+func (m *DependencyService) CheckUnification(ctx context.Context, userID int, repoIDs []int) (*domain.UnificationLicenseCheck, error) {
+	args := m.Called(ctx, userID, repoIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UnificationLicenseCheck), args.Error(1)
+}
+
+func (m *DependencyService) GetSBOM(ctx context.Context, userID, repoID int, format domain.SBOMFormat) (*domain.SBOM, error) {
+	args := m.Called(ctx, userID, repoID, format)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SBOM), args.Error(1)
+}
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/biodoia/ghrego/internal/core/domain"
@@ -39,6 +40,24 @@ func (m *AnalysisRepository) Update(ctx context.Context, id int, updates map[str
 	return args.Error(0)
 }
 
+// MockAnalysisCacheRepository
+type AnalysisCacheRepository struct {
+	mock.Mock
+}
+
+func (m *AnalysisCacheRepository) Get(ctx context.Context, fingerprint string) (*domain.RepositoryAnalysisResponse, error) {
+	args := m.Called(ctx, fingerprint)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RepositoryAnalysisResponse), args.Error(1)
+}
+
+func (m *AnalysisCacheRepository) Set(ctx context.Context, fingerprint string, repoID int, response *domain.RepositoryAnalysisResponse) error {
+	args := m.Called(ctx, fingerprint, repoID, response)
+	return args.Error(0)
+}
+
 // MockFeatureRepository
 type FeatureRepository struct {
 	mock.Mock
@@ -62,6 +81,11 @@ func (m *FeatureRepository) BulkCreate(ctx context.Context, features []domain.Fe
 	return args.Error(0)
 }
 
+func (m *FeatureRepository) Replace(ctx context.Context, repoID int, features []domain.Feature) error {
+	args := m.Called(ctx, repoID, features)
+	return args.Error(0)
+}
+
 // MockTechnologyRepository
 type TechnologyRepository struct {
 	mock.Mock
@@ -75,11 +99,47 @@ func (m *TechnologyRepository) GetByRepositoryID(ctx context.Context, repoID int
 	return args.Get(0).([]domain.Technology), args.Error(1)
 }
 
+func (m *TechnologyRepository) GetByWorkspaceID(ctx context.Context, workspaceID int) ([]domain.Technology, error) {
+	args := m.Called(ctx, workspaceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Technology), args.Error(1)
+}
+
 func (m *TechnologyRepository) BulkCreate(ctx context.Context, techs []domain.Technology) error {
 	args := m.Called(ctx, techs)
 	return args.Error(0)
 }
 
+func (m *TechnologyRepository) ReplaceDetected(ctx context.Context, repoID int, techs []domain.Technology) error {
+	args := m.Called(ctx, repoID, techs)
+	return args.Error(0)
+}
+
+func (m *TechnologyRepository) ReplaceAnalyzed(ctx context.Context, repoID int, techs []domain.Technology) error {
+	args := m.Called(ctx, repoID, techs)
+	return args.Error(0)
+}
+
+// MockLicenseRepository
+type LicenseRepository struct {
+	mock.Mock
+}
+
+func (m *LicenseRepository) GetByRepositoryIDs(ctx context.Context, repoIDs []int) ([]domain.RepositoryLicense, error) {
+	args := m.Called(ctx, repoIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.RepositoryLicense), args.Error(1)
+}
+
+func (m *LicenseRepository) Replace(ctx context.Context, repoID int, licenses []domain.RepositoryLicense) error {
+	args := m.Called(ctx, repoID, licenses)
+	return args.Error(0)
+}
+
 // MockSuggestionRepository
 type SuggestionRepository struct {
 	mock.Mock
@@ -93,8 +153,8 @@ func (m *SuggestionRepository) GetByRepositoryID(ctx context.Context, repoID int
 	return args.Get(0).([]domain.Suggestion), args.Error(1)
 }
 
-func (m *SuggestionRepository) GetAllPending(ctx context.Context, userID int) ([]domain.Suggestion, error) {
-	args := m.Called(ctx, userID)
+func (m *SuggestionRepository) GetAllPending(ctx context.Context, workspaceID int) ([]domain.Suggestion, error) {
+	args := m.Called(ctx, workspaceID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -167,3 +227,362 @@ func (m *UnificationRepository) GetByUserID(ctx context.Context, userID int) ([]
 	}
 	return args.Get(0).([]domain.UnificationOperation), args.Error(1)
 }
+
+// MockUsageService
+type UsageService struct {
+	mock.Mock
+}
+
+func (m *UsageService) CheckBudget(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *UsageService) Record(ctx context.Context, analysis *domain.Analysis, userID int, usage domain.TokenUsage) error {
+	args := m.Called(ctx, analysis, userID, usage)
+	return args.Error(0)
+}
+
+func (m *UsageService) GetUserUsage(ctx context.Context, userID int, since time.Time) (*domain.UsageReport, error) {
+	args := m.Called(ctx, userID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UsageReport), args.Error(1)
+}
+
+// MockUsageRepository
+type UsageRepository struct {
+	mock.Mock
+}
+
+func (m *UsageRepository) Create(ctx context.Context, usage *domain.AIUsage) (int, error) {
+	args := m.Called(ctx, usage)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *UsageRepository) SumCostSince(ctx context.Context, since time.Time) (float64, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *UsageRepository) GetDailyByUser(ctx context.Context, userID int, since time.Time) ([]domain.DailyUsage, error) {
+	args := m.Called(ctx, userID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.DailyUsage), args.Error(1)
+}
+
+func (m *UsageRepository) GetByRepositoryForUser(ctx context.Context, userID int, since time.Time) ([]domain.RepositoryUsage, error) {
+	args := m.Called(ctx, userID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.RepositoryUsage), args.Error(1)
+}
+
+// MockBatchRepository
+type BatchRepository struct {
+	mock.Mock
+}
+
+func (m *BatchRepository) Create(ctx context.Context, batch *domain.AnalysisBatch, repoIDs []int) error {
+	args := m.Called(ctx, batch, repoIDs)
+	return args.Error(0)
+}
+
+func (m *BatchRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.AnalysisBatch, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AnalysisBatch), args.Error(1)
+}
+
+func (m *BatchRepository) GetItems(ctx context.Context, batchID uuid.UUID) ([]domain.AnalysisBatchItem, error) {
+	args := m.Called(ctx, batchID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.AnalysisBatchItem), args.Error(1)
+}
+
+func (m *BatchRepository) GetUnfinishedItems(ctx context.Context) ([]domain.AnalysisBatchItem, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.AnalysisBatchItem), args.Error(1)
+}
+
+func (m *BatchRepository) UpdateItem(ctx context.Context, item *domain.AnalysisBatchItem) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+// MockWorkspaceRepository
+type WorkspaceRepository struct {
+	mock.Mock
+}
+
+func (m *WorkspaceRepository) Create(ctx context.Context, workspace *domain.Workspace, ownerID int) error {
+	args := m.Called(ctx, workspace, ownerID)
+	return args.Error(0)
+}
+
+func (m *WorkspaceRepository) GetByID(ctx context.Context, id int) (*domain.Workspace, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Workspace), args.Error(1)
+}
+
+func (m *WorkspaceRepository) GetPersonal(ctx context.Context, userID int) (*domain.Workspace, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Workspace), args.Error(1)
+}
+
+func (m *WorkspaceRepository) ListByUser(ctx context.Context, userID int) ([]domain.WorkspaceMembership, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.WorkspaceMembership), args.Error(1)
+}
+
+func (m *WorkspaceRepository) GetMember(ctx context.Context, workspaceID, userID int) (*domain.WorkspaceMember, error) {
+	args := m.Called(ctx, workspaceID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WorkspaceMember), args.Error(1)
+}
+
+func (m *WorkspaceRepository) ListMembers(ctx context.Context, workspaceID int) ([]domain.WorkspaceMember, error) {
+	args := m.Called(ctx, workspaceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.WorkspaceMember), args.Error(1)
+}
+
+func (m *WorkspaceRepository) RemoveMember(ctx context.Context, workspaceID, userID int) error {
+	args := m.Called(ctx, workspaceID, userID)
+	return args.Error(0)
+}
+
+func (m *WorkspaceRepository) CreateInvitation(ctx context.Context, invitation *domain.WorkspaceInvitation) error {
+	args := m.Called(ctx, invitation)
+	return args.Error(0)
+}
+
+func (m *WorkspaceRepository) GetInvitation(ctx context.Context, id int) (*domain.WorkspaceInvitation, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WorkspaceInvitation), args.Error(1)
+}
+
+func (m *WorkspaceRepository) ListPendingInvitations(ctx context.Context, githubUsername string) ([]domain.WorkspaceInvitation, error) {
+	args := m.Called(ctx, githubUsername)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.WorkspaceInvitation), args.Error(1)
+}
+
+func (m *WorkspaceRepository) AcceptInvitation(ctx context.Context, invitation *domain.WorkspaceInvitation, userID int) error {
+	args := m.Called(ctx, invitation, userID)
+	return args.Error(0)
+}
+
+// MockWorkspaceService
+type WorkspaceService struct {
+	mock.Mock
+}
+
+func (m *WorkspaceService) Create(ctx context.Context, userID int, name string) (*domain.Workspace, error) {
+	args := m.Called(ctx, userID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Workspace), args.Error(1)
+}
+
+func (m *WorkspaceService) List(ctx context.Context, userID int) ([]domain.WorkspaceMembership, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.WorkspaceMembership), args.Error(1)
+}
+
+func (m *WorkspaceService) Resolve(ctx context.Context, userID, workspaceID int) (int, error) {
+	args := m.Called(ctx, userID, workspaceID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *WorkspaceService) ListMembers(ctx context.Context, userID, workspaceID int) ([]domain.WorkspaceMember, error) {
+	args := m.Called(ctx, userID, workspaceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.WorkspaceMember), args.Error(1)
+}
+
+func (m *WorkspaceService) RemoveMember(ctx context.Context, userID, workspaceID, memberID int) error {
+	args := m.Called(ctx, userID, workspaceID, memberID)
+	return args.Error(0)
+}
+
+func (m *WorkspaceService) Invite(ctx context.Context, userID, workspaceID int, githubUsername string, role domain.WorkspaceRole) (*domain.WorkspaceInvitation, error) {
+	args := m.Called(ctx, userID, workspaceID, githubUsername, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WorkspaceInvitation), args.Error(1)
+}
+
+func (m *WorkspaceService) ListInvitations(ctx context.Context, userID int) ([]domain.WorkspaceInvitation, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.WorkspaceInvitation), args.Error(1)
+}
+
+func (m *WorkspaceService) AcceptInvitation(ctx context.Context, userID, invitationID int) error {
+	args := m.Called(ctx, userID, invitationID)
+	return args.Error(0)
+}
+
+type APITokenRepository struct {
+	mock.Mock
+}
+
+func (m *APITokenRepository) Create(ctx context.Context, token *domain.APIToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *APITokenRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.APIToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.APIToken), args.Error(1)
+}
+
+func (m *APITokenRepository) ListByUser(ctx context.Context, userID int) ([]domain.APIToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.APIToken), args.Error(1)
+}
+
+func (m *APITokenRepository) Delete(ctx context.Context, userID, id int) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *APITokenRepository) TouchLastUsed(ctx context.Context, id int, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+type TokenService struct {
+	mock.Mock
+}
+
+func (m *TokenService) Create(ctx context.Context, userID int, name string, scopes []domain.TokenScope, ttl time.Duration) (*domain.CreatedAPIToken, error) {
+	args := m.Called(ctx, userID, name, scopes, ttl)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CreatedAPIToken), args.Error(1)
+}
+
+func (m *TokenService) List(ctx context.Context, userID int) ([]domain.APIToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.APIToken), args.Error(1)
+}
+
+func (m *TokenService) Revoke(ctx context.Context, userID, tokenID int) error {
+	args := m.Called(ctx, userID, tokenID)
+	return args.Error(0)
+}
+
+func (m *TokenService) Authenticate(ctx context.Context, secret string) (*domain.APIToken, error) {
+	args := m.Called(ctx, secret)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.APIToken), args.Error(1)
+}
+
+type WebhookRepository struct {
+	mock.Mock
+}
+
+func (m *WebhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (bool, error) {
+	args := m.Called(ctx, delivery)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *WebhookRepository) GetDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *WebhookRepository) UpdateDeliveryStatus(ctx context.Context, id string, status domain.WebhookDeliveryStatus, errMsg string) error {
+	args := m.Called(ctx, id, status, errMsg)
+	return args.Error(0)
+}
+
+func (m *WebhookRepository) UpsertInstallation(ctx context.Context, installation *domain.GitHubInstallation) error {
+	args := m.Called(ctx, installation)
+	return args.Error(0)
+}
+
+func (m *WebhookRepository) DeleteInstallation(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+type WebhookService struct {
+	mock.Mock
+}
+
+func (m *WebhookService) HandleDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (bool, error) {
+	args := m.Called(ctx, delivery)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *WebhookService) Replay(ctx context.Context, userID int, deliveryID string) (*domain.WebhookDelivery, error) {
+	args := m.Called(ctx, userID, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WebhookDelivery), args.Error(1)
+}
+
+type GitHubInstallations struct {
+	mock.Mock
+}
+
+func (m *GitHubInstallations) ForgetInstallation(ctx context.Context, installationID int64) {
+	m.Called(ctx, installationID)
+}
@@ -0,0 +1,13 @@
+-- AI response cache keyed on (commit SHA, prompt version, model) fingerprint
+CREATE TABLE IF NOT EXISTS "analysisCache" (
+	fingerprint    varchar(64) PRIMARY KEY,
+	"repositoryId" integer NOT NULL,
+	response       jsonb NOT NULL,
+	"createdAt"    timestamp NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE analyses
+	ADD COLUMN IF NOT EXISTS fingerprint varchar(64),
+	ADD COLUMN IF NOT EXISTS "cacheHit" boolean NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS "analyses_fingerprint_idx" ON analyses (fingerprint);
@@ -0,0 +1,16 @@
+-- Token usage and cost of every billed AI call
+CREATE TABLE IF NOT EXISTS "aiUsage" (
+	id             serial PRIMARY KEY,
+	"analysisId"   integer NOT NULL,
+	"repositoryId" integer NOT NULL,
+	"userId"       integer NOT NULL,
+	model          varchar(100) NOT NULL,
+	"promptTokens" integer NOT NULL DEFAULT 0,
+	"outputTokens" integer NOT NULL DEFAULT 0,
+	"cachedTokens" integer NOT NULL DEFAULT 0,
+	"costUsd"      numeric(12, 6) NOT NULL DEFAULT 0,
+	"createdAt"    timestamp NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC') -- UTC, as the budget month is
+);
+
+CREATE INDEX IF NOT EXISTS "aiUsage_userId_createdAt_idx" ON "aiUsage" ("userId", "createdAt");
+CREATE INDEX IF NOT EXISTS "aiUsage_createdAt_idx" ON "aiUsage" ("createdAt");
@@ -0,0 +1,22 @@
+-- GitHub topics, used to select repositories for bulk analysis
+ALTER TABLE repositories ADD COLUMN IF NOT EXISTS topics text[];
+
+-- Bulk analysis batches and their per-repository progress
+CREATE TABLE IF NOT EXISTS "analysisBatches" (
+	id             uuid PRIMARY KEY,
+	"userId"       integer NOT NULL,
+	"analysisType" varchar(50) NOT NULL,
+	force          boolean NOT NULL DEFAULT false,
+	total          integer NOT NULL,
+	"createdAt"    timestamp NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS "analysisBatchItems" (
+	"batchId"      uuid NOT NULL REFERENCES "analysisBatches" (id) ON DELETE CASCADE,
+	"repositoryId" integer NOT NULL,
+	status         varchar(20) NOT NULL DEFAULT 'queued',
+	"analysisId"   integer,
+	"errorMessage" text,
+	"updatedAt"    timestamp NOT NULL DEFAULT NOW(),
+	PRIMARY KEY ("batchId", "repositoryId")
+);
@@ -0,0 +1,55 @@
+-- Workspaces (teams) share repositories between their members
+CREATE TABLE IF NOT EXISTS workspaces (
+	id                  serial PRIMARY KEY,
+	name                varchar(255) NOT NULL,
+	"personalForUserId" integer UNIQUE,
+	"createdAt"         timestamp NOT NULL DEFAULT NOW(),
+	"updatedAt"         timestamp NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS "workspaceMembers" (
+	"workspaceId" integer NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+	"userId"      integer NOT NULL,
+	role          varchar(20) NOT NULL,
+	"createdAt"   timestamp NOT NULL DEFAULT NOW(),
+	PRIMARY KEY ("workspaceId", "userId")
+);
+
+CREATE INDEX IF NOT EXISTS "workspaceMembers_userId_idx" ON "workspaceMembers" ("userId");
+
+CREATE TABLE IF NOT EXISTS "workspaceInvitations" (
+	id               serial PRIMARY KEY,
+	"workspaceId"    integer NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+	"githubUsername" varchar(255) NOT NULL,
+	role             varchar(20) NOT NULL,
+	"invitedBy"      integer NOT NULL,
+	status           varchar(20) NOT NULL DEFAULT 'pending',
+	"createdAt"      timestamp NOT NULL DEFAULT NOW(),
+	"acceptedAt"     timestamp
+);
+
+CREATE INDEX IF NOT EXISTS "workspaceInvitations_githubUsername_idx" ON "workspaceInvitations" ("githubUsername") WHERE status = 'pending';
+
+-- Existing users get a personal workspace holding the repositories they already synced
+INSERT INTO workspaces (name, "personalForUserId")
+SELECT COALESCE(u."githubUsername", u.name, 'user ' || u.id), u.id
+FROM users u
+ON CONFLICT ("personalForUserId") DO NOTHING;
+
+INSERT INTO "workspaceMembers" ("workspaceId", "userId", role)
+SELECT w.id, w."personalForUserId", 'owner'
+FROM workspaces w
+WHERE w."personalForUserId" IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE repositories ADD COLUMN IF NOT EXISTS "workspaceId" integer REFERENCES workspaces (id) ON DELETE CASCADE;
+
+UPDATE repositories r
+SET "workspaceId" = w.id
+FROM workspaces w
+WHERE w."personalForUserId" = r."userId" AND r."workspaceId" IS NULL;
+
+ALTER TABLE repositories ALTER COLUMN "workspaceId" SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS "repositories_workspaceId_idx" ON repositories ("workspaceId");
+CREATE UNIQUE INDEX IF NOT EXISTS "repositories_workspaceId_githubId_idx" ON repositories ("workspaceId", "githubId");
@@ -0,0 +1,14 @@
+-- Personal access tokens for scripts and CI. Only the SHA-256 of the secret is stored.
+CREATE TABLE IF NOT EXISTS "apiTokens" (
+	id           serial PRIMARY KEY,
+	"userId"     integer NOT NULL,
+	name         varchar(255) NOT NULL,
+	prefix       varchar(16) NOT NULL,
+	"tokenHash"  char(64) NOT NULL UNIQUE,
+	scopes       text[] NOT NULL,
+	"expiresAt"  timestamp NOT NULL,
+	"lastUsedAt" timestamp,
+	"createdAt"  timestamp NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS "apiTokens_userId_idx" ON "apiTokens" ("userId");
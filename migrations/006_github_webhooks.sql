@@ -0,0 +1,28 @@
+-- GitHub webhook deliveries, kept for deduplication and replay
+CREATE TABLE IF NOT EXISTS "webhookDeliveries" (
+	id            varchar(64) PRIMARY KEY, -- X-GitHub-Delivery
+	event         varchar(64) NOT NULL,
+	action        varchar(64) NOT NULL DEFAULT '',
+	payload       jsonb NOT NULL,
+	status        varchar(20) NOT NULL DEFAULT 'received',
+	error         text,
+	"receivedAt"  timestamp NOT NULL DEFAULT NOW(),
+	"processedAt" timestamp
+);
+
+CREATE INDEX IF NOT EXISTS "webhookDeliveries_receivedAt_idx" ON "webhookDeliveries" ("receivedAt");
+
+-- GitHub App installations, maintained from installation events
+CREATE TABLE IF NOT EXISTS "githubInstallations" (
+	id             bigint PRIMARY KEY,
+	"accountLogin" varchar(255) NOT NULL,
+	"accountType"  varchar(20) NOT NULL,
+	"suspendedAt"  timestamp,
+	"createdAt"    timestamp NOT NULL DEFAULT NOW(),
+	"updatedAt"    timestamp NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS "githubInstallations_accountLogin_idx" ON "githubInstallations" (lower("accountLogin"));
+
+ALTER TABLE repositories ADD COLUMN IF NOT EXISTS "isArchived" boolean NOT NULL DEFAULT false;
+CREATE INDEX IF NOT EXISTS "repositories_githubId_idx" ON repositories ("githubId");
@@ -0,0 +1,3 @@
+-- Repositories synced through a GitHub App remember the installation they belong to
+ALTER TABLE repositories ADD COLUMN IF NOT EXISTS "installationId" bigint;
+CREATE INDEX IF NOT EXISTS "repositories_installationId_idx" ON repositories ("installationId");
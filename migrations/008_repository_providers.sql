@@ -0,0 +1,6 @@
+-- Repositories can come from GitHub, GitLab or Gitea; "githubId" holds the ID on the provider
+ALTER TABLE repositories ADD COLUMN IF NOT EXISTS provider varchar(20) NOT NULL DEFAULT 'github';
+
+-- IDs are only unique within a provider
+DROP INDEX IF EXISTS "repositories_workspaceId_githubId_idx";
+CREATE UNIQUE INDEX IF NOT EXISTS "repositories_workspaceId_provider_githubId_idx" ON repositories ("workspaceId", provider, "githubId");
@@ -0,0 +1,8 @@
+-- Static code metrics are stored as analyses of type 'metrics'. Where the
+-- application schema declares "analysisType" as an enum, add the value.
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_type WHERE typname = 'analysisType' AND typtype = 'e') THEN
+		ALTER TYPE "analysisType" ADD VALUE IF NOT EXISTS 'metrics';
+	END IF;
+END $$;
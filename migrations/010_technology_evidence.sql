@@ -0,0 +1,3 @@
+-- Technologies found by the static detection rules record the file that matched;
+-- rows from the AI analysis leave it NULL
+ALTER TABLE technologies ADD COLUMN IF NOT EXISTS evidence text;
@@ -0,0 +1,15 @@
+-- Licenses found at the root of a checkout by the static analysis, replaced on every run
+CREATE TABLE IF NOT EXISTS "repositoryLicenses" (
+	id             serial PRIMARY KEY,
+	"repositoryId" integer NOT NULL,
+	"spdxId"       varchar(255) NOT NULL,
+	source         text NOT NULL,
+	method         varchar(20) NOT NULL,
+	confidence     real NOT NULL,
+	"createdAt"    timestamp NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS "repositoryLicenses_repositoryId_idx" ON "repositoryLicenses" ("repositoryId");
+
+-- The license a vendored copy of a library declares
+ALTER TABLE technologies ADD COLUMN IF NOT EXISTS license varchar(255);